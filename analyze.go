@@ -0,0 +1,168 @@
+package svg2pdf
+
+import (
+	"io"
+	"slices"
+	"strings"
+)
+
+// Report describes what an SVG source uses, as found by Analyze
+type Report struct {
+	Documents int            // svg documents in the source, each a page
+	Elements  map[string]int // Elements that would be drawn or used, by name, e.g. "rect"
+	Features  []string       // Features used, named as by Features, sorted
+	// Unsupported lists the elements and attributes that would be skipped,
+	// with the index of their svg document as Page
+	Unsupported []Warning
+}
+
+// Supported reports whether the converter supports everything the source
+// uses
+func (r *Report) Supported() bool {
+	return len(r.Unsupported) == 0
+}
+
+// Analyze parses the SVG read from r and reports the features it uses and
+// the content the converter does not support, without converting it, e.g.
+// to check a batch of files before committing to a conversion job. opts
+// configure the converter as for a conversion, e.g. the SVG profile the
+// source is validated against or element handlers. Sources that cannot be
+// decoded or fail validation return the error the conversion would.
+func Analyze(r io.Reader, opts ...Option) (*Report, error) {
+	p, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &ParseError{Op: "reading", Err: err}
+	}
+	d := p.decodeSource(source)
+	if d.invalid != nil {
+		return nil, d.invalid
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	report := &Report{Documents: len(d.roots), Elements: make(map[string]int)}
+	used := make(map[string]bool)
+	if len(d.roots) > 1 {
+		used["svg/multi-root"] = true
+	}
+	for i, root := range d.roots {
+		if i < len(d.unsupported) {
+			for _, w := range d.unsupported[i] {
+				w.Page = i
+				report.Unsupported = append(report.Unsupported, w)
+			}
+		}
+		report.Elements["svg"]++
+		analyzeStyles(root.Styles, used)
+		if blends(root.Blend, root.Inline) {
+			used["svg/blend-modes"] = true
+		}
+		root.Walk(func(element any) bool {
+			if name := p.analyzeElement(element, used); name != "" {
+				report.Elements[name]++
+			}
+			return true
+		})
+	}
+	for feature, ok := range used {
+		if ok {
+			report.Features = append(report.Features, feature)
+		}
+	}
+	slices.Sort(report.Features)
+	return report, nil
+}
+
+// analyzeElement records the features element uses and returns its name,
+// or "" for extension elements without a handler
+func (p *PDF) analyzeElement(element any, used map[string]bool) string {
+	switch e := element.(type) {
+	case *Rect:
+		used["svg/clip-path"] = used["svg/clip-path"] || e.Clip != ""
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "rect"
+	case *Path:
+		used["svg/path"] = true
+		used["svg/clip-path"] = used["svg/clip-path"] || e.Clip != ""
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "path"
+	case *Text:
+		used["text/bidi"] = used["text/bidi"] || e.Dir == "rtl"
+		used["text/vertical"] = used["text/vertical"] || strings.HasPrefix(e.Writing, "tb") || strings.HasPrefix(e.Writing, "vertical")
+		used["svg/clip-path"] = used["svg/clip-path"] || e.Clip != ""
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "text"
+	case *Image:
+		if mediaType, _, err := parseDataURI(e.Href); err == nil {
+			switch mediaType {
+			case "image/png":
+				used["image/png"] = true
+			case "image/jpeg", "image/jpg":
+				used["image/jpeg"] = true
+			}
+		} else if e.Href != "" {
+			used["svg/resource-loader"] = true
+		}
+		used["svg/clip-path"] = used["svg/clip-path"] || e.ClipPath != ""
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "image"
+	case *ClipPath:
+		used["svg/clip-path"] = true
+		return "clipPath"
+	case *SVG:
+		used["svg/nested-viewports"] = true
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		analyzeStyles(e.Styles, used)
+		return "svg"
+	case *Symbol:
+		used["svg/symbol-use"] = true
+		return "symbol"
+	case *Use:
+		used["svg/symbol-use"] = true
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "use"
+	case *Group:
+		used["svg/groups"] = true
+		used["svg/blend-modes"] = used["svg/blend-modes"] || blends(e.Blend, e.Inline)
+		return "g"
+	case *Switch:
+		used["svg/switch"] = true
+		return "switch"
+	case *ForeignObject:
+		return "foreignObject"
+	case *Element:
+		if p.handlers[e.XMLName] == nil {
+			return "" // Skipped, and reported if in the SVG namespace
+		}
+		used["svg/element-handlers"] = true
+		return e.XMLName.Local
+	}
+	return ""
+}
+
+// analyzeStyles records the features the style sheets use
+func analyzeStyles(styles []Style, used map[string]bool) {
+	for _, style := range styles {
+		content := strings.ToLower(stripCSSComments(style.Content))
+		if strings.Contains(content, "@font-face") {
+			used["css/font-face"] = true
+		}
+		if strings.Contains(content, "@media") {
+			used["css/media-print"] = true
+		}
+		if strings.Contains(content, "@import") {
+			used["svg/resource-loader"] = true
+		}
+	}
+}
+
+// blends reports whether an element sets mix-blend-mode, as an attribute or
+// an inline declaration
+func blends(attr, inline string) bool {
+	return (attr != "" && attr != "normal") || strings.Contains(inline, "mix-blend-mode")
+}
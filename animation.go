@@ -0,0 +1,905 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetAnimationTime draws animated SVGs as they appear t after they start,
+// e.g. a logo once it has faded in, instead of as they are before their
+// animations run. SMIL animate, set, animateColor and animateTransform
+// elements with offset begin times are evaluated, as are CSS animations,
+// whose values at t are added to the style attribute of the elements they
+// animate. Interpolation is linear: timing functions, keySplines and event
+// based timing are not evaluated. A negative t draws SVGs unanimated, as by
+// default.
+func (p *PDF) SetAnimationTime(t time.Duration) {
+	if t < 0 {
+		p.animationTime = nil
+		return
+	}
+	p.animationTime = &t
+}
+
+// SetAnimationTime draws animated SVGs as they appear t after they start
+func (d *Document) SetAnimationTime(t time.Duration) {
+	d.pdf.SetAnimationTime(t)
+}
+
+// WithAnimationTime draws animated SVGs as they appear t after they start
+func WithAnimationTime(t time.Duration) Option {
+	return func(p *PDF) error {
+		p.SetAnimationTime(t)
+		return nil
+	}
+}
+
+// animationMarkers finds the animation elements and CSS animations of a
+// source, which is left as is without them
+var animationMarkers = regexp.MustCompile(`[<:](animate|set)[\s/>A-Z]|animation`)
+
+// animationElements are the SMIL elements animating an attribute of their
+// parent, or of the element they reference
+var animationElements = setOf("animate", "set", "animateColor", "animateTransform", "animateMotion")
+
+// xmlNode is an element of a source read to be rewritten
+type xmlNode struct {
+	start   xml.StartElement
+	content []any // Tokens and child *xmlNode, in order
+}
+
+// attr returns the value of the attribute without a prefix called name
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.start.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// setAttr sets the attribute without a prefix called name
+func (n *xmlNode) setAttr(name, value string) {
+	for i, a := range n.start.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			n.start.Attr[i].Value = value
+			return
+		}
+	}
+	n.start.Attr = append(n.start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// href returns the element id an href or xlink:href attribute references
+func (n *xmlNode) href() string {
+	for _, a := range n.start.Attr {
+		if a.Name.Local == "href" {
+			return strings.TrimPrefix(strings.TrimSpace(a.Value), "#")
+		}
+	}
+	return ""
+}
+
+// setStyle adds declarations to the style attribute, overriding earlier
+// ones
+func (n *xmlNode) setStyle(decls []cssDecl) {
+	style, _ := n.attr("style")
+	for _, decl := range decls {
+		if strings.TrimSpace(style) != "" && !strings.HasSuffix(strings.TrimSpace(style), ";") {
+			style += ";"
+		}
+		style += decl.Property + ":" + decl.Value
+	}
+	n.setAttr("style", style)
+}
+
+// walk calls visit for n and its descendants in document order
+func (n *xmlNode) walk(visit func(n *xmlNode)) {
+	visit(n)
+	for _, c := range n.content {
+		if child, ok := c.(*xmlNode); ok {
+			child.walk(visit)
+		}
+	}
+}
+
+// animationFrame returns source as it appears at the animation time, with
+// animated attributes set to their values then and animation elements
+// removed. Sources without animations, or that cannot be read, are
+// returned as they are, for decoding to report their errors.
+func (p *PDF) animationFrame(source []byte) []byte {
+	if p.animationTime == nil || !animationMarkers.Match(source) {
+		return source
+	}
+	root, err := readNodes(source, p.entityBytes())
+	if err != nil {
+		return source
+	}
+	t := p.animationTime.Seconds()
+	applySMIL(root, t)
+	p.applyCSSAnimations(root, t)
+	var b bytes.Buffer
+	writeNodes(&b, root.content)
+	return b.Bytes()
+}
+
+// readNodes reads the tokens of source into a tree under an element
+// without a name, expanding entities to at most maxEntityBytes
+func readNodes(source []byte, maxEntityBytes int64) (*xmlNode, error) {
+	decoder := newDecoder(bytes.NewReader(source), maxEntityBytes)
+	stack := []*xmlNode{{}}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &xmlNode{start: t.Copy()}
+			parent.content = append(parent.content, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unexpected end element </%s>", t.Name.Local)
+			}
+			stack = stack[:len(stack)-1]
+		case xml.Directive:
+			if err := declareEntities(decoder, t); err != nil {
+				return nil, err
+			}
+			parent.content = append(parent.content, t.Copy())
+		default:
+			parent.content = append(parent.content, xml.CopyToken(token))
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unclosed element <%s>", stack[len(stack)-1].start.Name.Local)
+	}
+	return stack[0], nil
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+)
+
+// writeNodes writes tokens and nodes read by readNodes back as XML in
+// UTF-8, keeping line breaks where they were
+func writeNodes(b *bytes.Buffer, content []any) {
+	name := func(n xml.Name) string {
+		if n.Space != "" {
+			return n.Space + ":" + n.Local
+		}
+		return n.Local
+	}
+	for _, c := range content {
+		switch t := c.(type) {
+		case *xmlNode:
+			b.WriteString("<" + name(t.start.Name))
+			for _, a := range t.start.Attr {
+				b.WriteString(" " + name(a.Name) + `="` + attrEscaper.Replace(a.Value) + `"`)
+			}
+			b.WriteString(">")
+			writeNodes(b, t.content)
+			b.WriteString("</" + name(t.start.Name) + ">")
+		case xml.CharData:
+			b.WriteString(textEscaper.Replace(string(t)))
+		case xml.Comment:
+			b.WriteString("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`) // Transcoded when read
+				continue
+			}
+			b.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			b.WriteString("<!" + string(t) + ">")
+		}
+	}
+}
+
+// smilAnimation is an animation element with the element it animates
+type smilAnimation struct {
+	node   *xmlNode
+	target *xmlNode
+	name   string  // Attribute animated
+	begin  float64 // Seconds
+}
+
+// applySMIL sets the attributes animated by the SMIL animations under root
+// to their values at t seconds, removing the animations. Animations of the
+// same attribute apply in order of their begin times, each replacing or
+// adding to the value of the ones before.
+func applySMIL(root *xmlNode, t float64) {
+	ids := make(map[string]*xmlNode)
+	root.walk(func(n *xmlNode) {
+		if id, ok := n.attr("id"); ok {
+			ids[id] = n
+		}
+	})
+	var animations []smilAnimation
+	root.walk(func(n *xmlNode) {
+		kept := n.content[:0]
+		for _, c := range n.content {
+			child, ok := c.(*xmlNode)
+			if !ok || !animationElements[child.start.Name.Local] {
+				kept = append(kept, c)
+				continue
+			}
+			a := smilAnimation{node: child, target: n}
+			if id := child.href(); id != "" {
+				a.target = ids[id]
+			}
+			a.name, _ = child.attr("attributeName")
+			if child.start.Name.Local == "animateTransform" && a.name == "" {
+				a.name = "transform"
+			}
+			var starts bool
+			a.begin, starts = beginTime(child)
+			if a.target != nil && a.name != "" && starts && child.start.Name.Local != "animateMotion" {
+				animations = append(animations, a)
+			}
+		}
+		n.content = kept
+	})
+	slices.SortStableFunc(animations, func(a, b smilAnimation) int {
+		return cmpFloat(a.begin, b.begin)
+	})
+
+	// Values are set once all animations of an attribute are evaluated
+	type attribute struct {
+		target *xmlNode
+		name   string
+	}
+	values := make(map[attribute]string)
+	var order []attribute
+	for _, a := range animations {
+		key := attribute{a.target, a.name}
+		base, ok := values[key]
+		if !ok {
+			base = baseValue(a.target, a.name)
+		}
+		if v, ok := a.valueAt(t, base); ok {
+			if _, seen := values[key]; !seen {
+				order = append(order, key)
+			}
+			values[key] = v
+		}
+	}
+	for _, key := range order {
+		key.target.setAttr(key.name, values[key])
+		// Style attributes take precedence over attributes
+		style, _ := key.target.attr("style")
+		for _, decl := range parseDeclarations(style) {
+			if decl.Property == key.name {
+				key.target.setStyle([]cssDecl{{key.name, values[key]}})
+				break
+			}
+		}
+	}
+}
+
+// cmpFloat compares a and b for sorting
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// baseValue returns the value of an attribute before it is animated, from
+// the style attribute or else the attribute
+func baseValue(n *xmlNode, name string) string {
+	value, _ := n.attr(name)
+	style, _ := n.attr("style")
+	for _, decl := range parseDeclarations(style) {
+		if decl.Property == name {
+			value = decl.Value
+		}
+	}
+	return value
+}
+
+// beginTime returns the earliest offset begin time of an animation, false
+// if it only begins on events or never
+func beginTime(n *xmlNode) (float64, bool) {
+	value, ok := n.attr("begin")
+	if !ok {
+		return 0, true
+	}
+	begin, found := math.Inf(1), false
+	for _, part := range strings.Split(value, ";") {
+		if v, ok := parseClock(part); ok {
+			begin, found = min(begin, v), true
+		}
+	}
+	return begin, found
+}
+
+// parseClock returns the seconds of a signed SMIL clock value, e.g. "2s",
+// "-500ms", "01:30" or "1.5"
+func parseClock(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	sign := 1.0
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		s, sign = strings.TrimSpace(rest), -1
+	} else {
+		s = strings.TrimSpace(strings.TrimPrefix(s, "+"))
+	}
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{{"ms", 0.001}, {"min", 60}, {"h", 3600}, {"s", 1}} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			v, err := strconv.ParseFloat(number, 64)
+			return sign * v * unit.scale, err == nil && v >= 0
+		}
+	}
+	seconds := 0.0
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + v
+	}
+	return sign * seconds, true
+}
+
+// progress returns how far into its simple duration an animation is at t
+// seconds, from 0 to 1, or false if it has no effect then
+func (a smilAnimation) progress(t float64) (float64, bool) {
+	n := a.node
+	if t < a.begin {
+		return 0, false
+	}
+	value, _ := n.attr("dur")
+	dur, finite := parseClock(value)
+	finite = finite && dur > 0
+	active := math.Inf(1)
+	if finite {
+		active = dur
+		count, hasCount := n.attr("repeatCount")
+		repeat, hasRepeat := n.attr("repeatDur")
+		if hasCount || hasRepeat {
+			active = math.Inf(1)
+			if c, err := strconv.ParseFloat(strings.TrimSpace(count), 64); hasCount && err == nil && c > 0 {
+				active = dur * c
+			}
+			if r, ok := parseClock(repeat); hasRepeat && ok && r > 0 {
+				active = min(active, r)
+			}
+		}
+	}
+	if value, ok := n.attr("end"); ok {
+		for _, part := range strings.Split(value, ";") {
+			if end, ok := parseClock(part); ok && end >= a.begin {
+				active = min(active, end-a.begin)
+			}
+		}
+	}
+	elapsed := t - a.begin
+	if elapsed < active {
+		if !finite {
+			return 0, true
+		}
+		return math.Mod(elapsed, dur) / dur, true
+	}
+	if fill, _ := n.attr("fill"); strings.TrimSpace(fill) != "freeze" {
+		return 0, false
+	}
+	if !finite {
+		return 0, true
+	}
+	iterations := active / dur
+	if f := iterations - math.Floor(iterations); f > 1e-9 {
+		return f, true
+	}
+	return 1, true // Frozen at the end of the last repetition
+}
+
+// valueAt returns the value an animation gives its attribute at t seconds
+// over the underlying value base, or false if it has no effect then
+func (a smilAnimation) valueAt(t float64, base string) (string, bool) {
+	q, ok := a.progress(t)
+	if !ok {
+		return "", false
+	}
+	n := a.node
+	kind := n.start.Name.Local
+	from, hasFrom := n.attr("from")
+	to, hasTo := n.attr("to")
+	by, hasBy := n.attr("by")
+	mode, _ := n.attr("calcMode")
+	additive, _ := n.attr("additive")
+	var values []string
+	if list, ok := n.attr("values"); ok {
+		for _, v := range strings.Split(list, ";") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	switch {
+	case len(values) > 0:
+	case kind == "set" && hasTo:
+		values = []string{to}
+	case hasTo && !hasFrom && kind == "animateTransform":
+		values = []string{to}
+	case hasTo:
+		if !hasFrom {
+			from, additive = base, "" // A to animation starts from the underlying value
+		}
+		values = []string{from, to}
+	case hasBy:
+		if !hasFrom {
+			from, additive = "0", "sum" // A by animation adds to the underlying value
+			if kind != "animateTransform" {
+				from, additive = base, ""
+			}
+		}
+		values = []string{from, addValues(from, by)}
+	default:
+		return "", false
+	}
+	if kind == "set" {
+		mode = "discrete"
+	}
+	var keyTimes []float64
+	if list, ok := n.attr("keyTimes"); ok {
+		for _, v := range strings.Split(list, ";") {
+			if k, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				keyTimes = append(keyTimes, k)
+			}
+		}
+		if len(keyTimes) != len(values) {
+			keyTimes = nil
+		}
+	}
+	v := interpolateValues(values, keyTimes, strings.TrimSpace(mode) == "discrete", q)
+	if kind == "animateTransform" {
+		transform, _ := n.attr("type")
+		if transform = strings.TrimSpace(transform); transform == "" {
+			transform = "translate"
+		}
+		v = transform + "(" + v + ")"
+		if strings.TrimSpace(additive) == "sum" && strings.TrimSpace(base) != "" {
+			v = base + " " + v
+		}
+		return v, true
+	}
+	if strings.TrimSpace(additive) == "sum" {
+		v = addValues(base, v)
+	}
+	return v, true
+}
+
+// interpolateValues returns the value at progress q of an animation
+// through values, spaced evenly or at keyTimes
+func interpolateValues(values []string, keyTimes []float64, discrete bool, q float64) string {
+	n := len(values)
+	if n == 1 {
+		return values[0]
+	}
+	if discrete {
+		i := min(int(q*float64(n)), n-1)
+		if keyTimes != nil {
+			i = 0
+			for i < n-1 && keyTimes[i+1] <= q {
+				i++
+			}
+		}
+		return values[i]
+	}
+	i := min(int(q*float64(n-1)), n-2)
+	f := q*float64(n-1) - float64(i)
+	if keyTimes != nil {
+		i = 0
+		for i < n-2 && keyTimes[i+1] <= q {
+			i++
+		}
+		f = 0
+		if span := keyTimes[i+1] - keyTimes[i]; span > 0 {
+			f = min(max((q-keyTimes[i])/span, 0), 1)
+		}
+	}
+	return interpolate(values[i], values[i+1], f)
+}
+
+// numberPattern matches the numbers of attribute and property values
+var numberPattern = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// splitNumbers returns the numbers of a value with the text around them,
+// spacing normalized
+func splitNumbers(s string) ([]float64, []string) {
+	var numbers []float64
+	var text []string
+	last := 0
+	for _, m := range numberPattern.FindAllStringIndex(s, -1) {
+		v, err := strconv.ParseFloat(s[m[0]:m[1]], 64)
+		if err != nil {
+			return nil, nil
+		}
+		numbers = append(numbers, v)
+		text = append(text, strings.Join(strings.Fields(strings.ReplaceAll(s[last:m[0]], ",", " ")), " "))
+		last = m[1]
+	}
+	return numbers, append(text, strings.TrimSpace(s[last:]))
+}
+
+// joinNumbers is the inverse of splitNumbers
+func joinNumbers(numbers []float64, text []string) string {
+	var b strings.Builder
+	for i, v := range numbers {
+		if i > 0 && text[i] == "" {
+			text[i] = " "
+		}
+		b.WriteString(text[i])
+		b.WriteString(strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64))
+	}
+	b.WriteString(text[len(numbers)])
+	return b.String()
+}
+
+// combine applies op to the colors, or the numbers, of two values of the
+// same form, reporting false for values that differ in form or reference
+// elements, whose ids may contain numbers
+func combine(a, b string, op func(x, y float64) float64) (string, bool) {
+	if ca, ok := parseColor(a); ok {
+		if cb, ok := parseColor(b); ok {
+			c := RGB{clamp01(op(ca.R, cb.R)), clamp01(op(ca.G, cb.G)), clamp01(op(ca.B, cb.B))}
+			return fmt.Sprintf("rgb(%d,%d,%d)", int(math.Round(c.R*255)), int(math.Round(c.G*255)), int(math.Round(c.B*255))), true
+		}
+	}
+	if strings.ContainsRune(a+b, '#') {
+		return "", false
+	}
+	na, ta := splitNumbers(a)
+	nb, tb := splitNumbers(b)
+	if len(na) == 0 || len(na) != len(nb) || !slices.Equal(ta, tb) {
+		return "", false
+	}
+	for i := range na {
+		na[i] = op(na[i], nb[i])
+	}
+	return joinNumbers(na, ta), true
+}
+
+// interpolate returns the value a fraction f of the way from a to b.
+// Values that are not colors or numbers of the same form change halfway.
+func interpolate(a, b string, f float64) string {
+	if v, ok := combine(a, b, func(x, y float64) float64 { return x + (y-x)*f }); ok {
+		return v
+	}
+	if f < 0.5 {
+		return a
+	}
+	return b
+}
+
+// addValues returns the sum of two values, or b for values that cannot be
+// added
+func addValues(a, b string) string {
+	if v, ok := combine(a, b, func(x, y float64) float64 { return x + y }); ok {
+		return v
+	}
+	return b
+}
+
+// declValue returns a CSS value without surrounding space or !important
+func declValue(value string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+}
+
+// isKeyframesRule reports whether prelude starts a @keyframes rule
+func isKeyframesRule(prelude string) bool {
+	return hasPrefixFold(prelude, "@keyframes") || hasPrefixFold(prelude, "@-webkit-keyframes")
+}
+
+// cssAnimation is one of the animations an element runs
+type cssAnimation struct {
+	name      string
+	duration  float64 // Seconds
+	delay     float64
+	count     float64 // Iterations, +Inf when infinite
+	direction string
+	fill      string
+}
+
+// applyCSSAnimations adds the values the CSS animations of the elements
+// under root give their properties at t seconds to their style attributes
+func (p *PDF) applyCSSAnimations(root *xmlNode, t float64) {
+	var styles []Style
+	root.walk(func(n *xmlNode) {
+		if n.start.Name.Local != "style" {
+			return
+		}
+		var b strings.Builder
+		for _, c := range n.content {
+			if text, ok := c.(xml.CharData); ok {
+				b.Write(text)
+			}
+		}
+		styles = append(styles, Style{Content: b.String()})
+	})
+	keyframes := make(map[string][]cssRule)
+	type animationRule struct {
+		sel   selector
+		decls []cssDecl
+	}
+	var rules []animationRule
+	for _, rule := range p.styleRules(styles) {
+		if isKeyframesRule(rule.Prelude) {
+			if fields := strings.Fields(rule.Prelude); len(fields) > 1 {
+				keyframes[strings.Trim(fields[1], `"'`)] = rule.Rules
+			}
+			continue
+		}
+		if strings.HasPrefix(rule.Prelude, "@") {
+			continue
+		}
+		var decls []cssDecl
+		for _, decl := range rule.Decls {
+			if strings.HasPrefix(decl.Property, "animation") {
+				decls = append(decls, decl)
+			}
+		}
+		if len(decls) == 0 {
+			continue
+		}
+		for _, s := range strings.Split(rule.Prelude, ",") {
+			if sel, err := parseSelector(strings.TrimSpace(s)); err == nil {
+				rules = append(rules, animationRule{sel, decls})
+			}
+		}
+	}
+	slices.SortStableFunc(rules, func(a, b animationRule) int {
+		return a.sel.specificity() - b.sel.specificity()
+	})
+	root.walk(func(n *xmlNode) {
+		tag := n.start.Name.Local
+		id, _ := n.attr("id")
+		class, _ := n.attr("class")
+		var decls []cssDecl
+		for _, rule := range rules {
+			if rule.sel.matches(tag, id, class) {
+				decls = append(decls, rule.decls...)
+			}
+		}
+		style, _ := n.attr("style")
+		decls = append(decls, parseDeclarations(style)...)
+		animations := cssAnimations(decls)
+		values := make(map[string]string)
+		for _, a := range animations {
+			q, ok := a.progress(t)
+			if !ok {
+				continue
+			}
+			for property, value := range keyframeValues(keyframes[a.name], q) {
+				values[property] = value
+			}
+		}
+		if len(values) == 0 {
+			return
+		}
+		var animated []cssDecl
+		for _, property := range slices.Sorted(maps.Keys(values)) {
+			animated = append(animated, cssDecl{property, values[property]})
+		}
+		n.setStyle(animated)
+	})
+}
+
+// cssAnimations returns the animations set by the animation declarations
+// of an element, in cascade order
+func cssAnimations(decls []cssDecl) []cssAnimation {
+	var animations []cssAnimation
+	newAnimation := func() cssAnimation { return cssAnimation{count: 1, direction: "normal", fill: "none"} }
+	for _, decl := range decls {
+		parts := splitOutside(declValue(decl.Value), ',')
+		if decl.Property == "animation" {
+			animations = nil
+			for _, part := range parts {
+				animations = append(animations, parseAnimation(part, newAnimation()))
+			}
+			continue
+		}
+		if decl.Property == "animation-name" {
+			for len(animations) < len(parts) {
+				animations = append(animations, newAnimation())
+			}
+			animations = animations[:len(parts)]
+		}
+		for i := range animations {
+			part := strings.TrimSpace(parts[i%len(parts)])
+			a := &animations[i]
+			switch decl.Property {
+			case "animation-name":
+				a.name = part
+			case "animation-duration":
+				a.duration, _ = parseCSSTime(part)
+			case "animation-delay":
+				a.delay, _ = parseCSSTime(part)
+			case "animation-iteration-count":
+				a.count = parseIterationCount(part, a.count)
+			case "animation-direction":
+				a.direction = part
+			case "animation-fill-mode":
+				a.fill = part
+			}
+		}
+	}
+	return animations
+}
+
+// parseAnimation returns a with the values of one animation of the
+// animation shorthand set
+func parseAnimation(s string, a cssAnimation) cssAnimation {
+	times := 0
+	for _, word := range splitOutside(strings.TrimSpace(s), ' ') {
+		word = strings.TrimSpace(word)
+		switch {
+		case word == "":
+		case strings.Contains(word, "("), cssTimingKeywords[word]:
+			// Timing functions are not evaluated
+		case word == "normal", word == "reverse", word == "alternate", word == "alternate-reverse":
+			a.direction = word
+		case word == "forwards", word == "backwards", word == "both", word == "none" && a.name != "":
+			a.fill = word
+		case word == "running", word == "paused":
+		case word == "infinite":
+			a.count = math.Inf(1)
+		default:
+			if v, ok := parseCSSTime(word); ok {
+				if times == 0 {
+					a.duration = v
+				} else {
+					a.delay = v
+				}
+				times++
+			} else if c, err := strconv.ParseFloat(word, 64); err == nil {
+				a.count = max(c, 0)
+			} else {
+				a.name = word
+			}
+		}
+	}
+	return a
+}
+
+// cssTimingKeywords are the easing keywords of CSS animations
+var cssTimingKeywords = setOf("linear", "ease", "ease-in", "ease-out", "ease-in-out", "step-start", "step-end")
+
+// parseCSSTime returns the seconds of a CSS time, e.g. "2s" or "150ms"
+func parseCSSTime(s string) (float64, bool) {
+	scale := 1.0
+	number, ok := strings.CutSuffix(s, "ms")
+	if ok {
+		scale = 0.001
+	} else if number, ok = strings.CutSuffix(s, "s"); !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	return v * scale, err == nil
+}
+
+// parseIterationCount returns the iterations of animation-iteration-count,
+// or count if it is invalid
+func parseIterationCount(s string, count float64) float64 {
+	if s == "infinite" {
+		return math.Inf(1)
+	}
+	if c, err := strconv.ParseFloat(s, 64); err == nil && c >= 0 {
+		return c
+	}
+	return count
+}
+
+// progress returns how far through its keyframes an animation is at t
+// seconds, from 0 to 1, or false if it has no effect then
+func (a cssAnimation) progress(t float64) (float64, bool) {
+	if a.name == "" || a.name == "none" || a.duration <= 0 || a.count <= 0 {
+		return 0, false
+	}
+	elapsed := t - a.delay
+	var iteration, q float64
+	switch {
+	case elapsed < 0:
+		if a.fill != "backwards" && a.fill != "both" {
+			return 0, false
+		}
+	case elapsed >= a.duration*a.count:
+		if a.fill != "forwards" && a.fill != "both" {
+			return 0, false
+		}
+		iteration = math.Ceil(a.count) - 1
+		q = a.count - iteration
+	default:
+		iteration = math.Floor(elapsed / a.duration)
+		q = elapsed/a.duration - iteration
+	}
+	odd := math.Mod(iteration, 2) == 1
+	switch a.direction {
+	case "reverse":
+		q = 1 - q
+	case "alternate":
+		if odd {
+			q = 1 - q
+		}
+	case "alternate-reverse":
+		if !odd {
+			q = 1 - q
+		}
+	}
+	return q, true
+}
+
+// keyframe is the value of a property at an offset of a @keyframes rule
+type keyframe struct {
+	offset float64
+	value  string
+}
+
+// keyframeValues returns the values of the properties of keyframes at
+// progress q. Properties keep the value of their first or last keyframe
+// outside them.
+func keyframeValues(rules []cssRule, q float64) map[string]string {
+	frames := make(map[string][]keyframe)
+	for _, rule := range rules {
+		for _, selector := range strings.Split(rule.Prelude, ",") {
+			var offset float64
+			switch s := strings.ToLower(strings.TrimSpace(selector)); s {
+			case "from":
+				offset = 0
+			case "to":
+				offset = 1
+			default:
+				v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+				if err != nil || !strings.HasSuffix(s, "%") {
+					continue
+				}
+				offset = v / 100
+			}
+			for _, decl := range rule.Decls {
+				frames[decl.Property] = append(frames[decl.Property], keyframe{offset, declValue(decl.Value)})
+			}
+		}
+	}
+	values := make(map[string]string)
+	for property, list := range frames {
+		slices.SortStableFunc(list, func(a, b keyframe) int { return cmpFloat(a.offset, b.offset) })
+		i := 0
+		for i < len(list)-1 && list[i+1].offset <= q {
+			i++
+		}
+		switch {
+		case q <= list[0].offset:
+			values[property] = list[0].value
+		case i == len(list)-1:
+			values[property] = list[i].value
+		default:
+			f := (q - list[i].offset) / (list[i+1].offset - list[i].offset)
+			values[property] = interpolate(list[i].value, list[i+1].value, f)
+		}
+	}
+	return values
+}
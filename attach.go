@@ -0,0 +1,98 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// attachment is a file embedded in the document and listed in the
+// document-level attachments
+type attachment struct {
+	name         string // File name
+	mediaType    string
+	desc         string
+	relationship string // Relationship to the document, e.g. Source
+	data         []byte
+}
+
+// SetSourceAttachment attaches the source SVG of every conversion after
+// the call to the document, for round-trip workflows where the PDF is
+// distributed but the SVG stays editable. Sources are named source.svg,
+// source-2.svg and so on.
+func (p *PDF) SetSourceAttachment(enabled bool) {
+	p.attachSources = enabled
+}
+
+// WithSourceAttachment attaches the source SVGs to the document
+func WithSourceAttachment() Option {
+	return func(p *PDF) error {
+		p.SetSourceAttachment(true)
+		return nil
+	}
+}
+
+// recordSource keeps source for attaching it, if enabled
+func (p *PDF) recordSource(source []byte) {
+	if !p.attachSources {
+		return
+	}
+	name := "source.svg"
+	if n := len(p.sources); n > 0 {
+		name = fmt.Sprintf("source-%d.svg", n+1)
+	}
+	p.sources = append(p.sources, attachment{
+		name:         name,
+		mediaType:    "image/svg+xml",
+		desc:         "Source SVG",
+		relationship: "Source",
+		data:         slices.Clone(source),
+	})
+}
+
+// attachments returns the files to embed, sorted by name as the name
+// tree requires
+func (p *PDF) attachments() []attachment {
+	files := slices.Clone(p.sources)
+	if p.report {
+		files = append(files, attachment{
+			name:         reportName,
+			mediaType:    "text/plain",
+			desc:         "Content skipped or approximated during conversion",
+			relationship: "Supplement",
+			data:         p.reportText(),
+		})
+	}
+	slices.SortFunc(files, func(a, b attachment) int { return strings.Compare(a.name, b.name) })
+	return files
+}
+
+// writeObject writes the attachment as an embedded file stream, object
+// first, and its file specification, object first+1
+func (a attachment) writeObject(w *pdfWriter, first int) {
+	w.stream(first, ContentStream, []string{
+		"/Type /EmbeddedFile",
+		"/Subtype " + nameObject(a.mediaType),
+		fmt.Sprintf("/Params << /Size %d >>", len(a.data)),
+	}, a.data)
+	w.object(first+1,
+		"<<",
+		"/Type /Filespec",
+		"/F "+textString(a.name),
+		"/UF "+textString(a.name),
+		"/Desc "+textString(a.desc),
+		"/EF << /F "+ref(first)+" >>",
+		"/AFRelationship /"+a.relationship,
+		">>",
+	)
+}
+
+// embeddedFilesEntry returns the catalog entry listing attachments whose
+// objects are numbered from first, two per attachment
+func embeddedFilesEntry(files []attachment, first int) string {
+	names := make([]string, len(files))
+	for i, a := range files {
+		names[i] = textString(a.name) + " " + ref(first+2*i+1)
+	}
+	return fmt.Sprintf("/Names << /EmbeddedFiles << /Names [%s] >> >>", strings.Join(names, " "))
+}
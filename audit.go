@@ -0,0 +1,70 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// SetReproducibilityAudit enables a strict mode for builds that must be
+// byte-for-byte reproducible. Inputs that do not come from the SVG and the
+// options (installed fonts, clocks) are recorded, and Write fails if any
+// was used or if writing the document twice gives different bytes, e.g.
+// because of map iteration order.
+func (p *PDF) SetReproducibilityAudit(enabled bool) {
+	p.audit = enabled
+}
+
+// WithReproducibilityAudit fails writing documents that are not reproducible
+func WithReproducibilityAudit() Option {
+	return func(p *PDF) error {
+		p.SetReproducibilityAudit(true)
+		return nil
+	}
+}
+
+// AuditLog returns the nondeterministic inputs recorded in audit mode
+func (p *PDF) AuditLog() []string {
+	return append([]string(nil), p.auditLog...)
+}
+
+// recordInput notes a nondeterministic input in audit mode
+func (p *PDF) recordInput(format string, args ...any) {
+	if p.audit {
+		p.auditLog = append(p.auditLog, fmt.Sprintf(format, args...))
+	}
+}
+
+// auditedWrite writes the document twice, failing if the two results
+// differ or nondeterministic inputs were recorded
+func (p *PDF) auditedWrite(out io.Writer) error {
+	var first, second bytes.Buffer
+	if err := p.write(&first); err != nil {
+		return err
+	}
+	if err := p.write(&second); err != nil {
+		return err
+	}
+	if len(p.auditLog) > 0 {
+		// Inputs may also be used while writing, e.g. the clock
+		return fmt.Errorf("document is not reproducible: %s", strings.Join(slices.Compact(p.AuditLog()), "; "))
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		return fmt.Errorf("document is not reproducible: output differs between writes at byte %d", commonPrefix(first.Bytes(), second.Bytes()))
+	}
+	if _, err := out.Write(first.Bytes()); err != nil {
+		return fmt.Errorf("error writing PDF: %v", err)
+	}
+	return nil
+}
+
+// commonPrefix returns the length of the common prefix of a and b
+func commonPrefix(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
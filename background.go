@@ -0,0 +1,96 @@
+package svg2pdf
+
+import "strings"
+
+// SetBackground fills every page with c before anything is drawn on it,
+// e.g. behind charts exported for a dark theme; nil leaves pages unfilled.
+// An SVG whose root element has a CSS background color, set in its style
+// attribute or a style sheet, fills its page, or its n-up cell, with that
+// color instead.
+func (p *PDF) SetBackground(c *RGB) {
+	p.background = c
+}
+
+// SetBackground fills every page with c
+func (d *Document) SetBackground(c *RGB) {
+	d.pdf.SetBackground(c)
+}
+
+// WithBackground fills every page with c
+func WithBackground(c RGB) Option {
+	return func(p *PDF) error {
+		p.SetBackground(&c)
+		return nil
+	}
+}
+
+// rootBackground returns the background color the CSS of an svg document
+// sets on its root element. Of the matching style rules, the most specific
+// one wins, and the style attribute over all of them.
+func (p *PDF) rootBackground(svgData *SVG) (RGB, bool) {
+	value, best := "", -1
+	for _, rule := range p.styleRules(svgData.Styles) {
+		if strings.HasPrefix(rule.Prelude, "@") {
+			continue
+		}
+		decl, ok := backgroundDecl(rule.Decls)
+		if !ok {
+			continue
+		}
+		for _, s := range strings.Split(rule.Prelude, ",") {
+			sel, err := parseSelector(strings.TrimSpace(s))
+			if err == nil && sel.specificity() >= best && sel.matches("svg", svgData.ID, svgData.Class) {
+				value, best = decl, sel.specificity()
+			}
+		}
+	}
+	if decl, ok := backgroundDecl(parseDeclarations(svgData.Inline)); ok {
+		value = decl
+	}
+	return backgroundColor(p.substitute(value))
+}
+
+// backgroundDecl returns the value of the last background or
+// background-color declaration
+func backgroundDecl(decls []cssDecl) (string, bool) {
+	value, ok := "", false
+	for _, decl := range decls {
+		if decl.Property == "background" || decl.Property == "background-color" {
+			value, ok = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(decl.Value), "!important")), true
+		}
+	}
+	return value, ok
+}
+
+// backgroundColor returns the color of a background value, which may be a
+// shorthand listing images and positions as well. Transparent backgrounds
+// have none.
+func backgroundColor(value string) (RGB, bool) {
+	if c, ok := parseColor(value); ok {
+		return c, true
+	}
+	for _, part := range splitOutside(value, ' ') {
+		if c, ok := parseColor(part); ok {
+			return c, true
+		}
+	}
+	return RGB{}, false
+}
+
+// fillBackground returns the operators filling box, in points from the top
+// left of the page, with c as an artifact
+func (p *PDF) fillBackground(c RGB, box viewBox, pageHeight float64) []string {
+	ops := append(p.beginArtifact(), "q", p.fillOp(c),
+		p.operator("re", box.X, pageHeight-box.Y-box.H, box.W, box.H), "f", "Q")
+	return append(ops, p.endMarked()...)
+}
+
+// backgroundOps returns the operators filling page i with the document
+// background, or "" without one
+func (p *PDF) backgroundOps(i int) string {
+	if p.background == nil {
+		return ""
+	}
+	size := p.pageSizes[i]
+	return strings.Join(p.fillBackground(*p.background, viewBox{0, 0, size[0], size[1]}, size[1]), "\n")
+}
@@ -0,0 +1,122 @@
+package svg2pdf
+
+import (
+	"io"
+	"runtime/metrics"
+	"time"
+)
+
+// BenchmarkResult reports the cost of each stage of a conversion, to
+// attribute slow conversions to parsing, rendering or serialization
+type BenchmarkResult struct {
+	Parse, Render, Serialize time.Duration
+	// Bytes allocated per stage
+	ParseAlloc, RenderAlloc, SerializeAlloc uint64
+	PeakHeap                                uint64 // Highest live heap sampled during the conversion, in bytes
+	OutputSize                              int    // Size of the PDF in bytes
+}
+
+// Conversion stages measured by Benchmark
+const (
+	stageParse = iota
+	stageRender
+	stageSerialize
+	stageCount
+)
+
+// stageCost is the time and allocations spent in a stage
+type stageCost struct {
+	time  time.Duration
+	alloc uint64
+}
+
+// heapSampleInterval is the interval the live heap is sampled at
+const heapSampleInterval = time.Millisecond
+
+// Benchmark converts source with opts, measuring every stage. The PDF is
+// discarded after its size is taken.
+func Benchmark(source []byte, opts ...Option) (BenchmarkResult, error) {
+	var r BenchmarkResult
+	p, err := New(opts...)
+	if err != nil {
+		return r, err
+	}
+	p.stages = new([stageCount]stageCost)
+
+	// Sample the live heap until the conversion is done
+	done, peak := make(chan struct{}), make(chan uint64)
+	go func() {
+		sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+		highest := uint64(0)
+		ticker := time.NewTicker(heapSampleInterval)
+		defer ticker.Stop()
+		for {
+			metrics.Read(sample)
+			if sample[0].Value.Kind() == metrics.KindUint64 {
+				highest = max(highest, sample[0].Value.Uint64())
+			}
+			select {
+			case <-done:
+				peak <- highest
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var out countingWriter
+	err = p.ConvertSVGBytes(source)
+	if err == nil {
+		err = p.measure(stageSerialize, func() error { return p.Write(&out) })
+	}
+	close(done)
+	r.PeakHeap = <-peak
+	if err != nil {
+		return r, err
+	}
+	s := p.stages
+	r.Parse, r.Render, r.Serialize = s[stageParse].time, s[stageRender].time, s[stageSerialize].time
+	r.ParseAlloc, r.RenderAlloc, r.SerializeAlloc = s[stageParse].alloc, s[stageRender].alloc, s[stageSerialize].alloc
+	r.OutputSize = out.n
+	return r, nil
+}
+
+// measure runs a stage, adding its time and allocations to the stage costs
+// when benchmarking
+func (p *PDF) measure(stage int, run func() error) error {
+	if p.stages == nil {
+		return run()
+	}
+	start, alloc := time.Now(), allocatedBytes()
+	err := run()
+	p.stages[stage].time += time.Since(start)
+	p.stages[stage].alloc += allocatedBytes() - alloc
+	return err
+}
+
+// allocatedBytes returns the bytes allocated on the heap so far
+func allocatedBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// countingWriter counts the bytes written to w, or discards them if w is
+// nil
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	if w.w == nil {
+		w.n += len(b)
+		return len(b), nil
+	}
+	n, err := w.w.Write(b)
+	w.n += n
+	return n, err
+}
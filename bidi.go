@@ -0,0 +1,332 @@
+package svg2pdf
+
+import (
+	"slices"
+	"unicode"
+)
+
+// TextShaper converts a run of text in logical order into the characters to
+// draw from left to right. It is the integration point for a full shaping
+// engine; the default applies the Unicode bidi algorithm and Arabic shaping.
+type TextShaper interface {
+	Shape(text string, rtl bool) string
+}
+
+// SetTextShaper replaces the built-in bidi reordering and Arabic shaping
+func (p *PDF) SetTextShaper(s TextShaper) {
+	p.shaper = s
+}
+
+// defaultShaper shapes Arabic letters and reorders bidirectional text
+type defaultShaper struct{}
+
+// Shape joins Arabic letters into their contextual forms, then reorders the
+// run into visual order
+func (defaultShaper) Shape(text string, rtl bool) string {
+	runes := shapeArabic([]rune(text))
+	return string(reorderBidi(runes, rtl))
+}
+
+// bidiClass is a simplified Unicode bidirectional character type
+type bidiClass int
+
+const (
+	bidiL   bidiClass = iota // Left-to-right letter
+	bidiR                    // Right-to-left letter (Hebrew and others)
+	bidiAL                   // Arabic letter
+	bidiEN                   // European number
+	bidiAN                   // Arabic number
+	bidiNSM                  // Non-spacing mark
+	bidiON                   // Neutral: whitespace and punctuation
+)
+
+// classify returns the bidi class of r
+func classify(r rune) bidiClass {
+	switch {
+	case r >= '0' && r <= '9':
+		return bidiEN
+	case r >= 0x0660 && r <= 0x0669, r >= 0x06F0 && r <= 0x06F9:
+		return bidiAN
+	case unicode.Is(unicode.Mn, r):
+		return bidiNSM
+	case r >= 0x0590 && r <= 0x05FF, r >= 0x07C0 && r <= 0x089F, r >= 0xFB1D && r <= 0xFB4F:
+		return bidiR
+	case r >= 0x0600 && r <= 0x07BF, r >= 0x08A0 && r <= 0x08FF,
+		r >= 0xFB50 && r <= 0xFDFF, r >= 0xFE70 && r <= 0xFEFF:
+		return bidiAL
+	case unicode.IsLetter(r):
+		return bidiL
+	}
+	return bidiON
+}
+
+// hasRTL reports whether s contains right-to-left characters
+func hasRTL(s string) bool {
+	for _, r := range s {
+		if c := classify(r); c == bidiR || c == bidiAL || c == bidiAN {
+			return true
+		}
+	}
+	return false
+}
+
+// reorderBidi resolves embedding levels for a single paragraph following
+// the implicit rules of the bidi algorithm and returns it in visual order
+func reorderBidi(runes []rune, rtl bool) []rune {
+	n := len(runes)
+	if n == 0 {
+		return runes
+	}
+	base := 0
+	if rtl {
+		base = 1
+	}
+	classes := make([]bidiClass, n)
+	for i, r := range runes {
+		classes[i] = classify(r)
+	}
+
+	// W1-W3: marks take the previous class, numbers after Arabic letters
+	// are Arabic numbers, and Arabic letters become plain R
+	lastStrong := bidiL
+	if rtl {
+		lastStrong = bidiR
+	}
+	for i, c := range classes {
+		if c == bidiNSM {
+			if i > 0 {
+				classes[i] = classes[i-1]
+			} else {
+				classes[i] = bidiON
+			}
+			c = classes[i]
+		}
+		switch c {
+		case bidiL, bidiR, bidiAL:
+			lastStrong = c
+		case bidiEN:
+			if lastStrong == bidiAL {
+				classes[i] = bidiAN
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == bidiAL {
+			classes[i] = bidiR
+		}
+	}
+
+	// N1-N2: neutrals between strong types of the same direction take that
+	// direction, numbers count as R; other neutrals take the base direction
+	strongDir := func(c bidiClass) bidiClass {
+		if c == bidiL || (c == bidiEN && !rtl) {
+			return bidiL // European numbers keep the LTR context
+		}
+		return bidiR
+	}
+	baseDir := bidiL
+	if rtl {
+		baseDir = bidiR
+	}
+	for i := 0; i < n; {
+		if classes[i] != bidiON {
+			i++
+			continue
+		}
+		j := i
+		for j < n && classes[j] == bidiON {
+			j++
+		}
+		before, after := baseDir, baseDir
+		if i > 0 {
+			before = strongDir(classes[i-1])
+		}
+		if j < n {
+			after = strongDir(classes[j])
+		}
+		resolved := baseDir
+		if before == after {
+			resolved = before
+		}
+		for k := i; k < j; k++ {
+			classes[k] = resolved
+		}
+		i = j
+	}
+
+	// I1-I2: implicit levels
+	levels := make([]int, n)
+	maxLevel := base
+	for i, c := range classes {
+		level := base
+		switch {
+		case base == 0 && c == bidiR:
+			level = 1
+		case base == 0 && (c == bidiEN || c == bidiAN):
+			level = 2
+		case base == 1 && (c == bidiL || c == bidiEN || c == bidiAN):
+			level = 2
+		}
+		levels[i] = level
+		maxLevel = max(maxLevel, level)
+	}
+
+	// L2: reverse every run at or above each odd level, highest first
+	out := slices.Clone(runes)
+	for level := maxLevel; level >= 1; level-- {
+		for i := 0; i < n; {
+			if levels[i] < level {
+				i++
+				continue
+			}
+			j := i
+			for j < n && levels[j] >= level {
+				j++
+			}
+			slices.Reverse(out[i:j])
+			slices.Reverse(levels[i:j])
+			i = j
+		}
+	}
+
+	// L4: mirror paired punctuation displayed right to left
+	for i, r := range out {
+		if levels[i]%2 == 1 {
+			if m, ok := mirrored[r]; ok {
+				out[i] = m
+			}
+		}
+	}
+	return out
+}
+
+// mirrored maps characters to their mirror image for right-to-left display
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+	'<': '>', '>': '<', '«': '»', '»': '«',
+}
+
+// arabicForm holds the presentation forms of an Arabic letter: isolated,
+// final, initial and medial. Right-joining letters have no initial or
+// medial form.
+type arabicForm [4]rune
+
+// arabicForms maps Arabic letters to their presentation forms
+var arabicForms = map[rune]arabicForm{
+	0x0621: {0xFE80, 0, 0, 0},
+	0x0622: {0xFE81, 0xFE82, 0, 0},
+	0x0623: {0xFE83, 0xFE84, 0, 0},
+	0x0624: {0xFE85, 0xFE86, 0, 0},
+	0x0625: {0xFE87, 0xFE88, 0, 0},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E, 0, 0},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94, 0, 0},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA, 0, 0},
+	0x0630: {0xFEAB, 0xFEAC, 0, 0},
+	0x0631: {0xFEAD, 0xFEAE, 0, 0},
+	0x0632: {0xFEAF, 0xFEB0, 0, 0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0640: {0x0640, 0x0640, 0x0640, 0x0640}, // Tatweel joins on both sides
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE, 0, 0},
+	0x0649: {0xFEEF, 0xFEF0, 0, 0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+}
+
+// lamAlef maps the alef following a lam to the isolated form of the ligature;
+// the final form is the next code point
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+// shapeArabic replaces Arabic letters with the presentation form matching
+// how they join their neighbors, including the mandatory lam-alef ligatures
+func shapeArabic(runes []rune) []rune {
+	if !slices.ContainsFunc(runes, func(r rune) bool { _, ok := arabicForms[r]; return ok }) {
+		return runes
+	}
+	// joinsNext reports whether the letter at i connects to the next letter
+	joinsNext := func(i int) bool {
+		f, ok := arabicForms[runes[i]]
+		return ok && f[2] != 0
+	}
+	// neighbor finds the closest letter in direction step, skipping marks
+	neighbor := func(i, step int) int {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if !unicode.Is(unicode.Mn, runes[j]) {
+				return j
+			}
+		}
+		return -1
+	}
+
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+		prev := neighbor(i, -1)
+		joinPrev := prev >= 0 && joinsNext(prev)
+
+		if r == 0x0644 {
+			if next := neighbor(i, 1); next == i+1 {
+				if lig, ok := lamAlef[runes[next]]; ok {
+					if joinPrev {
+						lig++ // Final form
+					}
+					out = append(out, lig)
+					i = next
+					continue
+				}
+			}
+		}
+
+		next := neighbor(i, 1)
+		_, nextArabic := arabicForms[runeAt(runes, next)]
+		joinNext := forms[2] != 0 && next >= 0 && nextArabic && runes[next] != 0x0621
+		switch {
+		case joinPrev && joinNext:
+			out = append(out, forms[3])
+		case joinPrev && forms[1] != 0:
+			out = append(out, forms[1])
+		case joinNext:
+			out = append(out, forms[2])
+		default:
+			out = append(out, forms[0])
+		}
+	}
+	return out
+}
+
+// runeAt returns runes[i], or 0 if i is out of range
+func runeAt(runes []rune, i int) rune {
+	if i < 0 || i >= len(runes) {
+		return 0
+	}
+	return runes[i]
+}
@@ -0,0 +1,121 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// blendModeNames maps CSS mix-blend-mode values to PDF blend modes
+var blendModeNames = map[string]string{
+	"normal":      "Normal",
+	"multiply":    "Multiply",
+	"screen":      "Screen",
+	"overlay":     "Overlay",
+	"darken":      "Darken",
+	"lighten":     "Lighten",
+	"color-dodge": "ColorDodge",
+	"color-burn":  "ColorBurn",
+	"hard-light":  "HardLight",
+	"soft-light":  "SoftLight",
+	"difference":  "Difference",
+	"exclusion":   "Exclusion",
+	"hue":         "Hue",
+	"saturation":  "Saturation",
+	"color":       "Color",
+	"luminosity":  "Luminosity",
+}
+
+// unboundedGroup is the bounding box of transparency groups whose content
+// is not clipped
+var unboundedGroup = viewBox{-1e5, -1e5, 2e5, 2e5}
+
+// blendMode returns the PDF blend mode of an element from its
+// mix-blend-mode attribute and style attribute, which takes precedence, or
+// "" if it paints normally
+func (p *PDF) blendMode(element, id, attr, style string) string {
+	value := attr
+	for _, decl := range parseDeclarations(style) {
+		if decl.Property == "mix-blend-mode" {
+			value = decl.Value
+		}
+	}
+	value = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p.substitute(value)), "!important")))
+	if value == "" || value == "normal" {
+		return ""
+	}
+	mode, ok := blendModeNames[value]
+	if !ok {
+		p.warn(element, id, "unsupported blend mode %q, painting normally", value)
+		return ""
+	}
+	return mode
+}
+
+// blendState returns the resource name of the ExtGState setting mode
+func (p *PDF) blendState(mode string) string {
+	if name, ok := p.blendStates[mode]; ok {
+		return name
+	}
+	if p.blendStates == nil {
+		p.blendStates = make(map[string]string)
+	}
+	name := p.resourceID("GS", []byte("/BM /"+mode))
+	p.blendStates[mode] = name
+	p.blendModes = append(p.blendModes, mode)
+	p.created(name)
+	return name
+}
+
+// beginBlend returns the operators painting the following content with
+// mode in a graphics state of its own, or nil for normal painting. The
+// caller restores the state with endBlend.
+func (p *PDF) beginBlend(mode string) []string {
+	if mode == "" {
+		return nil
+	}
+	return []string{"q", "/" + p.blendState(mode) + " gs"}
+}
+
+// endBlend returns the operator ending content started with beginBlend
+func endBlend(mode string) []string {
+	if mode == "" {
+		return nil
+	}
+	return []string{"Q"}
+}
+
+// groupBBox returns the bounding box of a transparency group holding a
+// viewport: the viewport itself unless its overflow is visible
+func groupBBox(vp viewBox, overflow string) viewBox {
+	switch strings.TrimSpace(overflow) {
+	case "visible", "auto":
+		return unboundedGroup
+	}
+	return vp
+}
+
+// drawBlendGroup draws the content emitted by draw as a transparency group
+// within bbox, composited as a whole onto the page with mode, as SVG blends
+// containers after rendering their children
+func (p *PDF) drawBlendGroup(mode string, bbox viewBox, draw func()) {
+	if p.pageCount == 0 {
+		p.AddPage()
+	}
+	page := p.current
+	saved := p.content[page]
+	p.content[page] = p.newContentStream()
+	draw()
+	ops := p.content[page].String()
+	p.content[page] = saved
+	if ops == "" {
+		return
+	}
+	// Identical groups, e.g. repeated uses of a symbol, share one form
+	name := p.resourceID("Gr", fmt.Appendf([]byte(ops), "%v", bbox))
+	if !slices.ContainsFunc(p.forms, func(f *formXObject) bool { return f.name == name }) {
+		p.forms = append(p.forms, &formXObject{name: name, bbox: bbox, ops: ops, group: true})
+		p.created(name)
+	}
+	p.emit(append(p.beginBlend(mode), "/"+name+" Do", "Q")...)
+}
@@ -0,0 +1,53 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recordBookmark sets the bookmark title of the current page from the
+// page settings or the SVG title
+func (p *PDF) recordBookmark(svg *SVG) {
+	if !p.outline {
+		return
+	}
+	title := p.pageBookmark
+	if title == "" {
+		title = strings.Join(strings.Fields(svg.Title), " ")
+	}
+	p.bookmarks[p.current] = title
+}
+
+// writeOutline writes the document outline with one bookmark per page,
+// numbered from first, for pages numbered pageObjs. Pages without a title
+// are bookmarked by number.
+func (p *PDF) writeOutline(w *pdfWriter, first int, pageObjs []int) {
+	n := len(pageObjs)
+	for i, page := range pageObjs {
+		title := p.bookmarks[i]
+		if title == "" {
+			title = fmt.Sprintf("Page %d", i+1)
+		}
+		item := []string{
+			"<<",
+			"/Title " + textString(title),
+			"/Parent " + ref(first),
+			"/Dest [" + ref(page) + " /Fit]",
+		}
+		if i > 0 {
+			item = append(item, "/Prev "+ref(first+i))
+		}
+		if i < n-1 {
+			item = append(item, "/Next "+ref(first+i+2))
+		}
+		w.object(first+i+1, append(item, ">>")...)
+	}
+	w.object(first,
+		"<<",
+		"/Type /Outlines",
+		"/First "+ref(first+1),
+		"/Last "+ref(first+n),
+		fmt.Sprintf("/Count %d", n),
+		">>",
+	)
+}
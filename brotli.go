@@ -0,0 +1,831 @@
+package svg2pdf
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// brotliDictionary is the static dictionary of RFC 7932, appendix A
+//
+//go:embed brotli.dict
+var brotliDictionary string
+
+// brotliReader decodes a Brotli stream (RFC 7932), as WOFF2 compresses
+// font tables. Errors are sticky: once err is set, reads return zeros.
+type brotliReader struct {
+	data []byte
+	pos  int    // Next byte of data to load into bits
+	bits uint64 // Loaded bits not read yet, first in the lowest bit
+	n    uint   // Number of loaded bits
+	err  error
+
+	out   []byte
+	max   int    // Size out may reach
+	dists [4]int // Last distances, the most recent first
+	limit int    // Largest backward distance of the window
+}
+
+// decodeBrotli decompresses data, failing if it decompresses to more than
+// max bytes
+func decodeBrotli(data []byte, max int) ([]byte, error) {
+	br := &brotliReader{data: data, max: max, dists: [4]int{4, 11, 15, 16}}
+	br.limit = 1<<br.windowBits() - 16
+	for last := false; !last && br.err == nil; {
+		last = br.read(1) == 1
+		if last && br.read(1) == 1 {
+			break // Empty last meta-block
+		}
+		br.metaBlock(last)
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+	return br.out, nil
+}
+
+// fail records the first error of the stream
+func (br *brotliReader) fail(format string, args ...any) {
+	if br.err == nil {
+		br.err = fmt.Errorf("invalid Brotli data: "+format, args...)
+	}
+}
+
+// fill loads bits until at least n are held or the data ends
+func (br *brotliReader) fill(n uint) {
+	for br.n < n && br.pos < len(br.data) {
+		br.bits |= uint64(br.data[br.pos]) << br.n
+		br.pos++
+		br.n += 8
+	}
+}
+
+// read reads an n-bit value, n up to 32
+func (br *brotliReader) read(n uint) int {
+	br.fill(n)
+	if br.n < n {
+		br.fail("unexpected end of data")
+	}
+	if br.err != nil {
+		return 0
+	}
+	v := int(br.bits & (1<<n - 1))
+	br.bits >>= n
+	br.n -= n
+	return v
+}
+
+// align skips the padding up to the next byte boundary, which must be
+// zero bits
+func (br *brotliReader) align() {
+	if br.read(br.n%8) != 0 {
+		br.fail("nonzero padding")
+	}
+}
+
+// windowBits reads the size of the sliding window, as a power of two
+func (br *brotliReader) windowBits() uint {
+	if br.read(1) == 0 {
+		return 16
+	}
+	if n := br.read(3); n != 0 {
+		return 17 + uint(n)
+	}
+	switch n := br.read(3); n {
+	case 0:
+		return 17
+	case 1:
+		br.fail("large window")
+		return 16
+	default:
+		return 8 + uint(n)
+	}
+}
+
+// count256 reads a count from 1 to 256, such as a number of block types
+// or of prefix codes
+func (br *brotliReader) count256() int {
+	if br.read(1) == 0 {
+		return 1
+	}
+	n := uint(br.read(3))
+	if n == 0 {
+		return 2
+	}
+	return 1<<n + br.read(n) + 1
+}
+
+// metaBlock decodes a meta-block, after its ISLAST bit
+func (br *brotliReader) metaBlock(last bool) {
+	nibbles := br.read(2)
+	if nibbles == 3 { // Metadata, skipped
+		if br.read(1) != 0 {
+			br.fail("reserved bit set")
+		}
+		size, skipBytes := 0, br.read(2)
+		for i := 0; i < skipBytes; i++ {
+			b := br.read(8)
+			if i > 0 && i == skipBytes-1 && b == 0 {
+				br.fail("metadata length with a leading zero byte")
+			}
+			size |= b << (8 * i)
+		}
+		if skipBytes > 0 {
+			size++
+		}
+		br.align()
+		for ; size > 0 && br.err == nil; size-- {
+			br.read(8)
+		}
+		return
+	}
+	nibbles += 4
+	length := br.read(uint(4*nibbles)) + 1
+	if nibbles > 4 && length-1 < 1<<(4*(nibbles-1)) {
+		br.fail("meta-block length with a leading zero nibble")
+	}
+	if len(br.out)+length > br.max {
+		br.fail("more than %d bytes", br.max)
+	}
+	if !last && br.read(1) == 1 { // Uncompressed
+		br.align()
+		for i := 0; i < length && br.err == nil; i++ {
+			br.out = append(br.out, byte(br.read(8)))
+		}
+		return
+	}
+	if br.err == nil {
+		br.compressed(len(br.out) + length)
+	}
+}
+
+// brotliCode is a prefix code, decoded canonically as in deflate
+type brotliCode struct {
+	counts  [16]int  // Number of codes of each length
+	symbols []uint16 // Symbols ordered by code
+	single  bool     // Whether symbols[0] is coded with no bits
+}
+
+// newBrotliCode returns the prefix code of the given code lengths
+func newBrotliCode(lengths []uint8) brotliCode {
+	var c brotliCode
+	for sym, l := range lengths {
+		if l > 0 {
+			c.counts[l]++
+			c.symbols = append(c.symbols, uint16(sym))
+		}
+	}
+	if len(c.symbols) == 1 {
+		c.single = true
+		return c
+	}
+	var offsets [16]int
+	for l := 2; l < 16; l++ {
+		offsets[l] = offsets[l-1] + c.counts[l-1]
+	}
+	for sym, l := range lengths {
+		if l > 0 {
+			c.symbols[offsets[l]] = uint16(sym)
+			offsets[l]++
+		}
+	}
+	return c
+}
+
+// symbol reads a symbol coded with c
+func (br *brotliReader) symbol(c *brotliCode) int {
+	if c.single {
+		return int(c.symbols[0])
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l < 16 && br.err == nil; l++ {
+		code |= br.read(1)
+		if count := c.counts[l]; code-first < count {
+			return int(c.symbols[index+code-first])
+		}
+		index += c.counts[l]
+		first = (first + c.counts[l]) << 1
+		code <<= 1
+	}
+	br.fail("invalid prefix code")
+	return 0
+}
+
+// brotliCodeLengthOrder is the order code length code lengths are stored in
+var brotliCodeLengthOrder = [18]int{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// brotliCodeLengthLengths and brotliCodeLengthValues decode the static
+// code of code length code lengths, indexed by its next 4 bits
+var (
+	brotliCodeLengthLengths = [16]uint{2, 2, 2, 3, 2, 2, 2, 4, 2, 2, 2, 3, 2, 2, 2, 4}
+	brotliCodeLengthValues  = [16]uint8{0, 4, 3, 2, 0, 4, 3, 1, 0, 4, 3, 2, 0, 4, 3, 5}
+)
+
+// readCode reads a prefix code of symbols below alphabet
+func (br *brotliReader) readCode(alphabet int) brotliCode {
+	lengths := make([]uint8, alphabet)
+	hskip := br.read(2)
+	if hskip == 1 { // Simple code
+		bits := uint(0)
+		for 1<<bits < alphabet {
+			bits++
+		}
+		symbols := make([]int, br.read(2)+1)
+		for i := range symbols {
+			symbols[i] = br.read(bits)
+			if symbols[i] >= alphabet || lengths[symbols[i]] != 0 {
+				br.fail("invalid simple prefix code")
+				return brotliCode{single: true, symbols: []uint16{0}}
+			}
+			lengths[symbols[i]] = 1
+		}
+		switch len(symbols) {
+		case 3:
+			lengths[symbols[1]], lengths[symbols[2]] = 2, 2
+		case 4:
+			if br.read(1) == 0 {
+				for _, sym := range symbols {
+					lengths[sym] = 2
+				}
+			} else {
+				lengths[symbols[1]], lengths[symbols[2]], lengths[symbols[3]] = 2, 3, 3
+			}
+		}
+		return newBrotliCode(lengths)
+	}
+
+	var codeLengths [18]uint8
+	space, codes := 32, 0
+	for i := hskip; i < len(codeLengths) && space > 0; i++ {
+		br.fill(4)
+		peek := br.bits & 15
+		if br.n < brotliCodeLengthLengths[peek] {
+			br.fail("unexpected end of data")
+			break
+		}
+		br.bits >>= brotliCodeLengthLengths[peek]
+		br.n -= brotliCodeLengthLengths[peek]
+		l := brotliCodeLengthValues[peek]
+		codeLengths[brotliCodeLengthOrder[i]] = l
+		if l != 0 {
+			space -= 32 >> l
+			codes++
+		}
+	}
+	if codes != 1 && space != 0 {
+		br.fail("invalid code length code")
+	}
+	code := newBrotliCode(codeLengths[:])
+
+	prev, repeat, repeatLength := uint8(8), 0, uint8(0)
+	space = 32768
+	for sym := 0; sym < alphabet && space > 0 && br.err == nil; {
+		l := br.symbol(&code)
+		if l < 16 {
+			lengths[sym] = uint8(l)
+			sym++
+			repeat = 0
+			if l != 0 {
+				prev = uint8(l)
+				space -= 32768 >> l
+			}
+			continue
+		}
+		// 16 repeats the previous nonzero length, 17 repeats zero, and
+		// consecutive repeats of the same length extend each other
+		extra, length := uint(2), prev
+		if l == 17 {
+			extra, length = 3, 0
+		}
+		if repeatLength != length {
+			repeat, repeatLength = 0, length
+		}
+		old := repeat
+		if repeat > 0 {
+			repeat = (repeat - 2) << extra
+		}
+		repeat += br.read(extra) + 3
+		if sym+repeat-old > alphabet {
+			br.fail("code lengths beyond the alphabet")
+			break
+		}
+		for ; old < repeat; old++ {
+			lengths[sym] = length
+			sym++
+			if length != 0 {
+				space -= 32768 >> length
+			}
+		}
+	}
+	if space != 0 {
+		br.fail("incomplete prefix code")
+	}
+	if br.err != nil {
+		return brotliCode{single: true, symbols: []uint16{0}}
+	}
+	return newBrotliCode(lengths)
+}
+
+// contextMap reads the map of size contexts to one of trees prefix codes
+func (br *brotliReader) contextMap(size, trees int) []uint8 {
+	m := make([]uint8, size)
+	if trees < 2 {
+		return m
+	}
+	maxRun := 0
+	if br.read(1) == 1 {
+		maxRun = br.read(4) + 1
+	}
+	code := br.readCode(trees + maxRun)
+	for i := 0; i < size && br.err == nil; {
+		v := br.symbol(&code)
+		switch {
+		case v == 0:
+			i++
+		case v <= maxRun: // A run of zeros
+			run := 1<<v + br.read(uint(v))
+			if i+run > size {
+				br.fail("context map run beyond its size")
+			}
+			i += run
+		default:
+			m[i] = uint8(v - maxRun)
+			i++
+		}
+	}
+	if br.read(1) == 1 { // Inverse move-to-front transform
+		var mtf [256]uint8
+		for i := range mtf {
+			mtf[i] = uint8(i)
+		}
+		for i, index := range m {
+			v := mtf[index]
+			copy(mtf[1:index+1], mtf[:index])
+			mtf[0], m[i] = v, v
+		}
+	}
+	for _, tree := range m {
+		if int(tree) >= trees {
+			br.fail("context map beyond its prefix codes")
+		}
+	}
+	return m
+}
+
+// brotliBlocks tracks the block types of a category: literals,
+// insert-and-copy commands or distances
+type brotliBlocks struct {
+	types      int
+	typeCode   brotliCode
+	countCode  brotliCode
+	kind, prev int // Current and previous block type
+	left       int // Items left in the current block
+}
+
+// brotliBlockCounts are the base and extra bits of block count codes
+var brotliBlockCounts = [26][2]int{
+	{1, 2}, {5, 2}, {9, 2}, {13, 2}, {17, 3}, {25, 3}, {33, 3}, {41, 3},
+	{49, 4}, {65, 4}, {81, 4}, {97, 4}, {113, 5}, {145, 5}, {177, 5}, {209, 5},
+	{241, 6}, {305, 6}, {369, 7}, {497, 8}, {753, 9}, {1265, 10}, {2289, 11}, {4337, 12},
+	{8433, 13}, {16625, 24},
+}
+
+// blocks reads the block types of a category and the first block count
+func (br *brotliReader) blocks() brotliBlocks {
+	b := brotliBlocks{types: br.count256(), prev: 1}
+	if b.types > 1 {
+		b.typeCode = br.readCode(b.types + 2)
+		b.countCode = br.readCode(len(brotliBlockCounts))
+		b.left = br.blockCount(&b.countCode)
+	}
+	return b
+}
+
+// blockCount reads a block count
+func (br *brotliReader) blockCount(c *brotliCode) int {
+	bc := brotliBlockCounts[br.symbol(c)]
+	return bc[0] + br.read(uint(bc[1]))
+}
+
+// next accounts for the next item of a category, switching block type at
+// the end of the current block
+func (br *brotliReader) next(b *brotliBlocks) {
+	if b.types < 2 {
+		return
+	}
+	if b.left == 0 {
+		t := br.symbol(&b.typeCode)
+		switch t {
+		case 0:
+			t = b.prev
+		case 1:
+			t = b.kind + 1
+		default:
+			t -= 2
+		}
+		if t >= b.types {
+			t -= b.types
+		}
+		b.prev, b.kind = b.kind, t
+		b.left = br.blockCount(&b.countCode)
+	}
+	b.left--
+}
+
+// brotliCommandCells are the first insert and copy length codes of each 64
+// insert-and-copy codes; the first 128 reuse the last distance
+var brotliCommandCells = [11][2]int{{0, 0}, {0, 8}, {0, 0}, {0, 8}, {8, 0}, {8, 8}, {0, 16}, {16, 0}, {8, 16}, {16, 8}, {16, 16}}
+
+// brotliInsertLengths and brotliCopyLengths are the base and extra bits of
+// insert and copy length codes
+var (
+	brotliInsertLengths = [24][2]int{
+		{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 1}, {8, 1},
+		{10, 2}, {14, 2}, {18, 3}, {26, 3}, {34, 4}, {50, 4}, {66, 5}, {98, 5},
+		{130, 6}, {194, 7}, {322, 8}, {578, 9}, {1090, 10}, {2114, 12}, {6210, 14}, {22594, 24},
+	}
+	brotliCopyLengths = [24][2]int{
+		{2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0},
+		{10, 1}, {12, 1}, {14, 2}, {18, 2}, {22, 3}, {30, 3}, {38, 4}, {54, 4},
+		{70, 5}, {102, 5}, {134, 6}, {198, 7}, {326, 8}, {582, 9}, {1094, 10}, {2118, 24},
+	}
+)
+
+// brotliLastDistances are the last distance and the offset from it coded
+// by the distance codes below 16
+var brotliLastDistances = [16][2]int{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0}, {0, -1}, {0, 1}, {0, -2}, {0, 2},
+	{0, -3}, {0, 3}, {1, -1}, {1, 1}, {1, -2}, {1, 2}, {1, -3}, {1, 3},
+}
+
+// compressed decodes the commands of a compressed meta-block ending when
+// the output reaches end
+func (br *brotliReader) compressed(end int) {
+	literals, commands, distances := br.blocks(), br.blocks(), br.blocks()
+	postfix := uint(br.read(2))
+	direct := br.read(4) << postfix
+	modes := make([]int, literals.types)
+	for i := range modes {
+		modes[i] = br.read(2)
+	}
+	literalTrees := br.count256()
+	literalMap := br.contextMap(64*literals.types, literalTrees)
+	distanceTrees := br.count256()
+	distanceMap := br.contextMap(4*distances.types, distanceTrees)
+	literalCodes := make([]brotliCode, literalTrees)
+	for i := range literalCodes {
+		literalCodes[i] = br.readCode(256)
+	}
+	commandCodes := make([]brotliCode, commands.types)
+	for i := range commandCodes {
+		commandCodes[i] = br.readCode(704)
+	}
+	distanceCodes := make([]brotliCode, distanceTrees)
+	for i := range distanceCodes {
+		distanceCodes[i] = br.readCode(16 + direct + 48<<postfix)
+	}
+
+	for len(br.out) < end && br.err == nil {
+		br.next(&commands)
+		command := br.symbol(&commandCodes[commands.kind])
+		cell := brotliCommandCells[command>>6]
+		insert := brotliInsertLengths[cell[0]+command>>3&7]
+		copying := brotliCopyLengths[cell[1]+command&7]
+		n := insert[0] + br.read(uint(insert[1]))
+		length := copying[0] + br.read(uint(copying[1]))
+		if len(br.out)+n > end {
+			br.fail("literals beyond the meta-block")
+			return
+		}
+		for ; n > 0 && br.err == nil; n-- {
+			br.next(&literals)
+			var p1, p2 byte
+			if k := len(br.out); k > 1 {
+				p1, p2 = br.out[k-1], br.out[k-2]
+			} else if k == 1 {
+				p1 = br.out[0]
+			}
+			tree := literalMap[64*literals.kind+brotliContext(modes[literals.kind], p1, p2)]
+			br.out = append(br.out, byte(br.symbol(&literalCodes[tree])))
+		}
+		if len(br.out) == end || br.err != nil {
+			return // The copy of the last command is ignored
+		}
+
+		code := 0
+		if command >= 128 {
+			br.next(&distances)
+			ctx := min(length-2, 3)
+			code = br.symbol(&distanceCodes[distanceMap[4*distances.kind+ctx]])
+		}
+		var distance int
+		switch {
+		case code < 16:
+			last := brotliLastDistances[code]
+			distance = br.dists[last[0]] + last[1]
+			if distance <= 0 {
+				br.fail("invalid distance")
+				return
+			}
+		case code < 16+direct:
+			distance = code - 15
+		default:
+			c := code - 16 - direct
+			bits := uint(1 + c>>(postfix+1))
+			offset := (2+c>>postfix&1)<<bits - 4
+			distance = (offset+br.read(bits))<<postfix + c&(1<<postfix-1) + direct + 1
+		}
+		if limit := min(len(br.out), br.limit); distance > limit {
+			br.dictionaryWord(distance-limit-1, length)
+		} else {
+			if len(br.out)+length > end {
+				br.fail("copy beyond the meta-block")
+				return
+			}
+			if code != 0 {
+				copy(br.dists[1:], br.dists[:3])
+				br.dists[0] = distance
+			}
+			for i := 0; i < length; i++ {
+				br.out = append(br.out, br.out[len(br.out)-distance])
+			}
+		}
+		if len(br.out) > end {
+			br.fail("copy beyond the meta-block")
+		}
+	}
+}
+
+// brotliDictionaryBits are the log2 of the number of dictionary words of
+// each length
+var brotliDictionaryBits = [25]uint{0, 0, 0, 0, 10, 10, 11, 11, 10, 10, 10, 10, 10, 9, 9, 8, 7, 7, 8, 7, 7, 6, 6, 5, 5}
+
+// dictionaryWord appends the transformed dictionary word of the given
+// length coded by id
+func (br *brotliReader) dictionaryWord(id, length int) {
+	if length < 4 || length > 24 {
+		br.fail("invalid dictionary word length %d", length)
+		return
+	}
+	bits := brotliDictionaryBits[length]
+	word, transform := id&(1<<bits-1), id>>bits
+	if transform >= len(brotliTransforms) {
+		br.fail("invalid dictionary transform %d", transform)
+		return
+	}
+	offset := 0
+	for l := 4; l < length; l++ {
+		offset += l << brotliDictionaryBits[l]
+	}
+	offset += word * length
+	br.out = brotliTransforms[transform].apply(br.out, brotliDictionary[offset:offset+length])
+}
+
+// brotliTransform is a transform of dictionary words. Kinds are numbered
+// as in RFC 7932: 0 keeps the word, 1 to 9 omit as many last bytes, 10
+// uppercases the first letter, 11 all letters, and 12 to 20 omit 1 to 9
+// first bytes.
+type brotliTransform struct {
+	prefix string
+	kind   int
+	suffix string
+}
+
+// apply appends word, transformed, to out
+func (t brotliTransform) apply(out []byte, word string) []byte {
+	out = append(out, t.prefix...)
+	switch {
+	case t.kind <= 9:
+		out = append(out, word[:len(word)-min(t.kind, len(word))]...)
+	case t.kind >= 12:
+		out = append(out, word[min(t.kind-11, len(word)):]...)
+	default:
+		start := len(out)
+		out = append(out, word...)
+		for i := start; i < len(out); {
+			// Lowercase ASCII letters and the last byte of 2-byte UTF-8
+			// sequences have their case bit flipped, 3-byte sequences are
+			// altered as RFC 7932 prescribes
+			switch c := out[i]; {
+			case c < 0xC0:
+				if c >= 'a' && c <= 'z' {
+					out[i] ^= 32
+				}
+				i++
+			case c < 0xE0:
+				if i+1 < len(out) {
+					out[i+1] ^= 32
+				}
+				i += 2
+			default:
+				if i+2 < len(out) {
+					out[i+2] ^= 5
+				}
+				i += 3
+			}
+			if t.kind == 10 {
+				break
+			}
+		}
+	}
+	return append(out, t.suffix...)
+}
+
+// brotliContext returns the literal context of context mode for the last
+// two bytes p1 and p2: the 6 last or first bits of p1, or a class of both
+// by UTF-8 or signed value
+func brotliContext(mode int, p1, p2 byte) int {
+	switch mode {
+	case 0:
+		return int(p1 & 0x3F)
+	case 1:
+		return int(p1 >> 2)
+	case 2:
+		return int(brotliUTF8Contexts[p1] | brotliUTF8Contexts[256+int(p2)])
+	default:
+		return brotliSignedClass(p1)<<3 | brotliSignedClass(p2)
+	}
+}
+
+// brotliSignedClass classes b by magnitude as a signed value
+func brotliSignedClass(b byte) int {
+	switch {
+	case b == 0:
+		return 0
+	case b < 16:
+		return 1
+	case b < 64:
+		return 2
+	case b < 128:
+		return 3
+	case b < 192:
+		return 4
+	case b < 240:
+		return 5
+	case b < 255:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// brotliUTF8Contexts are the UTF-8 context classes of the last byte, then
+// of the byte before it
+var brotliUTF8Contexts = [512]uint8{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 4, 0, 0, 4, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	8, 12, 16, 12, 12, 20, 12, 16, 24, 28, 12, 12, 32, 12, 36, 12,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44, 32, 32, 24, 40, 28, 12,
+	12, 48, 52, 52, 52, 48, 52, 52, 52, 48, 52, 52, 52, 52, 52, 48,
+	52, 52, 52, 52, 52, 48, 52, 52, 52, 52, 52, 24, 12, 28, 12, 12,
+	12, 56, 60, 60, 60, 56, 60, 60, 60, 56, 60, 60, 60, 60, 60, 56,
+	60, 60, 60, 60, 60, 56, 60, 60, 60, 60, 60, 24, 12, 28, 12, 0,
+	0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1,
+	0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1,
+	0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1,
+	0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1,
+	2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3,
+	2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3,
+	2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3,
+	2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1,
+	1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1,
+	1, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 1, 1, 1, 1, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+}
+
+// brotliTransforms are the 121 transforms of RFC 7932, appendix B
+var brotliTransforms = [...]brotliTransform{
+	{"", 0, ""},
+	{"", 0, " "},
+	{" ", 0, " "},
+	{"", 12, ""},
+	{"", 10, " "},
+	{"", 0, " the "},
+	{" ", 0, ""},
+	{"s ", 0, " "},
+	{"", 0, " of "},
+	{"", 10, ""},
+	{"", 0, " and "},
+	{"", 13, ""},
+	{"", 1, ""},
+	{", ", 0, " "},
+	{"", 0, ", "},
+	{" ", 10, " "},
+	{"", 0, " in "},
+	{"", 0, " to "},
+	{"e ", 0, " "},
+	{"", 0, "\""},
+	{"", 0, "."},
+	{"", 0, "\">"},
+	{"", 0, "\n"},
+	{"", 3, ""},
+	{"", 0, "]"},
+	{"", 0, " for "},
+	{"", 14, ""},
+	{"", 2, ""},
+	{"", 0, " a "},
+	{"", 0, " that "},
+	{" ", 10, ""},
+	{"", 0, ". "},
+	{".", 0, ""},
+	{" ", 0, ", "},
+	{"", 15, ""},
+	{"", 0, " with "},
+	{"", 0, "'"},
+	{"", 0, " from "},
+	{"", 0, " by "},
+	{"", 16, ""},
+	{"", 17, ""},
+	{" the ", 0, ""},
+	{"", 4, ""},
+	{"", 0, ". The "},
+	{"", 11, ""},
+	{"", 0, " on "},
+	{"", 0, " as "},
+	{"", 0, " is "},
+	{"", 7, ""},
+	{"", 1, "ing "},
+	{"", 0, "\n\t"},
+	{"", 0, ":"},
+	{" ", 0, ". "},
+	{"", 0, "ed "},
+	{"", 20, ""},
+	{"", 18, ""},
+	{"", 6, ""},
+	{"", 0, "("},
+	{"", 10, ", "},
+	{"", 8, ""},
+	{"", 0, " at "},
+	{"", 0, "ly "},
+	{" the ", 0, " of "},
+	{"", 5, ""},
+	{"", 9, ""},
+	{" ", 10, ", "},
+	{"", 10, "\""},
+	{".", 0, "("},
+	{"", 11, " "},
+	{"", 10, "\">"},
+	{"", 0, "=\""},
+	{" ", 0, "."},
+	{".com/", 0, ""},
+	{" the ", 0, " of the "},
+	{"", 10, "'"},
+	{"", 0, ". This "},
+	{"", 0, ","},
+	{".", 0, " "},
+	{"", 10, "("},
+	{"", 10, "."},
+	{"", 0, " not "},
+	{" ", 0, "=\""},
+	{"", 0, "er "},
+	{" ", 11, " "},
+	{"", 0, "al "},
+	{" ", 11, ""},
+	{"", 0, "='"},
+	{"", 11, "\""},
+	{"", 10, ". "},
+	{" ", 0, "("},
+	{"", 0, "ful "},
+	{" ", 10, ". "},
+	{"", 0, "ive "},
+	{"", 0, "less "},
+	{"", 11, "'"},
+	{"", 0, "est "},
+	{" ", 10, "."},
+	{"", 11, "\">"},
+	{" ", 0, "='"},
+	{"", 10, ","},
+	{"", 0, "ize "},
+	{"", 11, "."},
+	{"\u00a0", 0, ""},
+	{" ", 0, ","},
+	{"", 10, "=\""},
+	{"", 11, "=\""},
+	{"", 0, "ous "},
+	{"", 11, ", "},
+	{"", 10, "='"},
+	{" ", 10, ","},
+	{" ", 11, "=\""},
+	{" ", 11, ", "},
+	{"", 11, ","},
+	{"", 11, "("},
+	{"", 11, ". "},
+	{" ", 11, "."},
+	{"", 11, "='"},
+	{" ", 11, ". "},
+	{" ", 10, "=\""},
+	{" ", 11, "='"},
+	{" ", 10, "='"},
+}
@@ -0,0 +1,44 @@
+package svg2pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeBrotli(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog. THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG! the time of the people, with the information. Größe, «naïve» café; 0x7F 0x80 0xFF — the end."
+	// Compressed at the highest quality, with dictionary words and
+	// literal context modes
+	compressed := []byte("" +
+		"\x1b\xbd\x00\xe0\xc5\x87\x3f\x17\xe9\x9b\xa7\x25\x65\x5e\x94\x6d" +
+		"\x4d\x17\x6d\x76\x7c\x6d\x6d\xfb\xdf\xd8\xd8\xc6\xdc\x62\x38\xca" +
+		"\x51\x26\xe7\xf1\x8c\xe7\x4f\x04\x67\x4b\x52\x46\xa3\xf8\xfd\xae" +
+		"\x06\x6d\x85\x22\xb8\x07\x65\xbd\x38\xa2\x71\xdf\x20\x2d\xff\x50" +
+		"\x75\xa2\x5c\x34\x2c\x06\x8e\x09\x92\x8a\x68\x9a\x61\xc5\x13\xe4" +
+		"\x54\xed\x13\xa4\xf6\x40\x9d\x4d\x61\xc4\x36\xc5\x89\x44\x14\x38" +
+		"\x67\xdb\x3e\x5c\xf5\xb5\xd7\xe4\x45\x4e\x15\x8d\xee\x3a\xcf\xc8" +
+		"\x73\x5f\x75\x7f\x14\x59\x4d\xce\x0a\xf8\xa3\x60\x05\x88\xbc\x59" +
+		"\xd6\x68\xdb\x0e\x7d\x85\x05")
+	out, err := decodeBrotli(compressed, len(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != text {
+		t.Errorf("got %q, want %q", out, text)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		max  int
+		err  string
+	}{
+		{"truncated", compressed[:len(compressed)/2], len(text), "unexpected end of data"},
+		{"too large", compressed, len(text) - 1, "more than"},
+		{"empty", nil, 0, "unexpected end of data"},
+	} {
+		if _, err := decodeBrotli(tt.data, tt.max); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Caption configures the caption drawn beneath converted figures
+type Caption struct {
+	Text       string  // Caption text; the SVG <title> is used when empty
+	Label      string  // Numbering label, "Figure" when empty
+	Unnumbered bool    // Omit the "Figure 3" label
+	FontSize   float64 // Caption font size, the PDF font size when 0
+}
+
+// Caption layout in points
+const (
+	captionMargin      = 36  // Page margin the figure and caption stay within
+	captionGap         = 8   // Space between the figure and its caption
+	captionLineSpacing = 1.2 // Baseline distance in font sizes
+)
+
+// SetCaption draws a numbered caption beneath every figure converted after
+// the call, until it is called again; nil disables captions. Captioned
+// figures are scaled to leave room for the caption within the page margins.
+func (p *PDF) SetCaption(c *Caption) {
+	p.caption = c
+}
+
+// captionLines returns the wrapped lines of the caption for a figure whose
+// document title is title, numbering the figure and wrapping it to width
+func (p *PDF) captionLines(title string, width float64) ([]string, float64) {
+	c := p.caption
+	size := c.FontSize
+	if size <= 0 {
+		size = p.fontSize
+	}
+	text := strings.Join(strings.Fields(c.Text), " ")
+	if text == "" {
+		text = strings.Join(strings.Fields(title), " ")
+	}
+	if !c.Unnumbered {
+		p.figureCount++
+		label := c.Label
+		if label == "" {
+			label = "Figure"
+		}
+		label = fmt.Sprintf("%s %d", label, p.figureCount)
+		if text != "" {
+			text = label + ": " + text
+		} else {
+			text = label
+		}
+	}
+	if text == "" {
+		return nil, size
+	}
+	return wrapText(text, size, width), size
+}
+
+// captionHeight returns the height taken by caption lines, including the
+// gap and, on a baseline grid, room for snapping the first line
+func (p *PDF) captionHeight(lines []string, size float64) float64 {
+	if len(lines) == 0 {
+		return 0
+	}
+	h := captionGap + float64(len(lines))*p.lineAdvance(size*captionLineSpacing)
+	if p.baselineGrid != nil {
+		h += p.baselineGrid.Spacing
+	}
+	return h
+}
+
+// drawCaption draws the lines centered within box, the first line's top at
+// top (measured from the top of the page)
+func (p *PDF) drawCaption(lines []string, size float64, box viewBox, top float64) {
+	if len(lines) == 0 {
+		return
+	}
+	face := standardFont{}
+	stream := append(p.beginMarked("Caption"), "BT", "/"+face.resourceName()+" "+p.operator("Tf", size))
+	baseline := p.snapBaseline(top + captionGap + p.lineAscent(face, size))
+	for i, line := range lines {
+		x := box.X + (box.W-textWidth(line, size))/2
+		if i > 0 {
+			baseline = p.snapBaseline(baseline + size*captionLineSpacing)
+		}
+		stream = append(stream,
+			p.operator("Tm", 1, 0, 0, 1, x, p.pageHeight-baseline),
+			face.encode(line)+" Tj",
+		)
+	}
+	p.emit(append(append(stream, "ET"), p.endMarked()...)...)
+}
+
+// wrapText breaks text into lines no wider than width, measured with the
+// built-in font metrics. Words longer than a line are kept whole.
+func wrapText(text string, size, width float64) []string {
+	return wrapWords(text, width, func(s string) float64 { return textWidth(s, size) })
+}
+
+// wrapWords breaks text into lines no wider than width as measured by
+// measure, keeping words longer than a line whole
+func wrapWords(text string, width float64, measure func(string) float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && measure(candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,513 @@
+package svg2pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// cffFont holds the parts of a CFF table needed to decode glyph outlines
+type cffFont struct {
+	charStrings [][]byte
+	globalSubrs [][]byte
+	localSubrs  [][][]byte // Local subroutines per font dict
+	fdSelect    []byte     // Font dict index per glyph, nil for non-CID fonts
+}
+
+// cffIndex reads a CFF INDEX structure at pos, returning its items and the
+// position following it
+func cffIndex(data []byte, pos int) ([][]byte, int, error) {
+	errTruncated := fmt.Errorf("truncated CFF INDEX")
+	if pos < 0 || pos >= len(data) {
+		return nil, 0, fmt.Errorf("CFF INDEX offset %d out of range", pos)
+	}
+	if pos+2 > len(data) {
+		return nil, 0, errTruncated
+	}
+	count := int(binary.BigEndian.Uint16(data[pos:]))
+	if count == 0 {
+		return nil, pos + 2, nil
+	}
+	if pos+3 > len(data) {
+		return nil, 0, errTruncated
+	}
+	offSize := int(data[pos+2])
+	if offSize < 1 || offSize > 4 {
+		return nil, 0, fmt.Errorf("invalid CFF offset size %d", offSize)
+	}
+	offsets := pos + 3
+	base := offsets + (count+1)*offSize - 1 // Offsets are 1-based
+	if base >= len(data) {
+		return nil, 0, errTruncated
+	}
+	readOffset := func(i int) int {
+		v := 0
+		for _, b := range data[offsets+i*offSize : offsets+(i+1)*offSize] {
+			v = v<<8 | int(b)
+		}
+		return base + v
+	}
+	items := make([][]byte, count)
+	for i := range items {
+		start, end := readOffset(i), readOffset(i+1)
+		if start > end || end > len(data) {
+			return nil, 0, errTruncated
+		}
+		items[i] = data[start:end]
+	}
+	return items, readOffset(count), nil
+}
+
+// cffDict parses a CFF DICT into operands keyed by operator, with escaped
+// operators stored as 1200 + the second byte
+func cffDict(data []byte) map[int][]float64 {
+	dict := make(map[int][]float64)
+	var operands []float64
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b <= 21:
+			op := int(b)
+			i++
+			if b == 12 && i < len(data) {
+				op = 1200 + int(data[i])
+				i++
+			}
+			dict[op] = operands
+			operands = nil
+		case b == 28 && i+3 <= len(data):
+			operands = append(operands, float64(int16(binary.BigEndian.Uint16(data[i+1:]))))
+			i += 3
+		case b == 29 && i+5 <= len(data):
+			operands = append(operands, float64(int32(binary.BigEndian.Uint32(data[i+1:]))))
+			i += 5
+		case b == 30:
+			// Real numbers are nibble encoded; skip to the terminating nibble
+			i++
+			for i < len(data) && data[i]&0x0f != 0x0f && data[i]>>4 != 0x0f {
+				i++
+			}
+			i++
+			operands = append(operands, 0)
+		case b >= 32 && b <= 246:
+			operands = append(operands, float64(int(b)-139))
+			i++
+		case b >= 247 && b <= 250 && i+2 <= len(data):
+			operands = append(operands, float64((int(b)-247)*256+int(data[i+1])+108))
+			i += 2
+		case b >= 251 && b <= 254 && i+2 <= len(data):
+			operands = append(operands, float64(-(int(b)-251)*256-int(data[i+1])-108))
+			i += 2
+		default:
+			return dict
+		}
+	}
+	return dict
+}
+
+// parseCFF extracts the charstrings and subroutines of a CFF table
+func parseCFF(data []byte) (*cffFont, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid CFF header")
+	}
+	_, pos, err := cffIndex(data, int(data[2])) // Name INDEX
+	if err != nil {
+		return nil, err
+	}
+	topDicts, pos, err := cffIndex(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	if len(topDicts) == 0 {
+		return nil, fmt.Errorf("CFF table has no fonts")
+	}
+	_, pos, err = cffIndex(data, pos) // String INDEX
+	if err != nil {
+		return nil, err
+	}
+	cff := &cffFont{}
+	if cff.globalSubrs, _, err = cffIndex(data, pos); err != nil {
+		return nil, err
+	}
+
+	top := cffDict(topDicts[0])
+	if len(top[17]) != 1 {
+		return nil, fmt.Errorf("CFF font has no CharStrings")
+	}
+	if cff.charStrings, _, err = cffIndex(data, int(top[17][0])); err != nil {
+		return nil, err
+	}
+
+	if fdArray := top[1236]; len(fdArray) == 1 {
+		// CID-keyed font: every font dict has its own private subroutines
+		fds, _, err := cffIndex(data, int(fdArray[0]))
+		if err != nil {
+			return nil, err
+		}
+		for _, fd := range fds {
+			subrs, err := cffPrivateSubrs(data, cffDict(fd))
+			if err != nil {
+				return nil, err
+			}
+			cff.localSubrs = append(cff.localSubrs, subrs)
+		}
+		if sel := top[1237]; len(sel) == 1 {
+			if cff.fdSelect, err = cffFDSelect(data, int(sel[0]), len(cff.charStrings)); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		subrs, err := cffPrivateSubrs(data, top)
+		if err != nil {
+			return nil, err
+		}
+		cff.localSubrs = [][][]byte{subrs}
+	}
+	return cff, nil
+}
+
+// cffPrivateSubrs returns the local subroutines of the Private DICT
+// referenced from dict, nil if it has none
+func cffPrivateSubrs(data []byte, dict map[int][]float64) ([][]byte, error) {
+	private := dict[18]
+	if len(private) != 2 {
+		return nil, nil
+	}
+	size, offset := int(private[0]), int(private[1])
+	if offset < 0 || size < 0 || offset+size > len(data) {
+		return nil, fmt.Errorf("CFF Private DICT out of range")
+	}
+	subrs := cffDict(data[offset : offset+size])[19]
+	if len(subrs) != 1 {
+		return nil, nil
+	}
+	pos := offset + int(subrs[0])
+	if pos < 0 || pos >= len(data) {
+		return nil, fmt.Errorf("CFF local subroutines out of range")
+	}
+	items, _, err := cffIndex(data, pos)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CFF local subroutines: %v", err)
+	}
+	return items, nil
+}
+
+// cffFDSelect expands an FDSelect structure (format 0 or 3) to one font dict
+// index per glyph
+func cffFDSelect(data []byte, pos, numGlyphs int) ([]byte, error) {
+	errTruncated := fmt.Errorf("truncated CFF FDSelect")
+	if pos < 0 || pos >= len(data) {
+		return nil, fmt.Errorf("CFF FDSelect offset %d out of range", pos)
+	}
+	switch data[pos] {
+	case 0:
+		if pos+1+numGlyphs > len(data) {
+			return nil, errTruncated
+		}
+		return data[pos+1 : pos+1+numGlyphs], nil
+	case 3:
+		if pos+3 > len(data) {
+			return nil, errTruncated
+		}
+		numRanges := int(binary.BigEndian.Uint16(data[pos+1:]))
+		if pos+3+3*numRanges+2 > len(data) {
+			return nil, errTruncated
+		}
+		sel := make([]byte, numGlyphs)
+		for i := 0; i < numRanges; i++ {
+			r := data[pos+3+3*i:]
+			first := int(binary.BigEndian.Uint16(r))
+			next := int(binary.BigEndian.Uint16(r[3:])) // Next range's first glyph or the sentinel
+			for g := first; g < next && g < numGlyphs; g++ {
+				sel[g] = r[2]
+			}
+		}
+		return sel, nil
+	}
+	return nil, fmt.Errorf("unsupported CFF FDSelect format %d", data[pos])
+}
+
+// subrBias returns the bias added to subroutine numbers
+func subrBias(n int) int {
+	switch {
+	case n < 1240:
+		return 107
+	case n < 33900:
+		return 1131
+	}
+	return 32768
+}
+
+// cffOutline decodes the Type 2 charstring of glyph gid
+func (f *Font) cffOutline(gid uint16) (outline, error) {
+	if f.cffData == nil {
+		cff, err := parseCFF(f.tables["CFF "])
+		if err != nil {
+			return nil, err
+		}
+		f.cffData = cff
+	}
+	cff := f.cffData
+	if int(gid) >= len(cff.charStrings) {
+		return nil, fmt.Errorf("glyph %d out of range", gid)
+	}
+	var local [][]byte
+	fd := 0
+	if cff.fdSelect != nil && int(gid) < len(cff.fdSelect) {
+		fd = int(cff.fdSelect[gid])
+	}
+	if fd < len(cff.localSubrs) {
+		local = cff.localSubrs[fd]
+	}
+	t := &type2Interpreter{global: cff.globalSubrs, local: local}
+	if err := t.run(cff.charStrings[gid], 0); err != nil {
+		return nil, err
+	}
+	t.closePath()
+	return t.out, nil
+}
+
+// type2Interpreter executes Type 2 charstrings into an outline
+type type2Interpreter struct {
+	global, local [][]byte
+	stack         []float64
+	x, y          float64
+	nStems        int
+	widthParsed   bool
+	open          bool
+	done          bool
+	out           outline
+}
+
+// takeWidth drops the optional leading width operand of the first
+// stack-clearing operator, which has one operand more than expected
+func (t *type2Interpreter) takeWidth(extra bool) {
+	if !t.widthParsed {
+		if extra && len(t.stack) > 0 {
+			t.stack = t.stack[1:]
+		}
+		t.widthParsed = true
+	}
+}
+
+func (t *type2Interpreter) closePath() {
+	if t.open {
+		t.out.close()
+		t.open = false
+	}
+}
+
+func (t *type2Interpreter) moveTo(dx, dy float64) {
+	t.closePath()
+	t.x += dx
+	t.y += dy
+	t.out.moveTo(point{t.x, t.y})
+	t.open = true
+}
+
+func (t *type2Interpreter) lineTo(dx, dy float64) {
+	t.x += dx
+	t.y += dy
+	t.out.lineTo(point{t.x, t.y})
+}
+
+func (t *type2Interpreter) curveTo(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	c1 := point{t.x + dx1, t.y + dy1}
+	c2 := point{c1.X + dx2, c1.Y + dy2}
+	t.x, t.y = c2.X+dx3, c2.Y+dy3
+	t.out.curveTo(c1, c2, point{t.x, t.y})
+}
+
+// run executes a charstring, recursing into subroutines
+func (t *type2Interpreter) run(code []byte, depth int) error {
+	if depth > 10 {
+		return fmt.Errorf("charstring subroutine nesting too deep")
+	}
+	for i := 0; i < len(code) && !t.done; {
+		b := code[i]
+		switch {
+		case b == 28 && i+3 <= len(code):
+			t.stack = append(t.stack, float64(int16(binary.BigEndian.Uint16(code[i+1:]))))
+			i += 3
+			continue
+		case b >= 32 && b <= 246:
+			t.stack = append(t.stack, float64(int(b)-139))
+			i++
+			continue
+		case b >= 247 && b <= 250 && i+2 <= len(code):
+			t.stack = append(t.stack, float64((int(b)-247)*256+int(code[i+1])+108))
+			i += 2
+			continue
+		case b >= 251 && b <= 254 && i+2 <= len(code):
+			t.stack = append(t.stack, float64(-(int(b)-251)*256-int(code[i+1])-108))
+			i += 2
+			continue
+		case b == 255 && i+5 <= len(code):
+			t.stack = append(t.stack, float64(int32(binary.BigEndian.Uint32(code[i+1:])))/65536)
+			i += 5
+			continue
+		}
+
+		i++
+		s := t.stack
+		switch b {
+		case 1, 3, 18, 23: // hstem, vstem, hstemhm, vstemhm
+			t.takeWidth(len(s)%2 == 1)
+			t.nStems += len(t.stack) / 2
+		case 19, 20: // hintmask, cntrmask
+			t.takeWidth(len(s)%2 == 1)
+			t.nStems += len(t.stack) / 2 // Implicit vstem operands
+			i += (t.nStems + 7) / 8
+		case 21: // rmoveto
+			t.takeWidth(len(s) > 2)
+			if s = t.stack; len(s) >= 2 {
+				t.moveTo(s[0], s[1])
+			}
+		case 22: // hmoveto
+			t.takeWidth(len(s) > 1)
+			if s = t.stack; len(s) >= 1 {
+				t.moveTo(s[0], 0)
+			}
+		case 4: // vmoveto
+			t.takeWidth(len(s) > 1)
+			if s = t.stack; len(s) >= 1 {
+				t.moveTo(0, s[0])
+			}
+		case 5: // rlineto
+			for ; len(s) >= 2; s = s[2:] {
+				t.lineTo(s[0], s[1])
+			}
+		case 6, 7: // hlineto, vlineto alternate direction
+			horizontal := b == 6
+			for ; len(s) >= 1; s = s[1:] {
+				if horizontal {
+					t.lineTo(s[0], 0)
+				} else {
+					t.lineTo(0, s[0])
+				}
+				horizontal = !horizontal
+			}
+		case 8: // rrcurveto
+			for ; len(s) >= 6; s = s[6:] {
+				t.curveTo(s[0], s[1], s[2], s[3], s[4], s[5])
+			}
+		case 24: // rcurveline
+			for ; len(s) >= 8; s = s[6:] {
+				t.curveTo(s[0], s[1], s[2], s[3], s[4], s[5])
+			}
+			if len(s) >= 2 {
+				t.lineTo(s[0], s[1])
+			}
+		case 25: // rlinecurve
+			for ; len(s) >= 8; s = s[2:] {
+				t.lineTo(s[0], s[1])
+			}
+			if len(s) >= 6 {
+				t.curveTo(s[0], s[1], s[2], s[3], s[4], s[5])
+			}
+		case 26: // vvcurveto
+			dx1 := 0.0
+			if len(s)%2 == 1 {
+				dx1, s = s[0], s[1:]
+			}
+			for ; len(s) >= 4; s = s[4:] {
+				t.curveTo(dx1, s[0], s[1], s[2], 0, s[3])
+				dx1 = 0
+			}
+		case 27: // hhcurveto
+			dy1 := 0.0
+			if len(s)%2 == 1 {
+				dy1, s = s[0], s[1:]
+			}
+			for ; len(s) >= 4; s = s[4:] {
+				t.curveTo(s[0], dy1, s[1], s[2], s[3], 0)
+				dy1 = 0
+			}
+		case 30, 31: // vhcurveto, hvcurveto alternate direction
+			vertical := b == 30
+			for len(s) >= 4 {
+				last := 0.0
+				if len(s) == 5 {
+					last = s[4]
+				}
+				if vertical {
+					t.curveTo(0, s[0], s[1], s[2], s[3], last)
+				} else {
+					t.curveTo(s[0], 0, s[1], s[2], last, s[3])
+				}
+				s = s[4:]
+				vertical = !vertical
+			}
+		case 10, 29: // callsubr, callgsubr
+			if len(s) == 0 {
+				return fmt.Errorf("charstring stack underflow")
+			}
+			subrs := t.local
+			if b == 29 {
+				subrs = t.global
+			}
+			n := int(s[len(s)-1]) + subrBias(len(subrs))
+			t.stack = s[:len(s)-1]
+			if n < 0 || n >= len(subrs) {
+				return fmt.Errorf("charstring subroutine %d out of range", n)
+			}
+			if err := t.run(subrs[n], depth+1); err != nil {
+				return err
+			}
+			continue // The subroutine manages the stack
+		case 11: // return
+			return nil
+		case 14: // endchar
+			t.takeWidth(len(s) == 1 || len(s) == 5)
+			t.closePath()
+			t.done = true
+		case 12: // Escaped operators
+			if i >= len(code) {
+				return fmt.Errorf("truncated charstring")
+			}
+			op := code[i]
+			i++
+			t.flex(op, s)
+		}
+		t.stack = t.stack[:0]
+	}
+	return nil
+}
+
+// flex handles the escaped flex operators, which draw two curves
+func (t *type2Interpreter) flex(op byte, s []float64) {
+	switch op {
+	case 35: // flex
+		if len(s) >= 12 {
+			t.curveTo(s[0], s[1], s[2], s[3], s[4], s[5])
+			t.curveTo(s[6], s[7], s[8], s[9], s[10], s[11])
+		}
+	case 34: // hflex
+		if len(s) >= 7 {
+			t.curveTo(s[0], 0, s[1], s[2], s[3], 0)
+			t.curveTo(s[4], 0, s[5], -s[2], s[6], 0)
+		}
+	case 36: // hflex1
+		if len(s) >= 9 {
+			startY := t.y
+			t.curveTo(s[0], s[1], s[2], s[3], s[4], 0)
+			t.curveTo(s[5], 0, s[6], s[7], s[8], startY-(t.y+s[7]))
+		}
+	case 37: // flex1
+		if len(s) >= 11 {
+			startX, startY := t.x, t.y
+			dx := s[0] + s[2] + s[4] + s[6] + s[8]
+			dy := s[1] + s[3] + s[5] + s[7] + s[9]
+			t.curveTo(s[0], s[1], s[2], s[3], s[4], s[5])
+			var lastX, lastY float64
+			if math.Abs(dx) > math.Abs(dy) {
+				lastX = s[10]
+				lastY = startY - (t.y + s[7] + s[9])
+			} else {
+				lastX = startX - (t.x + s[6] + s[8])
+				lastY = s[10]
+			}
+			t.curveTo(s[6], s[7], s[8], s[9], lastX, lastY)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package svg2pdf
+
+import "strings"
+
+// ClipPath is a clipPath element; its rectangles and paths are united into
+// the clip region of the elements referencing it
+type ClipPath struct {
+	ID    string `xml:"id,attr"`
+	Units string `xml:"clipPathUnits,attr"` // userSpaceOnUse (default) or objectBoundingBox
+	Rects []Rect `xml:"http://www.w3.org/2000/svg rect"`
+	Paths []Path `xml:"http://www.w3.org/2000/svg path"`
+}
+
+// parseURLRef extracts the id of a url(#id) reference
+func parseURLRef(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "url(") || !strings.HasSuffix(value, ")") {
+		return "", false
+	}
+	ref := strings.Trim(strings.TrimSpace(value[4:len(value)-1]), `"'`)
+	if !strings.HasPrefix(ref, "#") {
+		return "", false // References into other documents are not supported
+	}
+	return ref[1:], true
+}
+
+// clipOps returns the operators saving the graphics state and clipping to
+// the clip path referenced by a clip-path value, for an element with the
+// bounding box bbox. The caller restores the state with Q. Missing or
+// unsupported references yield nil, leaving the element unclipped, as do
+// clip paths exceeding Limits.PathSegments, aborting the conversion.
+func (p *PDF) clipOps(clipPath string, bbox viewBox, ctx unitContext) []string {
+	id, ok := parseURLRef(clipPath)
+	if !ok {
+		return nil
+	}
+	clip, ok := p.clipPaths[id]
+	if !ok {
+		p.warn("", "", "clip path %q not found, drawing unclipped", id)
+		return nil
+	}
+	ops := []string{"q"}
+	if clip.Units == "objectBoundingBox" {
+		// Coordinates are fractions of the bounding box
+		ops = append(ops, p.operator("cm", bbox.W, 0, 0, bbox.H, bbox.X, bbox.Y))
+		defer p.scaleUserSpace(bbox.W, bbox.H)()
+		ctx = ctx.withViewport(1, 1)
+	}
+	shapes := len(ops)
+	for _, rect := range clip.Rects {
+		ops = append(ops, p.operator("re",
+			ctx.resolve(rect.X, axisX, 0), ctx.resolve(rect.Y, axisY, 0),
+			ctx.resolve(rect.Width, axisX, 0), ctx.resolve(rect.Height, axisY, 0)))
+	}
+	// The clip-rule of a path only applies to the whole region if it is the
+	// only child, as the region is the union of the children
+	rule := "W"
+	for _, path := range clip.Paths {
+		var b strings.Builder
+		_, segments := writePathData(&b, path.D, p.decimals(), p.pathSegmentLimit())
+		if max := p.pathSegmentLimit(); max > 0 && segments > max {
+			p.exceed("PathSegments", float64(max))
+			return nil
+		}
+		if segments > 0 {
+			ops = append(ops, strings.TrimSuffix(b.String(), "\n"))
+		}
+		if p.evenOdd("clip-rule", path) {
+			if len(clip.Rects)+len(clip.Paths) == 1 {
+				rule = "W*"
+			} else {
+				p.warn("clipPath", clip.ID, "clip-rule evenodd is only supported for a single path")
+			}
+		}
+	}
+	if len(ops) == shapes {
+		ops = append(ops, "0 0 0 0 re") // An empty clip path hides the element
+	}
+	ops = append(ops, rule+" n")
+	if clip.Units == "objectBoundingBox" {
+		// Undo the bounding box mapping, the clip region stays in place
+		ops = append(ops, p.transform("cm", 1/max(bbox.W, 1e-9), 0, 0, 1/max(bbox.H, 1e-9),
+			-bbox.X/max(bbox.W, 1e-9), -bbox.Y/max(bbox.H, 1e-9)))
+	}
+	return ops
+}
+
+// runsBBox approximates the bounding box of laid out text from the font
+// advances and size
+func runsBBox(runs []glyphRun, face fontFace, fontSize float64) viewBox {
+	if len(runs) == 0 {
+		return viewBox{}
+	}
+	minX, minY := runs[0].X, runs[0].Y
+	maxX, maxY := minX, minY
+	for _, run := range runs {
+		width := 0.0
+		for _, r := range run.Text {
+			width += face.advance(r) * fontSize / 1000
+		}
+		if run.Sideways {
+			// Glyphs extend along y, ascenders pointing right
+			minX, maxX = min(minX, run.X-0.2*fontSize), max(maxX, run.X+0.8*fontSize)
+			minY, maxY = min(minY, run.Y), max(maxY, run.Y+width)
+			continue
+		}
+		minX, maxX = min(minX, run.X), max(maxX, run.X+width)
+		minY, maxY = min(minY, run.Y-0.8*fontSize), max(maxY, run.Y+0.2*fontSize)
+	}
+	return viewBox{minX, minY, maxX - minX, maxY - minY}
+}
@@ -0,0 +1,91 @@
+// Command svg2pdf runs tools around the svg2pdf package.
+//
+// Usage:
+//
+//	svg2pdf bench [-cpuprofile file] [-memprofile file] file.svg
+//
+// The bench subcommand converts an SVG once and reports the time and memory
+// spent parsing, rendering and serializing it, optionally writing pprof
+// profiles of the conversion.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"svg2pdf"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "bench":
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "svg2pdf bench:", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: svg2pdf bench [-cpuprofile file] [-memprofile file] file.svg")
+	os.Exit(2)
+}
+
+// bench converts the SVG named in args and reports the cost of each stage
+func bench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	cpuProfile := flags.String("cpuprofile", "", "write a CPU profile of the conversion to `file`")
+	memProfile := flags.String("memprofile", "", "write a heap profile after the conversion to `file`")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+	}
+	r, err := svg2pdf.Benchmark(source)
+	if *cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if err != nil {
+		return err
+	}
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		runtime.GC() // Up-to-date statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("input      %12d bytes\n", len(source))
+	fmt.Printf("parse      %12v %12d bytes allocated\n", r.Parse, r.ParseAlloc)
+	fmt.Printf("render     %12v %12d bytes allocated\n", r.Render, r.RenderAlloc)
+	fmt.Printf("serialize  %12v %12d bytes allocated\n", r.Serialize, r.SerializeAlloc)
+	fmt.Printf("peak heap  %12d bytes\n", r.PeakHeap)
+	fmt.Printf("output     %12d bytes\n", r.OutputSize)
+	return nil
+}
@@ -0,0 +1,128 @@
+package svg2pdf
+
+import "fmt"
+
+// CMYKOutput configures the conversion of colors to CMYK for print
+type CMYKOutput struct {
+	Profile   []byte // ICC profile of the printing condition; colors are DeviceCMYK without one
+	Condition string // Identifier of the printing condition, e.g. "FOGRA39"
+}
+
+// cmykOutput is the CMYK output configuration in effect
+type cmykOutput struct {
+	profile   *iccProfile // Nil for DeviceCMYK
+	condition string
+}
+
+// cmykColorSpace is the resource name of the ICC-based CMYK color space
+const cmykColorSpace = "CSCMYK"
+
+// SetCMYKOutput converts all RGB paints and images drawn after the call to
+// CMYK and declares the printing condition as the output intent, for
+// prepress workflows that reject RGB. With a profile, colors are ICC-based
+// in it; the conversion itself is the uncalibrated complement with full
+// black generation, as no color management is done. Nil turns it off.
+func (p *PDF) SetCMYKOutput(c *CMYKOutput) error {
+	if c == nil {
+		p.cmyk = nil
+		return nil
+	}
+	cmyk := &cmykOutput{condition: c.Condition}
+	if c.Profile != nil {
+		if len(c.Profile) < 128 || string(c.Profile[16:20]) != "CMYK" {
+			return fmt.Errorf("output profile is not a CMYK ICC profile")
+		}
+		cmyk.profile = &iccProfile{data: c.Profile, components: 4, alternate: "/DeviceCMYK"}
+		p.profiles = append(p.profiles, cmyk.profile) // Written with the image profiles
+	}
+	if cmyk.condition == "" {
+		cmyk.condition = "Custom"
+	}
+	p.cmyk = cmyk
+	return nil
+}
+
+// WithCMYKOutput converts colors to CMYK for print
+func WithCMYKOutput(c CMYKOutput) Option {
+	return func(p *PDF) error {
+		return p.SetCMYKOutput(&c)
+	}
+}
+
+// toCMYK converts an RGB color to CMYK components
+func toCMYK(c RGB) (cyan, magenta, yellow, black float64) {
+	black = 1 - max(c.R, c.G, c.B)
+	if black == 1 {
+		return 0, 0, 0, 1
+	}
+	return (1 - c.R - black) / (1 - black), (1 - c.G - black) / (1 - black), (1 - c.B - black) / (1 - black), black
+}
+
+// fillOp returns the operator setting c as the fill color: its gray level
+// in grayscale mode, its spot color if mapped to one, converted to CMYK in
+// CMYK output mode
+func (p *PDF) fillOp(c RGB) string {
+	if p.grayscale {
+		return grayOp(c, false)
+	}
+	if op := p.spotOp(c, false); op != "" {
+		return op
+	}
+	if p.cmyk == nil {
+		return c.fillOp()
+	}
+	cyan, magenta, yellow, black := toCMYK(c)
+	if p.cmyk.profile != nil {
+		p.cmykSpace = p.cmyk.profile
+		return "/" + cmykColorSpace + " cs " + formatNumbers(colorDecimals, cyan, magenta, yellow, black) + " sc"
+	}
+	return formatNumbers(colorDecimals, cyan, magenta, yellow, black) + " k"
+}
+
+// strokeOp returns the operator setting c as the stroke color: its gray
+// level in grayscale mode, its spot color if mapped to one, converted to
+// CMYK in CMYK output mode
+func (p *PDF) strokeOp(c RGB) string {
+	if p.grayscale {
+		return grayOp(c, true)
+	}
+	if op := p.spotOp(c, true); op != "" {
+		return op
+	}
+	if p.cmyk == nil {
+		return c.strokeOp()
+	}
+	cyan, magenta, yellow, black := toCMYK(c)
+	if p.cmyk.profile != nil {
+		p.cmykSpace = p.cmyk.profile
+		return "/" + cmykColorSpace + " CS " + formatNumbers(colorDecimals, cyan, magenta, yellow, black) + " SC"
+	}
+	return formatNumbers(colorDecimals, cyan, magenta, yellow, black) + " K"
+}
+
+// convertToCMYK converts the RGB samples of img to CMYK
+func (img *pdfImage) convertToCMYK(profile *iccProfile) {
+	data := make([]byte, 0, len(img.data)/3*4)
+	for i := 0; i+3 <= len(img.data); i += 3 {
+		// The complement, with the common part moved to black
+		c, m, y := 255-img.data[i], 255-img.data[i+1], 255-img.data[i+2]
+		k := min(c, m, y)
+		if k == 255 {
+			data = append(data, 0, 0, 0, 255)
+			continue
+		}
+		scale := func(v byte) byte { return byte((int(v-k)*255 + int(255-k)/2) / int(255-k)) }
+		data = append(data, scale(c), scale(m), scale(y), k)
+	}
+	img.data, img.colorSpace, img.profile = data, "/DeviceCMYK", profile
+}
+
+// outputIntent returns the catalog entry declaring the printing
+// condition, with its profile as object profileObj (0 for none)
+func (c *cmykOutput) outputIntent(profileObj int) string {
+	entry := "/OutputIntents [<< /Type /OutputIntent /S /GTS_PDFX /OutputConditionIdentifier " + textString(c.condition)
+	if profileObj != 0 {
+		entry += " /DestOutputProfile " + ref(profileObj)
+	}
+	return entry + " >>]"
+}
@@ -0,0 +1,104 @@
+package svg2pdf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RGB represents a color with components in the range 0..1
+type RGB struct {
+	R, G, B float64
+}
+
+// namedColors maps the CSS basic color keywords to their RGB values
+var namedColors = map[string]RGB{
+	"black":   {0, 0, 0},
+	"silver":  {192.0 / 255, 192.0 / 255, 192.0 / 255},
+	"gray":    {128.0 / 255, 128.0 / 255, 128.0 / 255},
+	"grey":    {128.0 / 255, 128.0 / 255, 128.0 / 255},
+	"white":   {1, 1, 1},
+	"maroon":  {128.0 / 255, 0, 0},
+	"red":     {1, 0, 0},
+	"purple":  {128.0 / 255, 0, 128.0 / 255},
+	"fuchsia": {1, 0, 1},
+	"magenta": {1, 0, 1},
+	"green":   {0, 128.0 / 255, 0},
+	"lime":    {0, 1, 0},
+	"olive":   {128.0 / 255, 128.0 / 255, 0},
+	"yellow":  {1, 1, 0},
+	"navy":    {0, 0, 128.0 / 255},
+	"blue":    {0, 0, 1},
+	"teal":    {0, 128.0 / 255, 128.0 / 255},
+	"aqua":    {0, 1, 1},
+	"cyan":    {0, 1, 1},
+	"orange":  {1, 165.0 / 255, 0},
+}
+
+// parseColor parses an SVG color value (#rgb, #rrggbb, rgb() or a keyword)
+func parseColor(value string) (RGB, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[value]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return RGB{}, false
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return RGB{}, false
+		}
+		return RGB{
+			R: float64(n>>16&0xff) / 255,
+			G: float64(n>>8&0xff) / 255,
+			B: float64(n&0xff) / 255,
+		}, true
+	}
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		parts := strings.Split(value[4:len(value)-1], ",")
+		if len(parts) != 3 {
+			return RGB{}, false
+		}
+		var comps [3]float64
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			scale := 255.0
+			if strings.HasSuffix(part, "%") {
+				part = strings.TrimSuffix(part, "%")
+				scale = 100
+			}
+			v, err := parseNumber(part)
+			if err != nil {
+				return RGB{}, false
+			}
+			comps[i] = clamp01(v / scale)
+		}
+		return RGB{comps[0], comps[1], comps[2]}, true
+	}
+	return RGB{}, false
+}
+
+// clamp01 limits v to the range 0..1
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// strokeOp returns the PDF operator setting c as the stroking color
+func (c RGB) strokeOp() string {
+	return formatNumbers(colorDecimals, c.R, c.G, c.B) + " RG"
+}
+
+// fillOp returns the PDF operator setting c as the non-stroking color
+func (c RGB) fillOp() string {
+	return formatNumbers(colorDecimals, c.R, c.G, c.B) + " rg"
+}
@@ -0,0 +1,108 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+)
+
+// Compressor encodes stream data with a PDF filter, e.g. a faster deflate
+// implementation than compress/zlib
+type Compressor interface {
+	// Filter returns the name of the filter decoding the data, without the
+	// leading slash (e.g. "FlateDecode")
+	Filter() string
+	// Compress encodes data
+	Compress(data []byte) ([]byte, error)
+}
+
+// FlateCompressor compresses streams with compress/zlib
+type FlateCompressor struct {
+	Level int // zlib compression level, e.g. zlib.BestSpeed
+}
+
+// Filter returns "FlateDecode"
+func (c FlateCompressor) Filter() string { return "FlateDecode" }
+
+// Compress deflates data in the zlib format
+func (c FlateCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, c.Level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StreamKind groups streams for SetStreamCompressor
+type StreamKind int
+
+// Stream kinds
+const (
+	ContentStream StreamKind = iota // Page content
+	FontStream                      // Font programs and ToUnicode maps
+	ImageStream                     // Image samples and soft masks without their own filter
+	ProfileStream                   // ICC color profiles
+	ObjectStream                    // Object and cross-reference streams
+	streamKinds
+)
+
+// defaultCompressor is used unless set otherwise
+var defaultCompressor Compressor = FlateCompressor{Level: zlib.BestCompression}
+
+// SetCompressor sets the compressor of all streams; nil disables
+// compression. Overrides set with SetStreamCompressor are kept.
+func (p *PDF) SetCompressor(c Compressor) {
+	p.compressor = c
+	p.compressorSet = true
+}
+
+// SetStreamCompressor sets the compressor of one kind of stream, e.g. a
+// fast level for content streams while fonts are compressed best; nil
+// disables compression for that kind
+func (p *PDF) SetStreamCompressor(kind StreamKind, c Compressor) error {
+	if kind < ContentStream || kind >= streamKinds {
+		return fmt.Errorf("unknown stream kind %d", kind)
+	}
+	if p.kindCompressors == nil {
+		p.kindCompressors = make(map[StreamKind]Compressor)
+	}
+	p.kindCompressors[kind] = c
+	return nil
+}
+
+// WithCompressor sets the compressor of all streams
+func WithCompressor(c Compressor) Option {
+	return func(p *PDF) error {
+		p.SetCompressor(c)
+		return nil
+	}
+}
+
+// WithStreamCompressor sets the compressor of one kind of stream
+func WithStreamCompressor(kind StreamKind, c Compressor) Option {
+	return func(p *PDF) error {
+		return p.SetStreamCompressor(kind, c)
+	}
+}
+
+// compressors returns the compressor of each stream kind
+func (p *PDF) compressors() [streamKinds]Compressor {
+	var cs [streamKinds]Compressor
+	for kind := range cs {
+		cs[kind] = defaultCompressor
+		if p.compressorSet {
+			cs[kind] = p.compressor
+		}
+		if c, ok := p.kindCompressors[StreamKind(kind)]; ok {
+			cs[kind] = c
+		}
+	}
+	return cs
+}
@@ -0,0 +1,67 @@
+package svg2pdf
+
+import (
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pageContent returns page content of about size bytes, the paths of
+// gisPathData converted to operators
+func pageContent(size int) []byte {
+	var b strings.Builder
+	writePathData(&b, gisPathData(size), 2, 0)
+	return []byte(b.String())
+}
+
+// flateLevels are the zlib levels benchmarked
+var flateLevels = []int{zlib.NoCompression, zlib.BestSpeed, zlib.DefaultCompression, zlib.BestCompression, zlib.HuffmanOnly}
+
+func BenchmarkFlateCompressor(b *testing.B) {
+	data := pageContent(4 << 20)
+	for _, level := range flateLevels {
+		b.Run(fmt.Sprintf("level=%d", level), func(b *testing.B) {
+			c := FlateCompressor{Level: level}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var out []byte
+			for i := 0; i < b.N; i++ {
+				var err error
+				if out, err = c.Compress(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(out))/float64(len(data)), "ratio")
+		})
+	}
+}
+
+func BenchmarkConvertCompressor(b *testing.B) {
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="1000" height="1000"><path d="%s" fill="none" stroke="black"/></svg>`,
+		gisPathData(4<<20))
+	names, compressors := []string{"none"}, []Compressor{nil}
+	for _, level := range flateLevels {
+		names = append(names, fmt.Sprintf("level=%d", level))
+		compressors = append(compressors, FlateCompressor{Level: level})
+	}
+	for i, c := range compressors {
+		b.Run(names[i], func(b *testing.B) {
+			b.SetBytes(int64(len(svg)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p, err := New(WithCompressor(c))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := p.ConvertSVG(strings.NewReader(svg)); err != nil {
+					b.Fatal(err)
+				}
+				if err := p.Write(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// encodedStream is a stream compressed ahead of being written
+type encodedStream struct {
+	dict []string
+	data []byte
+	err  error
+}
+
+// SetConcurrency sets the number of goroutines used for work that can run
+// in parallel: decoding the sources passed to AddSVGPages, drawing their
+// pages into separate content streams, and compressing page content
+// streams. 0, the default, uses GOMAXPROCS; 1 does all work on the calling
+// goroutine. Pages are merged and written in order, so the output, events
+// and log records do not depend on it. Pages are drawn in order when they
+// depend on each other, with n-up, tiling, text flow, captions, headers or
+// footers, fonts of @font-face rules or language pages, and when callbacks
+// are set that may not be safe for concurrent use: an after-page hook,
+// element handlers, a resource loader, font resolver, text shaper,
+// rasterizer or progress callback.
+func (p *PDF) SetConcurrency(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid concurrency %d", n)
+	}
+	p.concurrency = n
+	return nil
+}
+
+// WithConcurrency sets the number of goroutines used for parallel work
+func WithConcurrency(n int) Option {
+	return func(p *PDF) error {
+		return p.SetConcurrency(n)
+	}
+}
+
+// workers returns the number of goroutines to run parallel work on
+func (p *PDF) workers() int {
+	if p.concurrency == 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return p.concurrency
+}
+
+// parallel calls run for 0 to n-1 on at most workers goroutines and waits
+// for all calls to return
+func parallel(n, workers int, run func(i int)) {
+	if workers <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			run(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				run(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// encodeContents prepares and compresses the content streams of all pages,
+// which are written to objects nums
+func (p *PDF) encodeContents(w *pdfWriter, nums []int) []encodedStream {
+	prefix := p.contentPrefix()
+	contents := make([]encodedStream, p.pageCount)
+	parallel(p.pageCount, p.workers(), func(i int) {
+		c := &contents[i]
+		c.dict, c.data, c.err = w.compress(nums[i], ContentStream, nil, p.prefixedContent(i, prefix))
+	})
+	return contents
+}
+
+// pageWorker is the state of a copy of a PDF drawing the pages of one
+// source in parallel with other copies, see drawDecoded
+type pageWorker struct {
+	shared *sync.Mutex // Guards the resource names all copies share
+	base   int         // Index in the document of the first page drawn
+	replay []replayed  // Events and log records, reported once merged
+}
+
+// replayed is an event of a page worker, or a log record if event is nil
+type replayed struct {
+	event *Event
+	level slog.Level
+	msg   string
+	args  []any
+}
+
+// pageIndex returns the index in the document of page i of p
+func (p *PDF) pageIndex(i int) int {
+	if p.worker != nil {
+		return p.worker.base + i
+	}
+	return i
+}
+
+// drawDecoded draws sources, decoded by decodeSource, in order as
+// convertDecoded does, returning the index of the source that failed with
+// its error. Where the pages of a source do not depend on those before,
+// sources are drawn in parallel by page workers, then merged in order.
+func (p *PDF) drawDecoded(sources [][]byte, decoded []decodedSource) (int, error) {
+	if !p.drawsInParallel(sources) {
+		for i, source := range sources {
+			if err := p.convertDecoded(source, decoded[i]); err != nil {
+				return i, err
+			}
+		}
+		return 0, nil
+	}
+
+	// Every svg document of a source is drawn on a page of its own
+	p.seedIDs(sources[0])
+	if p.resourceIDs == nil {
+		p.resourceIDs = make(map[string]string)
+	}
+	shared := new(sync.Mutex)
+	workers := make([]*PDF, len(sources))
+	base := p.pageCount
+	for i := range sources {
+		workers[i] = p.newWorker(base, shared)
+		if decoded[i].invalid == nil {
+			base += len(decoded[i].roots)
+		}
+	}
+	errs := make([]error, len(sources))
+	parallel(len(sources), p.workers(), func(i int) {
+		errs[i] = workers[i].convertDecoded(sources[i], decoded[i])
+	})
+	for i, w := range workers {
+		if decoded[i].invalid == nil {
+			p.recordSource(sources[i])
+		}
+		p.merge(w)
+		if errs[i] != nil {
+			return i, errs[i]
+		}
+	}
+	return 0, nil
+}
+
+// drawsInParallel reports whether sources can be drawn by page workers with
+// the same result as in order: each svg document gets a page of its own,
+// nothing drawn depends on the sources before, e.g. fonts of @font-face
+// rules, and no callbacks that may not be safe for concurrent use are made
+func (p *PDF) drawsInParallel(sources [][]byte) bool {
+	if p.workers() <= 1 || len(sources) <= 1 {
+		return false
+	}
+	if p.nup != nil || p.tiling != nil || p.flow != nil || p.languagePages || p.caption != nil ||
+		p.header != nil || p.footer != nil || p.streaming != nil || p.stages != nil {
+		return false
+	}
+	if p.afterPage != nil || len(p.handlers) > 0 || p.loader != nil || p.fontResolver != nil ||
+		p.shaper != nil || p.rasterizer != nil || p.progress != nil {
+		return false
+	}
+	for _, source := range sources {
+		if bytes.Contains(source, []byte("@font-face")) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWorker returns a copy of p drawing pages from index base on, in
+// parallel with other copies sharing the resource names guarded by shared.
+// It draws into content streams of its own, adds resources to copies of the
+// resource lists and records the glyphs used in copies of the fonts, so
+// only merge changes p.
+func (p *PDF) newWorker(base int, shared *sync.Mutex) *PDF {
+	w := *p
+	w.worker = &pageWorker{shared: shared, base: base}
+	w.pages, w.content, w.pageEntries, w.pageSizes = nil, nil, nil, nil
+	w.structure, w.bookmarks, w.written = nil, nil, nil
+	w.pageCount, w.current = 0, 0
+	w.fonts = make([]*Font, len(p.fonts))
+	for i, font := range p.fonts {
+		copied := *font
+		copied.used = make(map[uint16]rune)
+		w.fonts[i] = &copied
+	}
+	w.spotColors = make(map[RGB]*spotColor, len(p.spotColors))
+	for c, spot := range p.spotColors {
+		copied := *spot
+		w.spotColors[c] = &copied
+	}
+	w.images, w.profiles, w.forms = slices.Clip(p.images), slices.Clip(p.profiles), slices.Clip(p.forms)
+	w.layers, w.blendModes, w.spots = slices.Clip(p.layers), slices.Clip(p.blendModes), slices.Clip(p.spots)
+	w.textForms, w.encodings, w.blendStates = maps.Clone(p.textForms), maps.Clone(p.encodings), maps.Clone(p.blendStates)
+	w.warnings, w.auditLog, w.elementCounts, w.usedSymbols = nil, nil, nil, nil
+	w.attachSources = false // Recorded by merge
+	return &w
+}
+
+// merge adds the pages and resources drawn by w, a copy made by newWorker,
+// to p and reports its events, as if p had drawn them
+func (p *PDF) merge(w *PDF) {
+	existing := p.resourceNames() // Before merging
+	added := maps.Clone(existing)
+
+	for _, c := range w.content {
+		c.doc = p
+	}
+	p.pages = append(p.pages, w.pages...)
+	p.content = append(p.content, w.content...)
+	p.pageEntries = append(p.pageEntries, w.pageEntries...)
+	p.pageSizes = append(p.pageSizes, w.pageSizes...)
+	p.structure = append(p.structure, w.structure...)
+	p.bookmarks = append(p.bookmarks, w.bookmarks...)
+	p.written = append(p.written, w.written...)
+	if w.pageCount > 0 {
+		p.pageCount += w.pageCount
+		p.current = p.pageCount - 1
+	}
+
+	// Glyphs used first keep the rune they were first drawn for
+	for i, font := range p.fonts {
+		copied := w.fonts[i]
+		if font.name == "" {
+			font.name = copied.name
+		}
+		if font.cffData == nil {
+			font.cffData = copied.cffData
+		}
+		for gid, r := range copied.used {
+			if _, seen := font.used[gid]; !seen {
+				font.used[gid] = r
+			}
+		}
+	}
+
+	profiles := make(map[*iccProfile]*iccProfile)
+	for _, profile := range w.profiles {
+		i := slices.IndexFunc(p.profiles, func(q *iccProfile) bool { return bytes.Equal(q.data, profile.data) })
+		if i < 0 {
+			p.profiles = append(p.profiles, profile)
+		} else {
+			profiles[profile] = p.profiles[i]
+		}
+	}
+	for _, img := range w.images {
+		if !added[img.name] {
+			if shared, ok := profiles[img.profile]; ok {
+				img.profile = shared
+			}
+			p.images = append(p.images, img)
+			added[img.name] = true
+		}
+	}
+	forms := make(map[string]*formXObject)
+	for _, form := range p.forms {
+		forms[form.name] = form
+	}
+	for _, form := range w.forms {
+		if _, ok := forms[form.name]; !ok {
+			p.forms = append(p.forms, form)
+			forms[form.name] = form
+		}
+	}
+	for key, form := range w.textForms {
+		if _, ok := p.textForms[key]; !ok {
+			if p.textForms == nil {
+				p.textForms = make(map[textKey]*formXObject)
+			}
+			if form != nil {
+				form = forms[form.name]
+			}
+			p.textForms[key] = form
+		}
+	}
+	for key, encoded := range w.encodings {
+		if _, ok := p.encodings[key]; !ok {
+			if p.encodings == nil {
+				p.encodings = make(map[textKey]string)
+			}
+			p.encodings[key] = encoded
+		}
+	}
+	for _, mode := range w.blendModes {
+		if _, ok := p.blendStates[mode]; !ok {
+			if p.blendStates == nil {
+				p.blendStates = make(map[string]string)
+			}
+			p.blendStates[mode] = w.blendStates[mode]
+			p.blendModes = append(p.blendModes, mode)
+		}
+	}
+	for _, spot := range w.spots {
+		if !added[spot.name] {
+			original := p.spotColors[spot.color]
+			original.name = spot.name
+			p.spots = append(p.spots, original)
+			added[spot.name] = true
+		}
+	}
+	for _, lay := range w.layers {
+		if !added[lay.resource] {
+			p.layers = append(p.layers, lay)
+			added[lay.resource] = true
+		}
+	}
+	if w.cmykSpace != nil {
+		p.cmykSpace = w.cmykSpace
+	}
+
+	if !p.described && w.described {
+		p.described, p.svgTitle, p.svgDesc = true, w.svgTitle, w.svgDesc
+	}
+	if p.lang == "" {
+		p.lang = w.lang
+	}
+	p.warnings = append(p.warnings, w.warnings...)
+	p.auditLog = append(p.auditLog, w.auditLog...)
+	for element, n := range w.elementCounts {
+		if p.elementCounts == nil {
+			p.elementCounts = make(map[string]int)
+		}
+		p.elementCounts[element] += n
+	}
+	if p.abortErr == nil {
+		p.abortErr = w.abortErr
+	}
+	p.interrupted = p.interrupted || w.interrupted
+
+	// Resources created by the pages before are not created again
+	for _, r := range w.worker.replay {
+		if r.event == nil {
+			p.log(r.level, r.msg, r.args...)
+			continue
+		}
+		e := *r.event
+		if e.Kind == ResourceCreated && existing[e.Resource] {
+			continue
+		}
+		p.logEvent(e)
+		if p.eventHook != nil {
+			p.eventHook(e)
+		}
+	}
+}
+
+// resourceNames returns the names of the resources drawn with so far
+func (p *PDF) resourceNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, font := range p.fonts {
+		if font.name != "" {
+			names[font.name] = true
+		}
+	}
+	for _, img := range p.images {
+		names[img.name] = true
+	}
+	for _, form := range p.forms {
+		names[form.name] = true
+	}
+	for _, name := range p.blendStates {
+		names[name] = true
+	}
+	for _, spot := range p.spots {
+		names[spot.name] = true
+	}
+	for _, lay := range p.layers {
+		names[lay.resource] = true
+	}
+	return names
+}
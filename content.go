@@ -0,0 +1,86 @@
+package svg2pdf
+
+import (
+	"bytes"
+)
+
+// contentStream is a content stream under construction, one operator per
+// line. Operators are appended to a buffer, so large pages are not copied
+// on every addition, and the typed emitters format operands alike
+// everywhere.
+type contentStream struct {
+	doc     *PDF // Converts colors for the paint operators
+	buf     bytes.Buffer
+	scratch []byte
+}
+
+// newContentStream returns an empty content stream painting colors as
+// configured for the document
+func (p *PDF) newContentStream() *contentStream {
+	return &contentStream{doc: p}
+}
+
+// op appends operator lines
+func (c *contentStream) op(lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(lines[0])
+	for _, line := range lines[1:] {
+		c.buf.WriteByte('\n')
+		c.buf.WriteString(line)
+	}
+}
+
+// append appends the operators of other
+func (c *contentStream) append(other *contentStream) {
+	if other.buf.Len() == 0 {
+		return
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.Write(other.buf.Bytes())
+}
+
+// operator appends an operator with numeric operands, given with the
+// precision of the document
+func (c *contentStream) operator(op string, operands ...float64) {
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.scratch = appendOperator(c.scratch[:0], op, c.doc.decimals(), operands...)
+	c.buf.Write(c.scratch)
+}
+
+func (c *contentStream) moveTo(x, y float64) { c.operator("m", x, y) }
+
+func (c *contentStream) lineTo(x, y float64) { c.operator("l", x, y) }
+
+func (c *contentStream) curveTo(x1, y1, x2, y2, x, y float64) {
+	c.operator("c", x1, y1, x2, y2, x, y)
+}
+
+func (c *contentStream) closePath() { c.operator("h") }
+
+func (c *contentStream) rect(x, y, w, h float64) { c.operator("re", x, y, w, h) }
+
+// setFill sets c as the fill color, converted as configured for the document
+func (c *contentStream) setFill(color RGB) { c.op(c.doc.fillOp(color)) }
+
+// setStroke sets c as the stroke color, converted as configured for the
+// document
+func (c *contentStream) setStroke(color RGB) { c.op(c.doc.strokeOp(color)) }
+
+func (c *contentStream) fill() { c.operator("f") }
+
+func (c *contentStream) stroke() { c.operator("S") }
+
+// Len returns the size of the stream in bytes
+func (c *contentStream) Len() int { return c.buf.Len() }
+
+// String returns the operators of the stream
+func (c *contentStream) String() string { return c.buf.String() }
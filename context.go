@@ -0,0 +1,73 @@
+package svg2pdf
+
+import (
+	"context"
+	"io"
+)
+
+// ConvertSVGContext converts the SVG read from r like ConvertSVG, stopping
+// with the context's error once ctx is canceled or its deadline passes. The
+// context is checked between elements and pages, so servers can bound the
+// time spent on pathological SVGs; the page being drawn is left incomplete.
+func (p *PDF) ConvertSVGContext(ctx context.Context, r io.Reader) error {
+	return p.withContext(ctx, func() error { return p.ConvertSVG(r) })
+}
+
+// ConvertContext converts the SVG read from r like Convert, stopping once
+// ctx is done; nothing is written to w then
+func ConvertContext(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) error {
+	p, err := New(opts...)
+	if err != nil {
+		return err
+	}
+	if err := p.ConvertSVGContext(ctx, r); err != nil {
+		return err
+	}
+	return p.Write(w)
+}
+
+// ConvertSVGBytesContext converts source like ConvertSVGBytes, stopping once
+// ctx is done
+func (p *PDF) ConvertSVGBytesContext(ctx context.Context, source []byte) error {
+	return p.withContext(ctx, func() error { return p.ConvertSVGBytes(source) })
+}
+
+// AddSVGPageContext adds the SVG read from r like AddSVGPage, stopping once
+// ctx is done
+func (d *Document) AddSVGPageContext(ctx context.Context, r io.Reader, opts ...PageOption) error {
+	return d.pdf.withContext(ctx, func() error { return d.AddSVGPage(r, opts...) })
+}
+
+// AddSVGPagesContext adds the SVGs in sources like AddSVGPages, stopping
+// once ctx is done
+func (d *Document) AddSVGPagesContext(ctx context.Context, sources [][]byte, opts ...PageOption) error {
+	return d.pdf.withContext(ctx, func() error { return d.AddSVGPages(sources, opts...) })
+}
+
+// withContext runs convert with ctx as the context canceled checks
+func (p *PDF) withContext(ctx context.Context, convert func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	saved := p.ctx
+	p.ctx, p.interrupted = ctx, false
+	defer func() { p.ctx = saved }()
+	err := convert()
+	if p.interrupted {
+		return ctx.Err()
+	}
+	return err
+}
+
+// canceled reports whether the running conversion must stop, because its
+// context is done or it was aborted; see abortErr
+func (p *PDF) canceled() bool {
+	if p.abortErr != nil {
+		return true
+	}
+	if p.ctx == nil || p.ctx.Err() == nil {
+		return false
+	}
+	p.interrupted = true
+	return true
+}
@@ -0,0 +1,348 @@
+package svg2pdf
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Style represents an SVG style element holding a CSS style sheet
+type Style struct {
+	Content string `xml:",chardata"`
+}
+
+// cssRule is a rule of a style sheet: a selector or at-rule prelude
+// followed by its declarations, or by nested rules for @media
+type cssRule struct {
+	Prelude string
+	Decls   []cssDecl
+	Rules   []cssRule
+}
+
+// cssDecl is a single property: value declaration
+type cssDecl struct {
+	Property string
+	Value    string
+}
+
+// parseStyleSheet splits CSS source into rules, ignoring comments
+func parseStyleSheet(src string) []cssRule {
+	src = stripCSSComments(src)
+	var rules []cssRule
+	for {
+		open := strings.IndexByte(src, '{')
+		if open < 0 {
+			break
+		}
+		end := matchingBrace(src, open)
+		if end < 0 {
+			end = len(src)
+		}
+		prelude := strings.TrimSpace(src[:open])
+		// Statements without a block (e.g. @import) end at a semicolon
+		if i := strings.LastIndexByte(prelude, ';'); i >= 0 {
+			prelude = strings.TrimSpace(prelude[i+1:])
+		}
+		rule := cssRule{Prelude: prelude}
+		if isMediaRule(prelude) || isKeyframesRule(prelude) {
+			rule.Rules = parseStyleSheet(src[open+1 : end])
+		} else {
+			rule.Decls = parseDeclarations(src[open+1 : end])
+		}
+		rules = append(rules, rule)
+		if end >= len(src) {
+			break
+		}
+		src = src[end+1:]
+	}
+	return rules
+}
+
+// stripCSSComments removes /* ... */ comments from CSS source
+func stripCSSComments(src string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(src, "/*")
+		if start < 0 {
+			b.WriteString(src)
+			return b.String()
+		}
+		b.WriteString(src[:start])
+		end := strings.Index(src[start+2:], "*/")
+		if end < 0 {
+			return b.String()
+		}
+		src = src[start+2+end+2:]
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at open
+func matchingBrace(src string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseDeclarations splits a declaration block on semicolons that are not
+// inside quotes or parentheses, which data URIs commonly contain
+func parseDeclarations(block string) []cssDecl {
+	var decls []cssDecl
+	for _, part := range splitOutside(block, ';') {
+		colon := strings.IndexByte(part, ':')
+		if colon < 0 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(part[:colon]))
+		value := strings.TrimSpace(part[colon+1:])
+		if prop != "" {
+			decls = append(decls, cssDecl{Property: prop, Value: value})
+		}
+	}
+	return decls
+}
+
+// splitOutside splits s on sep, skipping separators inside quotes or parentheses
+func splitOutside(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// cssURLs extracts the targets of all url(...) references in a value
+func cssURLs(value string) []string {
+	var urls []string
+	for _, part := range splitOutside(value, ',') {
+		part = strings.TrimSpace(part)
+		start := strings.Index(part, "url(")
+		if start < 0 {
+			continue
+		}
+		end := strings.IndexByte(part[start:], ')')
+		if end < 0 {
+			continue
+		}
+		target := strings.TrimSpace(part[start+4 : start+end])
+		urls = append(urls, strings.Trim(target, `"'`))
+	}
+	return urls
+}
+
+// parseDataURI decodes a data: URI into its media type and payload
+func parseDataURI(uri string) (string, []byte, error) {
+	if !strings.HasPrefix(uri, "data:") {
+		return "", nil, fmt.Errorf("not a data URI")
+	}
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 {
+		return "", nil, fmt.Errorf("malformed data URI")
+	}
+	meta, payload := uri[5:comma], uri[comma+1:]
+	isBase64 := strings.HasSuffix(meta, ";base64")
+	mediaType := strings.TrimSuffix(meta, ";base64")
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	if !isBase64 {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", nil, fmt.Errorf("error decoding data URI: %v", err)
+		}
+		return mediaType, []byte(data), nil
+	}
+	// Base64 payloads are often wrapped across lines in style sheets
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("error decoding data URI: %v", err)
+	}
+	return mediaType, data, nil
+}
+
+// maxImportDepth limits nested @import statements, which may be cyclic
+const maxImportDepth = 8
+
+// importStyles replaces the @import statements of the style sheets with the
+// sheets they reference, loaded through the resource loader
+func (p *PDF) importStyles(styles []Style) {
+	for i := range styles {
+		styles[i].Content = p.expandImports(styles[i].Content, 0)
+	}
+}
+
+// expandImports returns src with its leading @import statements replaced by
+// the imported style sheets, in a @media block if the import has a media
+// query list. Sheets that cannot be loaded are skipped with a warning.
+func (p *PDF) expandImports(src string, depth int) string {
+	rest := strings.TrimSpace(stripCSSComments(src))
+	var imported strings.Builder
+	for hasPrefixFold(rest, "@import") || hasPrefixFold(rest, "@charset") {
+		statement := splitOutside(rest, ';')[0]
+		rest = strings.TrimSpace(rest[min(len(statement)+1, len(rest)):])
+		if hasPrefixFold(statement, "@charset") {
+			continue
+		}
+		target, media := importTarget(statement[len("@import"):])
+		if target == "" {
+			continue
+		}
+		if depth >= maxImportDepth {
+			p.warn("style", "", "@import of %q skipped: imports nested too deeply", truncate(target, 32))
+			continue
+		}
+		data, _, err := p.load("stylesheet", target)
+		if err != nil {
+			p.fail(&ResourceError{Op: "loading", Kind: "stylesheet", Name: truncate(target, 32), Err: err})
+			p.warn("style", "", "style sheet skipped: %v", err)
+			continue
+		}
+		sheet := p.expandImports(string(data), depth+1)
+		if media != "" {
+			sheet = "@media " + media + " {\n" + sheet + "\n}"
+		}
+		imported.WriteString(sheet + "\n")
+	}
+	if imported.Len() == 0 {
+		return src
+	}
+	return imported.String() + rest
+}
+
+// importTarget splits the rest of an @import statement into the imported
+// URI and the media query list
+func importTarget(s string) (target, media string) {
+	s = strings.TrimSpace(s)
+	switch {
+	case hasPrefixFold(s, "url("):
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return "", ""
+		}
+		target, media = strings.Trim(strings.TrimSpace(s[4:end]), `"'`), s[end+1:]
+	case s != "" && (s[0] == '"' || s[0] == '\''):
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", ""
+		}
+		target, media = s[1:end+1], s[end+2:]
+	}
+	return target, strings.TrimSpace(media)
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring case
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// registerFontFaces registers the fonts of all @font-face rules in the style
+// sheets, including those of matching media rules. Sources the resource
+// loader does not permit are skipped.
+func (p *PDF) registerFontFaces(styles []Style) error {
+	for _, rule := range p.styleRules(styles) {
+		if !strings.EqualFold(rule.Prelude, "@font-face") {
+			continue
+		}
+		if err := p.registerFontFace(rule.Decls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerFontFace registers the first decodable source of a @font-face rule
+func (p *PDF) registerFontFace(decls []cssDecl) error {
+	var family, weight, style, src string
+	for _, decl := range decls {
+		switch decl.Property {
+		case "font-family":
+			family = strings.Trim(decl.Value, `"'`)
+		case "font-weight":
+			weight = decl.Value
+		case "font-style":
+			style = decl.Value
+		case "src":
+			src = decl.Value
+		}
+	}
+	if family == "" {
+		return nil
+	}
+
+	var lastErr error
+	for _, uri := range cssURLs(src) {
+		data, _, err := p.load("font", uri)
+		if errors.Is(err, ErrResourceDenied) {
+			continue
+		}
+		if err == nil {
+			var font *Font
+			if font, err = parseFont(data); err == nil {
+				font.Family = family
+				if weight != "" {
+					font.Weight = parseFontWeight(weight)
+				}
+				if style != "" {
+					font.Italic = style == "italic" || style == "oblique"
+				}
+				if ok, err := p.checkEmbedding(family, font); !ok {
+					return err // Nil when the font is substituted
+				}
+				p.addFont(font)
+				return nil
+			}
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return &ResourceError{Op: "loading", Kind: "@font-face", Name: family, Err: lastErr}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document combines several SVGs into one PDF, each on its own page, e.g.
+// the charts of a report. Fonts and images shared between pages are
+// embedded once, and the outline has a bookmark for every page.
+type Document struct {
+	pdf *PDF
+}
+
+// PageOption configures a single page added with AddSVGPage, overriding the
+// document options
+type PageOption func(*pageSettings) error
+
+// pageSettings are the document settings a page may override
+type pageSettings struct {
+	width, height float64
+	margins       *pageMargins
+	fitMode       FitMode
+	autoSize      bool
+	bookmark      string   // Outline title, the SVG title when empty
+	caption       *Caption // Caption beneath the figure, see SetCaption
+}
+
+// PageSize sets the size of the page in points, overriding automatic sizing
+func PageSize(width, height float64) PageOption {
+	return func(s *pageSettings) error {
+		if !(width > 0 && height > 0) {
+			return fmt.Errorf("invalid page size %gx%g", width, height)
+		}
+		s.width, s.height, s.autoSize = width, height, false
+		return nil
+	}
+}
+
+// PageMargins sets the margins of the page in points
+func PageMargins(top, right, bottom, left float64) PageOption {
+	return func(s *pageSettings) error {
+		if !(top >= 0 && right >= 0 && bottom >= 0 && left >= 0) {
+			return fmt.Errorf("invalid margins %g %g %g %g", top, right, bottom, left)
+		}
+		s.margins = &pageMargins{top, right, bottom, left}
+		return nil
+	}
+}
+
+// PageAutoSize sizes the page to its SVG, see SetAutoPageSize
+func PageAutoSize() PageOption {
+	return func(s *pageSettings) error {
+		s.autoSize = true
+		return nil
+	}
+}
+
+// PageFitMode sets how the SVG is scaled onto the page
+func PageFitMode(mode FitMode) PageOption {
+	return func(s *pageSettings) error {
+		if mode < FitContain || mode > FitActualSize {
+			return fmt.Errorf("unknown fit mode %d", mode)
+		}
+		s.fitMode = mode
+		return nil
+	}
+}
+
+// PageBookmark sets the title of the page's bookmark in the document
+// outline, instead of the SVG <title>
+func PageBookmark(title string) PageOption {
+	return func(s *pageSettings) error {
+		s.bookmark = strings.Join(strings.Fields(title), " ")
+		return nil
+	}
+}
+
+// PageCaption draws text as the caption beneath the page's figure, numbered
+// and set as the document caption is, see SetCaption
+func PageCaption(text string) PageOption {
+	return func(s *pageSettings) error {
+		c := Caption{}
+		if s.caption != nil {
+			c = *s.caption
+		}
+		c.Text = text
+		s.caption = &c
+		return nil
+	}
+}
+
+// NewDocument creates an empty document; opts set the defaults of its pages
+func NewDocument(opts ...Option) (*Document, error) {
+	p, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.outline = true
+	return &Document{pdf: p}, nil
+}
+
+// AddSVGPage converts the SVG read from r onto a new page at the end of the
+// document. Sources holding several concatenated documents add one page each.
+func (d *Document) AddSVGPage(r io.Reader, opts ...PageOption) error {
+	return d.withPageSettings(opts, func() error {
+		return d.pdf.ConvertSVG(r)
+	})
+}
+
+// AddSVGPages converts several SVGs onto new pages at the end of the
+// document, in order, with the same page options. The sources are decoded
+// and drawn in parallel, see SetConcurrency, which speeds up reports of
+// hundreds of charts; pages are merged in order, so the document is the
+// same as when adding them one by one.
+func (d *Document) AddSVGPages(sources [][]byte, opts ...PageOption) error {
+	p := d.pdf
+	if p.streamingParse {
+		// Streamed sources are drawn while they are decoded
+		for i, source := range sources {
+			if err := d.AddSVGPage(bytes.NewReader(source), opts...); err != nil {
+				return fmt.Errorf("SVG %d: %v", i+1, err)
+			}
+		}
+		return nil
+	}
+
+	// Decode a few sources per worker at a time, so only those are held
+	// in memory
+	batch := 4 * p.workers()
+	for start := 0; start < len(sources) && !p.canceled(); start += batch {
+		chunk := sources[start:min(start+batch, len(sources))]
+		decoded := make([]decodedSource, len(chunk))
+		parallel(len(chunk), p.workers(), func(i int) {
+			decoded[i] = p.decodeSource(chunk[i])
+		})
+		var failed int
+		err := d.withPageSettings(opts, func() error {
+			var err error
+			failed, err = p.drawDecoded(chunk, decoded)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("SVG %d: %v", start+failed+1, err)
+		}
+	}
+	return nil
+}
+
+// withPageSettings runs convert with the page options applied, restoring
+// the document settings afterwards
+func (d *Document) withPageSettings(opts []PageOption, convert func() error) error {
+	p := d.pdf
+	saved := pageSettings{p.pageWidth, p.pageHeight, p.margins, p.fitMode, p.autoPageSize, p.pageBookmark, p.caption}
+	settings := saved
+	for _, opt := range opts {
+		if err := opt(&settings); err != nil {
+			return err
+		}
+	}
+
+	// Apply the page settings for this conversion only
+	p.apply(settings)
+	defer p.apply(saved)
+	return convert()
+}
+
+// PageCount returns the number of pages added so far
+func (d *Document) PageCount() int {
+	return d.pdf.PageCount()
+}
+
+// InsertPageAt inserts a blank page at index i, e.g. a cover page once the
+// sources are added
+func (d *Document) InsertPageAt(i int) error {
+	return d.pdf.InsertPageAt(i)
+}
+
+// MovePage moves the page at index from to index to
+func (d *Document) MovePage(from, to int) error {
+	return d.pdf.MovePage(from, to)
+}
+
+// PDF returns the underlying PDF, e.g. to add custom objects before writing
+func (d *Document) PDF() *PDF {
+	return d.pdf
+}
+
+// Write serializes the document to out
+func (d *Document) Write(out io.Writer) error {
+	return d.pdf.Write(out)
+}
+
+// apply sets the page settings of the PDF
+func (p *PDF) apply(s pageSettings) {
+	p.pageWidth, p.pageHeight = s.width, s.height
+	p.margins, p.fitMode, p.autoPageSize = s.margins, s.fitMode, s.autoSize
+	p.pageBookmark, p.caption = s.bookmark, s.caption
+}
+
+// Close finishes a document streamed with WithStreamingOutput
+func (d *Document) Close() error {
+	return d.pdf.Close()
+}
+
+// Result returns the inventory of the resources added so far
+func (d *Document) Result() Result {
+	return d.pdf.Result()
+}
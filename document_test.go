@@ -0,0 +1,70 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPageCaption(t *testing.T) {
+	d, err := NewDocument(WithCompressor(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100"><title>Chart</title><rect width="10" height="10"/></svg>`
+	for _, caption := range []string{"Revenue by region", "Costs by quarter"} {
+		if err := d.AddSVGPage(strings.NewReader(svg), PageCaption(caption)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Pages added without the option have no caption
+	if err := d.AddSVGPage(strings.NewReader(svg)); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := d.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	pages := bytes.Split(out.Bytes(), []byte("endstream"))
+	for i, want := range []string{"(Figure 1: Revenue by region) Tj", "(Figure 2: Costs by quarter) Tj", ""} {
+		if i >= len(pages) {
+			t.Fatalf("%d content streams, want 3", len(pages)-1)
+		}
+		if got := bytes.Contains(pages[i], []byte(" Tj")); want == "" && got {
+			t.Errorf("page %d: caption drawn", i+1)
+		} else if want != "" && !bytes.Contains(pages[i], []byte(want)) {
+			t.Errorf("page %d: %q not drawn", i+1, want)
+		}
+	}
+}
+
+func TestInsertAndMovePage(t *testing.T) {
+	d, err := NewDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := d.AddSVGPage(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"/>`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := d.pdf.content[0]
+	if err := d.InsertPageAt(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.MovePage(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if d.pdf.content[3] != first {
+		t.Errorf("moved page not at index 3")
+	}
+	// Labels follow the positions of the pages
+	want := []string{"Page 1", "Page 2", "Page 3", "Page 4"}
+	if !slices.Equal(d.pdf.pages, want) {
+		t.Errorf("labels %q, want %q", d.pdf.pages, want)
+	}
+	if err := d.InsertPageAt(5); err == nil {
+		t.Errorf("page inserted past the end")
+	}
+}
@@ -0,0 +1,95 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Element is an element the converter does not draw itself, e.g. an
+// extension element such as <chart:axis>, passed to the handler registered
+// for its name
+type Element struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",innerxml"` // Markup of its children
+}
+
+// Attr returns the value of the attribute without a namespace called name,
+// or "" if there is none
+func (e *Element) Attr(name string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// HandlerFunc draws an element on page. Unlike in the AfterPage hook,
+// drawing uses the user space of the element: SVG units with y pointing
+// down, so rectangles extend down from x, y. An error aborts the
+// conversion.
+type HandlerFunc func(page *Page, e *Element) error
+
+// RegisterElementHandler makes fn draw the elements called local in
+// namespace (e.g. an application's chart namespace, or the SVG namespace
+// for an element the converter does not support) instead of ignoring them
+func (p *PDF) RegisterElementHandler(namespace, local string, fn HandlerFunc) {
+	if p.handlers == nil {
+		p.handlers = make(map[xml.Name]HandlerFunc)
+	}
+	p.handlers[xml.Name{Space: namespace, Local: local}] = fn
+}
+
+// WithElementHandler makes fn draw the elements called local in namespace
+func WithElementHandler(namespace, local string, fn HandlerFunc) Option {
+	return func(p *PDF) error {
+		p.RegisterElementHandler(namespace, local, fn)
+		return nil
+	}
+}
+
+// handled reports whether an SVG element called local has a handler, or is
+// passed to the Rasterizer
+func (p *PDF) handled(local string) bool {
+	_, ok := p.handlers[xml.Name{Space: svgNamespace, Local: local}]
+	return ok || p.rasterizes(local)
+}
+
+// renderExtensions draws the elements of c with a registered handler, each
+// in a graphics state of its own, and those passed to the Rasterizer
+func (p *PDF) renderExtensions(c *Container, ctx unitContext) {
+	for i := range c.Extensions {
+		e := &c.Extensions[i]
+		fn := p.handlers[e.XMLName]
+		if fn == nil && !(e.XMLName.Space == svgNamespace && p.rasterizes(e.XMLName.Local)) {
+			continue
+		}
+		if p.step() {
+			return
+		}
+		if !p.holds(attrConditions(e.Attrs)) || p.hidden(e.XMLName.Local, e.Attr("id"), e.Attr("class"), e.Attr("display"), e.Attr("visibility"), e.Attr("style")) {
+			continue
+		}
+		if fn == nil {
+			p.emit(p.beginMarked("Figure")...)
+			ok := p.rasterize(e, nil, shapeRegion(e, ctx))
+			p.emit(p.endMarked()...)
+			if ok {
+				p.rendered(e.XMLName.Local, e.Attr("id"))
+			}
+			continue
+		}
+		page := &Page{Index: p.current, Width: p.pageWidth, Height: p.pageHeight, pdf: p, userSpace: true}
+		p.emit(append(p.beginMarked("Figure"), "q")...)
+		err := fn(page, e)
+		p.emit(append([]string{"Q"}, p.endMarked()...)...)
+		if err != nil {
+			if p.abortErr == nil {
+				p.abortErr = fmt.Errorf("error in handler of <%s>: %v", e.XMLName.Local, err)
+			}
+			return
+		}
+		p.rendered(e.XMLName.Local, e.Attr("id"))
+	}
+}
@@ -0,0 +1,435 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// Permissions are the operations allowed on an encrypted document without
+// the owner password. Viewers enforce them; they do not stop determined
+// users able to open the document, who can decrypt its content.
+type Permissions uint32
+
+// Permission flags, combined with |
+const (
+	AllowPrint            Permissions = 1 << 2  // Print, possibly at low resolution
+	AllowModify           Permissions = 1 << 3  // Change the content
+	AllowCopy             Permissions = 1 << 4  // Copy or extract text and graphics
+	AllowAnnotate         Permissions = 1 << 5  // Add or change annotations
+	AllowFillForms        Permissions = 1 << 8  // Fill in form fields
+	AllowAccessibility    Permissions = 1 << 9  // Extract text for accessibility tools
+	AllowAssemble         Permissions = 1 << 10 // Insert, rotate or delete pages
+	AllowPrintHighQuality Permissions = 1 << 11 // Print at full resolution
+)
+
+// EncryptionMethod selects the cipher of encrypted documents
+type EncryptionMethod int
+
+// Encryption methods
+const (
+	AES256 EncryptionMethod = iota // AES-256, revision 6 of the standard security handler
+	AES128                         // AES-128, revision 4, for viewers predating PDF 1.7 extension level 8
+)
+
+// encryption holds the passwords and permissions of an encrypted document
+type encryption struct {
+	userPassword  string
+	ownerPassword string
+	permissions   Permissions
+}
+
+// SetEncryption encrypts documents with a user password, needed to open
+// them, and an owner password, which lifts the restrictions to perms. An
+// empty user password opens the document without a prompt.
+func (p *PDF) SetEncryption(userPassword, ownerPassword string, perms Permissions) error {
+	if ownerPassword == "" {
+		return fmt.Errorf("owner password is empty")
+	}
+	p.encryption = &encryption{userPassword: userPassword, ownerPassword: ownerPassword, permissions: perms}
+	return nil
+}
+
+// WithEncryption encrypts documents with user and owner passwords
+func WithEncryption(userPassword, ownerPassword string, perms Permissions) Option {
+	return func(p *PDF) error {
+		return p.SetEncryption(userPassword, ownerPassword, perms)
+	}
+}
+
+// SetPermissions encrypts documents with an owner password only, so they
+// open without a prompt while viewers restrict them to perms, e.g.
+// AllowPrint|AllowAccessibility
+func (p *PDF) SetPermissions(ownerPassword string, perms Permissions) error {
+	return p.SetEncryption("", ownerPassword, perms)
+}
+
+// WithPermissions encrypts documents with an owner password only
+func WithPermissions(ownerPassword string, perms Permissions) Option {
+	return func(p *PDF) error {
+		return p.SetPermissions(ownerPassword, perms)
+	}
+}
+
+// SetEncryptionMethod sets the cipher of encrypted documents, AES256 by
+// default
+func (p *PDF) SetEncryptionMethod(method EncryptionMethod) error {
+	if method != AES256 && method != AES128 {
+		return fmt.Errorf("unknown encryption method %d", method)
+	}
+	p.encryptionMethod = method
+	return nil
+}
+
+// WithEncryptionMethod sets the cipher of encrypted documents
+func WithEncryptionMethod(method EncryptionMethod) Option {
+	return func(p *PDF) error {
+		return p.SetEncryptionMethod(method)
+	}
+}
+
+// encryptor encrypts the strings and streams of a document with the
+// standard security handler
+type encryptor struct {
+	version string       // PDF version the method needs
+	catalog []string     // Catalog entries the method needs
+	dict    []string     // Encryption dictionary lines
+	id      []byte       // File identifier the keys depend on, nil if they do not
+	key     []byte       // File encryption key
+	block   cipher.Block // Cipher of all objects, nil if keys are per object
+	// Initialization vectors are derived from the data instead of random
+	deterministic bool
+}
+
+// newEncryptor generates a file key and the encryption dictionary for e.
+// Keys and salts are random, so encrypted documents are not reproducible,
+// unless they are derived in deterministic mode.
+func (p *PDF) newEncryptor(e *encryption) (*encryptor, error) {
+	n := 32 + 4*8 + 4
+	var random []byte
+	if p.deterministic {
+		random = p.derivedRandom(e, n)
+	} else {
+		p.recordInput("random encryption key generated")
+		random = make([]byte, n)
+		if _, err := rand.Read(random); err != nil {
+			return nil, fmt.Errorf("error generating encryption key: %v", err)
+		}
+	}
+	// Bits 1-2 are reserved and 7-8 and 13-32 must be set
+	p32 := uint32(e.permissions)&0xf3c | 0xfffff0c0
+	var crypt *encryptor
+	if p.encryptionMethod == AES128 {
+		crypt = newAES128Encryptor(e, p32, random[:16])
+	} else {
+		crypt = newAES256Encryptor(e, p32, random)
+	}
+	crypt.deterministic = p.deterministic
+	return crypt, nil
+}
+
+// newAES256Encryptor returns an encryptor for revision 6, given 68 random
+// bytes for the key, salts and permissions padding
+func newAES256Encryptor(e *encryption, p32 uint32, random []byte) *encryptor {
+	key, salts := random[:32], random[32:64]
+	block, _ := aes.NewCipher(key)
+
+	// Passwords are at most 127 bytes of UTF-8
+	user, owner := []byte(e.userPassword), []byte(e.ownerPassword)
+	user, owner = user[:min(len(user), 127)], owner[:min(len(owner), 127)]
+	u := append(passwordHash(user, salts[0:8], nil), salts[0:16]...)
+	ue := encryptKey(passwordHash(user, salts[8:16], nil), key)
+	o := append(passwordHash(owner, salts[16:24], u), salts[16:32]...)
+	oe := encryptKey(passwordHash(owner, salts[24:32], u), key)
+
+	// The permissions are also stored encrypted to detect tampering
+	perms := make([]byte, 16)
+	binary.LittleEndian.PutUint32(perms, p32)
+	copy(perms[4:], "\xff\xff\xff\xffTadb")
+	copy(perms[12:], random[64:])
+	block.Encrypt(perms, perms)
+
+	return &encryptor{
+		version: "1.7",
+		catalog: []string{"/Extensions << /ADBE << /BaseVersion /1.7 /ExtensionLevel 8 >> >>"},
+		key:     key,
+		block:   block,
+		dict: []string{
+			"<<",
+			"/Filter /Standard",
+			"/V 5",
+			"/R 6",
+			"/Length 256",
+			"/CF << /StdCF << /AuthEvent /DocOpen /CFM /AESV3 /Length 32 >> >>",
+			"/StmF /StdCF",
+			"/StrF /StdCF",
+			fmt.Sprintf("/O <%X>", o),
+			fmt.Sprintf("/U <%X>", u),
+			fmt.Sprintf("/OE <%X>", oe),
+			fmt.Sprintf("/UE <%X>", ue),
+			fmt.Sprintf("/Perms <%X>", perms),
+			fmt.Sprintf("/P %d", int32(p32)),
+			">>",
+		},
+	}
+}
+
+// passwordPadding completes passwords to 32 bytes in revision 4
+const passwordPadding = "\x28\xbf\x4e\x5e\x4e\x75\x8a\x41\x64\x00\x4e\x56\xff\xfa\x01\x08" +
+	"\x2e\x2e\x00\xb6\xd0\x68\x3e\x80\x2f\x0c\xa9\xfe\x64\x53\x69\x7a"
+
+// newAES128Encryptor returns an encryptor for revision 4, whose keys are
+// derived from the passwords and the file identifier id
+func newAES128Encryptor(e *encryption, p32 uint32, id []byte) *encryptor {
+	pad := func(password string) []byte {
+		return []byte((password + passwordPadding)[:32])
+	}
+	user := pad(e.userPassword)
+
+	// The owner entry is the padded user password encrypted with a key
+	// derived from the owner password (algorithm 3)
+	digest := md5.Sum(pad(e.ownerPassword))
+	for range 50 {
+		digest = md5.Sum(digest[:])
+	}
+	o := rc4Rounds(digest[:], user)
+
+	// File key (algorithm 2)
+	h := md5.New()
+	h.Write(user)
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, p32)
+	h.Write(id)
+	key := h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+
+	// The user entry checks the user password (algorithm 5), padded with
+	// arbitrary bytes
+	check := md5.Sum(append([]byte(passwordPadding), id...))
+	u := append(rc4Rounds(key, check[:]), make([]byte, 16)...)
+
+	return &encryptor{
+		version: "1.6",
+		id:      id,
+		key:     key,
+		dict: []string{
+			"<<",
+			"/Filter /Standard",
+			"/V 4",
+			"/R 4",
+			"/Length 128",
+			"/CF << /StdCF << /AuthEvent /DocOpen /CFM /AESV2 /Length 16 >> >>",
+			"/StmF /StdCF",
+			"/StrF /StdCF",
+			fmt.Sprintf("/O <%X>", o),
+			fmt.Sprintf("/U <%X>", u),
+			fmt.Sprintf("/P %d", int32(p32)),
+			">>",
+		},
+	}
+}
+
+// rc4Rounds encrypts data with RC4 20 times, with the key XORed with the
+// round number
+func rc4Rounds(key, data []byte) []byte {
+	out := append([]byte(nil), data...)
+	roundKey := make([]byte, len(key))
+	for i := range 20 {
+		for j := range key {
+			roundKey[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(roundKey)
+		c.XORKeyStream(out, out)
+	}
+	return out
+}
+
+// objectCipher returns the cipher of object num
+func (e *encryptor) objectCipher(num int) cipher.Block {
+	if e.block != nil {
+		return e.block
+	}
+	// Algorithm 1: the file key extended with the object and generation
+	// numbers and the AES salt
+	h := md5.New()
+	h.Write(e.key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), 0, 0})
+	h.Write([]byte("sAlT"))
+	block, _ := aes.NewCipher(h.Sum(nil))
+	return block
+}
+
+// passwordHash computes the hash of a password with a salt and, for the
+// owner password, the user key (ISO 32000-2 algorithm 2.B)
+func passwordHash(password, salt, userKey []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(userKey)
+	k := h.Sum(nil)
+	for round := 0; ; round++ {
+		k1 := bytes.Repeat(append(append(append([]byte(nil), password...), k...), userKey...), 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		// The first 16 bytes as a number modulo 3 pick the next hash
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var next hash.Hash
+		switch sum % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		default:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+		if round >= 63 && int(e[len(e)-1]) <= round-31 {
+			return k[:32]
+		}
+	}
+}
+
+// encryptKey encrypts the file key with a key derived from a password
+func encryptKey(kek, key []byte) []byte {
+	block, _ := aes.NewCipher(kek)
+	out := make([]byte, len(key))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, key)
+	return out
+}
+
+// encrypt encrypts a string or stream of object num with a random or
+// derived initialization vector, which precedes the padded data
+func (e *encryptor) encrypt(num int, data []byte) []byte {
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+pad)
+	if e.deterministic {
+		copy(out, e.derivedIV(num, data))
+	} else {
+		rand.Read(out[:aes.BlockSize])
+	}
+	copy(out[aes.BlockSize:], data)
+	for i := len(out) - pad; i < len(out); i++ {
+		out[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(e.objectCipher(num), out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+	return out
+}
+
+// encryptStrings replaces the literal and hex strings of object num, as
+// written by the package, with encrypted hex strings
+func (e *encryptor) encryptStrings(num int, s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '(':
+			str, n := literalString(s[i:])
+			b.WriteString("<" + hex.EncodeToString(e.encrypt(num, str)) + ">")
+			i += n
+		case s[i] == '<' && i+1 < len(s) && s[i+1] == '<':
+			b.WriteString("<<")
+			i += 2
+		case s[i] == '<':
+			end := bytes.IndexByte([]byte(s[i:]), '>')
+			if end < 0 {
+				end = len(s) - i - 1
+			}
+			str := decodeHexString(s[i+1 : i+end])
+			b.WriteString("<" + hex.EncodeToString(e.encrypt(num, str)) + ">")
+			i += end + 1
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// literalString decodes the literal string at the start of s, returning its
+// bytes and the length of its syntax
+func literalString(s string) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+			out = append(out, c)
+		case '\\':
+			i++
+			if i == len(s) {
+				return out, i
+			}
+			switch c = s[i]; c {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\n':
+				// Line continuation
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := 0
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					v = v*8 + int(s[i]-'0')
+					i++
+				}
+				i--
+				out = append(out, byte(v))
+			default:
+				out = append(out, c)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, len(s)
+}
+
+// decodeHexString decodes the digits of a hex string, ignoring white space;
+// a missing final digit is taken as 0
+func decodeHexString(s string) []byte {
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	hex.Decode(out, digits)
+	return out
+}
@@ -0,0 +1,126 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// hexEntry returns the bytes of the hex string entry name of dict, the
+// first of an array
+func hexEntry(t *testing.T, dict []byte, name string) []byte {
+	t.Helper()
+	m := regexp.MustCompile(`/` + name + ` \[?<([0-9A-F]*)>`).FindSubmatch(dict)
+	if m == nil {
+		t.Fatalf("no /%s entry", name)
+	}
+	b, err := hex.DecodeString(string(m[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// streamKey returns the cipher of the streams of object num for password,
+// following the standard security handler as a viewer does, or nil if the
+// password does not match the /U entry
+func streamKey(t *testing.T, encrypt []byte, id []byte, password string, num int) cipher.Block {
+	t.Helper()
+	u := hexEntry(t, encrypt, "U")
+	if bytes.Contains(encrypt, []byte("/R 6")) {
+		// Algorithm 2.A: check the validation salt, then decrypt the file
+		// key with the key salt
+		if !bytes.Equal(passwordHash([]byte(password), u[32:40], nil), u[:32]) {
+			return nil
+		}
+		ue := hexEntry(t, encrypt, "UE")
+		block, _ := aes.NewCipher(passwordHash([]byte(password), u[40:48], nil))
+		key := make([]byte, 32)
+		cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, ue)
+		block, _ = aes.NewCipher(key)
+		return block
+	}
+
+	// Algorithm 2: the file key from the padded password, /O, /P and the
+	// file identifier, checked against /U (algorithm 5)
+	m := regexp.MustCompile(`/P (-?\d+)`).FindSubmatch(encrypt)
+	perms, _ := strconv.Atoi(string(m[1]))
+	h := md5.New()
+	h.Write([]byte((password + passwordPadding)[:32]))
+	h.Write(hexEntry(t, encrypt, "O"))
+	binary.Write(h, binary.LittleEndian, int32(perms))
+	h.Write(id)
+	key := h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	check := md5.Sum(append([]byte(passwordPadding), id...))
+	if !bytes.Equal(rc4Rounds(key, check[:]), u[:16]) {
+		return nil
+	}
+	// Algorithm 1: the object key
+	h = md5.New()
+	h.Write(key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), 0, 0, 's', 'A', 'l', 'T'})
+	block, _ := aes.NewCipher(h.Sum(nil))
+	return block
+}
+
+func TestEncryption(t *testing.T) {
+	for name, method := range map[string]EncryptionMethod{"AES256": AES256, "AES128": AES128} {
+		t.Run(name, func(t *testing.T) {
+			pdf := writeDocument(t, false, WithEncryption("secret", "owner", AllowPrint), WithEncryptionMethod(method))
+			offsets, _ := xrefTable(t, pdf, startXref(t, pdf))
+			object := func(pattern string) int {
+				t.Helper()
+				m := regexp.MustCompile(pattern + ` (\d+) 0 R`).FindSubmatch(pdf)
+				if m == nil {
+					t.Fatalf("no %s reference", pattern)
+				}
+				num, _ := strconv.Atoi(string(m[1]))
+				return num
+			}
+			encryptNum, contentsNum := object("/Encrypt"), object("/Contents")
+			encrypt := pdf[offsets[encryptNum]:]
+			encrypt = encrypt[:bytes.Index(encrypt, []byte("endobj"))]
+			id := hexEntry(t, pdf[startXref(t, pdf):], "ID")
+
+			if streamKey(t, encrypt, id, "wrong", contentsNum) != nil {
+				t.Error("a wrong password matches /U")
+			}
+			// The content stream decrypts with the user password to the
+			// drawing operators
+			block := streamKey(t, encrypt, id, "secret", contentsNum)
+			if block == nil {
+				t.Fatal("the user password does not match /U")
+			}
+			_, data := rawStream(t, pdf, contentsNum, offsets[contentsNum])
+			if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+				t.Fatalf("encrypted stream of %d bytes", len(data))
+			}
+			plain := make([]byte, len(data)-aes.BlockSize)
+			cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plain, data[aes.BlockSize:])
+			pad := int(plain[len(plain)-1])
+			if pad == 0 || pad > aes.BlockSize {
+				t.Fatalf("invalid padding %d", pad)
+			}
+			content := inflate(plain[:len(plain)-pad])
+			if !bytes.Contains(content, []byte("(Hello) Tj")) {
+				t.Errorf("decrypted content %q lacks the text", content)
+			}
+			// Neither streams nor strings are left in the clear
+			for _, clear := range []string{"Hello", "(One)", "(Two)"} {
+				if bytes.Contains(pdf, []byte(clear)) {
+					t.Errorf("%q written unencrypted", clear)
+				}
+			}
+		})
+	}
+}
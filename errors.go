@@ -0,0 +1,79 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// ParseError reports SVG input that could not be decoded or does not
+// conform to the SVG profile, i.e. bad input. The underlying error is, for
+// example, an *xml.SyntaxError.
+type ParseError struct {
+	Op       string // "decoding" or "validating"
+	Document int    // Index of the svg document in a source holding several, from 1
+	Line     int    // Position in the source, 0 if unknown
+	Column   int
+	Element  string // Element at fault, if known
+	Err      error
+}
+
+// Error describes the error, e.g. "error decoding SVG: XML syntax error on
+// line 3: unexpected EOF"
+func (e *ParseError) Error() string {
+	if e.Document > 1 {
+		return fmt.Sprintf("error %s SVG document %d: %v", e.Op, e.Document, e.Err)
+	}
+	return fmt.Sprintf("error %s SVG: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// UnsupportedFeatureError reports content the converter cannot render, a
+// limitation of the converter rather than bad input. Conversions only fail
+// with it in strict mode; otherwise it is a warning.
+type UnsupportedFeatureError struct {
+	Warning Warning
+}
+
+// Error describes the unsupported content and where it is
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("strict mode: page %d: %s", e.Warning.Page+1, e.Warning.detail())
+}
+
+// ResourceError reports a font, image or style sheet that could not be
+// loaded or embedded
+type ResourceError struct {
+	Op   string // What failed, e.g. "loading" or "registering"
+	Kind string // "font", "@font-face", "image" or "stylesheet"
+	Name string // Font family, image or style sheet reference
+	Err  error
+}
+
+// Error describes the error, e.g. `error registering font "Inter": invalid
+// sfnt header`
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("error %s %s %q: %v", e.Op, e.Kind, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ResourceError) Unwrap() error { return e.Err }
+
+// decodeError returns the error decoding the nth svg document of a source
+// with decoder
+func decodeError(n int, err error, decoder *xml.Decoder) *ParseError {
+	e := &ParseError{Op: "decoding", Document: n, Err: err}
+	e.Line, e.Column = decoder.InputPos()
+	if syntax, ok := err.(*xml.SyntaxError); ok && syntax.Line != e.Line {
+		e.Line, e.Column = syntax.Line, 0 // Only the line is known
+	}
+	return e
+}
+
+// elementError returns the error decoding the element started by start in
+// the nth svg document
+func elementError(n int, err error, decoder *xml.Decoder, start xml.StartElement) *ParseError {
+	e := decodeError(n, err, decoder)
+	e.Element = start.Name.Local
+	return e
+}
@@ -0,0 +1,137 @@
+package svg2pdf
+
+import "fmt"
+
+// EventKind identifies the kind of a conversion event
+type EventKind int
+
+// Conversion event kinds
+const (
+	ElementRendered EventKind = iota // An SVG element was drawn
+	ResourceCreated                  // A font, image, form or color space was added to the document
+	WarningEmitted                   // Content was skipped or approximated
+)
+
+// String returns the name of the event kind
+func (k EventKind) String() string {
+	switch k {
+	case ElementRendered:
+		return "ElementRendered"
+	case ResourceCreated:
+		return "ResourceCreated"
+	case WarningEmitted:
+		return "WarningEmitted"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event is a step of a conversion, reported to the event hook in order
+type Event struct {
+	Kind     EventKind
+	Page     int    // Index of the page being converted; String numbers pages from 1
+	Element  string // Element name, e.g. "rect", if the event concerns an element
+	ID       string // id attribute of the element
+	Resource string // Resource name in the page resources, for ResourceCreated
+	Message  string // What was skipped or approximated, for WarningEmitted
+	// Attribute that was ignored, and the position of the element in the
+	// source if known, for WarningEmitted
+	Attribute    string
+	Line, Column int
+}
+
+// String describes the event, e.g. for test failure messages
+func (e Event) String() string {
+	element := e.Element
+	if e.ID != "" {
+		element += "#" + e.ID
+	}
+	switch e.Kind {
+	case ElementRendered:
+		return fmt.Sprintf("page %d: rendered <%s>", e.Page+1, element)
+	case ResourceCreated:
+		return fmt.Sprintf("page %d: created %s", e.Page+1, e.Resource)
+	}
+	return e.warning().String()
+}
+
+// warning returns the warning a WarningEmitted event reports
+func (e Event) warning() Warning {
+	return Warning{Page: e.Page, Element: e.Element, ID: e.ID, Attribute: e.Attribute,
+		Line: e.Line, Column: e.Column, Reason: e.Message}
+}
+
+// SetEventHook sets a hook called for every conversion event, in order.
+// It lets tests assert how SVGs are interpreted, e.g. that an element was
+// drawn or an image was skipped, without parsing the PDF.
+func (p *PDF) SetEventHook(hook func(Event)) {
+	p.eventHook = hook
+}
+
+// WithEventHook sets a hook called for every conversion event
+func WithEventHook(hook func(Event)) Option {
+	return func(p *PDF) error {
+		p.SetEventHook(hook)
+		return nil
+	}
+}
+
+// EventLog records conversion events, for use as an event hook:
+//
+//	var log svg2pdf.EventLog
+//	p, err := svg2pdf.New(svg2pdf.WithEventHook(log.Record))
+type EventLog []Event
+
+// Record appends e to the log
+func (l *EventLog) Record(e Event) {
+	*l = append(*l, e)
+}
+
+// Kind returns the recorded events of kind k, in order
+func (l EventLog) Kind(k EventKind) []Event {
+	var events []Event
+	for _, e := range l {
+		if e.Kind == k {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// event reports e on the current page to the event hook, recording
+// warnings for Warnings and the conversion report. Page workers report it
+// once merged.
+func (p *PDF) event(e Event) {
+	e.Page = p.pageIndex(p.current)
+	if e.Kind == WarningEmitted {
+		w := e.warning()
+		p.warnings = append(p.warnings, w)
+		p.fail(&UnsupportedFeatureError{Warning: w})
+	}
+	if p.worker != nil {
+		if p.logger != nil || p.eventHook != nil {
+			p.worker.replay = append(p.worker.replay, replayed{event: &e})
+		}
+		return
+	}
+	p.logEvent(e)
+	if p.eventHook != nil {
+		p.eventHook(e)
+	}
+}
+
+// rendered reports that an element was drawn
+func (p *PDF) rendered(element, id string) {
+	p.countRendered(element)
+	p.event(Event{Kind: ElementRendered, Element: element, ID: id})
+}
+
+// created reports that a resource was added to the document
+func (p *PDF) created(name string) {
+	p.event(Event{Kind: ResourceCreated, Resource: name})
+}
+
+// warn reports that content of an element, or of no element if element is
+// empty, was skipped or approximated
+func (p *PDF) warn(element, id, format string, args ...any) {
+	p.event(Event{Kind: WarningEmitted, Element: element, ID: id, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,107 @@
+package svg2pdf
+
+import (
+	"slices"
+	"strings"
+)
+
+// features lists the capabilities compiled into the package, grouped by a
+// prefix such as "filter/" or "font/". Optional capabilities (rasterizer,
+// encryption and the like) are only listed once they are available.
+var features = map[string]bool{
+	"filter/FlateDecode":       true, // Compression of content, font and image streams
+	"filter/DCTDecode":         true, // JPEG images embedded without recompression
+	"font/truetype":            true,
+	"font/opentype-cff":        true,
+	"font/woff":                true,
+	"font/woff2":               true, // Brotli-compressed, with transformed glyf, loca and hmtx tables
+	"font/system":              true, // Font discovery through SystemFonts
+	"font/outlines":            true, // Text drawn as glyph outlines
+	"image/png":                true,
+	"image/jpeg":               true,
+	"image/color-key-mask":     true,
+	"image/icc-profiles":       true, // Embedded PNG and JPEG profiles are preserved
+	"color/cmyk-output":        true, // RGB paints and images converted to CMYK
+	"color/spot-colors":        true, // SVG colors mapped to Separation color spaces
+	"color/grayscale":          true, // Paints and images converted to DeviceGray
+	"text/bidi":                true,
+	"text/arabic-shaping":      true,
+	"text/vertical":            true,
+	"text/columns":             true, // Reflowing into columns through SetTextFlow
+	"text/baseline-grid":       true, // Snapping laid out text to a grid through SetBaselineGrid
+	"css/font-face":            true,
+	"css/media-print":          true, // @media print rules hiding elements with display: none
+	"css/custom-properties":    true, // var() with fallbacks, custom properties inherited from :root and other elements
+	"svg/nested-viewports":     true, // Inner svg elements with their own viewport, clipping and style sheets
+	"svg/symbol-use":           true,
+	"svg/path":                 true, // Path data, converted while scanning, with fill-rule
+	"svg/clip-path":            true, // Rectangles and paths, with clip-rule for a single path
+	"svg/filters":              true, // feGaussianBlur and feDropShadow on paths and groups, rasterized at SetFilterResolution
+	"raster/fallback":          true, // Filters, foreignObject and basic shapes through SetRasterizer
+	"svg/foreign-object":       true, // HTML text drawn in place, set in columns through SetTextFlow, or rasterized
+	"svg/multi-root":           true, // Concatenated documents convert to one page each
+	"svg/streaming-parse":      true, // Element by element conversion through SetStreamingParse
+	"svg/document-tree":        true, // Parsing, changing and rendering the element tree through Parse and Render
+	"svg/element-selection":    true, // Drawing a single element, e.g. one icon of a sprite sheet, through SetElementID
+	"svg/analysis":             true, // Pre-flight reports of used and unsupported content through Analyze
+	"svg/resource-loader":      true, // Images, fonts and @import style sheets through SetResourceLoader
+	"svg/doctype-entities":     true, // Entities declared in a DOCTYPE; external ones are refused
+	"svg/encodings":            true, // UTF-16, ISO 8859-1 and Windows-1252 sources
+	"svg/missing-namespace":    true, // Documents without the SVG namespace or with a prefix for it
+	"svg/limits":               true, // Caps on elements, nesting, path segments and image data through SetLimits
+	"svg/element-handlers":     true, // Extension elements drawn through RegisterElementHandler
+	"svg/cancellation":         true, // Conversions stopped through a context, e.g. ConvertSVGContext
+	"svg/parallel-decode":      true, // Sources of AddSVGPages decoded concurrently through SetConcurrency
+	"svg/parallel-draw":        true, // Pages of AddSVGPages drawn concurrently into separate content streams
+	"svg/redaction":            true, // Selector based redaction
+	"svg/profiles":             true, // Validation against SVG 1.1 Full or SVG Tiny 1.2
+	"svg/blend-modes":          true, // mix-blend-mode through ExtGState /BM and transparency groups
+	"svg/groups":               true,
+	"svg/visibility":           true, // display: none and inherited visibility: hidden, from attributes, style sheets and style attributes
+	"svg/switch":               true, // systemLanguage, requiredFeatures and requiredExtensions on switches and other elements, optionally a page per language
+	"svg/animation-snapshot":   true, // SMIL and CSS animations drawn at a chosen time through SetAnimationTime
+	"svg/design-tokens":        true, // SVG markup in JSON or YAML fields through ConvertTokens
+	"pdf/layers":               true, // Layer groups as optional content through SetLayers
+	"pdf/tiling":               true, // Posters split across pages through SetTiling
+	"pdf/n-up":                 true, // Several SVGs per page in a grid through SetNUp
+	"pdf/watermark":            true, // Text or SVG drawn on every page through SetWatermark
+	"pdf/page-templates":       true, // Headers and footers with page numbers through SetHeader and SetFooter
+	"pdf/background":           true, // Page backgrounds through SetBackground or the CSS of the root svg
+	"pdf/custom-objects":       true,
+	"pdf/xmp":                  true, // Document information as XMP metadata
+	"pdf/resource-inventory":   true, // Fonts, images and other resources through Result
+	"pdf/statistics":           true, // Element counts, content bounds and stream sizes through Stats
+	"pdf/object-streams":       true, // Object and cross-reference streams through SetObjectStreams
+	"pdf/precision":            true, // Shorter coordinates through SetPrecision
+	"pdf/streaming":            true, // Writing pages as they are finished through SetStreamingOutput
+	"pdf/deterministic":        true, // Byte-identical output through SetDeterministicOutput
+	"pdf/xmp-rights":           true,
+	"pdf/a-2b":                 true, // PDF/A-2b conformance through SetPDFA
+	"pdf/encryption":           true, // AES-128 and AES-256 through SetEncryption
+	"pdf/tagged":               true, // Structure tree for accessibility through SetTaggedPDF
+	"pdf/conversion-report":    true, // Warnings embedded as an attachment
+	"svg/unsupported-warnings": true, // Skipped elements and attributes with their source position through Warnings
+	"svg/strict-mode":          true, // Failing on the first warning through SetStrictMode
+	"pdf/source-attachment":    true, // Source SVGs attached through SetSourceAttachment
+}
+
+// Supports reports whether the package was built with the named feature,
+// e.g. "filter/FlateDecode" or "font/woff". Names are case-insensitive.
+func Supports(feature string) bool {
+	for name := range features {
+		if strings.EqualFold(name, feature) {
+			return true
+		}
+	}
+	return false
+}
+
+// Features returns the names of all supported features, sorted
+func Features() []string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,740 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Filter represents an SVG filter element. Of its primitives, feGaussianBlur
+// and feDropShadow are approximated, as is the drop shadow built from a
+// blurred SourceAlpha, feOffset and feMerge; the others are skipped.
+type Filter struct {
+	ID         string    `xml:"id,attr"`
+	X          Length    `xml:"x,attr"`
+	Y          Length    `xml:"y,attr"`
+	Width      Length    `xml:"width,attr"`
+	Height     Length    `xml:"height,attr"`
+	Units      string    `xml:"filterUnits,attr"` // objectBoundingBox (default) or userSpaceOnUse
+	Primitives []Element `xml:",any"`
+	markup     *Element  // Source, for the Rasterizer
+}
+
+// defaultFilterResolution is the resolution in dots per inch filtered
+// elements are rasterized at unless set with SetFilterResolution
+const defaultFilterResolution = 150
+
+// maxRasterSide is the most pixels along either side of a rasterized
+// element; larger ones are rasterized at a lower resolution
+const maxRasterSide = 2048
+
+// SetFilterResolution sets the resolution, in dots per inch of the page,
+// at which filtered elements are rasterized, 150 by default
+func (p *PDF) SetFilterResolution(dpi float64) error {
+	if !(dpi > 0 && dpi <= 1200) {
+		return fmt.Errorf("invalid filter resolution %g", dpi)
+	}
+	p.filterResolution = dpi
+	return nil
+}
+
+// SetFilterResolution sets the resolution filtered elements are rasterized at
+func (d *Document) SetFilterResolution(dpi float64) error {
+	return d.pdf.SetFilterResolution(dpi)
+}
+
+// WithFilterResolution sets the resolution filtered elements are rasterized at
+func WithFilterResolution(dpi float64) Option {
+	return func(p *PDF) error {
+		return p.SetFilterResolution(dpi)
+	}
+}
+
+// filterEffect is what the primitives of a filter amount to
+type filterEffect struct {
+	blurX, blurY float64     // Standard deviation of the blur of the element, in user units
+	shadow       *dropShadow // Shadow drawn below the element, nil for none
+	source       bool        // Whether the element itself is drawn
+}
+
+// dropShadow is an offset, blurred and colored copy of the alpha of an element
+type dropShadow struct {
+	dx, dy       float64
+	blurX, blurY float64
+	color        RGB
+	opacity      float64
+}
+
+// filterOf returns the filter referenced by the filter property of an
+// element, given as attr and in the style attribute, or nil for none
+func (p *PDF) filterOf(tag, id, attr, style string) *Filter {
+	value := attr
+	for _, decl := range parseDeclarations(style) {
+		if decl.Property == "filter" {
+			value = decl.Value
+		}
+	}
+	value = declValue(value)
+	if value == "" || value == "none" {
+		return nil
+	}
+	ref, ok := parseURLRef(value)
+	if !ok {
+		p.warn(tag, id, "filter %s is not supported, drawing unfiltered", value)
+		return nil
+	}
+	f, ok := p.filters[ref]
+	if !ok {
+		p.warn(tag, id, "filter %q not found, drawing unfiltered", ref)
+		return nil
+	}
+	return f
+}
+
+// filterEffect returns what the primitives of f amount to, warning about those
+// that are skipped
+func (p *PDF) filterEffect(f *Filter) filterEffect {
+	e := filterEffect{source: true}
+	var skipped []string
+	var alpha *dropShadow // Shadow built from SourceAlpha, see below
+	for _, prim := range f.Primitives {
+		name := prim.XMLName.Local
+		switch {
+		case name == "feGaussianBlur" && prim.Attr("in") == "SourceAlpha":
+			alpha = &dropShadow{opacity: 1}
+			alpha.blurX, alpha.blurY = stdDeviation(prim.Attr("stdDeviation"), 0)
+		case name == "feGaussianBlur":
+			e.blurX, e.blurY = stdDeviation(prim.Attr("stdDeviation"), 0)
+		case name == "feDropShadow":
+			s := &dropShadow{dx: attrNumber(prim, "dx", 2), dy: attrNumber(prim, "dy", 2), opacity: 1}
+			s.blurX, s.blurY = stdDeviation(prim.Attr("stdDeviation"), 2)
+			if c, ok := parseColor(prim.Attr("flood-color")); ok {
+				s.color = c
+			}
+			if v, err := parseNumber(prim.Attr("flood-opacity")); err == nil {
+				s.opacity = clamp01(v)
+			}
+			e.shadow = s
+		case name == "feOffset" && alpha != nil:
+			alpha.dx, alpha.dy = attrNumber(prim, "dx", 0), attrNumber(prim, "dy", 0)
+		case name == "feMerge" && alpha != nil:
+			// The element is merged over its shadow unless it is left out
+			e.source = strings.Contains(prim.Content, "SourceGraphic")
+		default:
+			skipped = append(skipped, name)
+		}
+	}
+	if alpha != nil {
+		e.shadow = alpha
+	}
+	if len(skipped) > 0 {
+		p.warn("filter", f.ID, "filter primitives %s are skipped", strings.Join(slices.Compact(skipped), ", "))
+	}
+	return e
+}
+
+// stdDeviation parses the one or two standard deviations of a blur
+func stdDeviation(value string, def float64) (float64, float64) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	sx, sy := def, def
+	if len(fields) > 0 {
+		if v, err := parseNumber(fields[0]); err == nil {
+			sx, sy = v, v
+		}
+	}
+	if len(fields) > 1 {
+		if v, err := parseNumber(fields[1]); err == nil {
+			sy = v
+		}
+	}
+	return max(sx, 0), max(sy, 0)
+}
+
+// attrNumber returns the number of an attribute of a filter primitive
+func attrNumber(e Element, name string, def float64) float64 {
+	v, err := parseNumber(e.Attr(name))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// region returns the filter region of an element with the bounding box bbox
+func (f *Filter) region(bbox viewBox, ctx unitContext) viewBox {
+	if f.Units == "userSpaceOnUse" {
+		return viewBox{
+			ctx.resolve(f.X, axisX, ctx.resolve("-10%", axisX, 0)),
+			ctx.resolve(f.Y, axisY, ctx.resolve("-10%", axisY, 0)),
+			ctx.resolve(f.Width, axisX, ctx.resolve("120%", axisX, 0)),
+			ctx.resolve(f.Height, axisY, ctx.resolve("120%", axisY, 0)),
+		}
+	}
+	// Fractions or percentages of the bounding box
+	unit := ctx.withViewport(1, 1)
+	return viewBox{
+		bbox.X + unit.resolve(f.X, axisX, -0.1)*bbox.W,
+		bbox.Y + unit.resolve(f.Y, axisY, -0.1)*bbox.H,
+		unit.resolve(f.Width, axisX, 1.2) * bbox.W,
+		unit.resolve(f.Height, axisY, 1.2) * bbox.H,
+	}
+}
+
+// rasterShape is a filled and/or stroked path drawn into a raster
+type rasterShape struct {
+	ops     string // Path construction operators, as written by writePathData
+	bbox    viewBox
+	fill    *RGB
+	evenOdd bool
+	stroke  *RGB
+	width   float64
+}
+
+// pathShape returns a path element as a shape to rasterize, reporting false
+// if it paints nothing
+func (p *PDF) pathShape(path Path) (rasterShape, bool) {
+	s := rasterShape{evenOdd: p.evenOdd("fill-rule", path), width: 1}
+	if value := p.cascade("fill", "path", path.ID, path.Class, path.Fill, path.Inline); value != "none" {
+		c, ok := parseColor(value)
+		if !ok {
+			c = RGB{0, 0, 0}
+		}
+		s.fill = &c
+	}
+	if c, ok := parseColor(p.cascade("stroke", "path", path.ID, path.Class, path.Stroke, path.Inline)); ok {
+		s.stroke = &c
+	}
+	if s.fill == nil && s.stroke == nil {
+		return s, false
+	}
+	var b strings.Builder
+	bbox, segments := writePathData(&b, path.D, p.decimals(), p.pathSegmentLimit())
+	if max := p.pathSegmentLimit(); max > 0 && segments > max {
+		p.exceed("PathSegments", float64(max))
+		return s, false
+	}
+	s.ops, s.bbox = b.String(), bbox
+	return s, segments > 0
+}
+
+// rectShape returns a rect element as a shape to rasterize, outlined in
+// black as it is drawn
+func rectShape(x, y, w, h float64) rasterShape {
+	black := RGB{}
+	return rasterShape{
+		ops:    fmt.Sprintf("%g %g m\n%g %g l\n%g %g l\n%g %g l\nh\n", x, y, x+w, y, x+w, y+h, x, y+h),
+		bbox:   viewBox{x, y, w, h},
+		stroke: &black,
+		width:  1,
+	}
+}
+
+// containerShapes returns the rects and paths drawn by c and the groups
+// within it as shapes to rasterize
+func (p *PDF) containerShapes(c *Container, ctx unitContext) []rasterShape {
+	var shapes []rasterShape
+	for _, rect := range c.Rects {
+		if !p.holds(rect.Conditions) || p.hidden("rect", rect.ID, rect.Class, rect.Display, rect.Visibility, rect.Inline) {
+			continue
+		}
+		shapes = append(shapes, rectShape(ctx.resolve(rect.X, axisX, 0), ctx.resolve(rect.Y, axisY, 0),
+			ctx.resolve(rect.Width, axisX, 0), ctx.resolve(rect.Height, axisY, 0)))
+	}
+	for _, path := range c.Paths {
+		if !p.holds(path.Conditions) || p.hidden("path", path.ID, path.Class, path.Display, path.Visibility, path.Inline) {
+			continue
+		}
+		restore := p.scopeProperties("path", path.ID, path.Class, path.Inline)
+		if s, ok := p.pathShape(path); ok {
+			shapes = append(shapes, s)
+		}
+		restore()
+	}
+	for i := range c.Groups {
+		g := &c.Groups[i]
+		if !p.holds(g.Conditions) || p.displayNone("g", g.ID, g.Class, g.Display, g.Inline) {
+			continue
+		}
+		restore := p.scopeProperties("g", g.ID, g.Class, g.Inline)
+		if p.redacts("g", g.ID, g.Class) {
+			// The black box of the redaction, none of its children
+			if box, ok := p.containerBBox(&g.Container, ctx); ok {
+				shapes = append(shapes, rectShape(box.X, box.Y, box.W, box.H))
+			}
+			restore()
+			continue
+		}
+		restoreVisibility := p.inheritVisibility(p.visible("g", g.ID, g.Class, g.Visibility, g.Inline))
+		shapes = append(shapes, p.containerShapes(&g.Container, ctx)...)
+		restoreVisibility()
+		restore()
+	}
+	return shapes
+}
+
+// withoutShapes returns c without the rects, paths and redacted groups
+// containerShapes returns, leaving what is drawn unfiltered
+func (p *PDF) withoutShapes(c Container) Container {
+	c.Rects, c.Paths = nil, nil
+	c.Groups = slices.DeleteFunc(slices.Clone(c.Groups), func(g Group) bool {
+		return p.redacts("g", g.ID, g.Class)
+	})
+	for i := range c.Groups {
+		c.Groups[i].Container = p.withoutShapes(c.Groups[i].Container)
+	}
+	return c
+}
+
+// hasUnfiltered reports whether c holds content other than rects and
+// paths, which is drawn unfiltered
+func hasUnfiltered(c *Container) bool {
+	if len(c.Texts)+len(c.Images)+len(c.SVGs)+len(c.Uses)+len(c.Foreign)+len(c.Switches)+len(c.Extensions) > 0 {
+		return true
+	}
+	for i := range c.Groups {
+		if hasUnfiltered(&c.Groups[i].Container) {
+			return true
+		}
+	}
+	return false
+}
+
+// drawFilteredGroup draws a group with a filter, its rects and paths
+// rasterized, calling render to draw it unfiltered where the filter leaves
+// it so
+func (p *PDF) drawFilteredGroup(f *Filter, g *Group, ctx unitContext, render func()) {
+	rest := p.withoutShapes(g.Container)
+	drawRest := func() {
+		if hasUnfiltered(&rest) {
+			p.warn("g", g.ID, "text, images and other content are drawn unfiltered")
+		}
+		p.renderContainer(&rest, ctx)
+	}
+	markup := g.markup
+	if p.redactsWithin(&g.Container) {
+		markup = nil // Redacted content is not rasterized from its source
+	}
+	p.drawFiltered(f, markup, p.containerShapes(&g.Container, ctx), ctx, render, drawRest)
+}
+
+// drawFiltered draws shapes with the effect of f: a drop shadow below them,
+// rasterized, and the shapes blurred into the same image or else drawn by
+// draw. Once the shapes are blurred, rest draws what they leave out, if
+// not nil. With a Rasterizer, the element is rasterized from its markup
+// instead, over the filter region of the shapes or else the viewport. It
+// reports whether draw was left uncalled.
+func (p *PDF) drawFiltered(f *Filter, markup *Element, shapes []rasterShape, ctx unitContext, draw, rest func()) bool {
+	var region viewBox
+	if len(shapes) > 0 {
+		bbox := shapes[0].bbox
+		for _, s := range shapes[1:] {
+			bbox = bbox.union(s.bbox)
+		}
+		region = f.region(bbox, ctx)
+	}
+	if p.rasterizer != nil && markup != nil {
+		box := region
+		if len(shapes) == 0 {
+			box = viewBox{0, 0, ctx.viewportW, ctx.viewportH}
+		}
+		if p.rasterize(markup, f, box) {
+			return true
+		}
+	}
+	effect := p.filterEffect(f)
+	blurred := effect.source && (effect.blurX > 0 || effect.blurY > 0)
+	if len(shapes) == 0 || !blurred && effect.shadow == nil {
+		draw()
+		return false
+	}
+	source, ok := p.newRaster(region)
+	if !ok {
+		if p.abortErr == nil {
+			draw() // An empty region
+		}
+		return p.abortErr != nil
+	}
+	for _, s := range shapes {
+		source.paint(s)
+	}
+	out := &raster{width: source.width, height: source.height, scale: source.scale,
+		pix: make([]float32, len(source.pix))}
+	if s := effect.shadow; s != nil {
+		shadow := source.shadow(s)
+		shadow.blur(s.blurX*source.scale, s.blurY*source.scale)
+		out.over(shadow)
+	}
+	if blurred {
+		source.blur(effect.blurX*source.scale, effect.blurY*source.scale)
+		out.over(source)
+	}
+	p.drawRaster(out, region)
+	switch {
+	case effect.source && !blurred:
+		draw()
+		return false
+	case blurred && rest != nil:
+		rest()
+	}
+	return true
+}
+
+// union returns the smallest box holding b and o
+func (b viewBox) union(o viewBox) viewBox {
+	x, y := min(b.X, o.X), min(b.Y, o.Y)
+	return viewBox{x, y, max(b.X+b.W, o.X+o.W) - x, max(b.Y+b.H, o.Y+o.H) - y}
+}
+
+// raster is an image drawn by the converter, with premultiplied RGBA
+// samples from 0 to 1 and its top left pixel at the origin of a region
+type raster struct {
+	width, height int
+	scale         float64 // Pixels per user unit
+	originX       float64 // Top left of the region, in user units
+	originY       float64
+	pix           []float32
+}
+
+// newRaster returns an empty raster covering region at the filter
+// resolution. It reports false for empty regions and, aborting the
+// conversion, for images over the ImageBytes limit.
+func (p *PDF) newRaster(region viewBox) (*raster, bool) {
+	dpi := p.filterResolution
+	if dpi == 0 {
+		dpi = defaultFilterResolution
+	}
+	scale := p.scaleX * dpi / 72
+	if !(region.W > 0 && region.H > 0 && scale > 0) {
+		return nil, false
+	}
+	scale = min(scale, maxRasterSide/region.W, maxRasterSide/region.H)
+	w, h := int(math.Ceil(region.W*scale)), int(math.Ceil(region.H*scale))
+	if err := p.checkImageSize(image.Config{Width: w, Height: h}); err != nil {
+		return nil, false
+	}
+	return &raster{width: w, height: h, scale: scale, originX: region.X, originY: region.Y,
+		pix: make([]float32, 4*w*h)}, true
+}
+
+// paint fills and strokes a shape over the raster
+func (r *raster) paint(s rasterShape) {
+	lines := r.flatten(s.ops)
+	if s.fill != nil {
+		r.composite(r.coverage(lines, s.evenOdd), *s.fill)
+	}
+	if s.stroke != nil {
+		// Every segment is a rectangle of the same orientation, so their
+		// nonzero union is the stroke, without joins or caps
+		hw := s.width * r.scale / 2
+		var quads []polyline
+		for _, l := range lines {
+			pts := l.pts
+			if l.closed {
+				pts = append(slices.Clip(pts), pts[0])
+			}
+			for i := 1; i < len(pts); i++ {
+				a, b := pts[i-1], pts[i]
+				length := math.Hypot(b.X-a.X, b.Y-a.Y)
+				if length == 0 {
+					continue
+				}
+				nx, ny := -(b.Y-a.Y)/length*hw, (b.X-a.X)/length*hw
+				quads = append(quads, polyline{pts: []point{
+					{a.X + nx, a.Y + ny}, {b.X + nx, b.Y + ny}, {b.X - nx, b.Y - ny}, {a.X - nx, a.Y - ny},
+				}})
+			}
+		}
+		r.composite(r.coverage(quads, false), *s.stroke)
+	}
+}
+
+// polyline is a flattened subpath, in pixels
+type polyline struct {
+	pts    []point
+	closed bool
+}
+
+// flatten converts path construction operators in user units into
+// polylines in pixels, approximating curves with line segments
+func (r *raster) flatten(ops string) []polyline {
+	var lines []polyline
+	var cur polyline
+	end := func() {
+		if len(cur.pts) > 1 {
+			lines = append(lines, cur)
+		}
+	}
+	at := func(x, y float64) point {
+		return point{(x - r.originX) * r.scale, (y - r.originY) * r.scale}
+	}
+	for _, line := range strings.Split(ops, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		n := make([]float64, len(fields)-1)
+		for i, f := range fields[:len(fields)-1] {
+			n[i], _ = parseNumber(f)
+		}
+		switch op := fields[len(fields)-1]; {
+		case op == "m" && len(n) == 2:
+			end()
+			cur = polyline{pts: []point{at(n[0], n[1])}}
+		case op == "l" && len(n) == 2 && len(cur.pts) > 0:
+			cur.pts = append(cur.pts, at(n[0], n[1]))
+		case op == "c" && len(n) == 6 && len(cur.pts) > 0:
+			p0, p1, p2, p3 := cur.pts[len(cur.pts)-1], at(n[0], n[1]), at(n[2], n[3]), at(n[4], n[5])
+			length := math.Hypot(p1.X-p0.X, p1.Y-p0.Y) + math.Hypot(p2.X-p1.X, p2.Y-p1.Y) + math.Hypot(p3.X-p2.X, p3.Y-p2.Y)
+			steps := min(max(int(length/2), 4), 64)
+			for i := 1; i <= steps; i++ {
+				t := float64(i) / float64(steps)
+				u := 1 - t
+				cur.pts = append(cur.pts, point{
+					u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+					u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+				})
+			}
+		case op == "h" && len(cur.pts) > 0:
+			cur.closed = true
+			end()
+			cur = polyline{pts: []point{cur.pts[0]}} // Drawing continues from the start
+		}
+	}
+	end()
+	return lines
+}
+
+// coverageSamples is the number of rows each pixel row is sampled at
+const coverageSamples = 4
+
+// coverage returns the fraction of each pixel the polygons cover, filled
+// by the nonzero or even-odd rule
+func (r *raster) coverage(lines []polyline, evenOdd bool) []float32 {
+	type edge struct {
+		x0, y0, x1, y1 float64
+		dir            int
+	}
+	var edges []edge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, l := range lines {
+		for i := range l.pts {
+			a, b := l.pts[i], l.pts[(i+1)%len(l.pts)] // Filled subpaths are closed
+			if a.Y == b.Y {
+				continue
+			}
+			dir := 1
+			if b.Y < a.Y {
+				dir = -1
+			}
+			edges = append(edges, edge{a.X, a.Y, b.X, b.Y, dir})
+			minY, maxY = min(minY, a.Y, b.Y), max(maxY, a.Y, b.Y)
+		}
+	}
+	cov := make([]float32, r.width*r.height)
+	if len(edges) == 0 {
+		return cov
+	}
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var xs []crossing
+	first, last := max(int(math.Floor(minY)), 0), min(int(math.Ceil(maxY)), r.height)
+	for row := first; row < last; row++ {
+		line := cov[row*r.width : (row+1)*r.width]
+		for s := 0; s < coverageSamples; s++ {
+			y := float64(row) + (float64(s)+0.5)/coverageSamples
+			xs = xs[:0]
+			for _, e := range edges {
+				if (e.y0 <= y) != (e.y1 <= y) {
+					xs = append(xs, crossing{e.x0 + (y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0), e.dir})
+				}
+			}
+			slices.SortFunc(xs, func(a, b crossing) int { return cmpFloat(a.x, b.x) })
+			winding := 0
+			for i := 0; i+1 < len(xs); i++ {
+				winding += xs[i].dir
+				inside := winding != 0
+				if evenOdd {
+					inside = winding%2 != 0
+				}
+				if inside {
+					addSpan(line, xs[i].x, xs[i+1].x, 1.0/coverageSamples)
+				}
+			}
+		}
+	}
+	return cov
+}
+
+// addSpan adds weight times the covered fraction of each pixel of a row
+// between x0 and x1
+func addSpan(line []float32, x0, x1, weight float64) {
+	x0, x1 = max(x0, 0), min(x1, float64(len(line)))
+	if x1 <= x0 {
+		return
+	}
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		line[i0] += float32((x1 - x0) * weight)
+		return
+	}
+	line[i0] += float32((float64(i0+1) - x0) * weight)
+	for i := i0 + 1; i < i1; i++ {
+		line[i] += float32(weight)
+	}
+	if i1 < len(line) {
+		line[i1] += float32((x1 - float64(i1)) * weight)
+	}
+}
+
+// composite paints c over the raster where it is covered
+func (r *raster) composite(cov []float32, c RGB) {
+	color := [3]float32{float32(c.R), float32(c.G), float32(c.B)}
+	for i, a := range cov {
+		a = min(a, 1)
+		if a == 0 {
+			continue
+		}
+		px := r.pix[4*i : 4*i+4]
+		for k := range 3 {
+			px[k] = color[k]*a + px[k]*(1-a)
+		}
+		px[3] = a + px[3]*(1-a)
+	}
+}
+
+// over paints src over the raster, both of the same size
+func (r *raster) over(src *raster) {
+	for i := 0; i < len(r.pix); i += 4 {
+		a := src.pix[i+3]
+		for k := range 4 {
+			r.pix[i+k] = src.pix[i+k] + r.pix[i+k]*(1-a)
+		}
+	}
+}
+
+// shadow returns the alpha of the raster, colored and offset as s
+func (r *raster) shadow(s *dropShadow) *raster {
+	out := &raster{width: r.width, height: r.height, scale: r.scale, originX: r.originX, originY: r.originY,
+		pix: make([]float32, len(r.pix))}
+	dx, dy := int(math.Round(s.dx*r.scale)), int(math.Round(s.dy*r.scale))
+	color := [3]float32{float32(s.color.R), float32(s.color.G), float32(s.color.B)}
+	for y := max(dy, 0); y < min(r.height, r.height+dy); y++ {
+		for x := max(dx, 0); x < min(r.width, r.width+dx); x++ {
+			a := r.pix[4*((y-dy)*r.width+x-dx)+3] * float32(s.opacity)
+			px := out.pix[4*(y*r.width+x):]
+			px[0], px[1], px[2], px[3] = color[0]*a, color[1]*a, color[2]*a, a
+		}
+	}
+	return out
+}
+
+// blur approximates a Gaussian blur of standard deviations sx and sy in
+// pixels with three box blurs along each axis, as the SVG specification
+// describes
+func (r *raster) blur(sx, sy float64) {
+	line := make([]float32, max(r.width, r.height))
+	for _, box := range boxBlurs(sx) {
+		for y := range r.height {
+			for k := range 4 {
+				boxBlur(r.pix, line, 4*y*r.width+k, 4, r.width, box[0], box[1])
+			}
+		}
+	}
+	for _, box := range boxBlurs(sy) {
+		for x := range r.width {
+			for k := range 4 {
+				boxBlur(r.pix, line, 4*x+k, 4*r.width, r.height, box[0], box[1])
+			}
+		}
+	}
+}
+
+// boxBlurs returns the extents before and after each pixel of the three
+// box blurs approximating a Gaussian blur of standard deviation s
+func boxBlurs(s float64) [][2]int {
+	d := int(math.Floor(s*3*math.Sqrt(2*math.Pi)/4 + 0.5))
+	switch {
+	case d < 2:
+		return nil
+	case d%2 == 1:
+		return [][2]int{{d / 2, d / 2}, {d / 2, d / 2}, {d / 2, d / 2}}
+	}
+	// Two boxes of even size d, offset left and right, and one of size d+1
+	return [][2]int{{d / 2, d/2 - 1}, {d/2 - 1, d / 2}, {d / 2, d / 2}}
+}
+
+// boxBlur averages n samples of pix from base, stride apart, over the
+// window from lo before to hi after each, samples beyond the ends counting
+// as transparent
+func boxBlur(pix, line []float32, base, stride, n, lo, hi int) {
+	for i := range n {
+		line[i] = pix[base+i*stride]
+	}
+	var sum float32
+	for j := 0; j <= hi && j < n; j++ {
+		sum += line[j]
+	}
+	scale := 1 / float32(lo+hi+1)
+	for i := range n {
+		pix[base+i*stride] = max(sum*scale, 0)
+		if j := i + hi + 1; j < n {
+			sum += line[j]
+		}
+		if j := i - lo; j >= 0 {
+			sum -= line[j]
+		}
+	}
+}
+
+// drawRaster embeds a raster as an image and draws it over region
+func (p *PDF) drawRaster(r *raster, region viewBox) {
+	pixels := image.NewNRGBA(image.Rect(0, 0, r.width, r.height))
+	for i := 0; i < len(r.pix); i += 4 {
+		a := r.pix[i+3]
+		if a <= 0 {
+			continue
+		}
+		for k := range 3 {
+			pixels.Pix[i+k] = uint8(clamp01(float64(r.pix[i+k]/a))*255 + 0.5)
+		}
+		pixels.Pix[i+3] = uint8(clamp01(float64(a))*255 + 0.5)
+	}
+	// Pixels are whole, so the image may extend past the region
+	p.drawImageOver(p.addRaster(pixels, pixels.Pix), viewBox{region.X, region.Y,
+		float64(r.width) / r.scale, float64(r.height) / r.scale})
+}
+
+// drawImageOver draws img stretched over box, in user units
+func (p *PDF) drawImageOver(img *pdfImage, box viewBox) {
+	p.emit("q",
+		p.operator("cm", box.W, 0, 0, -box.H, box.X, box.Y+box.H),
+		fmt.Sprintf("/%s Do", img.name),
+		"Q",
+	)
+}
+
+// addRaster adds pixels drawn by the converter or the Rasterizer as an
+// image, sharing the XObject of identical ones, whose samples are those of
+// pixels
+func (p *PDF) addRaster(pixels image.Image, samples []byte) *pdfImage {
+	name := p.resourceID("Im", samples)
+	for _, img := range p.images {
+		if img.name == name {
+			return img
+		}
+	}
+	img := &pdfImage{name: name}
+	img.setPixels(pixels, false)
+	switch {
+	case p.grayscale:
+		img.convertToGray()
+	case p.cmyk != nil:
+		img.convertToCMYK(p.cmyk.profile)
+	}
+	p.images = append(p.images, img)
+	p.created(img.name)
+	return img
+}
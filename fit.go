@@ -0,0 +1,101 @@
+package svg2pdf
+
+import "fmt"
+
+// FitMode selects how the SVG canvas is scaled onto the page
+type FitMode int
+
+// Fit modes for SetFitMode
+const (
+	FitContain    FitMode = iota // Scale uniformly to fit the page, centered (default)
+	FitCover                     // Scale uniformly to cover the page, centered and cropped
+	FitStretch                   // Scale each axis to fill the page, distorting the drawing
+	FitActualSize                // Keep the SVG size (96 px per inch unless set with SetDPI), centered
+)
+
+// Alignment places the fitted canvas within the page margins when it does
+// not fill them
+type Alignment int
+
+// Alignments for SetAlignment
+const (
+	AlignCenter Alignment = iota // Default
+	AlignTopLeft
+	AlignTop
+	AlignTopRight
+	AlignLeft
+	AlignRight
+	AlignBottomLeft
+	AlignBottom
+	AlignBottomRight
+)
+
+// alignmentFractions are the fractions of the free space left of and above
+// the canvas, per alignment
+var alignmentFractions = [...][2]float64{
+	AlignCenter:      {0.5, 0.5},
+	AlignTopLeft:     {0, 0},
+	AlignTop:         {0.5, 0},
+	AlignTopRight:    {1, 0},
+	AlignLeft:        {0, 0.5},
+	AlignRight:       {1, 0.5},
+	AlignBottomLeft:  {0, 1},
+	AlignBottom:      {0.5, 1},
+	AlignBottomRight: {1, 1},
+}
+
+// SetAlignment sets where converted SVGs are placed within the margins
+func (p *PDF) SetAlignment(a Alignment) error {
+	if a < AlignCenter || a > AlignBottomRight {
+		return fmt.Errorf("unknown alignment %d", a)
+	}
+	p.alignment = a
+	return nil
+}
+
+// SetContentOffset moves converted SVGs by dx, dy points from their aligned
+// position, positive values moving right and down
+func (p *PDF) SetContentOffset(dx, dy float64) {
+	p.contentOffset = [2]float64{dx, dy}
+}
+
+// SetFitMode sets how converted SVGs are scaled onto the page
+func (p *PDF) SetFitMode(mode FitMode) error {
+	if mode < FitContain || mode > FitActualSize {
+		return fmt.Errorf("unknown fit mode %d", mode)
+	}
+	p.fitMode = mode
+	return nil
+}
+
+// fitBox returns the scale factors and offset (from the top left corner of
+// the page) placing a canvas of w by h user units into box, which is given
+// in points from the top left corner of the page
+func (p *PDF) fitBox(w, h float64, box viewBox) (sx, sy, dx, dy float64) {
+	sx, sy = box.W/w, box.H/h
+	switch p.fitMode {
+	case FitContain:
+		sx = min(sx, sy)
+		sy = sx
+	case FitCover:
+		sx = max(sx, sy)
+		sy = sx
+	case FitActualSize:
+		sx = p.actualScale()
+		sy = sx
+	}
+	// Align the canvas in the free space; stretched canvases fill the box
+	// exactly
+	align := alignmentFractions[p.alignment]
+	dx = box.X + (box.W-w*sx)*align[0] + p.contentOffset[0]
+	dy = box.Y + (box.H-h*sy)*align[1] + p.contentOffset[1]
+	return sx, sy, dx, dy
+}
+
+// actualScale returns the points per SVG pixel at actual size
+func (p *PDF) actualScale() float64 {
+	if p.dpi == 0 {
+		return 0.75 // CSS pixels, 96 per inch
+	}
+	return 72 / p.dpi
+}
@@ -0,0 +1,196 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextFlow configures the reflowing of text into columns, for text-heavy
+// SVGs such as newsletters exported from layout tools
+type TextFlow struct {
+	Columns   int     // Number of columns, 1 when 0
+	Gap       float64 // Space between columns in points
+	MinLength int     // Text elements of at least this many characters are reflowed; 0 reflows foreignObject text only
+}
+
+// Text flow layout in font sizes
+const (
+	flowLineSpacing      = 1.2 // Baseline distance
+	flowParagraphSpacing = 0.6 // Extra space between blocks
+)
+
+// flowBlock is a paragraph of text to reflow
+type flowBlock struct {
+	face fontFace
+	size float64 // Font size in points
+	text string
+}
+
+// SetTextFlow reflows the text of foreignObject elements and long text
+// elements into columns within the page margins, instead of drawing it at
+// its position, for SVGs converted after the call. Other graphics are drawn
+// as usual beneath the columns. Text that does not fit continues on new
+// pages. Nil turns it off.
+func (p *PDF) SetTextFlow(f *TextFlow) error {
+	if f == nil {
+		p.flow = nil
+		return nil
+	}
+	if f.Columns < 0 || !(f.Gap >= 0) || f.MinLength < 0 {
+		return fmt.Errorf("invalid text flow: %d columns, gap %g, minimum length %d", f.Columns, f.Gap, f.MinLength)
+	}
+	flow := *f
+	flow.Columns = max(flow.Columns, 1)
+	p.flow = &flow
+	return nil
+}
+
+// WithTextFlow reflows text into columns
+func WithTextFlow(f TextFlow) Option {
+	return func(p *PDF) error {
+		return p.SetTextFlow(&f)
+	}
+}
+
+// reflows reports whether text is reflowed rather than positioned
+func (p *PDF) reflows(text Text) bool {
+	return p.flow != nil && p.flow.MinLength > 0 &&
+		utf8.RuneCountInString(strings.TrimSpace(text.Content)) >= p.flow.MinLength
+}
+
+// addFlowBlock collects text to reflow in face at fontSize user units
+func (p *PDF) addFlowBlock(face fontFace, fontSize float64, text string) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+	// Text keeps the size it has at actual size, whatever the figure scale
+	p.flowBlocks = append(p.flowBlocks, flowBlock{face, fontSize * p.actualScale(), text})
+}
+
+// flowBreaks are the XHTML elements that start a new paragraph
+var flowBreaks = setOf("p", "div", "br", "li", "h1", "h2", "h3", "h4", "h5", "h6",
+	"blockquote", "pre", "section", "article", "header", "footer", "tr", "dt", "dd")
+
+// paragraphs extracts the text of foreign content, one string per
+// paragraph. Scripts and style sheets are skipped.
+func paragraphs(content string) []string {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	var paras []string
+	var current bytes.Buffer
+	skip := 0
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			paras = append(paras, text)
+		}
+		current.Reset()
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break // The rest is not well-formed
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if name == "script" || name == "style" {
+				skip++
+			}
+			if flowBreaks[name] {
+				flush()
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if (name == "script" || name == "style") && skip > 0 {
+				skip--
+			}
+			if flowBreaks[name] {
+				flush()
+			}
+		case xml.CharData:
+			if skip == 0 {
+				current.Write(t)
+				current.WriteByte(' ') // Words do not run across elements
+			}
+		}
+	}
+	flush()
+	return paras
+}
+
+// drawFlow sets the collected text blocks in columns within box, measured
+// from the top of the page. Overflowing text continues on new pages, each
+// finished with the page hook.
+func (p *PDF) drawFlow(box viewBox) error {
+	blocks := p.flowBlocks
+	p.flowBlocks = nil
+	if len(blocks) == 0 {
+		return nil
+	}
+	columns := p.flow.Columns
+	width := (box.W - p.flow.Gap*float64(columns-1)) / float64(columns)
+	if !(width > 0) {
+		return fmt.Errorf("%d text columns do not fit within %.2f points", columns, box.W)
+	}
+
+	column, y := 0, box.Y
+	for _, block := range blocks {
+		measure := func(s string) float64 {
+			w := 0.0
+			for _, r := range s {
+				w += block.face.advance(r) * block.size / 1000
+			}
+			return w
+		}
+		lineHeight := block.size * flowLineSpacing
+		ascent := p.lineAscent(block.face, block.size)
+		if y > box.Y {
+			y += block.size * flowParagraphSpacing
+		}
+		var runs []glyphRun
+		for _, line := range wrapWords(block.text, width, measure) {
+			baseline := p.snapBaseline(y + ascent)
+			if baseline-ascent+lineHeight > box.Y+box.H && y > box.Y {
+				// Continue in the next column, or on a new page
+				p.drawFlowLines(runs, block)
+				runs = nil
+				column, y = column+1, box.Y
+				if column == columns {
+					if err := p.finishPage(); err != nil {
+						return err
+					}
+					p.AddPage()
+					box = p.contentBox()
+					column, y = 0, box.Y
+				}
+				baseline = p.snapBaseline(y + ascent)
+			}
+			x := box.X + float64(column)*(width+p.flow.Gap)
+			runs = append(runs, glyphRun{X: x, Y: baseline, Text: line})
+			y = baseline - ascent + lineHeight
+		}
+		p.drawFlowLines(runs, block)
+	}
+	return nil
+}
+
+// drawFlowLines draws lines of a block as a paragraph, with the baselines
+// of runs measured from the top of the page
+func (p *PDF) drawFlowLines(runs []glyphRun, block flowBlock) {
+	if len(runs) == 0 {
+		return
+	}
+	runs = p.shapeRuns(runs, false, block.face, block.size)
+	p.emit(append(p.beginMarked("P"), "q", p.operator("cm", 1, 0, 0, -1, 0, p.pageHeight))...) // y-down page space
+	if font, ok := isOutlineFont(block.face); ok && p.textAsOutlines {
+		p.drawTextOutlines(runs, font, block.size)
+	} else {
+		p.drawTextRuns(runs, block.face, block.size)
+	}
+	p.emit(append([]string{"Q"}, p.endMarked()...)...)
+}
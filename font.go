@@ -0,0 +1,582 @@
+package svg2pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// fontFace is implemented by every font text can be laid out and drawn with
+type fontFace interface {
+	// resourceName returns the name of the font in the page resources (e.g. F1)
+	resourceName() string
+	// advance returns the advance width of r in 1/1000 em
+	advance(r rune) float64
+	// encode returns the PDF string operand drawing s with this font
+	encode(s string) string
+}
+
+// standardFont is the built-in Helvetica font every document references as F1
+type standardFont struct{}
+
+func (standardFont) resourceName() string { return "F1" }
+
+func (standardFont) advance(r rune) float64 { return float64(glyphWidth(r)) }
+
+func (standardFont) encode(s string) string { return "(" + escapeText(s) + ")" }
+
+// Font is a TrueType or OpenType font embedded into the PDF
+type Font struct {
+	Family string
+	Weight int  // CSS font weight (400 normal, 700 bold)
+	Italic bool // Whether this is an italic or oblique face
+
+	name        string // Resource name within the document
+	data        []byte // Complete sfnt data embedded as the font file
+	tables      map[string][]byte
+	cffData     *cffFont // Parsed lazily when outlines are needed
+	postscript  string
+	cff         bool // Outlines are CFF rather than glyf
+	unitsPerEm  float64
+	bbox        [4]int16
+	ascent      int16
+	descent     int16
+	capHeight   int16
+	italicAngle float64
+	fixedPitch  bool
+	fsType      uint16
+	advances    []uint16
+	cmap        map[rune]uint16
+	used        map[uint16]rune // Glyphs drawn so far, with the rune they came from
+}
+
+func (f *Font) resourceName() string { return f.name }
+
+// advance returns the advance width of r in 1/1000 em
+func (f *Font) advance(r rune) float64 {
+	return f.glyphAdvance(f.cmap[r])
+}
+
+// glyphAdvance returns the advance width of glyph gid in 1/1000 em
+func (f *Font) glyphAdvance(gid uint16) float64 {
+	if len(f.advances) == 0 {
+		return 0
+	}
+	i := int(gid)
+	if i >= len(f.advances) {
+		i = len(f.advances) - 1 // Trailing glyphs share the last advance
+	}
+	return float64(f.advances[i]) * 1000 / f.unitsPerEm
+}
+
+// encode returns s as a hex string of glyph IDs and records the glyphs used
+func (f *Font) encode(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		gid := f.cmap[r]
+		if _, seen := f.used[gid]; !seen {
+			f.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// scale converts a value in font units to 1/1000 em
+func (f *Font) scale(v int16) int {
+	return int(float64(v) * 1000 / f.unitsPerEm)
+}
+
+// RegisterFont parses TrueType, OpenType, WOFF or WOFF2 data and makes it
+// available to text elements under the given font-family name
+func (p *PDF) RegisterFont(family string, data []byte) error {
+	font, err := parseFont(data)
+	if err != nil {
+		return &ResourceError{Op: "registering", Kind: "font", Name: family, Err: err}
+	}
+	font.Family = family
+	if ok, err := p.checkEmbedding(family, font); !ok {
+		return err // Nil when the font is substituted
+	}
+	p.addFont(font)
+	return nil
+}
+
+// addFont adds font to the registry
+func (p *PDF) addFont(font *Font) {
+	p.fonts = append(p.fonts, font)
+}
+
+// useFont assigns a resource name to font when it is first used, so the
+// name is derived from the seed of the document it is drawn in. F1 is
+// reserved for the built-in font.
+func (p *PDF) useFont(font *Font) *Font {
+	if font.name == "" {
+		font.name = p.resourceID("F", font.data)
+		p.created(font.name)
+	}
+	return font
+}
+
+// resolveFont picks the registered font best matching a CSS font-family list,
+// weight and style. Families that are not registered are looked up through
+// the font resolver, then the fallback chain, then the built-in font is used.
+func (p *PDF) resolveFont(families, weight, style string) fontFace {
+	wantWeight := parseFontWeight(weight)
+	wantItalic := style == "italic" || style == "oblique"
+	for _, family := range splitFontFamilies(families) {
+		var best *Font
+		bestScore := 0
+		for _, font := range p.fonts {
+			if !strings.EqualFold(font.Family, family) {
+				continue
+			}
+			score := abs(font.Weight - wantWeight)
+			if font.Italic != wantItalic {
+				score += 1000
+			}
+			if best == nil || score < bestScore {
+				best, bestScore = font, score
+			}
+		}
+		if best != nil {
+			return p.useFont(best)
+		}
+		if !genericFamilies[strings.ToLower(family)] {
+			if font := p.resolveSystemFont(family, wantWeight, wantItalic); font != nil {
+				return p.useFont(font)
+			}
+		}
+	}
+	for _, family := range p.fontFallback {
+		if font := p.resolveSystemFont(family, wantWeight, wantItalic); font != nil {
+			return p.useFont(font)
+		}
+	}
+	return standardFont{}
+}
+
+// genericFamilies are the CSS generic font families, which are never looked
+// up by name
+var genericFamilies = map[string]bool{
+	"serif":      true,
+	"sans-serif": true,
+	"monospace":  true,
+	"cursive":    true,
+	"fantasy":    true,
+	"system-ui":  true,
+}
+
+// splitFontFamilies splits a CSS font-family list and removes quoting
+func splitFontFamilies(families string) []string {
+	var names []string
+	for _, name := range strings.Split(families, ",") {
+		name = strings.Trim(strings.TrimSpace(name), `"'`)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseFontWeight converts a CSS font-weight value to its numeric form
+func parseFontWeight(weight string) int {
+	switch strings.TrimSpace(weight) {
+	case "", "normal":
+		return 400
+	case "bold", "bolder":
+		return 700
+	case "lighter":
+		return 300
+	}
+	w, err := strconv.Atoi(strings.TrimSpace(weight))
+	if err != nil {
+		return 400
+	}
+	return w
+}
+
+// abs returns the absolute value of v
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// parseFont decodes WOFF and WOFF2 containers and parses the resulting sfnt
+// data
+func parseFont(data []byte) (*Font, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("font data too short")
+	}
+	switch string(data[:4]) {
+	case "wOFF":
+		sfnt, err := decodeWOFF(data)
+		if err != nil {
+			return nil, err
+		}
+		data = sfnt
+	case "wOF2":
+		sfnt, err := decodeWOFF2(data)
+		if err != nil {
+			return nil, err
+		}
+		data = sfnt
+	case "ttcf":
+		return nil, fmt.Errorf("font collections are not supported")
+	}
+	return parseSFNT(data)
+}
+
+// sfntTables indexes the tables of sfnt data by tag
+func sfntTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("invalid sfnt header")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, fmt.Errorf("truncated sfnt table directory")
+	}
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := data[12+16*i:]
+		tag := string(rec[:4])
+		offset := binary.BigEndian.Uint32(rec[8:])
+		length := binary.BigEndian.Uint32(rec[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("table %q out of bounds", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// parseSFNT reads the metrics, character map and naming of a TrueType or
+// OpenType font
+func parseSFNT(data []byte) (*Font, error) {
+	tables, err := sfntTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("missing required %q table", tag)
+		}
+	}
+
+	f := &Font{
+		Weight: 400,
+		data:   data,
+		tables: tables,
+		used:   make(map[uint16]rune),
+	}
+	_, f.cff = tables["CFF "]
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("invalid head table")
+	}
+	f.unitsPerEm = float64(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("invalid unitsPerEm")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int16(binary.BigEndian.Uint16(head[36+2*i:]))
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("invalid hhea table")
+	}
+	f.ascent = int16(binary.BigEndian.Uint16(hhea[4:]))
+	f.descent = int16(binary.BigEndian.Uint16(hhea[6:]))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	hmtx := tables["hmtx"]
+	if len(hmtx) < 4*numHMetrics {
+		return nil, fmt.Errorf("truncated hmtx table")
+	}
+	f.advances = make([]uint16, numHMetrics)
+	for i := range f.advances {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	if f.cmap, err = parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 10 {
+		f.Weight = int(binary.BigEndian.Uint16(os2[4:]))
+		f.fsType = binary.BigEndian.Uint16(os2[8:])
+		if len(os2) >= 64 {
+			f.Italic = binary.BigEndian.Uint16(os2[62:])&1 != 0
+		}
+		if version := binary.BigEndian.Uint16(os2); version >= 2 && len(os2) >= 90 {
+			f.capHeight = int16(binary.BigEndian.Uint16(os2[88:]))
+		}
+	}
+	if post := tables["post"]; len(post) >= 16 {
+		f.italicAngle = float64(int32(binary.BigEndian.Uint32(post[4:]))) / 65536
+		f.fixedPitch = binary.BigEndian.Uint32(post[12:]) != 0
+	}
+	f.postscript = sanitizeFontName(parseNameTable(tables["name"], 6))
+	if f.postscript == "" {
+		f.postscript = "EmbeddedFont"
+	}
+	return f, nil
+}
+
+// parseCmap builds the character to glyph mapping from the best Unicode subtable
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("invalid cmap table")
+	}
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	var format4, format12 []byte
+	for i := 0; i < numTables; i++ {
+		if len(cmap) < 4+8*i+8 {
+			break
+		}
+		rec := cmap[4+8*i:]
+		platform := binary.BigEndian.Uint16(rec)
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		offset := binary.BigEndian.Uint32(rec[4:])
+		if int(offset)+2 > len(cmap) {
+			continue
+		}
+		sub := cmap[offset:]
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			format4 = sub
+		case 12:
+			format12 = sub
+		}
+	}
+	switch {
+	case format12 != nil:
+		return parseCmap12(format12)
+	case format4 != nil:
+		return parseCmap4(format4)
+	}
+	return nil, fmt.Errorf("no supported Unicode cmap subtable")
+}
+
+// parseCmap4 decodes a segment mapping (format 4) cmap subtable
+func parseCmap4(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 14 {
+		return nil, fmt.Errorf("invalid cmap format 4")
+	}
+	segCount := int(binary.BigEndian.Uint16(sub[6:]) / 2)
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if len(sub) < idRangeOffsets+2*segCount {
+		return nil, fmt.Errorf("truncated cmap format 4")
+	}
+	m := make(map[rune]uint16)
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(sub[endCodes+2*i:]))
+		start := int(binary.BigEndian.Uint16(sub[startCodes+2*i:]))
+		delta := binary.BigEndian.Uint16(sub[idDeltas+2*i:])
+		rangeOffset := int(binary.BigEndian.Uint16(sub[idRangeOffsets+2*i:]))
+		for c := start; c <= end && c != 0xffff; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				pos := idRangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if pos+2 > len(sub) {
+					continue
+				}
+				gid = binary.BigEndian.Uint16(sub[pos:])
+				if gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				m[rune(c)] = gid
+			}
+		}
+	}
+	return m, nil
+}
+
+// parseCmap12 decodes a segmented coverage (format 12) cmap subtable
+func parseCmap12(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 16 {
+		return nil, fmt.Errorf("invalid cmap format 12")
+	}
+	numGroups := int(binary.BigEndian.Uint32(sub[12:]))
+	if len(sub) < 16+12*numGroups {
+		return nil, fmt.Errorf("truncated cmap format 12")
+	}
+	m := make(map[rune]uint16)
+	for i := 0; i < numGroups; i++ {
+		group := sub[16+12*i:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		gid := binary.BigEndian.Uint32(group[8:])
+		if end < start || end-start > 0x10ffff {
+			continue
+		}
+		for c := start; c <= end; c++ {
+			m[rune(c)] = uint16(gid + c - start)
+		}
+	}
+	return m, nil
+}
+
+// parseNameTable returns the name record with the given ID, preferring
+// Windows Unicode entries
+func parseNameTable(name []byte, nameID uint16) string {
+	if len(name) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	var fallback string
+	for i := 0; i < count; i++ {
+		if len(name) < 6+12*i+12 {
+			break
+		}
+		rec := name[6+12*i:]
+		if binary.BigEndian.Uint16(rec[6:]) != nameID {
+			continue
+		}
+		platform := binary.BigEndian.Uint16(rec)
+		length := int(binary.BigEndian.Uint16(rec[8:]))
+		offset := storage + int(binary.BigEndian.Uint16(rec[10:]))
+		if offset+length > len(name) {
+			continue
+		}
+		raw := name[offset : offset+length]
+		if platform == 3 || platform == 0 {
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			return string(utf16.Decode(units))
+		}
+		fallback = string(raw)
+	}
+	return fallback
+}
+
+// sanitizeFontName strips characters that are not allowed in a PDF font name
+func sanitizeFontName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r > 32 && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeObjects writes the PDF objects embedding f, numbered from first, with
+// the Type0 font dictionary as the first object
+func (f *Font) writeObjects(w *pdfWriter, first int) {
+	descendant, descriptor, fontFile, toUnicode := first+1, first+2, first+3, first+4
+
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, int(f.glyphAdvance(uint16(gid))))
+	}
+
+	subtype, fileKey, fileDict := "/CIDFontType2", "/FontFile2", fmt.Sprintf("/Length1 %d", len(f.data))
+	if f.cff {
+		subtype, fileKey = "/CIDFontType0", "/FontFile3"
+		fileDict = "/Subtype /OpenType"
+	}
+
+	flags := 32 // Nonsymbolic
+	if f.fixedPitch {
+		flags |= 1
+	}
+	if f.Italic {
+		flags |= 64
+	}
+
+	cmap := f.toUnicodeCMap(gids)
+	w.object(first,
+		"<<",
+		"/Type /Font",
+		"/Subtype /Type0",
+		"/BaseFont /"+f.postscript,
+		"/Encoding /Identity-H",
+		fmt.Sprintf("/DescendantFonts [%d 0 R]", descendant),
+		fmt.Sprintf("/ToUnicode %d 0 R", toUnicode),
+		">>",
+	)
+	cidFont := []string{
+		"<<",
+		"/Type /Font",
+		"/Subtype " + subtype,
+		"/BaseFont /" + f.postscript,
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >>",
+		fmt.Sprintf("/FontDescriptor %d 0 R", descriptor),
+		"/W [" + strings.TrimSpace(widths.String()) + "]",
+	}
+	if !f.cff {
+		cidFont = append(cidFont, "/CIDToGIDMap /Identity")
+	}
+	w.object(descendant, append(cidFont, ">>")...)
+	w.object(descriptor,
+		"<<",
+		"/Type /FontDescriptor",
+		"/FontName /"+f.postscript,
+		fmt.Sprintf("/Flags %d", flags),
+		fmt.Sprintf("/FontBBox [%d %d %d %d]", f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3])),
+		fmt.Sprintf("/ItalicAngle %.2f", f.italicAngle),
+		fmt.Sprintf("/Ascent %d", f.scale(f.ascent)),
+		fmt.Sprintf("/Descent %d", f.scale(f.descent)),
+		fmt.Sprintf("/CapHeight %d", f.scale(f.capHeight)),
+		"/StemV 80",
+		fmt.Sprintf("%s %d 0 R", fileKey, fontFile),
+		">>",
+	)
+	w.stream(fontFile, FontStream, []string{fileDict}, f.data)
+	w.stream(toUnicode, FontStream, nil, []byte(cmap))
+}
+
+// toUnicodeCMap builds the CMap mapping the used glyph IDs back to Unicode so
+// text can be searched and copied
+func (f *Font) toUnicodeCMap(gids []int) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := min(start+100, len(gids))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune{f.used[uint16(gid)]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return b.String()
+}
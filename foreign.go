@@ -0,0 +1,153 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+)
+
+// foreignLayout is where the HTML of a foreignObject places its text, as
+// diagram tools such as draw.io lay out labels: the margin, padding and
+// size of the outermost styled element, in px, centered by flexbox or
+// text-align, with the color and size of the first text styled
+type foreignLayout struct {
+	left, top        float64
+	width, height    float64 // 0 if not set
+	centerX, centerY bool
+	color            *RGB
+	fontSize         float64 // 0 if not set
+}
+
+// readForeignLayout reads the layout of the text of foreign content from
+// its style attributes
+func readForeignLayout(content string) foreignLayout {
+	var l foreignLayout
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	px := func(value string) float64 {
+		v, unit, ok := Length(declValue(value)).split()
+		if !ok || unit != "" && unit != "px" {
+			return 0
+		}
+		return v
+	}
+	boxed := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return l // The rest is not well-formed
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		var style string
+		for _, attr := range start.Attr {
+			if strings.EqualFold(attr.Name.Local, "style") {
+				style = attr.Value
+			}
+		}
+		decls := parseDeclarations(style)
+		for _, decl := range decls {
+			value := cssKeyword(decl.Value)
+			switch decl.Property {
+			case "margin-left", "padding-top", "width", "height", "justify-content", "align-items":
+				if boxed {
+					continue
+				}
+				switch decl.Property {
+				case "margin-left":
+					l.left = px(value)
+				case "padding-top":
+					l.top = px(value)
+				case "width":
+					l.width = px(value)
+				case "height":
+					l.height = px(value)
+				case "justify-content":
+					l.centerX = l.centerX || strings.HasSuffix(value, "center")
+				case "align-items":
+					l.centerY = strings.HasSuffix(value, "center")
+				}
+			case "text-align":
+				l.centerX = l.centerX || value == "center"
+			case "color":
+				if c, ok := parseColor(value); ok && l.color == nil {
+					l.color = &c
+				}
+			case "font-size":
+				if l.fontSize == 0 {
+					l.fontSize = px(value)
+				}
+			}
+		}
+		boxed = boxed || len(decls) > 0
+	}
+}
+
+// drawForeignText draws the text of foreign content, as laid out by
+// readForeignLayout, in place of content neither set in columns nor
+// rasterized. Content without text is outlined with a dashed placeholder.
+func (p *PDF) drawForeignText(fo ForeignObject, ctx unitContext) {
+	box := viewBox{ctx.resolve(fo.X, axisX, 0), ctx.resolve(fo.Y, axisY, 0),
+		ctx.resolve(fo.Width, axisX, 0), ctx.resolve(fo.Height, axisY, 0)}
+	paras := paragraphs(fo.Content)
+	if len(paras) == 0 {
+		if box.W > 0 && box.H > 0 {
+			p.warn("foreignObject", fo.ID, "foreign content is drawn as a placeholder")
+			p.emit(append(p.beginMarked("Figure"), "q", p.strokeOp(RGB{0.6, 0.6, 0.6}), "[2 2] 0 d",
+				p.operator("re", box.X, box.Y, box.W, box.H), "S", "Q")...)
+			p.emit(p.endMarked()...)
+		}
+		return
+	}
+	p.warn("foreignObject", fo.ID, "foreign content is drawn as plain text")
+	l := readForeignLayout(fo.Content)
+	face := p.resolveFont(fo.Family, fo.Weight, fo.Style)
+	size := ctx.withFontSize(fo.Size).fontSize
+	if l.fontSize > 0 {
+		size = l.fontSize
+	}
+	measure := func(s string) float64 {
+		w := 0.0
+		for _, r := range s {
+			w += face.advance(r) * size / 1000
+		}
+		return w
+	}
+	width := l.width
+	if width <= 0 {
+		width = box.W - l.left
+	}
+	if width <= 0 {
+		width = math.Inf(1) // Unwrapped
+	}
+	var lines []string
+	for _, para := range paras {
+		lines = append(lines, wrapWords(para, width, measure)...)
+	}
+	lineHeight := size * flowLineSpacing
+	x, top := box.X+l.left, box.Y+l.top
+	if l.centerY {
+		top += (l.height - lineHeight*float64(len(lines))) / 2
+	}
+	runs := make([]glyphRun, len(lines))
+	for i, line := range lines {
+		runs[i] = glyphRun{X: x, Y: top + float64(i)*lineHeight + size*0.8, Text: line}
+		if l.centerX && !math.IsInf(width, 1) {
+			runs[i].X += (width - measure(line)) / 2
+		}
+	}
+	runs = p.shapeRuns(runs, false, face, size)
+	p.emit(append(p.beginMarked("Span"), "q")...)
+	if l.color != nil {
+		p.emit(p.fillOp(*l.color))
+	}
+	if font, ok := isOutlineFont(face); ok && p.textAsOutlines {
+		p.drawTextOutlines(runs, font, size)
+	} else {
+		p.drawTextRuns(runs, face, size)
+	}
+	p.emit(append([]string{"Q"}, p.endMarked()...)...)
+}
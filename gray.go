@@ -0,0 +1,41 @@
+package svg2pdf
+
+// SetGrayscale converts all fills, strokes, gradients and images drawn
+// after the call to DeviceGray by luminance, for fax and print-economy
+// pipelines. It takes precedence over CMYK output and spot colors.
+func (p *PDF) SetGrayscale(enabled bool) {
+	p.grayscale = enabled
+}
+
+// WithGrayscale converts all colors to gray
+func WithGrayscale() Option {
+	return func(p *PDF) error {
+		p.SetGrayscale(true)
+		return nil
+	}
+}
+
+// luminance returns the gray level of an RGB color with the Rec. 601
+// luma weights
+func luminance(r, g, b float64) float64 {
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+// grayOp returns the operator setting the gray level of c for filling, or
+// for stroking if stroke is set
+func grayOp(c RGB, stroke bool) string {
+	if stroke {
+		return formatNumbers(colorDecimals, luminance(c.R, c.G, c.B)) + " G"
+	}
+	return formatNumbers(colorDecimals, luminance(c.R, c.G, c.B)) + " g"
+}
+
+// convertToGray converts the RGB samples of img to gray levels
+func (img *pdfImage) convertToGray() {
+	data := make([]byte, 0, len(img.data)/3)
+	for i := 0; i+3 <= len(img.data); i += 3 {
+		gray := luminance(float64(img.data[i]), float64(img.data[i+1]), float64(img.data[i+2]))
+		data = append(data, byte(gray+0.5))
+	}
+	img.data, img.colorSpace, img.profile = data, "/DeviceGray", nil
+}
@@ -0,0 +1,84 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// BaselineGrid is a grid of horizontal lines that the baselines of laid
+// out text snap to, so text lines up across columns and pages
+type BaselineGrid struct {
+	Spacing float64 // Distance between grid lines in points
+	Offset  float64 // First grid line below the top of the content box in points
+}
+
+// helveticaAscent is the ascender of the built-in font in 1/1000 em
+const helveticaAscent = 718
+
+// SetBaselineGrid snaps the baselines of text the converter lays out
+// itself, reflowed text columns and figure captions, to the next line of
+// g, measured from the top of the page content box. Lines are placed by
+// the ascent of their font, and line spacing grows to whole grid lines.
+// Nil turns it off.
+func (p *PDF) SetBaselineGrid(g *BaselineGrid) error {
+	if g == nil {
+		p.baselineGrid = nil
+		return nil
+	}
+	if !(g.Spacing > 0) || !(g.Offset >= 0) {
+		return fmt.Errorf("invalid baseline grid: spacing %g, offset %g", g.Spacing, g.Offset)
+	}
+	grid := *g
+	p.baselineGrid = &grid
+	return nil
+}
+
+// WithBaselineGrid snaps the baselines of laid out text to a grid
+func WithBaselineGrid(g BaselineGrid) Option {
+	return func(p *PDF) error {
+		return p.SetBaselineGrid(&g)
+	}
+}
+
+// fontAscent returns the ascent of face in em
+func fontAscent(face fontFace) float64 {
+	if font, ok := face.(*Font); ok && font.unitsPerEm > 0 && font.ascent > 0 {
+		return float64(font.ascent) / font.unitsPerEm
+	}
+	return helveticaAscent / 1000.0
+}
+
+// lineAscent returns the distance from the top of a line of text in face
+// at size to its baseline: the font's ascent on a baseline grid, else
+// about 0.8em
+func (p *PDF) lineAscent(face fontFace, size float64) float64 {
+	if p.baselineGrid == nil {
+		return size * 0.8
+	}
+	return size * fontAscent(face)
+}
+
+// snapBaseline returns the first grid line at or below y, measured from
+// the top of the page, or y itself without a baseline grid
+func (p *PDF) snapBaseline(y float64) float64 {
+	g := p.baselineGrid
+	if g == nil {
+		return y
+	}
+	origin := p.contentBox().Y + g.Offset
+	if y <= origin {
+		return origin
+	}
+	// Baselines already on a line stay despite rounding errors
+	return origin + math.Ceil((y-origin)/g.Spacing-1e-6)*g.Spacing
+}
+
+// lineAdvance returns the baseline distance of lines height apart, rounded
+// up to whole grid lines on a baseline grid
+func (p *PDF) lineAdvance(height float64) float64 {
+	g := p.baselineGrid
+	if g == nil {
+		return height
+	}
+	return math.Max(math.Ceil(height/g.Spacing-1e-6), 1) * g.Spacing
+}
@@ -0,0 +1,145 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderingIntent selects how colors are mapped to the gamut of the output device
+type RenderingIntent string
+
+// Rendering intents defined by the PDF specification
+const (
+	Perceptual           RenderingIntent = "Perceptual"
+	RelativeColorimetric RenderingIntent = "RelativeColorimetric"
+	AbsoluteColorimetric RenderingIntent = "AbsoluteColorimetric"
+	Saturation           RenderingIntent = "Saturation"
+)
+
+// SetRenderingIntent sets the rendering intent applied to every page
+func (p *PDF) SetRenderingIntent(intent RenderingIntent) error {
+	switch intent {
+	case Perceptual, RelativeColorimetric, AbsoluteColorimetric, Saturation, "":
+	default:
+		return fmt.Errorf("unknown rendering intent %q", intent)
+	}
+	p.renderingIntent = intent
+	return nil
+}
+
+// SetStrokeAdjustment turns automatic stroke adjustment (/SA) on or off for
+// every page, so thin lines are snapped to device pixels consistently
+func (p *PDF) SetStrokeAdjustment(enabled bool) {
+	p.strokeAdjustment = &enabled
+}
+
+// LineJoin is the shape of the corners of stroked paths
+type LineJoin int
+
+// Line joins for SetLineJoin
+const (
+	MiterJoin LineJoin = iota
+	RoundJoin
+	BevelJoin
+)
+
+// LineCap is the shape of the ends of open stroked paths
+type LineCap int
+
+// Line caps for SetLineCap
+const (
+	ButtCap LineCap = iota
+	RoundCap
+	SquareCap
+)
+
+// SetLineWidth sets the default line width of every page, in user units
+func (p *PDF) SetLineWidth(width float64) error {
+	if !(width >= 0) {
+		return fmt.Errorf("invalid line width %g", width)
+	}
+	p.lineWidth = &width
+	return nil
+}
+
+// SetLineJoin sets the default line join of every page
+func (p *PDF) SetLineJoin(join LineJoin) error {
+	if join < MiterJoin || join > BevelJoin {
+		return fmt.Errorf("unknown line join %d", join)
+	}
+	p.lineJoin = &join
+	return nil
+}
+
+// SetLineCap sets the default line cap of every page
+func (p *PDF) SetLineCap(lineCap LineCap) error {
+	if lineCap < ButtCap || lineCap > SquareCap {
+		return fmt.Errorf("unknown line cap %d", lineCap)
+	}
+	p.lineCap = &lineCap
+	return nil
+}
+
+// SetFlatness sets the flatness tolerance of every page, the maximum
+// distance in device pixels between curves and their approximation (0 to 100)
+func (p *PDF) SetFlatness(flatness float64) error {
+	if !(flatness >= 0 && flatness <= 100) {
+		return fmt.Errorf("invalid flatness %g", flatness)
+	}
+	p.flatness = &flatness
+	return nil
+}
+
+// SetSmoothness sets the smoothness tolerance of every page, the maximum
+// color error of shading approximations (0 to 1)
+func (p *PDF) SetSmoothness(smoothness float64) error {
+	if !(smoothness >= 0 && smoothness <= 1) {
+		return fmt.Errorf("invalid smoothness %g", smoothness)
+	}
+	p.smoothness = &smoothness
+	return nil
+}
+
+// hasGraphicsState reports whether document-wide graphics state defaults are set
+func (p *PDF) hasGraphicsState() bool {
+	return p.renderingIntent != "" || p.strokeAdjustment != nil || p.lineWidth != nil ||
+		p.lineJoin != nil || p.lineCap != nil || p.flatness != nil || p.smoothness != nil
+}
+
+// graphicsStateEntries returns the entries of the document-wide ExtGState
+func (p *PDF) graphicsStateEntries() []string {
+	entries := []string{"/Type /ExtGState"}
+	if p.renderingIntent != "" {
+		entries = append(entries, "/RI /"+string(p.renderingIntent))
+	}
+	if p.strokeAdjustment != nil {
+		entries = append(entries, fmt.Sprintf("/SA %t", *p.strokeAdjustment))
+	}
+	if p.lineWidth != nil {
+		entries = append(entries, "/LW "+shortenNumber(fmt.Sprintf("%.4f", *p.lineWidth)))
+	}
+	if p.lineCap != nil {
+		entries = append(entries, fmt.Sprintf("/LC %d", *p.lineCap))
+	}
+	if p.lineJoin != nil {
+		entries = append(entries, fmt.Sprintf("/LJ %d", *p.lineJoin))
+	}
+	if p.flatness != nil {
+		entries = append(entries, "/FL "+shortenNumber(fmt.Sprintf("%.4f", *p.flatness)))
+	}
+	if p.smoothness != nil {
+		entries = append(entries, "/SM "+shortenNumber(fmt.Sprintf("%.4f", *p.smoothness)))
+	}
+	return entries
+}
+
+// graphicsStateName returns the resource name of the document-wide ExtGState
+func (p *PDF) graphicsStateName() string {
+	return p.resourceID("GS", []byte(strings.Join(p.graphicsStateEntries(), " ")))
+}
+
+// writeGraphicsState writes the ExtGState object holding the document-wide
+// graphics state defaults, applied at the start of each page
+func (p *PDF) writeGraphicsState(w *pdfWriter, num int) {
+	w.object(num, append(append([]string{"<<"}, p.graphicsStateEntries()...), ">>")...)
+}
@@ -0,0 +1,90 @@
+package svg2pdf
+
+import "fmt"
+
+// Page is a converted page passed to the AfterPage hook and to element
+// handlers. Drawing methods use PDF coordinates in the hook: points from the
+// bottom left corner of the page.
+type Page struct {
+	Index     int     // Index of the page in the document
+	Width     float64 // Page size in points
+	Height    float64
+	pdf       *PDF
+	userSpace bool // Drawing in the y-down user space of an element handler
+}
+
+// SetAfterPage sets a hook called after the SVG content of each page is
+// rendered, e.g. to stamp timestamps, user IDs or classification banners.
+// An error returned by the hook aborts the conversion.
+func (p *PDF) SetAfterPage(hook func(page *Page) error) {
+	p.afterPage = hook
+}
+
+// WithAfterPage sets a hook called after each page is rendered
+func WithAfterPage(hook func(page *Page) error) Option {
+	return func(p *PDF) error {
+		p.SetAfterPage(hook)
+		return nil
+	}
+}
+
+// runAfterPage calls the hook for the current page, in a graphics state of
+// its own
+func (p *PDF) runAfterPage() error {
+	if p.afterPage == nil {
+		return nil
+	}
+	page := &Page{Index: p.current, Width: p.pageWidth, Height: p.pageHeight, pdf: p}
+	p.emit(append(p.beginArtifact(), "q")...)
+	err := p.afterPage(page)
+	p.emit(append([]string{"Q"}, p.endMarked()...)...)
+	if err != nil {
+		return fmt.Errorf("error in page hook: %v", err)
+	}
+	return nil
+}
+
+// Emit appends raw content stream operators to the page
+func (pg *Page) Emit(ops ...string) {
+	current := pg.pdf.current
+	pg.pdf.current = pg.Index
+	pg.pdf.emit(ops...)
+	pg.pdf.current = current
+}
+
+// Text draws text in Helvetica with its baseline starting at x, y
+func (pg *Page) Text(x, y, size float64, text string, color RGB) {
+	face := standardFont{}
+	pg.Emit(
+		"BT",
+		pg.pdf.fillOp(color),
+		"/"+face.resourceName()+" "+pg.pdf.operator("Tf", size),
+		pg.pdf.operator("Tm", 1, 0, 0, pg.textScaleY(), x, y),
+		face.encode(text)+" Tj",
+		"ET",
+	)
+}
+
+// textScaleY returns the vertical scale of text, which is mirrored in the
+// y-down user space so glyphs stay upright
+func (pg *Page) textScaleY() float64 {
+	if pg.userSpace {
+		return -1
+	}
+	return 1
+}
+
+// TextWidth returns the width of text drawn with Text at size
+func (pg *Page) TextWidth(text string, size float64) float64 {
+	return textWidth(text, size)
+}
+
+// FillRect fills the rectangle with its lower left corner at x, y
+func (pg *Page) FillRect(x, y, w, h float64, color RGB) {
+	pg.Emit(pg.pdf.fillOp(color), pg.pdf.operator("re", x, y, w, h), "f")
+}
+
+// StrokeRect outlines the rectangle with its lower left corner at x, y
+func (pg *Page) StrokeRect(x, y, w, h, lineWidth float64, color RGB) {
+	pg.Emit(pg.pdf.strokeOp(color), pg.pdf.operator("w", lineWidth), pg.pdf.operator("re", x, y, w, h), "S")
+}
@@ -0,0 +1,140 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// ImageColorPolicy decides how color profiles embedded in images are handled
+type ImageColorPolicy int
+
+// Policies for SetImageColorPolicy
+const (
+	PreserveImageProfiles ImageColorPolicy = iota // Embed the profile as an ICCBased color space (default)
+	DiscardImageProfiles                          // Use device color spaces, treating images as sRGB
+)
+
+// SetImageColorPolicy sets how ICC profiles of PNG and JPEG images are
+// handled. Images tagged sRGB without a profile always use DeviceRGB, which
+// viewers treat as sRGB.
+func (p *PDF) SetImageColorPolicy(policy ImageColorPolicy) error {
+	if policy < PreserveImageProfiles || policy > DiscardImageProfiles {
+		return fmt.Errorf("unknown image color policy %d", policy)
+	}
+	p.imageColorPolicy = policy
+	return nil
+}
+
+// iccProfile is an ICC profile shared by the images tagged with it
+type iccProfile struct {
+	data       []byte
+	components int    // Number of color components, the /N entry
+	alternate  string // Device color space used by viewers without color management
+	obj        int    // Object number, assigned when the document is written
+}
+
+// iccColorSpaces maps the data color space signature of a profile header to
+// its component count and alternate color space
+var iccColorSpaces = map[string]struct {
+	components int
+	alternate  string
+}{
+	"GRAY": {1, "/DeviceGray"},
+	"RGB ": {3, "/DeviceRGB"},
+	"CMYK": {4, "/DeviceCMYK"},
+}
+
+// imageProfile returns the profile embedded in the image data if it
+// describes samples in the device color space colorSpace, sharing identical
+// profiles between images
+func (p *PDF) imageProfile(mediaType string, data []byte, colorSpace string) *iccProfile {
+	if p.imageColorPolicy == DiscardImageProfiles {
+		return nil
+	}
+	var raw []byte
+	switch mediaType {
+	case "image/png":
+		raw = pngProfile(data)
+	case "image/jpeg", "image/jpg":
+		raw = jpegProfile(data)
+	}
+	if len(raw) < 128 {
+		return nil // No profile or a truncated header
+	}
+	space, ok := iccColorSpaces[string(raw[16:20])]
+	if !ok || space.alternate != colorSpace {
+		return nil // A profile for other samples would shift colors
+	}
+	for _, profile := range p.profiles {
+		if bytes.Equal(profile.data, raw) {
+			return profile
+		}
+	}
+	profile := &iccProfile{data: raw, components: space.components, alternate: space.alternate}
+	p.profiles = append(p.profiles, profile)
+	return profile
+}
+
+// pngProfile returns the decompressed profile of the iCCP chunk
+func pngProfile(data []byte) []byte {
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || kind == "IDAT" || kind == "IEND" {
+			break // The profile precedes the image data
+		}
+		chunk := data[pos+8 : pos+8+length]
+		pos += 12 + length
+		if kind != "iCCP" {
+			continue
+		}
+		// Profile name, compression method, zlib data
+		_, rest, ok := bytes.Cut(chunk, []byte{0})
+		if !ok || len(rest) < 1 {
+			return nil
+		}
+		return inflate(rest[1:])
+	}
+	return nil
+}
+
+// jpegProfile reassembles the profile stored in ICC_PROFILE APP2 segments,
+// which may be split over several segments
+func jpegProfile(data []byte) []byte {
+	type part struct {
+		seq  int
+		data []byte
+	}
+	var parts []part
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil // Malformed
+		}
+		if marker == 0xDA {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		pos += 2 + length
+		if marker == 0xE2 && len(segment) > 14 && bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00")) {
+			parts = append(parts, part{seq: int(segment[12]), data: segment[14:]})
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].seq < parts[j].seq })
+	var profile []byte
+	for _, part := range parts {
+		profile = append(profile, part.data...)
+	}
+	return profile
+}
+
+// writeObject writes the ICC profile stream
+func (profile *iccProfile) writeObject(w *pdfWriter) {
+	w.stream(profile.obj, ProfileStream, []string{
+		fmt.Sprintf("/N %d", profile.components),
+		"/Alternate " + profile.alternate,
+	}, profile.data)
+}
@@ -0,0 +1,260 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register JPEG decoding
+	_ "image/png"  // Register PNG decoding
+)
+
+// Image represents an SVG image element
+type Image struct {
+	X      Length `xml:"x,attr"`
+	Y      Length `xml:"y,attr"`
+	Width  Length `xml:"width,attr"`
+	Height Length `xml:"height,attr"`
+	Href   string `xml:"href,attr"` // Matches both href and xlink:href
+	Aspect string `xml:"preserveAspectRatio,attr"`
+	// Overflow and Clip control clipping of sliced images to their box
+	Overflow   string `xml:"overflow,attr"`
+	Clip       string `xml:"clip,attr"`
+	ClipPath   string `xml:"clip-path,attr"`
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Blend      string `xml:"mix-blend-mode,attr"`
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
+}
+
+// pdfImage is a decoded raster image ready to be written as an image XObject
+type pdfImage struct {
+	name       string // Resource name within the document (e.g. Im3f2a9c01)
+	width      int
+	height     int
+	colorSpace string
+	filter     string // Stream filter, empty for raw samples
+	data       []byte
+	smask      []byte      // 8-bit alpha channel, nil if the image is opaque
+	colorKey   []int       // Color key /Mask ranges, nil if unused
+	copyright  string      // Copyright notice from the image metadata
+	artist     string      // Author from the image metadata
+	profile    *iccProfile // Embedded color profile, nil for device color
+}
+
+// SetColorKeyMasking makes images with binary transparency use a color key
+// /Mask instead of a soft mask, for viewers and print drivers that mishandle
+// SMasks. Images with partial transparency always use a soft mask.
+func (p *PDF) SetColorKeyMasking(enabled bool) {
+	p.colorKeyMasking = enabled
+}
+
+// loadImage decodes the image referenced by an image element, loaded
+// through the resource loader
+func (p *PDF) loadImage(href string) (*pdfImage, error) {
+	data, mediaType, err := p.load("image", href)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical images share a single XObject
+	name := p.resourceID("Im", data)
+	for _, img := range p.images {
+		if img.name == name {
+			return img, nil
+		}
+	}
+	img := &pdfImage{name: name}
+	img.copyright, img.artist = imageRights(mediaType, data)
+	if mediaType == "image/jpeg" || mediaType == "image/jpg" {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JPEG image: %v", err)
+		}
+		if err := p.checkImageSize(cfg); err != nil {
+			return nil, err
+		}
+		// JPEG data is embedded as is, unless it must be converted to gray
+		// or CMYK
+		keep := p.cmyk == nil || cfg.ColorModel == color.CMYKModel
+		if p.grayscale {
+			keep = cfg.ColorModel == color.GrayModel
+		}
+		if keep {
+			return p.addJPEG(img, cfg, mediaType, data), nil
+		}
+	}
+
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if err := p.checkImageSize(cfg); err != nil {
+			return nil, err
+		}
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+	img.setPixels(decoded, p.colorKeyMasking && p.cmyk == nil && !p.grayscale)
+	switch {
+	case p.grayscale:
+		img.convertToGray()
+	case p.cmyk != nil:
+		img.convertToCMYK(p.cmyk.profile)
+	default:
+		img.profile = p.imageProfile(mediaType, data, img.colorSpace)
+	}
+	p.images = append(p.images, img)
+	p.created(img.name)
+	return img, nil
+}
+
+// addJPEG adds img with the JPEG data embedded as is
+func (p *PDF) addJPEG(img *pdfImage, cfg image.Config, mediaType string, data []byte) *pdfImage {
+	img.width, img.height = cfg.Width, cfg.Height
+	img.colorSpace = "/DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		img.colorSpace = "/DeviceCMYK"
+	}
+	img.filter = "/DCTDecode"
+	img.data = data
+	img.profile = p.imageProfile(mediaType, data, img.colorSpace)
+	p.images = append(p.images, img)
+	p.created(img.name)
+	return img
+}
+
+// setPixels converts decoded pixels to RGB samples and an alpha channel, or a
+// color key mask when requested and the transparency is binary
+func (img *pdfImage) setPixels(src image.Image, colorKey bool) {
+	bounds := src.Bounds()
+	img.width, img.height = bounds.Dx(), bounds.Dy()
+	img.colorSpace = "/DeviceRGB"
+	img.data = make([]byte, 0, img.width*img.height*3)
+	alpha := make([]byte, 0, img.width*img.height)
+	opaque, binary := true, true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			img.data = append(img.data, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+				if c.A != 0 {
+					binary = false
+				}
+			}
+		}
+	}
+	switch {
+	case opaque:
+	case colorKey && binary && img.applyColorKey(alpha):
+	default:
+		img.smask = alpha
+	}
+}
+
+// applyColorKey paints all transparent pixels with a color no opaque pixel
+// uses and masks that color. It reports false if no free color was found.
+func (img *pdfImage) applyColorKey(alpha []byte) bool {
+	used := make(map[[3]byte]bool)
+	for i, a := range alpha {
+		if a != 0 {
+			used[[3]byte(img.data[3*i:3*i+3])] = true
+		}
+	}
+	// Try magenta first as it rarely occurs in real images, then scan
+	key := [3]byte{0xff, 0x00, 0xff}
+	for n := 0; used[key]; n++ {
+		if n == 1<<24 {
+			return false
+		}
+		key = [3]byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+	for i, a := range alpha {
+		if a == 0 {
+			copy(img.data[3*i:], key[:])
+		}
+	}
+	img.colorKey = []int{int(key[0]), int(key[0]), int(key[1]), int(key[1]), int(key[2]), int(key[2])}
+	return true
+}
+
+// drawImage places img into the box x, y, w, h of the user space following
+// the element's preserveAspectRatio. Images overflowing their box are
+// clipped unless overflow is visible.
+func (p *PDF) drawImage(img *pdfImage, x, y, w, h float64, elem Image, ctx unitContext) {
+	ar := parseAspectRatio(elem.Aspect)
+	sx, sy, tx, ty := ar.fit(viewBox{W: float64(img.width), H: float64(img.height)}, w, h)
+	dw, dh := float64(img.width)*sx, float64(img.height)*sy
+
+	p.emit("q")
+	if clip, ok := viewportClip(elem.Overflow, elem.Clip, w, h, ctx); ok {
+		p.emit(p.operator("re W n", x+clip.X, y+clip.Y, clip.W, clip.H))
+	}
+	p.emit(
+		// Map the unit square to the box; images are drawn y-up, so the
+		// top row lands at the top edge of the box
+		p.operator("cm", dw, 0, 0, -dh, x+tx, y+ty+dh),
+		fmt.Sprintf("/%s Do", img.name),
+		"Q",
+	)
+}
+
+// writeObjects writes the PDF objects for img, numbered from first, with the
+// image XObject first and its soft mask (if any) second
+func (img *pdfImage) writeObjects(w *pdfWriter, first int) {
+	colorSpace := img.colorSpace
+	if img.profile != nil {
+		colorSpace = fmt.Sprintf("[/ICCBased %s]", ref(img.profile.obj))
+	}
+	dict := []string{
+		"/Type /XObject",
+		"/Subtype /Image",
+		fmt.Sprintf("/Width %d", img.width),
+		fmt.Sprintf("/Height %d", img.height),
+		"/ColorSpace " + colorSpace,
+		"/BitsPerComponent 8",
+	}
+	if img.filter != "" {
+		dict = append(dict, "/Filter "+img.filter)
+	}
+	if img.smask != nil {
+		dict = append(dict, fmt.Sprintf("/SMask %d 0 R", first+1))
+	}
+	if img.colorKey != nil {
+		dict = append(dict, "/Mask "+fmt.Sprint(img.colorKey)) // Prints as a PDF array
+	}
+	w.stream(first, ImageStream, dict, img.data)
+	if img.smask != nil {
+		w.stream(first+1, ImageStream, []string{
+			"/Type /XObject",
+			"/Subtype /Image",
+			fmt.Sprintf("/Width %d", img.width),
+			fmt.Sprintf("/Height %d", img.height),
+			"/ColorSpace /DeviceGray",
+			"/BitsPerComponent 8",
+		}, img.smask)
+	}
+}
+
+// objectCount returns the number of PDF objects written for img
+func (img *pdfImage) objectCount() int {
+	if img.smask != nil {
+		return 2
+	}
+	return 1
+}
+
+// truncate shortens s to at most n bytes for use in error messages
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
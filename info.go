@@ -0,0 +1,127 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// producer is the /Producer of generated documents
+const producer = "svg2pdf"
+
+// Metadata is written to the document information dictionary
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string    // Application that created the original content
+	Created  time.Time // Creation date; see creationDate for the default
+	// FromSVG fills an empty Title and Subject from the <title> and <desc>
+	// of the first converted SVG
+	FromSVG bool
+}
+
+// SetMetadata sets the document information written to the /Info
+// dictionary; nil removes it
+func (p *PDF) SetMetadata(m *Metadata) {
+	p.metadata = m
+}
+
+// SetMetadata sets the document information
+func (d *Document) SetMetadata(m *Metadata) {
+	d.pdf.SetMetadata(m)
+}
+
+// WithMetadata sets the document information
+func WithMetadata(m *Metadata) Option {
+	return func(p *PDF) error {
+		p.SetMetadata(m)
+		return nil
+	}
+}
+
+// recordDescription keeps the title and description of the first converted
+// SVG for metadata taken from the SVG
+func (p *PDF) recordDescription(svg *SVG) {
+	if p.described {
+		return
+	}
+	p.described = true
+	p.svgTitle = strings.Join(strings.Fields(svg.Title), " ")
+	p.svgDesc = strings.Join(strings.Fields(svg.Desc), " ")
+}
+
+// infoEntries returns the entries of the /Info dictionary, or nil if there
+// is no metadata
+func (p *PDF) infoEntries(created time.Time) []string {
+	m := p.documentMetadata()
+	if m == nil {
+		return nil
+	}
+	title, subject := p.documentTitle(m)
+	var entries []string
+	if !created.IsZero() {
+		entries = append(entries, "/CreationDate "+textString(pdfDate(created)))
+	}
+	for _, entry := range []struct{ key, value string }{
+		{"Title", title},
+		{"Author", m.Author},
+		{"Subject", subject},
+		{"Keywords", m.Keywords},
+		{"Creator", m.Creator},
+		{"Producer", producer},
+	} {
+		if entry.value != "" {
+			entries = append(entries, fmt.Sprintf("/%s %s", entry.key, textString(entry.value)))
+		}
+	}
+	return entries
+}
+
+// documentMetadata returns the metadata to write, which PDF/A documents
+// always have
+func (p *PDF) documentMetadata() *Metadata {
+	if p.metadata == nil && p.pdfa {
+		return &Metadata{}
+	}
+	return p.metadata
+}
+
+// documentTitle returns the title and subject of the metadata, taken from
+// the SVG if requested
+func (p *PDF) documentTitle(m *Metadata) (title, subject string) {
+	title, subject = m.Title, m.Subject
+	if m.FromSVG {
+		if title == "" {
+			title = p.svgTitle
+		}
+		if subject == "" {
+			subject = p.svgDesc
+		}
+	}
+	return title, subject
+}
+
+// textString encodes s as a PDF text string: a literal string for ASCII
+// text, UTF-16BE with a byte order mark otherwise
+func textString(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "(" + escapeText(s) + ")"
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
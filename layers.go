@@ -0,0 +1,154 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Group represents an SVG g element. Layers exported by Inkscape and
+// Illustrator are groups marked with inkscape:groupmode="layer" or a
+// data-name attribute.
+type Group struct {
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	GroupMode  string `xml:"http://www.inkscape.org/namespaces/inkscape groupmode,attr"`
+	Label      string `xml:"http://www.inkscape.org/namespaces/inkscape label,attr"`
+	DataName   string `xml:"data-name,attr"` // Layer name of Illustrator exports
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Blend      string `xml:"mix-blend-mode,attr"`
+	FilterRef  string `xml:"filter,attr"` // Reference to a filter element, url(#id)
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
+	Container
+	markup *Element // Source of filtered groups, for the Rasterizer
+}
+
+// layer is an optional content group drawn from the SVG layers of a name
+type layer struct {
+	name     string
+	resource string // Resource name in the page /Properties
+	visible  bool   // Initially on
+	obj      int
+}
+
+// SetLayers maps SVG layer groups to PDF layers (optional content groups)
+// viewers can toggle. Layers of the same name on different pages share a
+// PDF layer. Layers hidden with display:none are kept but initially off,
+// unless SetLayerVisibility says otherwise.
+func (p *PDF) SetLayers(enabled bool) {
+	p.layersEnabled = enabled
+}
+
+// WithLayers maps SVG layer groups to PDF layers
+func WithLayers() Option {
+	return func(p *PDF) error {
+		p.SetLayers(true)
+		return nil
+	}
+}
+
+// SetLayerVisibility sets whether the named layer is initially visible,
+// overriding its display property
+func (p *PDF) SetLayerVisibility(name string, visible bool) {
+	if p.layerVisibility == nil {
+		p.layerVisibility = make(map[string]bool)
+	}
+	p.layerVisibility[name] = visible
+}
+
+// WithLayerVisibility sets whether the named layer is initially visible
+func WithLayerVisibility(name string, visible bool) Option {
+	return func(p *PDF) error {
+		p.SetLayerVisibility(name, visible)
+		return nil
+	}
+}
+
+// layerName returns the name of g if it is a layer group
+func (g *Group) layerName() (string, bool) {
+	if g.GroupMode != "layer" && g.DataName == "" {
+		return "", false
+	}
+	for _, name := range []string{g.Label, g.DataName, g.ID} {
+		if name = strings.Join(strings.Fields(name), " "); name != "" {
+			return name, true
+		}
+	}
+	return "Layer", true
+}
+
+// renderGroup draws the elements of a group, as a layer if it is one
+func (p *PDF) renderGroup(g *Group, ctx unitContext) {
+	name, isLayer := g.layerName()
+	isLayer = isLayer && p.layersEnabled
+	if !p.holds(g.Conditions) {
+		return
+	}
+	defer p.scopeProperties("g", g.ID, g.Class, g.Inline)()
+	hidden := p.displayNone("g", g.ID, g.Class, g.Display, g.Inline)
+	if hidden && !isLayer {
+		return // Only layers are kept when hidden, to be toggled on
+	}
+	if p.redacts("g", g.ID, g.Class) {
+		// None of the children are drawn, only a box over them all
+		if box, ok := p.containerBBox(&g.Container, ctx); ok && !hidden {
+			p.drawRedaction(box)
+		}
+		return
+	}
+	var lay *layer
+	if isLayer {
+		lay = p.layer(name, !hidden)
+		p.emit(fmt.Sprintf("/OC /%s BDC", lay.resource))
+	}
+	defer p.inheritVisibility(p.visible("g", g.ID, g.Class, g.Visibility, g.Inline))()
+	draw := func() {
+		p.renderContainer(&g.Container, ctx)
+	}
+	if f := p.filterOf("g", g.ID, g.FilterRef, g.Inline); f != nil {
+		render := draw
+		draw = func() { p.drawFilteredGroup(f, g, ctx, render) }
+	}
+	if blend := p.blendMode("g", g.ID, g.Blend, g.Inline); blend != "" {
+		p.drawBlendGroup(blend, unboundedGroup, draw)
+	} else {
+		draw()
+	}
+	if lay != nil {
+		p.emit("EMC")
+	}
+	p.rendered("g", g.ID)
+}
+
+// layer returns the optional content group of the named layer, created
+// with the visibility from SetLayerVisibility or else visible
+func (p *PDF) layer(name string, visible bool) *layer {
+	for _, lay := range p.layers {
+		if lay.name == name {
+			return lay
+		}
+	}
+	if v, ok := p.layerVisibility[name]; ok {
+		visible = v
+	}
+	lay := &layer{name: name, resource: p.resourceID("OC", []byte(name)), visible: visible}
+	p.layers = append(p.layers, lay)
+	p.created(lay.resource)
+	return lay
+}
+
+// optionalContent returns the catalog entry listing the layers, in the
+// order they were first drawn
+func (p *PDF) optionalContent() string {
+	var all, off []string
+	for _, lay := range p.layers {
+		all = append(all, ref(lay.obj))
+		if !lay.visible {
+			off = append(off, ref(lay.obj))
+		}
+	}
+	refs := strings.Join(all, " ")
+	return fmt.Sprintf("/OCProperties << /OCGs [%s] /D << /Name (Layers) /Order [%s] /OFF [%s] >> >>",
+		refs, refs, strings.Join(off, " "))
+}
@@ -0,0 +1,232 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// FontEmbeddingPolicy decides what happens to fonts whose OS/2 fsType
+// forbids embedding them into documents
+type FontEmbeddingPolicy int
+
+// Policies for SetFontEmbeddingPolicy
+const (
+	RefuseRestrictedFonts       FontEmbeddingPolicy = iota // Fail registering the font (default)
+	SubstituteRestrictedFonts                              // Skip the font, text falls back to other fonts
+	IgnoreEmbeddingRestrictions                            // Embed anyway, e.g. under a separate license
+)
+
+// fsType bits of the OS/2 table
+const (
+	fsTypeRestricted = 0x0002 // Restricted License embedding
+	fsTypeBitmapOnly = 0x0200 // Only bitmaps may be embedded, never outlines
+)
+
+// SetFontEmbeddingPolicy sets how fonts that do not permit embedding are
+// handled. Fonts found through the font resolver are always substituted
+// unless restrictions are ignored, as they were not requested explicitly.
+func (p *PDF) SetFontEmbeddingPolicy(policy FontEmbeddingPolicy) error {
+	if policy < RefuseRestrictedFonts || policy > IgnoreEmbeddingRestrictions {
+		return fmt.Errorf("unknown font embedding policy %d", policy)
+	}
+	p.embeddingPolicy = policy
+	return nil
+}
+
+// embeddingRestriction describes why font may not be embedded, or returns
+// an empty string if it may
+func (f *Font) embeddingRestriction() string {
+	switch {
+	case f.fsType&fsTypeRestricted != 0:
+		return "restricted license embedding"
+	case f.fsType&fsTypeBitmapOnly != 0:
+		return "bitmap embedding only"
+	}
+	return ""
+}
+
+// checkEmbedding applies the embedding policy to font, reporting whether it
+// can be used; an error is returned if the policy refuses it
+func (p *PDF) checkEmbedding(family string, font *Font) (bool, error) {
+	restriction := font.embeddingRestriction()
+	if restriction == "" || p.embeddingPolicy == IgnoreEmbeddingRestrictions {
+		return true, nil
+	}
+	if p.embeddingPolicy == SubstituteRestrictedFonts {
+		return false, nil
+	}
+	return false, &ResourceError{Op: "embedding", Kind: "font", Name: family,
+		Err: fmt.Errorf("font does not permit embedding (fsType %#04x: %s)", font.fsType, restriction)}
+}
+
+// imageRights extracts the copyright notice and author recorded in PNG text
+// chunks or JPEG Exif data
+func imageRights(mediaType string, data []byte) (copyright, artist string) {
+	switch mediaType {
+	case "image/png":
+		return pngRights(data)
+	case "image/jpeg", "image/jpg":
+		return jpegRights(data)
+	}
+	return "", ""
+}
+
+// pngRights reads the Copyright and Author keywords of tEXt, zTXt and iTXt chunks
+func pngRights(data []byte) (copyright, artist string) {
+	if len(data) < 8 {
+		return "", ""
+	}
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || kind == "IEND" {
+			break
+		}
+		chunk := data[pos+8 : pos+8+length]
+		pos += 12 + length
+
+		keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+		if !ok {
+			continue
+		}
+		var text string
+		switch kind {
+		case "tEXt":
+			text = latin1(rest)
+		case "zTXt":
+			if len(rest) > 0 {
+				text = latin1(inflate(rest[1:]))
+			}
+		case "iTXt":
+			// Compression flag and method, then language and translated keyword
+			if len(rest) < 2 {
+				continue
+			}
+			compressed := rest[0] == 1
+			parts := bytes.SplitN(rest[2:], []byte{0}, 3)
+			if len(parts) < 3 {
+				continue
+			}
+			if compressed {
+				text = string(inflate(parts[2]))
+			} else {
+				text = string(parts[2])
+			}
+		default:
+			continue
+		}
+		switch string(keyword) {
+		case "Copyright":
+			copyright = text
+		case "Author":
+			artist = text
+		}
+	}
+	return strings.TrimSpace(copyright), strings.TrimSpace(artist)
+}
+
+// jpegRights reads the Copyright and Artist tags of the Exif APP1 segment
+func jpegRights(data []byte) (copyright, artist string) {
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA || pos+2+length > len(data) {
+			break // Start of scan, no more metadata
+		}
+		segment := data[pos+4 : pos+2+length]
+		pos += 2 + length
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifRights(segment[6:])
+		}
+	}
+	return "", ""
+}
+
+// exifRights reads the Copyright (0x8298) and Artist (0x013B) ASCII tags of
+// the first IFD of TIFF data
+func exifRights(tiff []byte) (copyright, artist string) {
+	if len(tiff) < 8 {
+		return "", ""
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if string(tiff[:2]) == "II" {
+		order = binary.LittleEndian
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return "", ""
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry:])
+		if order.Uint16(tiff[entry+2:]) != 2 { // ASCII
+			continue
+		}
+		n := int(order.Uint32(tiff[entry+4:]))
+		value := tiff[entry+8 : entry+12] // Short values are stored inline
+		if n > 4 {
+			offset := int(order.Uint32(tiff[entry+8:]))
+			if offset < 0 || offset+n > len(tiff) {
+				continue
+			}
+			value = tiff[offset : offset+n]
+		} else {
+			value = value[:n]
+		}
+		// Copyright may hold photographer and editor notices separated by NUL
+		text := strings.Join(strings.FieldsFunc(string(value), func(r rune) bool { return r == 0 }), "; ")
+		switch tag {
+		case 0x8298:
+			copyright = strings.TrimSpace(text)
+		case 0x013B:
+			artist = strings.TrimSpace(text)
+		}
+	}
+	return copyright, artist
+}
+
+// latin1 decodes ISO 8859-1 text
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// inflate decompresses zlib data, returning nil on errors
+func inflate(b []byte) []byte {
+	r, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// imageRightsNotices returns the distinct copyright notices and authors of
+// the embedded images
+func (p *PDF) imageRightsNotices() (rights, creators []string) {
+	for _, img := range p.images {
+		if img.copyright != "" && !slices.Contains(rights, img.copyright) {
+			rights = append(rights, img.copyright)
+		}
+		if img.artist != "" && !slices.Contains(creators, img.artist) {
+			creators = append(creators, img.artist)
+		}
+	}
+	return rights, creators
+}
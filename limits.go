@@ -0,0 +1,140 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"strconv"
+)
+
+// Limits caps the work a conversion may do, hardening servers converting
+// untrusted SVGs against documents built to exhaust them. Zero fields are
+// unlimited. A conversion exceeding a limit fails with a *LimitError.
+type Limits struct {
+	Elements     int   // Elements drawn per svg document, counting those of every use of a symbol
+	Depth        int   // Nesting of groups, nested svgs, switches and symbols drawn by use
+	PathSegments int   // Segments of a single path
+	ImageBytes   int64 // Pixel data of a single image once decoded, at 4 bytes per pixel
+	// ExpansionRatio caps the elements drawn per element of an svg document,
+	// counting documents with fewer than 100 elements as 100, against
+	// symbols used over and over. It is not checked when parsing is streamed,
+	// as the size of the document is not known.
+	ExpansionRatio float64
+	// EntityBytes caps the bytes the entity references of a source expand
+	// to in total. Unlike the other limits, sources are capped at 16 MiB
+	// when it is zero.
+	EntityBytes int64
+}
+
+// expansionFloor is the least element count ExpansionRatio applies to
+const expansionFloor = 100
+
+// LimitError reports a conversion aborted for exceeding one of its Limits
+type LimitError struct {
+	Limit string  // Name of the field of Limits, e.g. "Depth"
+	Max   float64 // Value it was set to
+}
+
+// Error describes the limit, e.g. "limit exceeded: more than 64 levels of
+// nesting"
+func (e *LimitError) Error() string {
+	what := map[string]string{
+		"Elements":       "elements",
+		"Depth":          "levels of nesting",
+		"PathSegments":   "path segments",
+		"ImageBytes":     "bytes of image data",
+		"ExpansionRatio": "elements drawn per element of the document",
+		"EntityBytes":    "bytes of entity expansion",
+	}[e.Limit]
+	return fmt.Sprintf("limit exceeded: more than %s %s", strconv.FormatFloat(e.Max, 'f', -1, 64), what)
+}
+
+// SetLimits caps the work of conversions; nil removes the limits
+func (p *PDF) SetLimits(l *Limits) error {
+	if l != nil && (l.Elements < 0 || l.Depth < 0 || l.PathSegments < 0 || l.ImageBytes < 0 || !(l.ExpansionRatio >= 0) || l.EntityBytes < 0) {
+		return fmt.Errorf("invalid limits %+v", *l)
+	}
+	p.limits = l
+	return nil
+}
+
+// WithLimits caps the work of conversions
+func WithLimits(l Limits) Option {
+	return func(p *PDF) error {
+		return p.SetLimits(&l)
+	}
+}
+
+// exceed aborts the conversion for exceeding limit, returning the error
+func (p *PDF) exceed(limit string, max float64) error {
+	err := &LimitError{Limit: limit, Max: max}
+	if p.abortErr == nil {
+		p.abortErr = err
+	}
+	p.log(slog.LevelWarn, "conversion aborted", "error", err)
+	return err
+}
+
+// checkElementLimits aborts the conversion once the current svg document
+// draws too many elements, reporting whether it was aborted
+func (p *PDF) checkElementLimits() bool {
+	l := p.limits
+	if l == nil {
+		return false
+	}
+	if l.Elements > 0 && p.renderDone > l.Elements {
+		p.exceed("Elements", float64(l.Elements))
+		return true
+	}
+	if l.ExpansionRatio > 0 && p.renderTotal > 0 && float64(p.renderDone) > l.ExpansionRatio*float64(max(p.renderTotal, expansionFloor)) {
+		p.exceed("ExpansionRatio", l.ExpansionRatio)
+		return true
+	}
+	return false
+}
+
+// nest enters a container, reporting false, and aborting the conversion, if
+// it is nested too deeply. Every call is paired with one of p.unnest.
+func (p *PDF) nest() bool {
+	p.depth++
+	// The root is not nested
+	if l := p.limits; l != nil && l.Depth > 0 && p.depth-1 > l.Depth {
+		p.exceed("Depth", float64(l.Depth))
+		return false
+	}
+	return true
+}
+
+// unnest leaves a container entered with nest
+func (p *PDF) unnest() {
+	p.depth--
+}
+
+// pathSegmentLimit returns the most segments a path may have, 0 for any
+func (p *PDF) pathSegmentLimit() int {
+	if p.limits == nil {
+		return 0
+	}
+	return p.limits.PathSegments
+}
+
+// entityBytes returns the most bytes the entity references of a source
+// may expand to
+func (p *PDF) entityBytes() int64 {
+	if p.limits == nil || p.limits.EntityBytes == 0 {
+		return defaultEntityBytes
+	}
+	return p.limits.EntityBytes
+}
+
+// checkImageSize fails for images whose pixels would take more than the
+// limit once decoded, before they are
+func (p *PDF) checkImageSize(cfg image.Config) error {
+	if p.limits == nil || p.limits.ImageBytes == 0 {
+		return nil
+	}
+	if int64(cfg.Width)*int64(cfg.Height)*4 > p.limits.ImageBytes {
+		return p.exceed("ImageBytes", float64(p.limits.ImageBytes))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package svg2pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResourceLoader loads the external resources SVGs reference: images,
+// @font-face sources and @import style sheets. Conversions only read what
+// their loader permits, so untrusted SVGs cannot reach local files or the
+// network unless the application allows it.
+type ResourceLoader interface {
+	// Load returns the content of the resource at uri and its media type,
+	// "" if unknown. kind is "image", "font" or "stylesheet". Loaders
+	// return an error wrapping ErrResourceDenied for references their
+	// policy does not permit.
+	Load(ctx context.Context, kind, uri string) (data []byte, mediaType string, err error)
+}
+
+// ErrResourceDenied is wrapped by the errors of loaders refusing a reference
+var ErrResourceDenied = errors.New("resource not permitted")
+
+// defaultMaxResourceSize caps the resources of loaders without a MaxSize
+const defaultMaxResourceSize = 32 << 20
+
+// defaultResourceTimeout limits HTTP requests of loaders without a Timeout
+const defaultResourceTimeout = 30 * time.Second
+
+// SetResourceLoader sets the loader of the resources SVGs reference. The
+// default, DataURILoader, only permits data: URIs.
+func (p *PDF) SetResourceLoader(loader ResourceLoader) {
+	p.loader = loader
+}
+
+// WithResourceLoader sets the loader of the resources SVGs reference
+func WithResourceLoader(loader ResourceLoader) Option {
+	return func(p *PDF) error {
+		p.SetResourceLoader(loader)
+		return nil
+	}
+}
+
+// load returns the resource at uri through the resource loader
+func (p *PDF) load(kind, uri string) ([]byte, string, error) {
+	loader := p.loader
+	if loader == nil {
+		loader = DataURILoader{}
+	}
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, mediaType, err := loader.Load(ctx, kind, uri)
+	if err != nil {
+		return nil, "", err
+	}
+	if mediaType == "" {
+		mediaType, _, _ = strings.Cut(http.DetectContentType(data), ";")
+	}
+	return data, mediaType, nil
+}
+
+// DataURILoader only permits resources embedded as data: URIs
+type DataURILoader struct{}
+
+// Load decodes uri if it is a data: URI
+func (DataURILoader) Load(ctx context.Context, kind, uri string) ([]byte, string, error) {
+	if !strings.HasPrefix(uri, "data:") {
+		return nil, "", fmt.Errorf("%w: %s reference %q", ErrResourceDenied, kind, truncate(uri, 32))
+	}
+	mediaType, data, err := parseDataURI(uri)
+	return data, mediaType, err
+}
+
+// FileLoader permits data: URIs and local files under Root. References are
+// file: URIs or paths, relative ones resolved against Root; those leaving
+// Root, also through symbolic links, are refused.
+type FileLoader struct {
+	Root    string
+	MaxSize int64 // Largest file read, 32 MiB if 0
+}
+
+// Load reads the file uri refers to
+func (l FileLoader) Load(ctx context.Context, kind, uri string) ([]byte, string, error) {
+	if strings.HasPrefix(uri, "data:") {
+		return DataURILoader{}.Load(ctx, kind, uri)
+	}
+	path := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		if u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+			return nil, "", fmt.Errorf("%w: %s reference %q", ErrResourceDenied, kind, truncate(uri, 32))
+		}
+		path = u.Path
+	}
+	root, err := filepath.Abs(l.Root)
+	if err != nil {
+		return nil, "", fmt.Errorf("error resolving resource root: %v", err)
+	}
+	if filepath.IsAbs(path) {
+		if path, err = filepath.Rel(root, path); err != nil {
+			return nil, "", fmt.Errorf("%w: %s reference %q", ErrResourceDenied, kind, truncate(uri, 32))
+		}
+	}
+	path = filepath.Clean(filepath.FromSlash(path))
+	if !filepath.IsLocal(path) {
+		return nil, "", fmt.Errorf("%w: %s reference %q outside the root", ErrResourceDenied, kind, truncate(uri, 32))
+	}
+
+	dir, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening resource root: %v", err)
+	}
+	defer dir.Close()
+	f, err := dir.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	data, err := readLimited(f, l.MaxSize)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mime.TypeByExtension(filepath.Ext(path)), nil
+}
+
+// HTTPLoader permits data: URIs and http and https URLs, fetched with a
+// timeout and a size cap
+type HTTPLoader struct {
+	Client  *http.Client  // http.DefaultClient if nil
+	Base    string        // URL relative references are resolved against, if any
+	Timeout time.Duration // Limit of each request, 30 seconds if 0
+	MaxSize int64         // Largest response read, 32 MiB if 0
+}
+
+// Load fetches the resource at uri
+func (l HTTPLoader) Load(ctx context.Context, kind, uri string) ([]byte, string, error) {
+	if strings.HasPrefix(uri, "data:") {
+		return DataURILoader{}.Load(ctx, kind, uri)
+	}
+	u, err := url.Parse(uri)
+	if err == nil && l.Base != "" {
+		var base *url.URL
+		if base, err = url.Parse(l.Base); err == nil {
+			u = base.ResolveReference(u)
+		}
+	}
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, "", fmt.Errorf("%w: %s reference %q", ErrResourceDenied, kind, truncate(uri, 32))
+	}
+
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = defaultResourceTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("error fetching %s: %s", u.Redacted(), resp.Status)
+	}
+	data, err := readLimited(resp.Body, l.MaxSize)
+	if err != nil {
+		return nil, "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return data, mediaType, nil
+}
+
+// readLimited reads r, failing once it exceeds max bytes, the default cap
+// if max is 0
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		max = defaultMaxResourceSize
+	}
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("resource exceeds %d bytes", max)
+	}
+	return data, nil
+}
@@ -0,0 +1,47 @@
+package svg2pdf
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SetLogger sets the logger receiving structured traces of conversions:
+// saved files at Info level, skipped content at Warn level, and rendered
+// elements, created resources and the time spent per page and on writing at
+// Debug level. By default nothing is logged.
+func (p *PDF) SetLogger(logger *slog.Logger) {
+	p.logger = logger
+}
+
+// WithLogger sets the logger receiving traces of conversions
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *PDF) error {
+		p.SetLogger(logger)
+		return nil
+	}
+}
+
+// log logs msg at level, unless no logger is set or it drops the level
+func (p *PDF) log(level slog.Level, msg string, args ...any) {
+	if p.logger == nil || !p.logger.Enabled(context.Background(), level) {
+		return
+	}
+	if p.worker != nil {
+		p.worker.replay = append(p.worker.replay, replayed{level: level, msg: msg, args: args})
+		return
+	}
+	p.logger.Log(context.Background(), level, msg, args...)
+}
+
+// logEvent logs a conversion event
+func (p *PDF) logEvent(e Event) {
+	switch e.Kind {
+	case ElementRendered:
+		p.log(slog.LevelDebug, "element rendered", "page", e.Page+1, "element", e.Element, "id", e.ID)
+	case ResourceCreated:
+		p.log(slog.LevelDebug, "resource created", "page", e.Page+1, "resource", e.Resource)
+	case WarningEmitted:
+		w := e.warning()
+		p.log(slog.LevelWarn, w.Reason, "page", w.Page+1, "element", w.Element, "id", w.ID, "line", w.Line, "column", w.Column)
+	}
+}
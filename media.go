@@ -0,0 +1,158 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetMediaType sets the media type @media rules of SVG style sheets are
+// evaluated for, "print" (the default) or "screen". Rules of other media
+// types and queries with media features never apply.
+func (p *PDF) SetMediaType(media string) error {
+	switch media {
+	case "print", "screen":
+	default:
+		return fmt.Errorf("unknown media type %q", media)
+	}
+	p.mediaType = media
+	return nil
+}
+
+// WithMediaType sets the media type @media rules are evaluated for
+func WithMediaType(media string) Option {
+	return func(p *PDF) error {
+		return p.SetMediaType(media)
+	}
+}
+
+// isMediaRule reports whether prelude starts a @media rule
+func isMediaRule(prelude string) bool {
+	return len(prelude) >= 6 && strings.EqualFold(prelude[:6], "@media")
+}
+
+// matchesMedia reports whether a media query list matches the media type
+// of the conversion
+func (p *PDF) matchesMedia(queries string) bool {
+	media := p.mediaType
+	if media == "" {
+		media = "print"
+	}
+	for _, query := range strings.Split(queries, ",") {
+		words := strings.Fields(strings.ToLower(query))
+		negate := len(words) > 0 && words[0] == "not"
+		if len(words) > 0 && (words[0] == "only" || negate) {
+			words = words[1:]
+		}
+		if len(words) != 1 {
+			continue // Media features are not evaluated
+		}
+		if (words[0] == "all" || words[0] == media) != negate {
+			return true
+		}
+	}
+	return false
+}
+
+// styleRules returns the rules of the style sheets that apply, in order,
+// with the rules of matching @media blocks in place of the blocks
+func (p *PDF) styleRules(styles []Style) []cssRule {
+	var flatten func(rules []cssRule) []cssRule
+	flatten = func(rules []cssRule) []cssRule {
+		var flat []cssRule
+		for _, rule := range rules {
+			if !isMediaRule(rule.Prelude) {
+				flat = append(flat, rule)
+			} else if p.matchesMedia(rule.Prelude[6:]) {
+				flat = append(flat, flatten(rule.Rules)...)
+			}
+		}
+		return flat
+	}
+	var rules []cssRule
+	for _, style := range styles {
+		rules = append(rules, flatten(parseStyleSheet(style.Content))...)
+	}
+	return rules
+}
+
+// propertyRule is a style rule setting one of the properties resolved
+// from style sheets
+type propertyRule struct {
+	sel         selector
+	specificity int
+	property    string
+	value       string
+}
+
+// ruleProperties are the properties resolved from style sheets, besides
+// custom properties
+var ruleProperties = setOf("display", "visibility", "fill", "stroke", "fill-rule", "clip-rule")
+
+// setPropertyRules keeps the style sheets of a document and records their
+// rules setting ruleProperties or custom properties. Rules with unsupported
+// selectors, e.g. descendant combinators, are skipped; :root matches svg
+// elements.
+func (p *PDF) setPropertyRules(styles []Style) {
+	p.propertyRules, p.styleSheets = nil, styles
+	for _, rule := range p.styleRules(styles) {
+		if strings.HasPrefix(rule.Prelude, "@") {
+			continue
+		}
+		var decls []cssDecl
+		for _, decl := range rule.Decls {
+			if ruleProperties[decl.Property] || strings.HasPrefix(decl.Property, "--") {
+				decls = append(decls, cssDecl{decl.Property, declValue(decl.Value)})
+			}
+		}
+		if len(decls) == 0 {
+			continue
+		}
+		for _, s := range strings.Split(rule.Prelude, ",") {
+			s, root := strings.CutSuffix(strings.TrimSpace(s), ":root")
+			if root {
+				if s != "" && s != "svg" {
+					continue
+				}
+				s = "svg"
+			}
+			sel, err := parseSelector(s)
+			if err != nil {
+				continue
+			}
+			specificity := sel.specificity()
+			if root {
+				specificity += 100 // A pseudo-class
+			}
+			for _, decl := range decls {
+				p.propertyRules = append(p.propertyRules, propertyRule{sel, specificity, decl.Property, decl.Value})
+			}
+		}
+	}
+}
+
+// specificity returns the CSS specificity of the selector as a number
+// ordering ids over classes over element names
+func (sel selector) specificity() int {
+	n := len(sel.classes) * 100
+	if sel.id != "" {
+		n += 10000
+	}
+	if sel.tag != "" {
+		n++
+	}
+	return n
+}
+
+// cssValue returns the value the style sheets set property to for an
+// element, or "" if they do not. Of the matching
+// rules, the most specific one wins, and the last one among equally
+// specific rules.
+func (p *PDF) cssValue(property, tag, id, class string) string {
+	value, best := "", -1
+	for _, rule := range p.propertyRules {
+		if rule.property == property && rule.specificity >= best && rule.sel.matches(tag, id, class) {
+			value, best = rule.value, rule.specificity
+		}
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package svg2pdf
+
+// helveticaWidths holds the Helvetica advance widths (in 1/1000 em) for the
+// printable ASCII range starting at the space character
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
+
+// defaultGlyphWidth is used for characters outside the known metrics
+const defaultGlyphWidth = 556
+
+// glyphWidth returns the advance width of r in 1/1000 em for the built-in font
+func glyphWidth(r rune) int {
+	if r >= 32 && r <= 126 {
+		return helveticaWidths[r-32]
+	}
+	return defaultGlyphWidth
+}
+
+// textWidth returns the advance width of s in user units at the given font size
+func textWidth(s string, fontSize float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += glyphWidth(r)
+	}
+	return float64(total) * fontSize / 1000
+}
@@ -0,0 +1,314 @@
+package svg2pdf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetMinifyContent enables an optimization pass over page content streams
+// that drops redundant color and line width changes and empty q/Q pairs, and
+// writes every operation on a single line with minimal whitespace
+func (p *PDF) SetMinifyContent(enabled bool) {
+	p.minifyContent = enabled
+}
+
+// contentOp is an operator with its operands, as written in a content stream
+type contentOp struct {
+	operands []string
+	operator string
+}
+
+// drawState is the part of the graphics state the optimizer tracks; empty
+// strings mean unknown
+type drawState struct {
+	stroke, fill, width string
+}
+
+// minifyContent returns an optimized equivalent of a content stream, or the
+// stream unchanged if it cannot be parsed
+func minifyContent(stream string) string {
+	ops, ok := parseContentOps(stream)
+	if !ok {
+		return stream
+	}
+
+	// Drop operators setting a value that is already current
+	var kept []contentOp
+	state := drawState{}
+	var stack []drawState
+	for _, op := range ops {
+		value := strings.Join(op.operands, " ") + " " + op.operator
+		switch op.operator {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			} else {
+				state = drawState{}
+			}
+		case "RG", "G", "K":
+			if value == state.stroke {
+				continue
+			}
+			state.stroke = value
+		case "rg", "g", "k":
+			if value == state.fill {
+				continue
+			}
+			state.fill = value
+		case "w":
+			if value == state.width {
+				continue
+			}
+			state.width = value
+		case "CS", "SC", "SCN":
+			state.stroke = ""
+		case "cs", "sc", "scn":
+			state.fill = ""
+		case "gs":
+			state = drawState{} // Graphics state dictionaries may set anything
+		}
+		kept = append(kept, op)
+	}
+
+	// Remove q/Q pairs enclosing no painting operators, including pairs that
+	// only change state or set up clipping
+	kept = dropUnpaintedSaves(kept)
+
+	lines := make([]string, len(kept))
+	for i, op := range kept {
+		lines[i] = strings.Join(append(op.operands, op.operator), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paintingOperators mark the page, all others only change state
+var paintingOperators = map[string]bool{
+	"S": true, "s": true, "f": true, "F": true, "f*": true, "B": true, "B*": true,
+	"b": true, "b*": true, "sh": true, "Do": true, "Tj": true, "TJ": true, "'": true, "\"": true,
+}
+
+// dropUnpaintedSaves removes every q...Q block that paints nothing
+func dropUnpaintedSaves(ops []contentOp) []contentOp {
+	// Match each q with its Q and note whether anything is painted inside
+	type save struct {
+		start   int
+		painted bool
+	}
+	drop := make([]bool, len(ops))
+	var stack []save
+	for i, op := range ops {
+		switch {
+		case op.operator == "q":
+			stack = append(stack, save{start: i})
+		case op.operator == "Q" && len(stack) > 0:
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !top.painted {
+				for j := top.start; j <= i; j++ {
+					drop[j] = true
+				}
+			} else if len(stack) > 0 {
+				stack[len(stack)-1].painted = true
+			}
+		case paintingOperators[op.operator] && len(stack) > 0:
+			stack[len(stack)-1].painted = true
+		}
+	}
+	out := ops[:0:0]
+	for i, op := range ops {
+		if !drop[i] {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// parseContentOps splits a content stream into operations, shortening
+// numbers. It reports false for streams it does not handle, such as inline
+// images or unbalanced delimiters.
+func parseContentOps(stream string) ([]contentOp, bool) {
+	var ops []contentOp
+	var operands []string
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0:
+			i++
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++ // Comments are dropped
+			}
+		case c == '(':
+			end, ok := literalStringEnd(stream, i)
+			if !ok {
+				return nil, false
+			}
+			operands = append(operands, stream[i:end])
+			i = end
+		case c == '<' || c == '[' || c == '{':
+			end, ok := compositeEnd(stream, i)
+			if !ok {
+				return nil, false
+			}
+			operands = append(operands, collapseComposite(stream[i:end]))
+			i = end
+		case c == '/':
+			end := tokenEnd(stream, i+1)
+			operands = append(operands, stream[i:end])
+			i = end
+		default:
+			end := tokenEnd(stream, i+1)
+			token := stream[i:end]
+			i = end
+			if isNumberToken(token) {
+				operands = append(operands, shortenNumber(token))
+				continue
+			}
+			if token == "BI" || token == "ID" || token == "EI" {
+				return nil, false // Inline image data is not tokenizable
+			}
+			if token == "true" || token == "false" || token == "null" {
+				operands = append(operands, token)
+				continue
+			}
+			ops = append(ops, contentOp{operands: operands, operator: token})
+			operands = nil
+		}
+	}
+	if len(operands) > 0 {
+		return nil, false // Trailing operands without an operator
+	}
+	return ops, true
+}
+
+// isDelimiter reports whether c ends a regular token
+func isDelimiter(c byte) bool {
+	return strings.IndexByte(" \n\r\t\f\x00()<>[]{}/%", c) >= 0
+}
+
+// tokenEnd returns the end of the regular token continuing at i
+func tokenEnd(s string, i int) int {
+	for i < len(s) && !isDelimiter(s[i]) {
+		i++
+	}
+	return i
+}
+
+// literalStringEnd returns the end of the literal string starting at i,
+// following nested parentheses and escapes
+func literalStringEnd(s string, i int) (int, bool) {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// compositeEnd returns the end of the hex string, dictionary or array
+// starting at i
+func compositeEnd(s string, i int) (int, bool) {
+	depth := 0
+	for i < len(s) {
+		switch {
+		case s[i] == '(':
+			end, ok := literalStringEnd(s, i)
+			if !ok {
+				return 0, false
+			}
+			i = end
+			if depth == 0 {
+				return i, true
+			}
+			continue
+		case strings.HasPrefix(s[i:], "<<"):
+			depth++
+			i += 2
+			continue
+		case strings.HasPrefix(s[i:], ">>"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, true
+			}
+			continue
+		case s[i] == '<' || s[i] == '[' || s[i] == '{':
+			depth++
+		case s[i] == '>' || s[i] == ']' || s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+		i++
+	}
+	return 0, false
+}
+
+// collapseComposite shortens whitespace runs outside strings in an array or
+// dictionary
+func collapseComposite(s string) string {
+	if !strings.HasPrefix(s, "[") {
+		return s // Hex strings and dictionaries are kept as written
+	}
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '(' {
+			end, _ := literalStringEnd(s, i)
+			b.WriteString(s[i:end])
+			i = end - 1
+			space = false
+			continue
+		}
+		if c == ' ' || c == '\n' || c == '\r' || c == '\t' {
+			space = true
+			continue
+		}
+		if space && b.Len() > 1 && c != ']' {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isNumberToken reports whether token is a PDF number
+func isNumberToken(token string) bool {
+	_, err := strconv.ParseFloat(token, 64)
+	return err == nil && !strings.ContainsAny(token, "eEnN") // No exponents, NaN or Inf
+}
+
+// shortenNumber drops redundant zeros, e.g. "1.50" becomes "1.5" and
+// "-0.00" becomes "0"
+func shortenNumber(token string) string {
+	if !strings.Contains(token, ".") {
+		return token
+	}
+	token = strings.TrimRight(strings.TrimRight(token, "0"), ".")
+	switch token {
+	case "", "-", "+", "-0", "+0":
+		return "0"
+	}
+	if strings.HasPrefix(token, "0.") {
+		return token[1:]
+	}
+	if strings.HasPrefix(token, "-0.") {
+		return "-" + token[2:]
+	}
+	return token
+}
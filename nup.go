@@ -0,0 +1,79 @@
+package svg2pdf
+
+import "fmt"
+
+// nUpLayout places several svg documents on each page in a grid
+type nUpLayout struct {
+	rows, columns int
+	gutter        float64 // Space in points between cells
+	page          int     // Index of the page being filled, -1 if none
+	cell          int     // Next free cell of that page
+}
+
+// SetNUp lays out converted SVGs rows by columns per page, e.g. 2 by 2 for
+// 4-up printing, filling each row from the left before the next one and
+// starting a page once all cells are taken. Each SVG is fitted into its
+// cell, and cells are gutter points apart within the page margins. Pages
+// are not sized to their SVGs meanwhile, and the AfterPage hook runs once
+// per page, when it is full or the document is written. 1 by 1 lays out
+// one SVG per page again.
+func (p *PDF) SetNUp(rows, columns int, gutter float64) error {
+	if rows < 1 || columns < 1 || !(gutter >= 0) {
+		return fmt.Errorf("invalid n-up layout of %d by %d with gutter %g", rows, columns, gutter)
+	}
+	if rows == 1 && columns == 1 {
+		p.nup = nil
+		return nil
+	}
+	p.nup = &nUpLayout{rows: rows, columns: columns, gutter: gutter, page: -1}
+	return nil
+}
+
+// WithNUp lays out converted SVGs rows by columns per page
+func WithNUp(rows, columns int, gutter float64) Option {
+	return func(p *PDF) error {
+		return p.SetNUp(rows, columns, gutter)
+	}
+}
+
+// nextCell returns the cell of box the next svg document is drawn in, and
+// whether it starts a new page
+func (p *PDF) nextCell(box viewBox) (viewBox, bool) {
+	l := p.nup
+	newPage := l.page < 0 || l.page != p.current || l.page >= p.pageCount
+	if newPage {
+		l.page, l.cell = p.pageCount, 0 // The page beginRoot adds
+	}
+	row, column := l.cell/l.columns, l.cell%l.columns
+	l.cell++
+	w := max((box.W-l.gutter*float64(l.columns-1))/float64(l.columns), 0)
+	h := max((box.H-l.gutter*float64(l.rows-1))/float64(l.rows), 0)
+	return viewBox{box.X + float64(column)*(w+l.gutter), box.Y + float64(row)*(h+l.gutter), w, h}, newPage
+}
+
+// pageFull reports whether the page the last svg document was drawn on is
+// done: always, unless cells of an n-up page are left
+func (p *PDF) pageFull() bool {
+	l := p.nup
+	if l == nil || l.page != p.current {
+		return true
+	}
+	if l.cell < l.rows*l.columns {
+		return false
+	}
+	l.page = -1
+	return true
+}
+
+// finishNUpPage finishes a partly filled n-up page before the document is
+// written
+func (p *PDF) finishNUpPage() error {
+	l := p.nup
+	if l == nil || l.page < 0 || l.page >= p.pageCount {
+		return nil
+	}
+	current := p.current
+	p.current, l.page = l.page, -1
+	defer func() { p.current = current }()
+	return p.finishPage()
+}
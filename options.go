@@ -0,0 +1,192 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Option configures a document created by New or Convert
+type Option func(*PDF) error
+
+// WithPageSize sets the page size to a paper size such as A3 or Letter
+func WithPageSize(size PaperSize) Option {
+	return func(p *PDF) error { return p.SetPageSize(size.Width, size.Height) }
+}
+
+// WithCustomPageSize sets the page size in points
+func WithCustomPageSize(width, height float64) Option {
+	return func(p *PDF) error { return p.SetPageSize(width, height) }
+}
+
+// WithOrientation turns pages to portrait or landscape orientation
+func WithOrientation(o Orientation) Option {
+	return func(p *PDF) error { return p.SetOrientation(o) }
+}
+
+// WithDPI sets the number of SVG pixels per inch for FitActualSize
+func WithDPI(dpi float64) Option {
+	return func(p *PDF) error { return p.SetDPI(dpi) }
+}
+
+// WithMargins sets the page margins in points
+func WithMargins(top, right, bottom, left float64) Option {
+	return func(p *PDF) error { return p.SetMargins(top, right, bottom, left) }
+}
+
+// WithFitMode sets how the SVG canvas is scaled onto the page
+func WithFitMode(mode FitMode) Option {
+	return func(p *PDF) error { return p.SetFitMode(mode) }
+}
+
+// WithAlignment sets where the SVG is placed within the margins
+func WithAlignment(a Alignment) Option {
+	return func(p *PDF) error { return p.SetAlignment(a) }
+}
+
+// WithContentOffset moves the SVG by dx, dy points from its aligned position
+func WithContentOffset(dx, dy float64) Option {
+	return func(p *PDF) error {
+		p.SetContentOffset(dx, dy)
+		return nil
+	}
+}
+
+// WithImageColorPolicy sets how color profiles of images are handled
+func WithImageColorPolicy(policy ImageColorPolicy) Option {
+	return func(p *PDF) error { return p.SetImageColorPolicy(policy) }
+}
+
+// WithAutoPageSize sizes each page to its SVG
+func WithAutoPageSize() Option {
+	return func(p *PDF) error {
+		p.SetAutoPageSize(true)
+		return nil
+	}
+}
+
+// WithLineWidth sets the default line width of every page
+func WithLineWidth(width float64) Option {
+	return func(p *PDF) error { return p.SetLineWidth(width) }
+}
+
+// WithLineJoin sets the default line join of every page
+func WithLineJoin(join LineJoin) Option {
+	return func(p *PDF) error { return p.SetLineJoin(join) }
+}
+
+// WithLineCap sets the default line cap of every page
+func WithLineCap(lineCap LineCap) Option {
+	return func(p *PDF) error { return p.SetLineCap(lineCap) }
+}
+
+// WithFlatness sets the flatness tolerance of every page
+func WithFlatness(flatness float64) Option {
+	return func(p *PDF) error { return p.SetFlatness(flatness) }
+}
+
+// WithSmoothness sets the smoothness tolerance of every page
+func WithSmoothness(smoothness float64) Option {
+	return func(p *PDF) error { return p.SetSmoothness(smoothness) }
+}
+
+// WithFontSize sets the default font size in points
+func WithFontSize(size float64) Option {
+	return func(p *PDF) error { return p.SetFontSize(size) }
+}
+
+// New creates an empty document with A4 pages, configured by opts
+func New(opts ...Option) (*PDF, error) {
+	p := NewPDF(0, 0, "Helvetica", 12)
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Convert converts the SVG read from r, writing the PDF to w
+func Convert(r io.Reader, w io.Writer, opts ...Option) error {
+	p, err := New(opts...)
+	if err != nil {
+		return err
+	}
+	if err := p.ConvertSVG(r); err != nil {
+		return err
+	}
+	return p.Write(w)
+}
+
+// pageMargins are the distances in points between the page edges and the
+// area figures are fitted into
+type pageMargins struct {
+	top, right, bottom, left float64
+}
+
+// SetPageSize sets the size in points of pages added after the call, A4 by
+// default. Pages are turned if an orientation is set.
+func (p *PDF) SetPageSize(width, height float64) error {
+	if !(width > 0 && height > 0) {
+		return fmt.Errorf("invalid page size %gx%g", width, height)
+	}
+	p.pageWidth, p.pageHeight = width, height
+	p.orient()
+	return nil
+}
+
+// SetDPI sets how many SVG pixels make an inch when drawing at actual size,
+// 96 (the CSS resolution) by default
+func (p *PDF) SetDPI(dpi float64) error {
+	if !(dpi > 0) {
+		return fmt.Errorf("invalid resolution %g dpi", dpi)
+	}
+	p.dpi = dpi
+	return nil
+}
+
+// SetMargins sets the page margins in points. Figures are fitted within the
+// margins and clipped to them.
+func (p *PDF) SetMargins(top, right, bottom, left float64) error {
+	if !(top >= 0 && right >= 0 && bottom >= 0 && left >= 0) {
+		return fmt.Errorf("invalid margins %g %g %g %g", top, right, bottom, left)
+	}
+	p.margins = &pageMargins{top, right, bottom, left}
+	return nil
+}
+
+// SetAutoPageSize sizes each page to the intrinsic width and height of its
+// SVG instead of the page size, so drawings come out at their physical size
+// (e.g. width="85mm"). Margins and captions are added around the drawing.
+func (p *PDF) SetAutoPageSize(enabled bool) {
+	p.autoPageSize = enabled
+}
+
+// SetFontSize sets the default font size in points, used for text without
+// a font-size and for relative units
+func (p *PDF) SetFontSize(size float64) error {
+	if !(size > 0) {
+		return fmt.Errorf("invalid font size %g", size)
+	}
+	p.fontSize = size
+	return nil
+}
+
+// contentBox returns the area of the page within the margins, in points
+// from the top left corner of the page. Captioned figures keep a default
+// margin when none is set.
+func (p *PDF) contentBox() viewBox {
+	m := p.pageMargins()
+	return viewBox{m.left, m.top, max(p.pageWidth-m.left-m.right, 0), max(p.pageHeight-m.top-m.bottom, 0)}
+}
+
+// pageMargins returns the margins in effect, which default to none, or to
+// the caption margin for captioned figures
+func (p *PDF) pageMargins() pageMargins {
+	switch {
+	case p.margins != nil:
+		return *p.margins
+	case p.caption != nil:
+		return pageMargins{captionMargin, captionMargin, captionMargin, captionMargin}
+	}
+	return pageMargins{}
+}
@@ -0,0 +1,361 @@
+package svg2pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// point is a 2D coordinate
+type point struct {
+	X, Y float64
+}
+
+// segment is a single path command: 'M' (move), 'L' (line), 'C' (cubic
+// curve with two control points) or 'Z' (close)
+type segment struct {
+	Op  byte
+	Pts [3]point
+}
+
+// outline is a sequence of path segments
+type outline []segment
+
+func (o *outline) moveTo(p point) { *o = append(*o, segment{Op: 'M', Pts: [3]point{p}}) }
+
+func (o *outline) lineTo(p point) { *o = append(*o, segment{Op: 'L', Pts: [3]point{p}}) }
+
+func (o *outline) curveTo(c1, c2, p point) {
+	*o = append(*o, segment{Op: 'C', Pts: [3]point{c1, c2, p}})
+}
+
+func (o *outline) close() { *o = append(*o, segment{Op: 'Z'}) }
+
+// quadTo appends a quadratic curve from the current point as a cubic curve
+func (o *outline) quadTo(from, c, p point) {
+	o.curveTo(
+		point{from.X + 2.0/3*(c.X-from.X), from.Y + 2.0/3*(c.Y-from.Y)},
+		point{p.X + 2.0/3*(c.X-p.X), p.Y + 2.0/3*(c.Y-p.Y)},
+		p,
+	)
+}
+
+// ops returns the path construction operators for the outline, with every
+// point mapped through transform and coordinates given with decimals, see
+// appendNumber
+func (o outline) ops(decimals int, transform func(point) point) []string {
+	ops := make([]string, 0, len(o))
+	var b []byte
+	operands := func(pts ...point) []byte {
+		b = b[:0]
+		for _, pt := range pts {
+			pt = transform(pt)
+			b = append(appendNumber(b, pt.X, decimals), ' ')
+			b = append(appendNumber(b, pt.Y, decimals), ' ')
+		}
+		return b
+	}
+	for _, seg := range o {
+		switch seg.Op {
+		case 'M':
+			ops = append(ops, string(operands(seg.Pts[0]))+"m")
+		case 'L':
+			ops = append(ops, string(operands(seg.Pts[0]))+"l")
+		case 'C':
+			ops = append(ops, string(operands(seg.Pts[:]...))+"c")
+		case 'Z':
+			ops = append(ops, "h")
+		}
+	}
+	return ops
+}
+
+// SetTextAsOutlines renders text drawn with embedded fonts as filled paths
+// built from the glyph outlines instead of text operators, so it looks the
+// same on viewers lacking the fonts. Text using the built-in font is not
+// affected, as no outlines are available for it.
+func (p *PDF) SetTextAsOutlines(enabled bool) {
+	p.textAsOutlines = enabled
+}
+
+// WithTextAsOutlines renders text drawn with embedded fonts as glyph
+// outlines
+func WithTextAsOutlines() Option {
+	return func(p *PDF) error {
+		p.SetTextAsOutlines(true)
+		return nil
+	}
+}
+
+// drawTextOutlines renders runs as filled glyph outlines of font, each run
+// drawing the form XObject shared by all runs of the same string
+func (p *PDF) drawTextOutlines(runs []glyphRun, font *Font, fontSize float64) {
+	var stream []string
+	for _, run := range runs {
+		if form := p.outlineForm(run, font, fontSize); form != nil {
+			stream = append(stream, "q "+p.operator("cm", 1, 0, 0, 1, run.X, run.Y)+" /"+form.name+" Do Q")
+		}
+	}
+	p.emit(stream...)
+}
+
+// glyphOutline returns the outline of glyph gid in font units
+func (f *Font) glyphOutline(gid uint16) (outline, error) {
+	if f.cff {
+		return f.cffOutline(gid)
+	}
+	return f.glyfOutline(gid, 0)
+}
+
+// glyfOutline decodes a TrueType glyph, following composite glyph references
+func (f *Font) glyfOutline(gid uint16, depth int) (outline, error) {
+	if depth > 8 {
+		return nil, fmt.Errorf("composite glyph nesting too deep")
+	}
+	glyf, loca, head := f.tables["glyf"], f.tables["loca"], f.tables["head"]
+	if glyf == nil || loca == nil {
+		return nil, fmt.Errorf("font has no glyf outlines")
+	}
+	var start, end int
+	if int16(binary.BigEndian.Uint16(head[50:])) == 0 {
+		if len(loca) < 2*int(gid)+4 {
+			return nil, fmt.Errorf("glyph %d out of range", gid)
+		}
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid):]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid)+2:]))
+	} else {
+		if len(loca) < 4*int(gid)+8 {
+			return nil, fmt.Errorf("glyph %d out of range", gid)
+		}
+		start = int(binary.BigEndian.Uint32(loca[4*int(gid):]))
+		end = int(binary.BigEndian.Uint32(loca[4*int(gid)+4:]))
+	}
+	if start == end {
+		return nil, nil // Empty glyph such as a space
+	}
+	if start > end || end > len(glyf) || end-start < 10 {
+		return nil, fmt.Errorf("invalid glyph %d", gid)
+	}
+	data := glyf[start:end]
+	numContours := int16(binary.BigEndian.Uint16(data))
+	if numContours < 0 {
+		return f.compositeOutline(data[10:], depth)
+	}
+	return simpleGlyphOutline(data[10:], int(numContours))
+}
+
+// simpleGlyphOutline decodes the contours of a simple TrueType glyph
+func simpleGlyphOutline(data []byte, numContours int) (outline, error) {
+	errTruncated := fmt.Errorf("truncated glyph data")
+	if len(data) < 2*numContours+2 {
+		return nil, errTruncated
+	}
+	endPts := make([]int, numContours)
+	for i := range endPts {
+		endPts[i] = int(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	numPoints := 0
+	if numContours > 0 {
+		numPoints = endPts[numContours-1] + 1
+	}
+	pos := 2*numContours + 2 + int(binary.BigEndian.Uint16(data[2*numContours:]))
+
+	// Flags, with run-length repetition
+	flags := make([]byte, 0, numPoints)
+	for len(flags) < numPoints {
+		if pos >= len(data) {
+			return nil, errTruncated
+		}
+		flag := data[pos]
+		pos++
+		flags = append(flags, flag)
+		if flag&0x08 != 0 {
+			if pos >= len(data) {
+				return nil, errTruncated
+			}
+			for n := data[pos]; n > 0 && len(flags) < numPoints; n-- {
+				flags = append(flags, flag)
+			}
+			pos++
+		}
+	}
+
+	// Coordinates are deltas, either short with a sign flag or 16-bit
+	readCoords := func(shortBit, sameBit byte) ([]float64, error) {
+		coords := make([]float64, numPoints)
+		v := 0
+		for i, flag := range flags {
+			switch {
+			case flag&shortBit != 0:
+				if pos >= len(data) {
+					return nil, errTruncated
+				}
+				d := int(data[pos])
+				pos++
+				if flag&sameBit == 0 {
+					d = -d
+				}
+				v += d
+			case flag&sameBit == 0:
+				if pos+2 > len(data) {
+					return nil, errTruncated
+				}
+				v += int(int16(binary.BigEndian.Uint16(data[pos:])))
+				pos += 2
+			}
+			coords[i] = float64(v)
+		}
+		return coords, nil
+	}
+	xs, err := readCoords(0x02, 0x10)
+	if err != nil {
+		return nil, err
+	}
+	ys, err := readCoords(0x04, 0x20)
+	if err != nil {
+		return nil, err
+	}
+
+	var o outline
+	start := 0
+	for _, end := range endPts {
+		if end < start || end >= numPoints {
+			return nil, fmt.Errorf("invalid contour end point")
+		}
+		o.addQuadContour(xs[start:end+1], ys[start:end+1], flags[start:end+1])
+		start = end + 1
+	}
+	return o, nil
+}
+
+// addQuadContour appends a closed contour of on- and off-curve points,
+// inserting the implied on-curve points between consecutive off-curve points
+func (o *outline) addQuadContour(xs, ys []float64, flags []byte) {
+	n := len(xs)
+	if n == 0 {
+		return
+	}
+	at := func(i int) (point, bool) {
+		i %= n
+		return point{xs[i], ys[i]}, flags[i]&0x01 != 0
+	}
+	// Find an on-curve starting point, or synthesize one
+	first := -1
+	for i := 0; i < n; i++ {
+		if _, on := at(i); on {
+			first = i
+			break
+		}
+	}
+	var start point
+	if first < 0 {
+		p0, _ := at(0)
+		p1, _ := at(1)
+		start = point{(p0.X + p1.X) / 2, (p0.Y + p1.Y) / 2}
+		first = 1 // The synthesized start lies between the first two points
+	} else {
+		start, _ = at(first)
+		first++
+	}
+	o.moveTo(start)
+	current := start
+	var control *point
+	for i := 0; i < n; i++ {
+		pt, on := at(first + i)
+		if i == n-1 && pt == start && on {
+			break
+		}
+		switch {
+		case on && control == nil:
+			o.lineTo(pt)
+			current = pt
+		case on:
+			o.quadTo(current, *control, pt)
+			current, control = pt, nil
+		case control == nil:
+			c := pt
+			control = &c
+		default:
+			mid := point{(control.X + pt.X) / 2, (control.Y + pt.Y) / 2}
+			o.quadTo(current, *control, mid)
+			current = mid
+			c := pt
+			control = &c
+		}
+	}
+	if control != nil {
+		o.quadTo(current, *control, start)
+	}
+	o.close()
+}
+
+// compositeOutline assembles a composite glyph from its transformed components
+func (f *Font) compositeOutline(data []byte, depth int) (outline, error) {
+	var o outline
+	for {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("truncated composite glyph")
+		}
+		flags := binary.BigEndian.Uint16(data)
+		gid := binary.BigEndian.Uint16(data[2:])
+		data = data[4:]
+
+		var dx, dy float64
+		if flags&0x0001 != 0 { // Arguments are words
+			if len(data) < 4 {
+				return nil, fmt.Errorf("truncated composite glyph")
+			}
+			dx = float64(int16(binary.BigEndian.Uint16(data)))
+			dy = float64(int16(binary.BigEndian.Uint16(data[2:])))
+			data = data[4:]
+		} else {
+			if len(data) < 2 {
+				return nil, fmt.Errorf("truncated composite glyph")
+			}
+			dx, dy = float64(int8(data[0])), float64(int8(data[1]))
+			data = data[2:]
+		}
+		if flags&0x0002 == 0 {
+			dx, dy = 0, 0 // Point matching is not supported, components stay in place
+		}
+
+		a, b, c, d := 1.0, 0.0, 0.0, 1.0
+		f2dot14 := func(i int) float64 { return float64(int16(binary.BigEndian.Uint16(data[2*i:]))) / 16384 }
+		switch {
+		case flags&0x0008 != 0 && len(data) >= 2:
+			a = f2dot14(0)
+			d = a
+			data = data[2:]
+		case flags&0x0040 != 0 && len(data) >= 4:
+			a, d = f2dot14(0), f2dot14(1)
+			data = data[4:]
+		case flags&0x0080 != 0 && len(data) >= 8:
+			a, b, c, d = f2dot14(0), f2dot14(1), f2dot14(2), f2dot14(3)
+			data = data[8:]
+		}
+
+		component, err := f.glyfOutline(gid, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, seg := range component {
+			for i := range seg.Pts {
+				pt := seg.Pts[i]
+				seg.Pts[i] = point{a*pt.X + c*pt.Y + dx, b*pt.X + d*pt.Y + dy}
+			}
+			o = append(o, seg)
+		}
+		if flags&0x0020 == 0 { // No more components
+			return o, nil
+		}
+	}
+}
+
+// isOutlineFont reports whether face can be drawn as outlines
+func isOutlineFont(face fontFace) (*Font, bool) {
+	font, ok := face.(*Font)
+	if !ok {
+		return nil, false
+	}
+	_, glyf := font.tables["glyf"]
+	return font, glyf || font.cff
+}
@@ -0,0 +1,48 @@
+package svg2pdf
+
+import "fmt"
+
+// PaperSize is a page size in points
+type PaperSize struct {
+	Width, Height float64
+}
+
+// Standard paper sizes, in portrait orientation
+var (
+	A3      = PaperSize{842, 1191}
+	A4      = PaperSize{595, 842}
+	A5      = PaperSize{420, 595}
+	B4      = PaperSize{709, 1001}
+	B5      = PaperSize{499, 709}
+	Letter  = PaperSize{612, 792}
+	Legal   = PaperSize{612, 1008}
+	Tabloid = PaperSize{792, 1224}
+)
+
+// Orientation turns pages upright or sideways
+type Orientation int
+
+// Orientations for SetOrientation; pages keep the size as given by default
+const (
+	Portrait  Orientation = iota + 1 // Height at least the width
+	Landscape                        // Width at least the height
+)
+
+// SetOrientation sets the orientation of pages added after the call,
+// swapping the width and height of the page size as needed
+func (p *PDF) SetOrientation(o Orientation) error {
+	if o != Portrait && o != Landscape {
+		return fmt.Errorf("unknown orientation %d", o)
+	}
+	p.orientation = o
+	p.orient()
+	return nil
+}
+
+// orient swaps the page width and height to match the orientation
+func (p *PDF) orient() {
+	if p.orientation == Portrait && p.pageWidth > p.pageHeight ||
+		p.orientation == Landscape && p.pageWidth < p.pageHeight {
+		p.pageWidth, p.pageHeight = p.pageHeight, p.pageWidth
+	}
+}
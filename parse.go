@@ -0,0 +1,312 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// SetStreamingParse converts SVGs element by element: each child of the
+// root, with its descendants, is decoded, drawn and released before the
+// next one is read, so huge documents such as GIS exports never exist as a
+// whole tree. Elements are drawn in document order. Titles, style sheets,
+// gradients and definitions are only used if they precede the first
+// graphics element, later style sheets and definitions apply to the
+// elements after them, and switches are drawn on a single page. ConvertSVG
+// reads the SVG as it goes unless the source is needed as a whole, for
+// source attachments or profile validation; resource names are then seeded
+// by SetIDSeed only.
+func (p *PDF) SetStreamingParse(enabled bool) {
+	p.streamingParse = enabled
+}
+
+// WithStreamingParse converts SVGs element by element
+func WithStreamingParse() Option {
+	return func(p *PDF) error {
+		p.SetStreamingParse(true)
+		return nil
+	}
+}
+
+// convertStream converts the svg documents read from r one element at a
+// time; size is the length of the source for progress reports, 0 if unknown
+func (p *PDF) convertStream(r io.Reader, size int) error {
+	p.unsupported = nil // Only children of the roots are checked, see streamRoot
+	decoder := newDecoder(r, p.entityBytes())
+	p.reportProgress(ProgressParse, 0, size)
+	for n := 1; ; n++ {
+		if p.canceled() {
+			return p.abortErr
+		}
+		var root xml.StartElement
+		err := p.measure(stageParse, func() error {
+			var err error
+			root, err = nextRoot(decoder)
+			return err
+		})
+		if err == io.EOF && n > 1 {
+			done := int(decoder.InputOffset())
+			p.reportProgress(ProgressParse, done, max(size, done))
+			return nil // Only trailing whitespace or comments remain
+		}
+		if err != nil {
+			return decodeError(n, err, decoder)
+		}
+		if err := p.streamRoot(decoder, root, n, size); err != nil {
+			return err
+		}
+	}
+}
+
+// nextRoot reads up to the start of the next root element, which must be
+// an svg element, declaring the entities of a DOCTYPE before it
+func nextRoot(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if directive, ok := token.(xml.Directive); ok {
+			if err := declareEntities(decoder, directive); err != nil {
+				return xml.StartElement{}, err
+			}
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Space != svgNamespace || start.Name.Local != "svg" {
+				return start, fmt.Errorf("expected element type <svg> but have <%s>", start.Name.Local)
+			}
+			return start, nil
+		}
+	}
+}
+
+// decodeRoot decodes the next root element, which must be an svg element,
+// into svgData
+func decodeRoot(decoder *xml.Decoder, svgData *SVG) error {
+	start, err := nextRoot(decoder)
+	if err != nil {
+		return err
+	}
+	if err := decoder.DecodeElement(svgData, &start); err != nil {
+		return err
+	}
+	svgData.namespaces = namespaceDecls(start.Attr)
+	return nil
+}
+
+// streamRoot converts the nth svg document, started by root, of a source of
+// size bytes (0 if unknown), decoding its
+// children one at a time. The page is started at the first graphics
+// element, once the title, style sheets and definitions before it are
+// known.
+func (p *PDF) streamRoot(decoder *xml.Decoder, root xml.StartElement, n, size int) error {
+	var svgData SVG
+	if err := decodeAttributes(root, &svgData); err != nil {
+		return decodeError(n, err, decoder)
+	}
+	svgData.namespaces = namespaceDecls(root.Attr)
+	var d *rootDrawing
+	var nested []Style // Style sheets of nested svg elements
+	begin := func() error {
+		p.recordDescription(&svgData)
+		p.importStyles(svgData.Styles)
+		nested = p.nestedStyles(&svgData.Container)
+		styles := append(slices.Clip(svgData.Styles), nested...)
+		if err := p.registerFontFaces(styles); err != nil {
+			return err
+		}
+		p.setPropertyRules(styles)
+		d = p.beginRoot(&svgData)
+		return nil
+	}
+	defer func() {
+		if d != nil {
+			d.restore()
+		}
+	}()
+
+	for {
+		if p.canceled() {
+			return p.abortErr
+		}
+		var token xml.Token
+		line, column := decoder.InputPos() // Start of the next tag, after any text
+		err := p.measure(stageParse, func() error {
+			var err error
+			token, err = decoder.Token()
+			return err
+		})
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return decodeError(n, err, decoder)
+		}
+		p.reportProgress(ProgressParse, int(decoder.InputOffset()), size)
+		if _, ok := token.(xml.EndElement); ok {
+			break // End of the root
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		// Elements before the first graphics element set up the page
+		if d == nil && headElements[start.Name.Local] && start.Name.Space == svgNamespace {
+			if err := p.measure(stageParse, func() error { return decodeHead(decoder, start, &svgData) }); err != nil {
+				return elementError(n, err, decoder, start)
+			}
+			continue
+		}
+		if d == nil {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+
+		if start.Name.Space == svgNamespace && start.Name.Local == "style" {
+			var style Style
+			if err := p.measure(stageParse, func() error { return decoder.DecodeElement(&style, &start) }); err != nil {
+				return elementError(n, err, decoder, start)
+			}
+			style.Content = p.expandImports(style.Content, 0)
+			svgData.Styles = append(svgData.Styles, style)
+			if err := p.registerFontFaces([]Style{style}); err != nil {
+				return err
+			}
+			p.setPropertyRules(append(slices.Clip(svgData.Styles), nested...))
+			continue
+		}
+		// Unsupported children are reported, the content of supported ones
+		// is decoded without checks
+		if start.Name.Space == svgNamespace && !p.handled(start.Name.Local) {
+			for _, w := range unsupportedContent(start, line, column) {
+				p.warnAt(w)
+			}
+			if !supportedElements[start.Name.Local] {
+				if err := decoder.Skip(); err != nil {
+					return elementError(n, err, decoder, start)
+				}
+				continue
+			}
+		}
+		var c Container
+		if err := p.measure(stageParse, func() error { return decodeChild(decoder, start, &c) }); err != nil {
+			return elementError(n, err, decoder, start)
+		}
+		if styles := p.nestedStyles(&c); len(styles) > 0 {
+			nested = append(nested, styles...)
+			if err := p.registerFontFaces(styles); err != nil {
+				return err
+			}
+			p.setPropertyRules(append(slices.Clip(svgData.Styles), nested...))
+		}
+		p.measure(stageRender, func() error {
+			p.indexReferences(&c)
+			p.renderContainer(p.selectElement(&c, d), d.ctx)
+			return nil
+		})
+	}
+
+	if d == nil {
+		if err := begin(); err != nil {
+			return err
+		}
+	}
+	if err := p.measure(stageRender, func() error { return p.endRoot(d) }); err != nil {
+		return err
+	}
+	return p.abortErr
+}
+
+// headElements are the children of the root used when they precede the
+// first graphics element
+var headElements = setOf("title", "desc", "style", "linearGradient", "defs", "symbol", "clipPath", "filter")
+
+// decodeAttributes decodes the attributes of start into v, without its
+// content
+func decodeAttributes(start xml.StartElement, v any) error {
+	head := &tokenList{start, start.End()}
+	return xml.NewTokenDecoder(head).Decode(v)
+}
+
+// tokenList reads a fixed list of tokens
+type tokenList []xml.Token
+
+func (l *tokenList) Token() (xml.Token, error) {
+	if len(*l) == 0 {
+		return nil, io.EOF
+	}
+	token := (*l)[0]
+	*l = (*l)[1:]
+	return token, nil
+}
+
+// decodeHead decodes a head element started by start into svgData
+func decodeHead(decoder *xml.Decoder, start xml.StartElement, svgData *SVG) error {
+	switch start.Name.Local {
+	case "title":
+		return decoder.DecodeElement(&svgData.Title, &start)
+	case "desc":
+		return decoder.DecodeElement(&svgData.Desc, &start)
+	case "style":
+		var style Style
+		err := decoder.DecodeElement(&style, &start)
+		svgData.Styles = append(svgData.Styles, style)
+		return err
+	case "linearGradient":
+		var gradient Gradient
+		err := decoder.DecodeElement(&gradient, &start)
+		svgData.Gradients = append(svgData.Gradients, gradient)
+		return err
+	}
+	return decodeChild(decoder, start, &svgData.Container)
+}
+
+// decodeChild decodes the element started by start into c, skipping
+// elements c does not hold
+func decodeChild(decoder *xml.Decoder, start xml.StartElement, c *Container) error {
+	if start.Name.Space != svgNamespace {
+		return decodeAppend(decoder, start, &c.Extensions)
+	}
+	switch start.Name.Local {
+	case "rect":
+		return decodeAppend(decoder, start, &c.Rects)
+	case "text":
+		return decodeAppend(decoder, start, &c.Texts)
+	case "path":
+		return decodeAppend(decoder, start, &c.Paths)
+	case "image":
+		return decodeAppend(decoder, start, &c.Images)
+	case "svg":
+		return decodeAppend(decoder, start, &c.SVGs)
+	case "symbol":
+		return decodeAppend(decoder, start, &c.Symbols)
+	case "use":
+		return decodeAppend(decoder, start, &c.Uses)
+	case "defs":
+		return decodeAppend(decoder, start, &c.Defs)
+	case "clipPath":
+		return decodeAppend(decoder, start, &c.Clips)
+	case "filter":
+		return decodeAppend(decoder, start, &c.Filters)
+	case "foreignObject":
+		return decodeAppend(decoder, start, &c.Foreign)
+	case "g":
+		return decodeAppend(decoder, start, &c.Groups)
+	case "switch":
+		return decodeAppend(decoder, start, &c.Switches)
+	}
+	return decodeAppend(decoder, start, &c.Extensions)
+}
+
+// decodeAppend decodes the element started by start onto the end of list
+func decodeAppend[T any](decoder *xml.Decoder, start xml.StartElement, list *[]T) error {
+	var v T
+	if err := decoder.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*list = append(*list, v)
+	return nil
+}
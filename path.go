@@ -0,0 +1,429 @@
+package svg2pdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Path represents an SVG path element
+type Path struct {
+	D          string `xml:"d,attr"`
+	Fill       string `xml:"fill,attr"`
+	FillRule   string `xml:"fill-rule,attr"`
+	ClipRule   string `xml:"clip-rule,attr"` // Applies within a clipPath
+	Stroke     string `xml:"stroke,attr"`
+	Clip       string `xml:"clip-path,attr"`
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Blend      string `xml:"mix-blend-mode,attr"`
+	FilterRef  string `xml:"filter,attr"` // Reference to a filter element, url(#id)
+	Conditions
+	Inline string   `xml:"style,attr"` // Inline CSS declarations
+	markup *Element // Source of filtered paths, for the Rasterizer
+}
+
+// evenOdd reports whether the fill-rule or clip-rule of a path, as
+// cascaded from its attribute, the style sheets and its style attribute, is
+// evenodd rather than the default nonzero
+func (p *PDF) evenOdd(property string, path Path) bool {
+	attr := path.FillRule
+	if property == "clip-rule" {
+		attr = path.ClipRule
+	}
+	return p.cascade(property, "path", path.ID, path.Class, attr, path.Inline) == "evenodd"
+}
+
+// drawPath paints a path element. Path data is converted while it is
+// scanned, so huge d attributes (e.g. from GIS exports) never exist as a
+// token list; only the resulting operators are buffered.
+func (p *PDF) drawPath(path Path, ctx unitContext) {
+	var b strings.Builder
+	b.Grow(len(path.D)) // Operators take about as much space as the data
+	b.WriteString("q\n")
+
+	// Fill defaults to black and stroke to none, as in SVG
+	fillValue := p.cascade("fill", "path", path.ID, path.Class, path.Fill, path.Inline)
+	fill, stroke := fillValue != "none", false
+	if fill {
+		c, ok := parseColor(fillValue)
+		if !ok {
+			c = RGB{0, 0, 0}
+		}
+		b.WriteString(p.fillOp(c) + "\n")
+	}
+	if c, ok := parseColor(p.cascade("stroke", "path", path.ID, path.Class, path.Stroke, path.Inline)); ok {
+		stroke = true
+		b.WriteString(p.strokeOp(c) + "\n")
+	}
+	if !(fill || stroke) {
+		return
+	}
+	bbox, segments := writePathData(&b, path.D, p.decimals(), p.pathSegmentLimit())
+	if max := p.pathSegmentLimit(); max > 0 && segments > max {
+		p.exceed("PathSegments", float64(max))
+		return
+	}
+	if segments == 0 {
+		if strings.TrimSpace(path.D) != "" {
+			p.warn("path", path.ID, "path data has no drawable segments")
+		}
+		return // Nothing to paint
+	}
+	if p.redacts("path", path.ID, path.Class) {
+		p.drawRedaction(bbox)
+		return
+	}
+
+	evenOdd := p.evenOdd("fill-rule", path)
+	switch {
+	case fill && stroke && evenOdd:
+		b.WriteString("B*\n")
+	case fill && stroke:
+		b.WriteString("B\n")
+	case fill && evenOdd:
+		b.WriteString("f*\n")
+	case fill:
+		b.WriteString("f\n")
+	default:
+		b.WriteString("S\n")
+	}
+	b.WriteString("Q")
+
+	clip := p.clipOps(path.Clip, bbox, ctx)
+	p.emit(clip...)
+	p.emit(b.String())
+	if clip != nil {
+		p.emit("Q")
+	}
+	p.rendered("path", path.ID)
+}
+
+// pathScanner reads commands, numbers and flags from path data in place
+type pathScanner struct {
+	d   string
+	pos int
+}
+
+// skipSeparators skips whitespace and commas
+func (s *pathScanner) skipSeparators() {
+	for s.pos < len(s.d) {
+		switch s.d[s.pos] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// command returns the next command letter, if the next token is one
+func (s *pathScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.pos < len(s.d) && strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", s.d[s.pos]) >= 0 {
+		s.pos++
+		return s.d[s.pos-1], true
+	}
+	return 0, false
+}
+
+// atNumber reports whether a number follows, i.e. the current command is
+// repeated with another set of arguments
+func (s *pathScanner) atNumber() bool {
+	s.skipSeparators()
+	if s.pos >= len(s.d) {
+		return false
+	}
+	c := s.d[s.pos]
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.'
+}
+
+// number reads a number. Numbers need no separator where the grammar is
+// unambiguous, e.g. "1.5.5" or "3-4".
+func (s *pathScanner) number() (float64, bool) {
+	s.skipSeparators()
+	start := s.pos
+	if s.pos < len(s.d) && (s.d[s.pos] == '-' || s.d[s.pos] == '+') {
+		s.pos++
+	}
+	digits := s.digits()
+	if s.pos < len(s.d) && s.d[s.pos] == '.' {
+		s.pos++
+		digits += s.digits()
+	}
+	if digits == 0 {
+		s.pos = start
+		return 0, false
+	}
+	if s.pos < len(s.d) && (s.d[s.pos] == 'e' || s.d[s.pos] == 'E') {
+		// Only an exponent if digits follow, "e" is no command letter
+		mark := s.pos
+		s.pos++
+		if s.pos < len(s.d) && (s.d[s.pos] == '-' || s.d[s.pos] == '+') {
+			s.pos++
+		}
+		if s.digits() == 0 {
+			s.pos = mark
+		}
+	}
+	v, err := strconv.ParseFloat(s.d[start:s.pos], 64)
+	return v, err == nil
+}
+
+// digits skips a run of decimal digits, returning its length
+func (s *pathScanner) digits() int {
+	start := s.pos
+	for s.pos < len(s.d) && s.d[s.pos] >= '0' && s.d[s.pos] <= '9' {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+// flag reads an arc flag, a single 0 or 1 that needs no separator
+func (s *pathScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.pos < len(s.d) && (s.d[s.pos] == '0' || s.d[s.pos] == '1') {
+		s.pos++
+		return s.d[s.pos-1] == '1', true
+	}
+	return false, false
+}
+
+// numbers reads len(v) numbers into v
+func (s *pathScanner) numbers(v []float64) bool {
+	for i := range v {
+		n, ok := s.number()
+		if !ok {
+			return false
+		}
+		v[i] = n
+	}
+	return true
+}
+
+// pathWriter appends PDF path construction operators, tracking the state
+// relative and smooth commands depend on
+type pathWriter struct {
+	b              *strings.Builder
+	x, y           float64 // Current point
+	startX, startY float64 // Start of the current subpath
+	ctrlX, ctrlY   float64 // Reflected control point for S and T
+	quadX, quadY   float64 // Last quadratic control point, for T
+	lastCmd        byte
+	decimals       int // Decimals of coordinates, see appendNumber
+	scratch        []byte
+	segments       int
+	// Bounds of all end and control points, which contain the path
+	minX, minY, maxX, maxY float64
+}
+
+// writeOp appends the coordinates and the operator
+func (w *pathWriter) writeOp(op string, coords ...float64) {
+	for i, v := range coords {
+		if i%2 == 0 {
+			w.minX, w.maxX = min(w.minX, v), max(w.maxX, v)
+		} else {
+			w.minY, w.maxY = min(w.minY, v), max(w.maxY, v)
+		}
+		w.scratch = appendNumber(w.scratch[:0], v, w.decimals)
+		w.b.Write(w.scratch)
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(op)
+	w.b.WriteByte('\n')
+	w.segments++
+}
+
+func (w *pathWriter) moveTo(x, y float64) {
+	w.writeOp("m", x, y)
+	w.x, w.y, w.startX, w.startY = x, y, x, y
+}
+
+func (w *pathWriter) lineTo(x, y float64) {
+	w.writeOp("l", x, y)
+	w.x, w.y = x, y
+}
+
+func (w *pathWriter) curveTo(x1, y1, x2, y2, x, y float64) {
+	w.writeOp("c", x1, y1, x2, y2, x, y)
+	w.ctrlX, w.ctrlY = 2*x-x2, 2*y-y2
+	w.x, w.y = x, y
+}
+
+func (w *pathWriter) quadTo(qx, qy, x, y float64) {
+	// Degree elevation of the quadratic curve
+	w.curveTo(w.x+2.0/3*(qx-w.x), w.y+2.0/3*(qy-w.y), x+2.0/3*(qx-x), y+2.0/3*(qy-y), x, y)
+	w.quadX, w.quadY = 2*x-qx, 2*y-qy
+}
+
+// writePathData appends the operators for path data d to b, returning the
+// bounding box of the control points and the number of segments written.
+// As in SVG, data following an error is ignored and everything before it is
+// drawn. Coordinates have the given decimals, see appendNumber. Scanning
+// stops once more than limit segments are written, unless limit is 0.
+func writePathData(b *strings.Builder, d string, decimals, limit int) (viewBox, int) {
+	s := &pathScanner{d: d}
+	w := &pathWriter{b: b, decimals: decimals, scratch: make([]byte, 0, 32)}
+	w.minX, w.minY = math.Inf(1), math.Inf(1)
+	w.maxX, w.maxY = math.Inf(-1), math.Inf(-1)
+	var args [7]float64
+	for {
+		cmd, ok := s.command()
+		if !ok {
+			break
+		}
+		if w.lastCmd == 0 && cmd != 'M' && cmd != 'm' {
+			break // Path data must start with a moveto
+		}
+		if !w.segment(s, cmd, args[:]) || (limit > 0 && w.segments > limit) {
+			break
+		}
+	}
+	if w.segments == 0 {
+		return viewBox{}, 0
+	}
+	return viewBox{w.minX, w.minY, w.maxX - w.minX, w.maxY - w.minY}, w.segments
+}
+
+// segment draws the segments of one command and its implicit repetitions
+func (w *pathWriter) segment(s *pathScanner, cmd byte, args []float64) bool {
+	rel := cmd >= 'a'
+	upper := cmd &^ 0x20
+	for first := true; first || (upper != 'Z' && s.atNumber()); first = false {
+		var ox, oy float64 // Origin of relative coordinates
+		if rel {
+			ox, oy = w.x, w.y
+		}
+		// Control points are only reflected after curves of the same kind
+		prev := w.lastCmd &^ 0x20
+		w.lastCmd = cmd
+		switch upper {
+		case 'M':
+			if !s.numbers(args[:2]) {
+				return false
+			}
+			if first {
+				w.moveTo(ox+args[0], oy+args[1])
+			} else {
+				w.lineTo(ox+args[0], oy+args[1]) // Extra pairs are lines
+			}
+		case 'L':
+			if !s.numbers(args[:2]) {
+				return false
+			}
+			w.lineTo(ox+args[0], oy+args[1])
+		case 'H':
+			if !s.numbers(args[:1]) {
+				return false
+			}
+			w.lineTo(ox+args[0], w.y)
+		case 'V':
+			if !s.numbers(args[:1]) {
+				return false
+			}
+			w.lineTo(w.x, oy+args[0])
+		case 'C':
+			if !s.numbers(args[:6]) {
+				return false
+			}
+			w.curveTo(ox+args[0], oy+args[1], ox+args[2], oy+args[3], ox+args[4], oy+args[5])
+		case 'S':
+			if !s.numbers(args[:4]) {
+				return false
+			}
+			x1, y1 := w.x, w.y
+			if prev == 'C' || prev == 'S' {
+				x1, y1 = w.ctrlX, w.ctrlY
+			}
+			w.curveTo(x1, y1, ox+args[0], oy+args[1], ox+args[2], oy+args[3])
+		case 'Q':
+			if !s.numbers(args[:4]) {
+				return false
+			}
+			w.quadTo(ox+args[0], oy+args[1], ox+args[2], oy+args[3])
+		case 'T':
+			if !s.numbers(args[:2]) {
+				return false
+			}
+			qx, qy := w.x, w.y
+			if prev == 'Q' || prev == 'T' {
+				qx, qy = w.quadX, w.quadY
+			}
+			w.quadTo(qx, qy, ox+args[0], oy+args[1])
+		case 'A':
+			if !s.numbers(args[:3]) {
+				return false
+			}
+			large, ok1 := s.flag()
+			sweep, ok2 := s.flag()
+			if !ok1 || !ok2 || !s.numbers(args[3:5]) {
+				return false
+			}
+			w.arcTo(args[0], args[1], args[2], large, sweep, ox+args[3], oy+args[4])
+		case 'Z':
+			w.writeOp("h")
+			w.x, w.y = w.startX, w.startY
+		}
+	}
+	return true
+}
+
+// arcTo approximates an elliptical arc with cubic curves of at most 90
+// degrees, following the endpoint to center conversion of the SVG
+// implementation notes
+func (w *pathWriter) arcTo(rx, ry, angle float64, large, sweep bool, x, y float64) {
+	if x == w.x && y == w.y {
+		return // Omitted
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		w.lineTo(x, y)
+		return
+	}
+	phi := angle * math.Pi / 180
+	sin, cos := math.Sincos(phi)
+
+	// Midpoint in the rotated frame, with radii scaled up if too small
+	dx, dy := (w.x-x)/2, (w.y-y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(num, 0) / den)
+	if large == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(w.x+x)/2, sin*cx1+cos*cy1+(w.y+y)/2
+
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(delta)/(math.Pi/2) - 1e-9))
+	step := delta / float64(n)
+	t := 4.0 / 3 * math.Tan(step/4)
+	point := func(a float64) (float64, float64, float64, float64) {
+		s, c := math.Sincos(a)
+		// Point and derivative on the ellipse
+		return cx + rx*c*cos - ry*s*sin, cy + rx*c*sin + ry*s*cos,
+			-rx*s*cos - ry*c*sin, -rx*s*sin + ry*c*cos
+	}
+	for i := 0; i < n; i++ {
+		a1, a2 := theta+float64(i)*step, theta+float64(i+1)*step
+		px1, py1, dx1, dy1 := point(a1)
+		px2, py2, dx2, dy2 := point(a2)
+		if i == n-1 {
+			px2, py2 = x, y // Land exactly on the endpoint
+		}
+		w.curveTo(px1+t*dx1, py1+t*dy1, px2-t*dx2, py2-t*dy2, px2, py2)
+	}
+}
@@ -0,0 +1,36 @@
+package svg2pdf
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gisPathData returns path data of about size bytes, as exported by GIS
+// tools: long polylines with fractional coordinates
+func gisPathData(size int) string {
+	var b strings.Builder
+	b.Grow(size + 64)
+	for i := 0; b.Len() < size; i++ {
+		if i%10000 == 0 {
+			b.WriteString("M")
+		} else {
+			b.WriteString(" L")
+		}
+		b.WriteString(strconv.FormatFloat(float64(i%4096)*0.173, 'f', 3, 64))
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatFloat(float64(i%3001)*0.291, 'f', 3, 64))
+	}
+	return b.String()
+}
+
+func BenchmarkWritePathData(b *testing.B) {
+	d := gisPathData(100 << 20)
+	b.SetBytes(int64(len(d)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out strings.Builder
+		writePathData(&out, d, 2, 0)
+	}
+}
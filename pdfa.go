@@ -0,0 +1,88 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetPDFA makes documents conform to PDF/A-2b for long-term archiving:
+// all fonts must be embedded, an sRGB output intent and XMP metadata with
+// the PDF/A identification are written, and the trailer gets a file
+// identifier. Write fails if the document uses anything PDF/A forbids, such
+// as text in the built-in Helvetica, which is never embedded.
+func (p *PDF) SetPDFA(enabled bool) {
+	p.pdfa = enabled
+}
+
+// WithPDFA makes documents conform to PDF/A-2b
+func WithPDFA() Option {
+	return func(p *PDF) error {
+		p.SetPDFA(true)
+		return nil
+	}
+}
+
+// pdfaViolations returns the reasons the document cannot conform to PDF/A
+func (p *PDF) pdfaViolations() []string {
+	var violations []string
+	for i, content := range p.content {
+		if usesStandardFont(content.String()) {
+			violations = append(violations, fmt.Sprintf("page %d draws text in the built-in Helvetica, which is not embedded; register a font for it", i+1))
+		}
+	}
+	if p.watermark != nil && p.watermark.svg == nil {
+		violations = append(violations, "the text watermark is drawn in the built-in Helvetica, which is not embedded")
+	}
+	if p.header != nil || p.footer != nil {
+		violations = append(violations, "headers and footers are drawn in the built-in Helvetica, which is not embedded")
+	}
+	for _, font := range p.fonts {
+		if len(font.used) > 0 && font.embeddingRestriction() != "" {
+			violations = append(violations, fmt.Sprintf("font %q does not permit embedding", font.Family))
+		}
+	}
+	for _, img := range p.images {
+		if img.colorSpace == "/DeviceCMYK" && img.profile == nil {
+			violations = append(violations, fmt.Sprintf("CMYK image %s has no ICC profile", img.name))
+		}
+	}
+	if p.cmyk != nil {
+		violations = append(violations, "CMYK output replaces the sRGB output intent")
+	}
+	if p.encryption != nil {
+		violations = append(violations, "encryption is not permitted")
+	}
+	if p.report {
+		violations = append(violations, "the embedded conversion report is not a PDF/A file")
+	}
+	if len(p.sources) > 0 {
+		violations = append(violations, "the attached source SVG is not a PDF/A file")
+	}
+	if _, custom := p.catalogEntries["Metadata"]; custom {
+		violations = append(violations, "a custom /Metadata catalog entry replaces the PDF/A metadata")
+	}
+	return violations
+}
+
+// usesStandardFont reports whether a content stream selects the built-in font
+func usesStandardFont(content string) bool {
+	name := "/" + standardFont{}.resourceName()
+	ops, ok := parseContentOps(content)
+	if !ok {
+		return strings.Contains(content, name+" ") // Conservative for unparsable streams
+	}
+	for _, op := range ops {
+		if op.operator == "Tf" && len(op.operands) > 0 && op.operands[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// outputIntent returns the catalog entry declaring the sRGB output intent
+// whose profile is object profileObj
+func outputIntent(profileObj int) string {
+	return "/OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 " +
+		"/OutputConditionIdentifier (sRGB IEC61966-2.1) /Info (sRGB IEC61966-2.1) " +
+		"/RegistryName (http://www.color.org) /DestOutputProfile " + ref(profileObj) + " >>]"
+}
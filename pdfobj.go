@@ -0,0 +1,181 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Name is a PDF name object, written as /Name
+type Name string
+
+// Dict is a PDF dictionary. Keys are names without the leading slash.
+type Dict map[string]any
+
+// Array is a PDF array
+type Array []any
+
+// Stream is a PDF stream object; /Length is added when the document is
+// written. Data is written as is, encoded as declared by any /Filter entry.
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+// Ref is a handle to an indirect object added with AddObject. The object
+// number is only assigned when the document is saved.
+type Ref struct {
+	doc   *PDF
+	index int
+}
+
+// Custom objects may hold nil, bool, integers, float64, string (written as
+// a literal string), []byte (written as a hex string), Name, Dict, Array and
+// Ref values. Streams are only allowed as the value of an indirect object.
+
+// AddObject adds v to the document as an indirect object and returns a
+// reference to it, for use in other custom objects and entries
+func (p *PDF) AddObject(v any) Ref {
+	p.objects = append(p.objects, v)
+	return Ref{doc: p, index: len(p.objects) - 1}
+}
+
+// SetObject replaces the value of an object added with AddObject, e.g. to
+// build objects referring to each other
+func (p *PDF) SetObject(r Ref, v any) error {
+	if r.doc != p || r.index < 0 || r.index >= len(p.objects) {
+		return fmt.Errorf("invalid object reference")
+	}
+	p.objects[r.index] = v
+	return nil
+}
+
+// reservedCatalogKeys and reservedPageKeys are managed by the package and
+// cannot be set through the object API
+var (
+	reservedCatalogKeys = []string{"Type", "Pages"}
+	reservedPageKeys    = []string{"Type", "Parent", "Contents", "Resources", "MediaBox"}
+)
+
+// SetCatalogEntry adds an entry to the document catalog, e.g. /Metadata or
+// /OpenAction
+func (p *PDF) SetCatalogEntry(key string, v any) error {
+	if slices.Contains(reservedCatalogKeys, key) {
+		return fmt.Errorf("catalog entry /%s is reserved", key)
+	}
+	if p.catalogEntries == nil {
+		p.catalogEntries = make(Dict)
+	}
+	p.catalogEntries[key] = v
+	return nil
+}
+
+// SetPageEntry adds an entry to the dictionary of the page at index i, e.g.
+// /Annots to attach custom annotations
+func (p *PDF) SetPageEntry(i int, key string, v any) error {
+	if i < 0 || i >= p.pageCount {
+		return fmt.Errorf("page index %d out of range [0, %d)", i, p.pageCount)
+	}
+	if slices.Contains(reservedPageKeys, key) {
+		return fmt.Errorf("page entry /%s is reserved", key)
+	}
+	if p.pageEntries[i] == nil {
+		p.pageEntries[i] = make(Dict)
+	}
+	p.pageEntries[i][key] = v
+	return nil
+}
+
+// objectWriter serializes custom values once object numbers are known
+type objectWriter struct {
+	doc   *PDF
+	first int // Number of the first custom object
+}
+
+// write writes custom value v as indirect object num
+func (w objectWriter) write(pw *pdfWriter, num int, v any) error {
+	if stream, ok := v.(Stream); ok {
+		dict, err := w.entries(stream.Dict)
+		if err != nil {
+			return err
+		}
+		pw.rawStream(num, dict, stream.Data) // Written as given, e.g. for XMP metadata
+		return nil
+	}
+	s, err := w.value(v)
+	if err != nil {
+		return err
+	}
+	pw.object(num, s)
+	return nil
+}
+
+// value returns the PDF syntax of a direct value
+func (w objectWriter) value(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("invalid number %v", v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case string:
+		return "(" + escapeText(v) + ")", nil
+	case []byte:
+		return fmt.Sprintf("<%X>", v), nil
+	case Name:
+		return "/" + sanitizeFontName(string(v)), nil
+	case Ref:
+		if v.doc != w.doc || v.index < 0 || v.index >= len(w.doc.objects) {
+			return "", fmt.Errorf("reference to an object of another document")
+		}
+		return fmt.Sprintf("%d 0 R", w.first+v.index), nil
+	case Array:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := w.value(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return "[" + strings.Join(items, " ") + "]", nil
+	case Dict:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys) // Deterministic output
+		entries := make([]string, 0, len(v))
+		for _, k := range keys {
+			s, err := w.value(v[k])
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, "/"+sanitizeFontName(k)+" "+s)
+		}
+		return "<< " + strings.Join(entries, " ") + " >>", nil
+	case Stream:
+		return "", fmt.Errorf("streams must be indirect objects")
+	}
+	return "", fmt.Errorf("unsupported object value of type %T", v)
+}
+
+// entries returns the lines of a dictionary's entries, for merging custom
+// entries into dictionaries written by the package
+func (w objectWriter) entries(d Dict) ([]string, error) {
+	s, err := w.value(d)
+	if err != nil || len(d) == 0 {
+		return nil, err
+	}
+	return []string{strings.TrimSuffix(strings.TrimPrefix(s, "<< "), " >>")}, nil
+}
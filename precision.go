@@ -0,0 +1,132 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxPrecision is the most decimals coordinates may be written with
+const maxPrecision = 6
+
+// defaultPrecision is the number of decimals of coordinates in points
+const defaultPrecision = 2
+
+// SetPrecision sets the number of decimals coordinates, lengths and font
+// sizes are written with, e.g. 1 for "12.5" instead of "12.46"; trailing
+// zeros are always dropped.
+// Path-heavy documents shrink considerably at 1 or 0 decimals, as a point
+// is 1/72 inch. The decimals are those of points on the page: coordinates
+// in user spaces scaled up, such as a small viewBox on a large page, get
+// as many more as the scale needs. By default coordinates have two.
+func (p *PDF) SetPrecision(decimals int) error {
+	if decimals < 0 || decimals > maxPrecision {
+		return fmt.Errorf("invalid precision %d, want 0 to %d decimals", decimals, maxPrecision)
+	}
+	p.precision = &decimals
+	return nil
+}
+
+// WithPrecision sets the number of decimals of coordinates
+func WithPrecision(decimals int) Option {
+	return func(p *PDF) error {
+		return p.SetPrecision(decimals)
+	}
+}
+
+// decimals returns the number of decimals of coordinates in the current
+// user space, those of the precision plus those its scale to points needs
+func (p *PDF) decimals() int {
+	d := defaultPrecision
+	if p.precision != nil {
+		d = *p.precision
+	}
+	if p.userScale > 0 {
+		// A user unit of 10^n points needs n more decimals
+		d += int(math.Ceil(math.Log10(p.userScale) - 1e-9))
+	}
+	return min(max(d, 0), maxUserDecimals)
+}
+
+// maxUserDecimals is the most decimals of coordinates in scaled user spaces,
+// about the precision of float64 for coordinates of a page
+const maxUserDecimals = 12
+
+// scaleUserSpace scales the current user space by sx and sy, for a
+// coordinate system nested in it, returning a function restoring it
+func (p *PDF) scaleUserSpace(sx, sy float64) func() {
+	saved, base := p.userScale, p.userScale
+	if base == 0 {
+		base = 1 // Page space
+	}
+	scaled := base * max(math.Abs(sx), math.Abs(sy))
+	if scaled > 0 && !math.IsInf(scaled, 0) {
+		p.userScale = scaled
+	}
+	return func() { p.userScale = saved }
+}
+
+// appendNumber appends v with the given number of decimals and without
+// trailing zeros
+func appendNumber(dst []byte, v float64, decimals int) []byte {
+	start := len(dst)
+	dst = strconv.AppendFloat(dst, v, 'f', decimals, 64)
+	if bytes.IndexByte(dst[start:], '.') >= 0 {
+		dst = bytes.TrimRight(dst, "0")
+		dst = bytes.TrimSuffix(dst, []byte("."))
+	}
+	if string(dst[start:]) == "-0" {
+		dst = append(dst[:start], '0')
+	}
+	return dst
+}
+
+// colorDecimals is the number of decimals of color components and opacities
+const colorDecimals = 3
+
+// appendFactor appends a scale factor of a matrix, with four significant
+// decimals however small it is
+func appendFactor(dst []byte, v float64) []byte {
+	decimals := 4
+	if a := math.Abs(v); a > 0 && a < 1 {
+		decimals = min(4-int(math.Floor(math.Log10(a))), maxUserDecimals)
+	}
+	return appendNumber(dst, v, decimals)
+}
+
+// appendOperator appends an operator line with numeric operands
+func appendOperator(dst []byte, op string, decimals int, operands ...float64) []byte {
+	for _, v := range operands {
+		dst = append(appendNumber(dst, v, decimals), ' ')
+	}
+	return append(dst, op...)
+}
+
+// operator returns an operator line with numeric operands, coordinates of
+// the current user space
+func (p *PDF) operator(op string, operands ...float64) string {
+	return string(appendOperator(nil, op, p.decimals(), operands...))
+}
+
+// transform returns the line of op, cm or Tm, with the matrix a b c d e f:
+// a translation in the current user space after the scale factors
+func (p *PDF) transform(op string, a, b, c, d, e, f float64) string {
+	var dst []byte
+	for _, v := range []float64{a, b, c, d} {
+		dst = append(appendFactor(dst, v), ' ')
+	}
+	return string(appendOperator(dst, op, p.decimals(), e, f))
+}
+
+// formatNumbers returns values with the given decimals, separated by spaces
+func formatNumbers(decimals int, values ...float64) string {
+	var dst []byte
+	for i, v := range values {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = appendNumber(dst, v, decimals)
+	}
+	return string(dst)
+}
@@ -0,0 +1,76 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestPrecisionScaledUserSpace(t *testing.T) {
+	// A user unit of about 600 points needs 3 more decimals than points
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="500" height="500" viewBox="0 0 1 1">` +
+		`<path d="M0.33333 0.33333L0.66667 0.33333"/>` +
+		`<svg viewBox="0 0 1000 1000" width="1" height="1"><path d="M333.333 0L500 500"/></svg></svg>`
+	tests := []struct {
+		opts []Option
+		want []string
+	}{
+		{nil, []string{"\n0.33333 0.33333 m\n0.66667 0.33333 l\n", "\n333.33 0 m\n500 500 l\n"}},
+		{[]Option{WithPrecision(0)}, []string{"\n0.333 0.333 m\n0.667 0.333 l\n", "\n333 0 m\n500 500 l\n"}},
+	}
+	for _, tt := range tests {
+		out := convert(t, svg, tt.opts...)
+		for _, want := range tt.want {
+			if !bytes.Contains(out, []byte(want)) {
+				t.Errorf("%d options: %q not written", len(tt.opts), want)
+			}
+		}
+	}
+}
+
+func TestAppendNumber(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		want     string
+	}{
+		{12.5, 2, "12.5"},
+		{12, 2, "12"},
+		{0.001, 2, "0"},
+		{-0.001, 2, "0"},
+		{-1.255, 1, "-1.3"},
+		{0.33333, 5, "0.33333"},
+		{100, 0, "100"},
+	}
+	for _, tt := range tests {
+		if got := string(appendNumber(nil, tt.v, tt.decimals)); got != tt.want {
+			t.Errorf("appendNumber(%g, %d) = %q, want %q", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestNumberFormat(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="300" height="200">` +
+		`<clipPath id="c"><rect x="5" y="5" width="100" height="100"/></clipPath>` +
+		`<rect x="10" y="10" width="50.5" height="20"/>` +
+		`<text x="10.25" y="80" font-size="12" clip-path="url(#c)">Label</text>` +
+		`<svg x="100" y="50" width="50" height="50" viewBox="0 0 10 10"><path d="M1 1L9 9" stroke="black"/></svg>` +
+		`</svg>`
+	out := convert(t, svg, WithFooter(PageTemplate{Center: "Page {page}"}))
+	// Numbers are written without trailing zeros, in every operator
+	if m := regexp.MustCompile(`[^\w.]\d+\.\d*0[\s\]]`).Find(out); m != nil {
+		t.Errorf("number with trailing zeros %q", m)
+	}
+	for _, want := range []string{"\n0 0 0 RG\n", "\n60.5 10 l\n", "\n5 0 0 5 100 50 cm\n", " 10.25 80 Tm\n", "/F1 12 Tf"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("%q not written", want)
+		}
+	}
+
+	// Fewer decimals make smaller documents
+	paths := `<svg xmlns="http://www.w3.org/2000/svg" width="300" height="300"><path d="` + gisPathData(1<<14) + `"/></svg>`
+	full, short := convert(t, paths), convert(t, paths, WithPrecision(0))
+	if len(short) >= len(full)*9/10 {
+		t.Errorf("precision 0 document of %d bytes, default %d", len(short), len(full))
+	}
+}
@@ -0,0 +1,149 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SVGProfile selects the SVG language level documents are validated
+// against before conversion
+type SVGProfile int
+
+// SVG profiles
+const (
+	SVG2Profile      SVGProfile = iota // SVG 2; unsupported elements are ignored, the default
+	SVGFull11Profile                   // SVG 1.1 Full; elements outside it are rejected
+	SVGTiny12Profile                   // SVG Tiny 1.2, for embedded devices; elements and attributes outside it are rejected
+)
+
+// String returns the name of the profile
+func (p SVGProfile) String() string {
+	switch p {
+	case SVG2Profile:
+		return "SVG 2"
+	case SVGFull11Profile:
+		return "SVG 1.1 Full"
+	case SVGTiny12Profile:
+		return "SVG Tiny 1.2"
+	}
+	return fmt.Sprintf("SVGProfile(%d)", int(p))
+}
+
+// SetSVGProfile sets the profile SVG documents must conform to. With a
+// profile other than SVG2Profile, conversion fails on the first element
+// the profile does not define instead of skipping it.
+func (p *PDF) SetSVGProfile(profile SVGProfile) error {
+	if profile < SVG2Profile || profile > SVGTiny12Profile {
+		return fmt.Errorf("unknown SVG profile %d", profile)
+	}
+	p.svgProfile = profile
+	return nil
+}
+
+// WithSVGProfile sets the profile SVG documents must conform to
+func WithSVGProfile(profile SVGProfile) Option {
+	return func(p *PDF) error {
+		return p.SetSVGProfile(profile)
+	}
+}
+
+// svgNamespace is the namespace of SVG elements
+const svgNamespace = "http://www.w3.org/2000/svg"
+
+// svgFull11Elements are the elements of SVG 1.1 Full
+var svgFull11Elements = setOf(
+	"a", "altGlyph", "altGlyphDef", "altGlyphItem", "animate", "animateColor",
+	"animateMotion", "animateTransform", "circle", "clipPath", "color-profile",
+	"cursor", "defs", "desc", "ellipse", "feBlend", "feColorMatrix",
+	"feComponentTransfer", "feComposite", "feConvolveMatrix", "feDiffuseLighting",
+	"feDisplacementMap", "feDistantLight", "feFlood", "feFuncA", "feFuncB",
+	"feFuncG", "feFuncR", "feGaussianBlur", "feImage", "feMerge", "feMergeNode",
+	"feMorphology", "feOffset", "fePointLight", "feSpecularLighting",
+	"feSpotLight", "feTile", "feTurbulence", "filter", "font", "font-face",
+	"font-face-format", "font-face-name", "font-face-src", "font-face-uri",
+	"foreignObject", "g", "glyph", "glyphRef", "hkern", "image", "line",
+	"linearGradient", "marker", "mask", "metadata", "missing-glyph", "mpath",
+	"path", "pattern", "polygon", "polyline", "radialGradient", "rect", "script",
+	"set", "stop", "style", "svg", "switch", "symbol", "text", "textPath", "title",
+	"tref", "tspan", "use", "view", "vkern",
+)
+
+// svgTiny12Elements are the elements of SVG Tiny 1.2
+var svgTiny12Elements = setOf(
+	"a", "animate", "animateColor", "animateMotion", "animateTransform",
+	"animation", "audio", "circle", "defs", "desc", "discard", "ellipse", "font",
+	"font-face", "font-face-src", "font-face-uri", "foreignObject", "g", "glyph",
+	"handler", "hkern", "image", "line", "linearGradient", "listener", "metadata",
+	"missing-glyph", "mpath", "path", "polygon", "polyline", "prefetch",
+	"radialGradient", "rect", "script", "set", "solidColor", "stop", "svg",
+	"switch", "tbreak", "text", "textArea", "title", "tspan", "use", "video",
+)
+
+// svgTiny12Excluded are attributes the converter reads that SVG Tiny 1.2
+// lacks, as it has no CSS or clipping
+var svgTiny12Excluded = setOf("style", "clip-path", "clip", "overflow")
+
+// setOf returns a set of the given strings
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// checkProfile validates the elements of source against the SVG profile,
+// returning a *ParseError for the first violation
+func (p *PDF) checkProfile(source []byte) error {
+	if p.svgProfile == SVG2Profile {
+		return nil
+	}
+	elements := svgFull11Elements
+	if p.svgProfile == SVGTiny12Profile {
+		elements = svgTiny12Elements
+	}
+	decoder := newDecoder(bytes.NewReader(source), p.entityBytes())
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return nil // Reported when decoding
+		}
+		switch t := token.(type) {
+		case xml.Directive:
+			declareEntities(decoder, t) // Errors are reported when decoding
+		case xml.StartElement:
+			depth++
+			if t.Name.Space != svgNamespace {
+				continue // Foreign content, e.g. metadata
+			}
+			line, column := decoder.InputPos()
+			name := t.Name.Local
+			if !elements[name] || name == "svg" && depth > 1 && p.svgProfile == SVGTiny12Profile {
+				return profileError(line, column, name, fmt.Errorf("line %d: element <%s> is not allowed in %s", line, name, p.svgProfile))
+			}
+			if p.svgProfile != SVGTiny12Profile {
+				continue
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "" && svgTiny12Excluded[strings.ToLower(attr.Name.Local)] {
+					return profileError(line, column, name, fmt.Errorf("line %d: attribute %s of <%s> is not allowed in %s", line, attr.Name.Local, name, p.svgProfile))
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// profileError returns the error for a profile violation by element name at
+// the given position
+func profileError(line, column int, name string, err error) error {
+	return &ParseError{Op: "validating", Line: line, Column: column, Element: name, Err: err}
+}
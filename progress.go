@@ -0,0 +1,97 @@
+package svg2pdf
+
+// Progress stages, passed to the progress callback
+const (
+	ProgressParse  = "parse"  // Decoding the SVG; done and total count bytes
+	ProgressRender = "render" // Drawing the current page; done and total count elements
+	ProgressWrite  = "write"  // Writing the PDF; done and total count pages
+)
+
+// SetProgress sets a callback reporting the progress of conversions, e.g.
+// for a progress bar: how much of stage (ProgressParse, ProgressRender or
+// ProgressWrite) is done out of total, which is 0 when unknown, as when
+// streaming an SVG from a reader. Each stage starts with done 0 and ends
+// with done equal to total. Calls are limited to about a thousand per stage
+// of a page.
+func (p *PDF) SetProgress(progress func(done, total int, stage string)) {
+	p.progress = progress
+}
+
+// WithProgress sets a callback reporting the progress of conversions
+func WithProgress(progress func(done, total int, stage string)) Option {
+	return func(p *PDF) error {
+		p.SetProgress(progress)
+		return nil
+	}
+}
+
+// progressState is the progress last reported for a stage
+type progressState struct {
+	total int
+	step  int // Reported thousandth of the total, or block of elements when it is unknown
+}
+
+// reportProgress reports done out of total for stage, unless it is too
+// close to the progress last reported for the stage
+func (p *PDF) reportProgress(stage string, done, total int) {
+	if p.progress == nil {
+		return
+	}
+	if total > 0 {
+		done = min(done, total)
+	}
+	step := done / 4096
+	if total > 0 {
+		step = done * 1000 / total
+	}
+	last, ok := p.progressStates[stage]
+	if ok && last.total == total && last.step == step && done != 0 && done != total {
+		return
+	}
+	if p.progressStates == nil {
+		p.progressStates = make(map[string]progressState)
+	}
+	p.progressStates[stage] = progressState{total, step}
+	p.progress(done, total, stage)
+}
+
+// startRender starts reporting the render progress of the svg document
+// drawn on the current page, whose content is c, or nil if it is not known
+// in advance
+func (p *PDF) startRender(c *Container) {
+	p.renderDone, p.renderTotal = 0, 0
+	if c != nil {
+		p.renderTotal = countElements(c)
+	}
+	p.reportProgress(ProgressRender, 0, p.renderTotal)
+}
+
+// finishRender reports that the current page is drawn
+func (p *PDF) finishRender() {
+	total := p.renderTotal
+	if total == 0 {
+		total = p.renderDone // Known now
+	}
+	p.reportProgress(ProgressRender, total, total)
+}
+
+// step counts an element towards the render progress and reports whether
+// the conversion must stop, see canceled
+func (p *PDF) step() bool {
+	p.renderDone++
+	p.reportProgress(ProgressRender, p.renderDone, p.renderTotal)
+	return p.checkElementLimits() || p.canceled()
+}
+
+// countElements returns the number of elements drawn for c, counting uses
+// and switches as one
+func countElements(c *Container) int {
+	n := len(c.Rects) + len(c.Images) + len(c.Paths) + len(c.Texts) + len(c.Foreign) + len(c.Uses) + len(c.Switches)
+	for i := range c.SVGs {
+		n += 1 + countElements(&c.SVGs[i].Container)
+	}
+	for i := range c.Groups {
+		n += 1 + countElements(&c.Groups[i].Container)
+	}
+	return n
+}
@@ -0,0 +1,248 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Rasterizer renders content the converter cannot draw, e.g. by calling an
+// external SVG renderer, so it is embedded as an image rather than lost. It
+// is passed filtered paths and groups, foreignObject elements and the basic
+// shapes the converter does not draw: circle, ellipse, line, polyline and
+// polygon.
+type Rasterizer interface {
+	// Rasterize renders svg, a standalone document holding the content with
+	// its width and height in points, at dpi dots per inch. The image is
+	// drawn over the area of the document, whatever its size.
+	Rasterize(svg []byte, dpi float64) (*image.RGBA, error)
+}
+
+// SetRasterizer makes r render the content the converter cannot draw, at
+// the resolution set with SetFilterResolution. Without one, such content is
+// left out, or approximated for filters. Content r fails to render is
+// handled as without one, with a warning.
+func (p *PDF) SetRasterizer(r Rasterizer) {
+	p.rasterizer = r
+}
+
+// WithRasterizer makes r render the content the converter cannot draw
+func WithRasterizer(r Rasterizer) Option {
+	return func(p *PDF) error {
+		p.SetRasterizer(r)
+		return nil
+	}
+}
+
+// rasterizedElements are the unsupported SVG elements passed to the
+// Rasterizer
+var rasterizedElements = setOf("circle", "ellipse", "line", "polyline", "polygon")
+
+// rasterizes reports whether the Rasterizer is passed the SVG elements
+// called local
+func (p *PDF) rasterizes(local string) bool {
+	return p.rasterizer != nil && rasterizedElements[local]
+}
+
+// filtered reports whether the element started by start has a filter, so
+// its markup is kept for the Rasterizer
+func filtered(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && (attr.Name.Local == "filter" ||
+			attr.Name.Local == "style" && strings.Contains(attr.Value, "filter")) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalXML decodes a path, keeping the markup of filtered ones
+func (path *Path) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Path // Without this method
+	if err := d.DecodeElement((*plain)(path), &start); err != nil {
+		return err
+	}
+	if filtered(start) {
+		path.markup = &Element{XMLName: start.Name, Attrs: start.Attr}
+	}
+	return nil
+}
+
+// UnmarshalXML decodes a group, keeping the markup of filtered ones
+func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Group // Without this method
+	if !filtered(start) {
+		return d.DecodeElement((*plain)(g), &start)
+	}
+	var v struct {
+		plain
+		Content string `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*g = Group(v.plain)
+	g.markup = &Element{XMLName: start.Name, Attrs: start.Attr, Content: v.Content}
+	return nil
+}
+
+// UnmarshalXML decodes a filter, keeping its markup
+func (f *Filter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Filter // Without this method
+	var v struct {
+		plain
+		Content string `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*f = Filter(v.plain)
+	f.markup = &Element{XMLName: start.Name, Attrs: start.Attr, Content: v.Content}
+	return nil
+}
+
+// UnmarshalXML decodes a foreignObject, keeping its markup
+func (fo *ForeignObject) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain ForeignObject // Without this method
+	if err := d.DecodeElement((*plain)(fo), &start); err != nil {
+		return err
+	}
+	fo.markup = &Element{XMLName: start.Name, Attrs: start.Attr, Content: fo.Content}
+	return nil
+}
+
+// namespaceDecls returns the namespace prefix declarations among attrs
+func namespaceDecls(attrs []xml.Attr) []xml.Attr {
+	var decls []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" {
+			decls = append(decls, attr)
+		}
+	}
+	return decls
+}
+
+// rasterize draws markup, along with the filter it references if not nil,
+// with the Rasterizer over region, in user units. It reports false, with a
+// warning, if the Rasterizer failed.
+func (p *PDF) rasterize(markup *Element, filter *Filter, region viewBox) bool {
+	tag, id := markup.XMLName.Local, markup.Attr("id")
+	if !(region.W > 0 && region.H > 0) {
+		return true // Nothing to draw
+	}
+	dpi := p.filterResolution
+	if dpi == 0 {
+		dpi = defaultFilterResolution
+	}
+	img, err := p.rasterizer.Rasterize(p.rasterDocument(markup, filter, region), dpi)
+	if err == nil && img == nil {
+		err = fmt.Errorf("no image")
+	}
+	if err != nil {
+		p.warn(tag, id, "error rasterizing: %v", err)
+		return false
+	}
+	bounds := img.Bounds()
+	if err := p.checkImageSize(image.Config{Width: bounds.Dx(), Height: bounds.Dy()}); err != nil {
+		return true // The conversion is aborted
+	}
+	p.drawImageOver(p.addRaster(img, img.Pix), region)
+	return true
+}
+
+// rasterDocument returns the standalone svg document passed to the
+// Rasterizer for markup: region of the user space at the size it is drawn,
+// with the namespace declarations of the root, the style sheets and the
+// filter it references. Other definitions it references, e.g. gradients or
+// symbols, are not included.
+func (p *PDF) rasterDocument(markup *Element, filter *Filter, region viewBox) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns=%q`, svgNamespace)
+	for _, decl := range p.namespaces {
+		fmt.Fprintf(&b, ` xmlns:%s=%q`, decl.Name.Local, decl.Value)
+	}
+	fmt.Fprintf(&b, ` width="%.2fpt" height="%.2fpt" viewBox="%g %g %g %g">`,
+		region.W*p.scaleX, region.H*p.scaleY, region.X, region.Y, region.W, region.H)
+	for _, style := range p.styleSheets {
+		b.WriteString("<style>")
+		xml.EscapeText(&b, []byte(style.Content))
+		b.WriteString("</style>")
+	}
+	e := xml.NewEncoder(&b)
+	if filter != nil && filter.markup != nil {
+		e.Encode(withoutDecls(filter.markup))
+	}
+	e.Encode(withoutDecls(markup))
+	e.Flush()
+	b.WriteString("</svg>")
+	return b.Bytes()
+}
+
+// withoutDecls returns e without the namespace declarations among its
+// attributes, which the encoder writes where they are needed
+func withoutDecls(e *Element) *Element {
+	out := *e
+	out.Attrs = nil
+	for _, attr := range e.Attrs {
+		if attr.Name.Space != "xmlns" && !(attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			out.Attrs = append(out.Attrs, attr)
+		}
+	}
+	return &out
+}
+
+// shapeRegion returns the area an unsupported basic shape may paint, its
+// bounding box grown by its stroke width, or the viewport if unknown
+func shapeRegion(e *Element, ctx unitContext) viewBox {
+	length := func(name string, axis lengthAxis) float64 {
+		return ctx.resolve(Length(e.Attr(name)), axis, 0)
+	}
+	var minX, minY, maxX, maxY float64
+	switch e.XMLName.Local {
+	case "circle":
+		r := length("r", axisOther)
+		minX, maxX = length("cx", axisX)-r, length("cx", axisX)+r
+		minY, maxY = length("cy", axisY)-r, length("cy", axisY)+r
+	case "ellipse":
+		rx, ry := length("rx", axisX), length("ry", axisY)
+		minX, maxX = length("cx", axisX)-rx, length("cx", axisX)+rx
+		minY, maxY = length("cy", axisY)-ry, length("cy", axisY)+ry
+	case "line":
+		minX, maxX = min(length("x1", axisX), length("x2", axisX)), max(length("x1", axisX), length("x2", axisX))
+		minY, maxY = min(length("y1", axisY), length("y2", axisY)), max(length("y1", axisY), length("y2", axisY))
+	case "polyline", "polygon":
+		numbers := strings.FieldsFunc(e.Attr("points"), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+		if len(numbers) < 2 {
+			return viewBox{}
+		}
+		minX, minY = math.Inf(1), math.Inf(1)
+		maxX, maxY = math.Inf(-1), math.Inf(-1)
+		for i := 0; i+1 < len(numbers); i += 2 {
+			x, errX := parseNumber(numbers[i])
+			y, errY := parseNumber(numbers[i+1])
+			if errX != nil || errY != nil {
+				break
+			}
+			minX, maxX, minY, maxY = min(minX, x), max(maxX, x), min(minY, y), max(maxY, y)
+		}
+		if minX > maxX {
+			return viewBox{}
+		}
+	default:
+		return viewBox{0, 0, ctx.viewportW, ctx.viewportH}
+	}
+	// Strokes are centered on the outline; miters may reach further
+	width := ctx.resolve(Length(e.Attr("stroke-width")), axisOther, 1)
+	for _, decl := range parseDeclarations(e.Attr("style")) {
+		if decl.Property == "stroke-width" {
+			width = ctx.resolve(Length(declValue(decl.Value)), axisOther, width)
+		}
+	}
+	pad := 2*width + 1
+	return viewBox{minX - pad, minY - pad, maxX - minX + 2*pad, maxY - minY + 2*pad}
+}
@@ -0,0 +1,267 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selector is a simple CSS selector: an optional element name followed by
+// #id and .class conditions, e.g. "text.secret" or "#account-number"
+type selector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// SetRedaction replaces elements matching any of the selectors with filled
+// black boxes, e.g. SetRedaction(".pii", "text.salary", "#ssn"). Redacted
+// text is not written to the document at all, so it cannot be recovered by
+// copying or by removing the boxes. Only simple selectors are supported: an
+// element name, #id and .class conditions, or comma separated lists of them.
+func (p *PDF) SetRedaction(selectors ...string) error {
+	var parsed []selector
+	for _, list := range selectors {
+		for _, s := range strings.Split(list, ",") {
+			sel, err := parseSelector(strings.TrimSpace(s))
+			if err != nil {
+				return err
+			}
+			parsed = append(parsed, sel)
+		}
+	}
+	p.redactions = parsed
+	return nil
+}
+
+// WithRedaction redacts the elements matching the selectors
+func WithRedaction(selectors ...string) Option {
+	return func(p *PDF) error { return p.SetRedaction(selectors...) }
+}
+
+// parseSelector parses a simple selector
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	if s == "" {
+		return sel, fmt.Errorf("empty selector")
+	}
+	isName := func(c byte) bool {
+		return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	name := func(i int) int {
+		for i < len(s) && isName(s[i]) {
+			i++
+		}
+		return i
+	}
+	i := name(0)
+	sel.tag = s[:i]
+	if sel.tag == "*" {
+		sel.tag = ""
+	}
+	for i < len(s) {
+		kind := s[i]
+		end := name(i + 1)
+		if (kind != '.' && kind != '#') || end == i+1 {
+			return sel, fmt.Errorf("unsupported selector %q", s)
+		}
+		if kind == '.' {
+			sel.classes = append(sel.classes, s[i+1:end])
+		} else {
+			sel.id = s[i+1 : end]
+		}
+		i = end
+	}
+	return sel, nil
+}
+
+// matches reports whether an element with the given name, id and class
+// attribute matches the selector
+func (sel selector) matches(tag, id, class string) bool {
+	if sel.tag != "" && sel.tag != tag || sel.id != "" && sel.id != id {
+		return false
+	}
+	classes := strings.Fields(class)
+	for _, c := range sel.classes {
+		found := false
+		for _, have := range classes {
+			found = found || have == c
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// redacts reports whether an element is redacted
+func (p *PDF) redacts(tag, id, class string) bool {
+	for _, sel := range p.redactions {
+		if sel.matches(tag, id, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// drawRedaction fills the box of a redacted element in black
+func (p *PDF) drawRedaction(box viewBox) {
+	if box.W <= 0 || box.H <= 0 {
+		return
+	}
+	p.emit(p.beginArtifact()...)
+	shape := p.newContentStream()
+	shape.op("q")
+	shape.setFill(RGB{})
+	shape.rect(box.X, box.Y, box.W, box.H)
+	shape.fill()
+	shape.op("Q")
+	p.emitStream(shape)
+	p.emit(p.endMarked()...)
+}
+
+// containerBBox returns the box around what c draws in user space, for
+// redacting a group without drawing any of it. Text is laid out only to be
+// measured. It reports false if c draws nothing.
+func (p *PDF) containerBBox(c *Container, ctx unitContext) (viewBox, bool) {
+	var box viewBox
+	found := false
+	add := func(b viewBox) {
+		if b.W <= 0 && b.H <= 0 {
+			return
+		}
+		if !found {
+			box, found = b, true
+			return
+		}
+		box = box.union(b)
+	}
+	area := func(x, y, w, h Length, defaultW, defaultH float64) viewBox {
+		return viewBox{ctx.resolve(x, axisX, 0), ctx.resolve(y, axisY, 0),
+			ctx.resolve(w, axisX, defaultW), ctx.resolve(h, axisY, defaultH)}
+	}
+	for _, rect := range c.Rects {
+		if p.holds(rect.Conditions) && !p.displayNone("rect", rect.ID, rect.Class, rect.Display, rect.Inline) {
+			add(area(rect.X, rect.Y, rect.Width, rect.Height, 0, 0))
+		}
+	}
+	for _, path := range c.Paths {
+		if !p.holds(path.Conditions) || p.displayNone("path", path.ID, path.Class, path.Display, path.Inline) {
+			continue
+		}
+		restore := p.scopeProperties("path", path.ID, path.Class, path.Inline)
+		if s, ok := p.pathShape(path); ok {
+			add(s.bbox)
+		}
+		restore()
+	}
+	for _, text := range c.Texts {
+		if !p.holds(text.Conditions) || p.displayNone("text", text.ID, text.Class, text.Display, text.Inline) {
+			continue
+		}
+		textCtx := ctx.withFontSize(text.Size)
+		family := text.Family
+		if family == "" {
+			family = text.Font
+		}
+		face := p.resolveFont(family, text.Weight, text.Style)
+		add(runsBBox(layoutTextRuns(text, face, textCtx), face, textCtx.fontSize))
+	}
+	for _, image := range c.Images {
+		if p.holds(image.Conditions) && !p.displayNone("image", image.ID, image.Class, image.Display, image.Inline) {
+			add(area(image.X, image.Y, image.Width, image.Height, 0, 0))
+		}
+	}
+	for _, fo := range c.Foreign {
+		if p.holds(fo.Conditions) && !p.displayNone("foreignObject", fo.ID, fo.Class, fo.Display, fo.Inline) {
+			add(area(fo.X, fo.Y, fo.Width, fo.Height, 0, 0))
+		}
+	}
+	for _, svg := range c.SVGs {
+		if p.holds(svg.Conditions) && !p.displayNone("svg", svg.ID, svg.Class, svg.Display, svg.Inline) {
+			add(area(svg.X, svg.Y, svg.Width, svg.Height, ctx.viewportW, ctx.viewportH))
+		}
+	}
+	for _, use := range c.Uses {
+		if p.holds(use.Conditions) && !p.displayNone("use", use.ID, use.Class, use.Display, use.Inline) {
+			add(area(use.X, use.Y, use.Width, use.Height, ctx.viewportW, ctx.viewportH))
+		}
+	}
+	for i := range c.Groups {
+		g := &c.Groups[i]
+		if !p.holds(g.Conditions) || p.displayNone("g", g.ID, g.Class, g.Display, g.Inline) {
+			continue
+		}
+		restore := p.scopeProperties("g", g.ID, g.Class, g.Inline)
+		if b, ok := p.containerBBox(&g.Container, ctx); ok {
+			add(b)
+		}
+		restore()
+	}
+	for _, s := range c.Switches {
+		if !p.holds(s.Conditions) || p.displayNone("switch", s.ID, s.Class, s.Display, s.Inline) {
+			continue
+		}
+		for i := range s.Children {
+			if child := &s.Children[i]; p.holds(child.Conditions) {
+				if b, ok := p.containerBBox(&child.Container, ctx); ok {
+					add(b)
+				}
+				break
+			}
+		}
+	}
+	return box, found
+}
+
+// redactsWithin reports whether anything in c is redacted, so its markup
+// must not be handed to the Rasterizer
+func (p *PDF) redactsWithin(c *Container) bool {
+	for _, rect := range c.Rects {
+		if p.redacts("rect", rect.ID, rect.Class) {
+			return true
+		}
+	}
+	for _, path := range c.Paths {
+		if p.redacts("path", path.ID, path.Class) {
+			return true
+		}
+	}
+	for _, text := range c.Texts {
+		if p.redacts("text", text.ID, text.Class) {
+			return true
+		}
+	}
+	for _, image := range c.Images {
+		if p.redacts("image", image.ID, image.Class) {
+			return true
+		}
+	}
+	for _, fo := range c.Foreign {
+		if p.redacts("foreignObject", fo.ID, fo.Class) {
+			return true
+		}
+	}
+	for _, use := range c.Uses {
+		if p.redacts("use", use.ID, use.Class) {
+			return true
+		}
+	}
+	for i := range c.SVGs {
+		if svg := &c.SVGs[i]; p.redacts("svg", svg.ID, svg.Class) || p.redactsWithin(&svg.Container) {
+			return true
+		}
+	}
+	for i := range c.Groups {
+		if g := &c.Groups[i]; p.redacts("g", g.ID, g.Class) || p.redactsWithin(&g.Container) {
+			return true
+		}
+	}
+	for _, s := range c.Switches {
+		for i := range s.Children {
+			if p.redactsWithin(&s.Children[i].Container) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// convert converts svg with opts, its streams uncompressed
+func convert(t *testing.T, svg string, opts ...Option) []byte {
+	t.Helper()
+	p, err := New(append([]Option{WithCompressor(nil)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ConvertSVG(strings.NewReader(svg)); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := p.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestRedactGroup(t *testing.T) {
+	tests := []struct {
+		name, content string
+	}{
+		{"group", `<g class="secret"><text x="10" y="20">TOPSECRET</text><rect x="10" y="30" width="5" height="5"/></g>`},
+		{"nested", `<g><g class="secret"><g><text x="10" y="20">TOPSECRET</text></g></g></g>`},
+		{"filtered", `<filter id="f"><feGaussianBlur stdDeviation="2"/></filter>` +
+			`<g filter="url(#f)"><rect width="5" height="5"/><g class="secret"><text x="10" y="20">TOPSECRET</text></g></g>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100">` + tt.content + `</svg>`
+			out := convert(t, svg, WithRedaction(".secret"))
+			if bytes.Contains(out, []byte("TOPSECRET")) {
+				t.Errorf("redacted text written to the document")
+			}
+			if !bytes.Contains(convert(t, svg), []byte("TOPSECRET")) {
+				t.Errorf("text not written without redaction")
+			}
+		})
+	}
+}
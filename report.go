@@ -0,0 +1,36 @@
+package svg2pdf
+
+import "strings"
+
+// reportName is the file name of the embedded conversion report
+const reportName = "conversion-report.txt"
+
+// SetConversionReport embeds a report of the content that was skipped or
+// approximated during conversion as a document-level attachment named
+// conversion-report.txt, invisible on the pages, so recipients can find out
+// what was dropped without rerunning the converter. The report lists the
+// warnings of all conversions, see Warnings.
+func (p *PDF) SetConversionReport(enabled bool) {
+	p.report = enabled
+}
+
+// WithConversionReport embeds a report of skipped and approximated content
+func WithConversionReport() Option {
+	return func(p *PDF) error {
+		p.SetConversionReport(true)
+		return nil
+	}
+}
+
+// reportText returns the text of the conversion report
+func (p *PDF) reportText() []byte {
+	var b strings.Builder
+	b.WriteString("svg2pdf conversion report\n\n")
+	if len(p.warnings) == 0 {
+		b.WriteString("No content was skipped or approximated.\n")
+	}
+	for _, w := range p.warnings {
+		b.WriteString(w.String() + "\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,57 @@
+package svg2pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SetIDSeed sets the seed mixed into the names of generated resources (fonts,
+// images, graphics states). Names are derived from the seed and the resource
+// content, so they are reproducible between runs, while documents converted
+// with different seeds can be merged without their resource names clashing.
+// By default the seed is derived from the first converted SVG.
+func (p *PDF) SetIDSeed(seed string) {
+	p.idSeed = seed
+	p.idSeedSet = true
+}
+
+// resourceID returns the resource name for content of the kind given by
+// prefix, e.g. "Im" followed by 8 hex digits. The same content always gets
+// the same name; if two contents hash to the same name, more digits are used.
+func (p *PDF) resourceID(prefix string, content []byte) string {
+	if p.worker != nil {
+		// Page workers share the names
+		p.worker.shared.Lock()
+		defer p.worker.shared.Unlock()
+	}
+	h := sha256.New()
+	h.Write([]byte(p.idSeed))
+	h.Write([]byte{0}) // Separate the seed from the content
+	h.Write([]byte(prefix))
+	h.Write(content)
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	if p.resourceIDs == nil {
+		p.resourceIDs = make(map[string]string)
+	}
+	for n := 8; n < len(digest); n += 4 {
+		name := prefix + digest[:n]
+		if owner, taken := p.resourceIDs[name]; !taken || owner == digest {
+			p.resourceIDs[name] = digest
+			return name
+		}
+	}
+	return prefix + digest
+}
+
+// seedIDs derives the default resource name seed from the first source
+// document, unless a seed was set explicitly. Later sources keep it, so
+// fonts, images and forms they share with earlier pages get the same names
+// and are embedded once.
+func (p *PDF) seedIDs(source []byte) {
+	if p.idSeedSet || p.idSeed != "" {
+		return
+	}
+	sum := sha256.Sum256(source)
+	p.idSeed = hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,107 @@
+package svg2pdf
+
+import "strings"
+
+// Result is an inventory of the resources of a document as it would be
+// written, for automated policy checks, e.g. that no font is left
+// unembedded. Resources are listed in the order they are written.
+type Result struct {
+	Pages       int
+	Fonts       []FontResource
+	Images      []ImageResource
+	SpotColors  []string // Colorant names
+	Forms       int      // Form XObjects, e.g. glyph outlines and blend groups
+	Attachments []string // File names
+	Annotations int      // Annotations added through page /Annots entries
+}
+
+// FontResource describes a font of the document
+type FontResource struct {
+	Name       string // Resource name, e.g. F2
+	BaseFont   string // PostScript name
+	Family     string
+	Embedded   bool // Font programs are embedded whole; the built-in Helvetica is not embedded
+	OpenType   bool // CFF outlines rather than TrueType
+	GlyphCount int  // Distinct glyphs drawn, 0 for the built-in font
+}
+
+// ImageResource describes an image of the document
+type ImageResource struct {
+	Name       string // Resource name
+	Width      int    // Width in pixels
+	Height     int    // Height in pixels
+	ColorSpace string // e.g. DeviceRGB or ICCBased
+	Filter     string // Compression filter, e.g. DCTDecode, or "" for raw samples
+	SoftMask   bool   // Has an alpha channel
+}
+
+// Result returns the inventory of the resources converted so far
+func (p *PDF) Result() Result {
+	r := Result{Pages: p.pageCount, Forms: len(p.forms)}
+
+	used := p.streaming != nil && p.streaming.helvetica
+	for _, content := range p.content {
+		used = used || usesStandardFont(content.String())
+	}
+	for _, form := range p.forms {
+		used = used || usesStandardFont(form.ops)
+	}
+	if used {
+		r.Fonts = append(r.Fonts, FontResource{Name: "F1", BaseFont: "Helvetica", Family: "Helvetica"})
+	}
+	for _, font := range p.fonts {
+		if len(font.used) == 0 {
+			continue // Not written
+		}
+		r.Fonts = append(r.Fonts, FontResource{
+			Name:       font.name,
+			BaseFont:   font.postscript,
+			Family:     font.Family,
+			Embedded:   true,
+			OpenType:   font.cff,
+			GlyphCount: len(font.used),
+		})
+	}
+
+	imageFilter := ""
+	if c := p.compressors()[ImageStream]; c != nil {
+		imageFilter = c.Filter() // Unless compression does not shrink the samples
+	}
+	for _, img := range p.images {
+		colorSpace := strings.TrimPrefix(img.colorSpace, "/")
+		if img.profile != nil {
+			colorSpace = "ICCBased"
+		}
+		filter := img.filter
+		if filter == "" {
+			filter = imageFilter
+		}
+		r.Images = append(r.Images, ImageResource{
+			Name:       img.name,
+			Width:      img.width,
+			Height:     img.height,
+			ColorSpace: colorSpace,
+			Filter:     strings.TrimPrefix(filter, "/"),
+			SoftMask:   img.smask != nil,
+		})
+	}
+
+	for _, spot := range p.spots {
+		r.SpotColors = append(r.SpotColors, spot.colorant)
+	}
+	if _, custom := p.catalogEntries["Names"]; !custom {
+		for _, a := range p.attachments() {
+			r.Attachments = append(r.Attachments, a.name)
+		}
+	}
+	for _, entries := range p.pageEntries {
+		annots := entries["Annots"]
+		if ref, ok := annots.(Ref); ok && ref.doc == p {
+			annots = p.objects[ref.index]
+		}
+		if a, ok := annots.(Array); ok {
+			r.Annotations += len(a)
+		}
+	}
+	return r
+}
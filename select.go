@@ -0,0 +1,147 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetElementID makes conversions draw only the element with the given id
+// and its descendants, e.g. one icon of a sprite sheet, scaled so its
+// bounding box fills the page as a whole document would; "" draws
+// everything. A symbol is drawn as if used at the origin. Converting a
+// document without the element fails.
+func (p *PDF) SetElementID(id string) {
+	p.elementID = id
+}
+
+// WithElementID makes conversions draw only the element with the given id
+func WithElementID(id string) Option {
+	return func(p *PDF) error {
+		p.SetElementID(id)
+		return nil
+	}
+}
+
+// selectElement returns c without the graphics elements that neither are
+// the selected element nor contain it, recording in d whether it was found.
+// c itself is not changed.
+func (p *PDF) selectElement(c *Container, d *rootDrawing) *Container {
+	if p.elementID == "" {
+		return c
+	}
+	selected := *c
+	if isolate(&selected, p.elementID) {
+		d.selected = true
+	}
+	return &selected
+}
+
+// finishSelection draws the selected element if it is a symbol, which is
+// not drawn by itself, and fits the drawing onto the page
+func (p *PDF) finishSelection(d *rootDrawing) error {
+	if p.elementID == "" {
+		return nil
+	}
+	if !d.selected {
+		if _, ok := p.symbols[p.elementID]; !ok {
+			return fmt.Errorf("no element with id %q", p.elementID)
+		}
+		p.renderContainer(&Container{Uses: []Use{{Href: "#" + p.elementID}}}, d.ctx)
+	}
+	p.emit("Q")
+	p.fitSelection(d)
+	p.emit("q") // Closed by endRoot
+	return nil
+}
+
+// fitSelection scales the drawing of the document on the current page so
+// its bounding box fills the figure box, shrinking pages sized to the SVG
+// around it
+func (p *PDF) fitSelection(d *rootDrawing) {
+	page := p.content[p.current].String()
+	before, drawing := page[:d.contentStart], page[d.contentStart:]
+	stats := p.contentStats(drawing, p.pageSizes[p.current])
+	if stats.Empty {
+		return
+	}
+	b := stats.Bounds
+	w, h := max(b[2]-b[0], 1e-3), max(b[3]-b[1], 1e-3)
+	if p.autoPageSize && p.nup == nil {
+		p.pageWidth -= d.box.W - w
+		p.pageHeight -= d.box.H - h
+		p.pageSizes[p.current] = [2]float64{p.pageWidth, p.pageHeight}
+		d.box.W, d.box.H = w, h
+	}
+	// Bounds are in points, while fitting works in user units
+	scale := 1.0
+	if p.fitMode == FitActualSize {
+		scale = p.actualScale()
+	}
+	sx, sy, dx, dy := p.fitBox(w/scale, h/scale, d.box)
+	sx, sy = sx/scale, sy/scale
+
+	content := p.newContentStream()
+	if before != "" {
+		content.op(strings.TrimSuffix(before, "\n"))
+	}
+	userScale := p.userScale
+	p.userScale = 0 // The drawing is fitted in page space
+	content.op("q", p.transform("cm", sx, 0, 0, sy, dx-b[0]*sx, p.pageHeight-dy-h*sy-b[1]*sy))
+	p.userScale = userScale
+	content.op(strings.TrimPrefix(drawing, "\n"), "Q")
+	p.content[p.current] = content
+	d.bottom = min(dy+h*sy, d.box.Y+d.box.H)
+}
+
+// isolate removes the graphics elements of c that neither have the given
+// id nor contain an element with it, keeping definitions, and reports
+// whether the element was found. Elements are copied before their children
+// are removed.
+func isolate(c *Container, id string) bool {
+	var found bool
+	keep := func(elementID string, descend func() bool) bool {
+		if elementID == id || (descend != nil && descend()) {
+			found = true
+			return true
+		}
+		return false
+	}
+	c.Rects = keepElements(c.Rects, func(r *Rect) bool { return keep(r.ID, nil) })
+	c.Texts = keepElements(c.Texts, func(t *Text) bool { return keep(t.ID, nil) })
+	c.Paths = keepElements(c.Paths, func(pa *Path) bool { return keep(pa.ID, nil) })
+	c.Images = keepElements(c.Images, func(img *Image) bool { return keep(img.ID, nil) })
+	c.Foreign = keepElements(c.Foreign, func(f *ForeignObject) bool { return keep(f.ID, nil) })
+	c.Uses = keepElements(c.Uses, func(u *Use) bool { return keep(u.ID, nil) })
+	c.Extensions = keepElements(c.Extensions, func(e *Element) bool { return keep(e.Attr("id"), nil) })
+	c.SVGs = keepElements(c.SVGs, func(s *SVG) bool {
+		return keep(s.ID, func() bool { return isolate(&s.Container, id) })
+	})
+	c.Groups = keepElements(c.Groups, func(g *Group) bool {
+		return keep(g.ID, func() bool { return isolate(&g.Container, id) })
+	})
+	c.Switches = keepElements(c.Switches, func(s *Switch) bool {
+		return keep(s.ID, func() bool {
+			children := make([]SwitchChild, len(s.Children))
+			var inside bool
+			for i, child := range s.Children {
+				inside = isolate(&child.Container, id) || inside
+				children[i] = child
+			}
+			s.Children = children
+			return inside
+		})
+	})
+	return found
+}
+
+// keepElements returns copies of the elements for which keep, passed the
+// copy, returns true
+func keepElements[T any](elements []T, keep func(*T) bool) []T {
+	var kept []T
+	for _, e := range elements {
+		if keep(&e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,96 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// spotColor is a Separation color space painting an SVG color with a
+// named colorant
+type spotColor struct {
+	colorant string // Colorant name, e.g. "PANTONE orange 021 C"
+	color    RGB    // Appearance on devices without the colorant
+	name     string // Resource name of the color space, once used
+	obj      int
+}
+
+// SetSpotColor maps an SVG color, e.g. "#ff6600", to the named spot
+// colorant, e.g. "PANTONE orange 021 C", so fills and strokes painted in
+// it separate onto their own plate on press. Viewers and printers without
+// the colorant show the SVG color, converted to CMYK in CMYK output mode.
+// An empty colorant removes the mapping.
+func (p *PDF) SetSpotColor(color, colorant string) error {
+	c, ok := parseColor(color)
+	if !ok {
+		return fmt.Errorf("invalid spot color %q", color)
+	}
+	if colorant == "" {
+		delete(p.spotColors, c)
+		return nil
+	}
+	if colorant == "All" || colorant == "None" {
+		return fmt.Errorf("colorant name %q is reserved", colorant)
+	}
+	if p.spotColors == nil {
+		p.spotColors = make(map[RGB]*spotColor)
+	}
+	p.spotColors[c] = &spotColor{colorant: colorant, color: c}
+	return nil
+}
+
+// WithSpotColor maps an SVG color to a named spot colorant
+func WithSpotColor(color, colorant string) Option {
+	return func(p *PDF) error {
+		return p.SetSpotColor(color, colorant)
+	}
+}
+
+// spotOp returns the operators selecting the spot color mapped to c at
+// full tint for filling, or for stroking if stroke is set, or "" if c is
+// not mapped
+func (p *PDF) spotOp(c RGB, stroke bool) string {
+	spot := p.spotColors[c]
+	if spot == nil {
+		return ""
+	}
+	if spot.name == "" {
+		spot.name = p.resourceID("CS", []byte(spot.colorant))
+		p.spots = append(p.spots, spot)
+		p.created(spot.name)
+	}
+	if stroke {
+		return fmt.Sprintf("/%s CS 1 SCN", spot.name)
+	}
+	return fmt.Sprintf("/%s cs 1 scn", spot.name)
+}
+
+// writeObject writes the Separation color space as object spot.obj, with
+// a CMYK alternate color space if cmyk is set. The tint transform blends
+// from no ink to the SVG color.
+func (spot *spotColor) writeObject(w *pdfWriter, cmyk bool) {
+	alternate, c0 := "/DeviceRGB", "1 1 1"
+	c1 := formatNumbers(colorDecimals, spot.color.R, spot.color.G, spot.color.B)
+	if cmyk {
+		cyan, magenta, yellow, black := toCMYK(spot.color)
+		alternate, c0 = "/DeviceCMYK", "0 0 0 0"
+		c1 = formatNumbers(colorDecimals, cyan, magenta, yellow, black)
+	}
+	w.object(spot.obj, fmt.Sprintf("[/Separation %s %s << /FunctionType 2 /Domain [0 1] /C0 [%s] /C1 [%s] /N 1 >>]",
+		nameObject(spot.colorant), alternate, c0, c1))
+}
+
+// nameObject returns s as a PDF name, escaping delimiters, whitespace and
+// non-ASCII bytes as #XX
+func nameObject(s string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x21 || c > 0x7e || strings.IndexByte("#()<>[]{}/%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// srgbProfile builds an ICC version 2 display profile for the sRGB IEC
+// 61966-2.1 color space, used as the PDF/A output intent. Primaries and
+// white point are the D50 adapted values of the specification.
+func srgbProfile() []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	xyz := func(x, y, z float64) []byte {
+		var b bytes.Buffer
+		b.WriteString("XYZ \x00\x00\x00\x00")
+		for _, v := range []float64{x, y, z} {
+			binary.Write(&b, binary.BigEndian, int32(math.Round(v*65536))) // s15Fixed16Number
+		}
+		return b.Bytes()
+	}
+	// The sRGB transfer function sampled at 1024 points
+	var trc bytes.Buffer
+	trc.WriteString("curv\x00\x00\x00\x00")
+	binary.Write(&trc, binary.BigEndian, uint32(1024))
+	for i := 0; i < 1024; i++ {
+		v := float64(i) / 1023
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(&trc, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+	text := func(sig, s string) []byte {
+		var b bytes.Buffer
+		b.WriteString(sig + "\x00\x00\x00\x00")
+		if sig == "desc" {
+			// ASCII description, then empty Unicode and ScriptCode descriptions
+			binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+			b.WriteString(s + "\x00")
+			b.Write(make([]byte, 4+4+2+1+67))
+		} else {
+			b.WriteString(s + "\x00")
+		}
+		return b.Bytes()
+	}
+	const name = "sRGB IEC61966-2.1"
+	tags := []tag{
+		{"desc", text("desc", name)},
+		{"cprt", text("text", "No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", trc.Bytes()},
+	}
+
+	// Tag data follows the header and tag table, 4-byte aligned; the three
+	// curves share one copy
+	offset := 128 + 4 + 12*(len(tags)+2)
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)+2))
+	for _, t := range tags {
+		entry := func(sig string) {
+			table.WriteString(sig)
+			binary.Write(&table, binary.BigEndian, uint32(offset+data.Len()))
+			binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+		}
+		entry(t.sig)
+		if t.sig == "rTRC" {
+			entry("gTRC")
+			entry("bTRC")
+		}
+		data.Write(t.data)
+		for data.Len()%4 != 0 {
+			data.WriteByte(0)
+		}
+	}
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint32(offset+data.Len()))
+	header.WriteString("\x00\x00\x00\x00")                         // Preferred CMM
+	header.WriteString("\x02\x10\x00\x00")                         // Version 2.1
+	header.WriteString("mntrRGB XYZ ")                             // Display device, RGB data, XYZ connection space
+	header.Write([]byte{0x07, 0xD0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0}) // Creation date 2000-01-01
+	header.WriteString("acsp")
+	header.Write(make([]byte, 4+4+4+4+8+4))    // Platform, flags, device and rendering intent
+	header.Write(xyz(0.9642, 1.0, 0.8249)[8:]) // D50 illuminant
+	header.Write(make([]byte, 128-header.Len()))
+	return append(append(header.Bytes(), table.Bytes()...), data.Bytes()...)
+}
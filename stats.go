@@ -0,0 +1,402 @@
+package svg2pdf
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Stats summarizes the conversions into a document, for tooling asserting
+// on expected output, e.g. that no page came out blank
+type Stats struct {
+	Elements map[string]int // Rendered elements by name, e.g. "rect" or "text"
+	Pages    []PageStats
+	Fonts    []string // Families of the fonts text is drawn in, as in Result
+	Images   int      // Embedded images
+	// Streams holds the bytes of the streams of the last written output by
+	// kind, after compression; it is empty before Write
+	Streams map[StreamKind]int
+}
+
+// PageStats describes the content of a page
+type PageStats struct {
+	Empty bool // Nothing is drawn on the page
+	// Bounds is the box around the drawn content in points from the bottom
+	// left corner of the page: left, bottom, right, top. It is approximate:
+	// clipping is ignored and text is measured by its advance widths.
+	Bounds [4]float64
+}
+
+// Stats returns the statistics of the conversions so far
+func (p *PDF) Stats() Stats {
+	r := p.Result()
+	s := Stats{Elements: make(map[string]int), Images: len(r.Images), Streams: make(map[StreamKind]int)}
+	for name, n := range p.elementCounts {
+		s.Elements[name] = n
+	}
+	for _, font := range r.Fonts {
+		s.Fonts = append(s.Fonts, font.Family)
+	}
+	for i := range p.pageCount {
+		if num := p.written[i]; num != 0 {
+			s.Pages = append(s.Pages, p.writtenStats[num])
+			continue
+		}
+		s.Pages = append(s.Pages, p.pageStats(i))
+	}
+	for kind, n := range p.streamSizes {
+		if n > 0 {
+			s.Streams[StreamKind(kind)] = n
+		}
+	}
+	return s
+}
+
+// countRendered counts a rendered element for Stats
+func (p *PDF) countRendered(element string) {
+	if p.elementCounts == nil {
+		p.elementCounts = make(map[string]int)
+	}
+	p.elementCounts[element]++
+}
+
+// pageStats measures the content drawn on page i. Content the content
+// parser does not handle, e.g. inline images, counts as covering the whole
+// page.
+func (p *PDF) pageStats(i int) PageStats {
+	return p.contentStats(p.content[i].String(), p.pageSizes[i])
+}
+
+// contentStats measures the content drawn by content stream operators on a
+// page of the given size
+func (p *PDF) contentStats(content string, size [2]float64) PageStats {
+	ops, ok := parseContentOps(content)
+	if !ok {
+		return PageStats{Bounds: [4]float64{0, 0, size[0], size[1]}}
+	}
+	b := newBoundsTracker(p)
+	b.run(ops)
+	if b.empty {
+		return PageStats{Empty: true}
+	}
+	return PageStats{Bounds: b.box}
+}
+
+// matrix is an affine transformation a b c d e f, as operands of cm
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns the transformation applying m, then n
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// apply maps pt through m
+func (m matrix) apply(pt point) point {
+	return point{m[0]*pt.X + m[2]*pt.Y + m[4], m[1]*pt.X + m[3]*pt.Y + m[5]}
+}
+
+// boundsTracker interprets content operators to find the box around what
+// they draw
+type boundsTracker struct {
+	p     *PDF
+	ctm   matrix
+	stack []boundsState
+	path  []point // Points of the current path in page space
+	width float64 // Line width in user space
+	font  string  // Resource name of the text font
+	size  float64 // Font size
+	tm    matrix  // Text matrix
+	tlm   matrix  // Text line matrix
+	lead  float64 // Text leading
+	empty bool
+	box   [4]float64
+	depth int // Nesting of form XObjects, which may not recurse
+}
+
+// boundsState is the graphics state q saves
+type boundsState struct {
+	ctm   matrix
+	width float64
+	font  string
+	size  float64
+}
+
+func newBoundsTracker(p *PDF) *boundsTracker {
+	return &boundsTracker{p: p, ctm: identity, width: 1, empty: true}
+}
+
+// add extends the box by pt, in page space
+func (b *boundsTracker) add(pt point) {
+	if math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+		return
+	}
+	if b.empty {
+		b.box = [4]float64{pt.X, pt.Y, pt.X, pt.Y}
+		b.empty = false
+		return
+	}
+	b.box[0], b.box[1] = min(b.box[0], pt.X), min(b.box[1], pt.Y)
+	b.box[2], b.box[3] = max(b.box[2], pt.X), max(b.box[3], pt.Y)
+}
+
+// addBox extends the box by the corners of a rectangle in user space
+// mapped through m
+func (b *boundsTracker) addBox(m matrix, x0, y0, x1, y1 float64) {
+	for _, pt := range []point{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+		b.add(m.apply(pt))
+	}
+}
+
+// paint adds the current path, widened by half the line width if stroked
+func (b *boundsTracker) paint(stroked bool) {
+	pad := 0.0
+	if stroked {
+		// The line width scales with the larger axis of the CTM
+		scale := max(math.Hypot(b.ctm[0], b.ctm[1]), math.Hypot(b.ctm[2], b.ctm[3]))
+		pad = b.width * scale / 2
+	}
+	for _, pt := range b.path {
+		b.add(point{pt.X - pad, pt.Y - pad})
+		b.add(point{pt.X + pad, pt.Y + pad})
+	}
+	b.path = b.path[:0]
+}
+
+// run interprets ops
+func (b *boundsTracker) run(ops []contentOp) {
+	for _, op := range ops {
+		n := operandNumbers(op.operands)
+		switch op.operator {
+		case "q":
+			b.stack = append(b.stack, boundsState{b.ctm, b.width, b.font, b.size})
+		case "Q":
+			if len(b.stack) > 0 {
+				s := b.stack[len(b.stack)-1]
+				b.stack = b.stack[:len(b.stack)-1]
+				b.ctm, b.width, b.font, b.size = s.ctm, s.width, s.font, s.size
+			}
+		case "cm":
+			if len(n) == 6 {
+				b.ctm = matrix(n).multiply(b.ctm)
+			}
+		case "w":
+			if len(n) == 1 {
+				b.width = n[0]
+			}
+		case "m", "l":
+			if len(n) == 2 {
+				b.path = append(b.path, b.ctm.apply(point{n[0], n[1]}))
+			}
+		case "c", "v", "y":
+			// Curves lie within the hull of their control points
+			for i := 0; i+1 < len(n); i += 2 {
+				b.path = append(b.path, b.ctm.apply(point{n[i], n[i+1]}))
+			}
+		case "re":
+			if len(n) == 4 {
+				for _, pt := range []point{{n[0], n[1]}, {n[0] + n[2], n[1]}, {n[0], n[1] + n[3]}, {n[0] + n[2], n[1] + n[3]}} {
+					b.path = append(b.path, b.ctm.apply(pt))
+				}
+			}
+		case "f", "F", "f*":
+			b.paint(false)
+		case "S", "s", "B", "B*", "b", "b*":
+			b.paint(true)
+		case "n":
+			b.path = b.path[:0] // Clipping paths draw nothing
+		case "BT":
+			b.tm, b.tlm = identity, identity
+		case "Tf":
+			if len(op.operands) == 2 && len(n) == 2 {
+				b.font, b.size = strings.TrimPrefix(op.operands[0], "/"), n[1]
+			}
+		case "Tm":
+			if len(n) == 6 {
+				b.tm, b.tlm = matrix(n), matrix(n)
+			}
+		case "Td", "TD":
+			if len(n) == 2 {
+				if op.operator == "TD" {
+					b.lead = -n[1]
+				}
+				b.tlm = matrix{1, 0, 0, 1, n[0], n[1]}.multiply(b.tlm)
+				b.tm = b.tlm
+			}
+		case "TL":
+			if len(n) == 1 {
+				b.lead = n[0]
+			}
+		case "T*":
+			b.tlm = matrix{1, 0, 0, 1, 0, -b.lead}.multiply(b.tlm)
+			b.tm = b.tlm
+		case "Tj", "'", "\"", "TJ":
+			if op.operator != "Tj" && op.operator != "TJ" {
+				b.tlm = matrix{1, 0, 0, 1, 0, -b.lead}.multiply(b.tlm) // As T*
+				b.tm = b.tlm
+			}
+			if len(op.operands) > 0 {
+				b.text(op.operands[len(op.operands)-1], op.operator == "TJ")
+			}
+		case "Do":
+			if len(op.operands) == 1 {
+				b.xObject(strings.TrimPrefix(op.operands[0], "/"))
+			}
+		}
+	}
+}
+
+// text adds a shown string, or the strings of a TJ array, advancing the
+// text matrix. Glyphs are taken to span from a quarter em below the
+// baseline to an em above it.
+func (b *boundsTracker) text(operand string, array bool) {
+	var width float64
+	if array {
+		inner := strings.TrimSuffix(strings.TrimPrefix(operand, "["), "]")
+		for i := 0; i < len(inner); {
+			switch c := inner[i]; {
+			case c == '(':
+				end, ok := literalStringEnd(inner, i)
+				if !ok {
+					return
+				}
+				width += b.advance(inner[i:end])
+				i = end
+			case c == '<':
+				end := strings.IndexByte(inner[i:], '>')
+				if end < 0 {
+					return
+				}
+				width += b.advance(inner[i : i+end+1])
+				i += end + 1
+			case c == ' ':
+				i++
+			default:
+				end := tokenEnd(inner, i+1)
+				if adjust, err := strconv.ParseFloat(inner[i:end], 64); err == nil {
+					width -= adjust / 1000 * b.size
+				}
+				i = end
+			}
+		}
+	} else {
+		width = b.advance(operand)
+	}
+	m := b.tm.multiply(b.ctm)
+	b.addBox(m, 0, -0.25*b.size, width, b.size)
+	b.tm = matrix{1, 0, 0, 1, width, 0}.multiply(b.tm)
+}
+
+// advance returns the width of a string operand in the current font
+func (b *boundsTracker) advance(s string) float64 {
+	if strings.HasPrefix(s, "<") {
+		data, err := hex.DecodeString(strings.Trim(s, "<>"))
+		if err != nil {
+			return 0
+		}
+		var font *Font
+		for _, f := range b.p.fonts {
+			if f.name == b.font {
+				font = f
+				break
+			}
+		}
+		var total float64
+		for i := 0; i+1 < len(data); i += 2 {
+			gid := uint16(data[i])<<8 | uint16(data[i+1])
+			if font != nil {
+				total += font.glyphAdvance(gid)
+			} else {
+				total += 500
+			}
+		}
+		return total * b.size / 1000
+	}
+	return textWidth(unescapeLiteral(s), b.size)
+}
+
+// xObject adds an image, as the unit square, or the bounding box of a form
+func (b *boundsTracker) xObject(name string) {
+	for _, img := range b.p.images {
+		if img.name == name {
+			b.addBox(b.ctm, 0, 0, 1, 1)
+			return
+		}
+	}
+	for _, form := range b.p.forms {
+		if form.name == name {
+			if b.depth < 8 {
+				inner := &boundsTracker{p: b.p, ctm: b.ctm, width: 1, empty: true, depth: b.depth + 1}
+				if ops, ok := parseContentOps(form.ops); ok {
+					inner.run(ops)
+					if !inner.empty {
+						b.add(point{inner.box[0], inner.box[1]})
+						b.add(point{inner.box[2], inner.box[3]})
+					}
+					return
+				}
+			}
+			v := form.bbox
+			b.addBox(b.ctm, v.X, v.Y, v.X+v.W, v.Y+v.H)
+			return
+		}
+	}
+}
+
+// operandNumbers returns the operands if they are all numbers
+func operandNumbers(operands []string) []float64 {
+	n := make([]float64, 0, len(operands))
+	for _, operand := range operands {
+		v, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return nil
+		}
+		n = append(n, v)
+	}
+	return n
+}
+
+// unescapeLiteral returns the text of a PDF literal string with its
+// parentheses
+func unescapeLiteral(s string) string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			if c >= '0' && c <= '7' {
+				end := i + 1
+				for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+					end++
+				}
+				v, _ := strconv.ParseUint(s[i:end], 8, 8)
+				b.WriteByte(byte(v))
+				i = end - 1
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamState is the part of a streamed document written so far
+type streamState struct {
+	out        io.Writer
+	w          *pdfWriter // Nil until the first page is written
+	ids        objectAllocator
+	version    string
+	catalogObj int
+	pagesObj   int
+	encryptObj int
+	crypt      *encryptor
+	helvetica  bool // Written pages draw text in the built-in font
+	closed     bool
+}
+
+// SetStreamingOutput writes the content of every page to out as soon as the
+// page is finished, instead of keeping all pages in memory until the
+// document is written, so peak memory stays proportional to one page plus
+// the shared fonts and images. Finish the document with Close; Write and
+// Save cannot be used. Pages cannot be drawn on once they are finished,
+// after the page hook ran, but can still be moved. It must be set before
+// the first page is added.
+func (p *PDF) SetStreamingOutput(out io.Writer) error {
+	if p.pageCount > 0 {
+		return fmt.Errorf("streaming output must be set before the first page")
+	}
+	p.streaming = &streamState{out: out}
+	return nil
+}
+
+// WithStreamingOutput writes pages to out as they are finished
+func WithStreamingOutput(out io.Writer) Option {
+	return func(p *PDF) error {
+		return p.SetStreamingOutput(out)
+	}
+}
+
+// Close finishes a document streamed with SetStreamingOutput, writing the
+// fonts, images and other resources shared by the pages, the page tree and
+// the cross-reference table
+func (p *PDF) Close() error {
+	s := p.streaming
+	if s == nil {
+		return fmt.Errorf("document is not streamed; write it with Write or Save")
+	}
+	if s.closed {
+		return fmt.Errorf("streamed document already closed")
+	}
+	s.closed = true
+	return p.write(s.out)
+}
+
+// finishPage runs the page hook on the current page and writes the pages
+// finished so far when streaming
+func (p *PDF) finishPage() error {
+	if err := p.runAfterPage(); err != nil {
+		return err
+	}
+	return p.flushPages()
+}
+
+// flushPages writes the content streams of the pages not yet written and
+// releases their content
+func (p *PDF) flushPages() error {
+	s := p.streaming
+	if s == nil {
+		return nil
+	}
+	if s.w == nil {
+		if err := p.startStream(); err != nil {
+			return err
+		}
+	}
+	if err := p.prepareWatermark(); err != nil {
+		return err
+	}
+	p.prepareTemplates()
+	for i, num := range p.written {
+		if num != 0 {
+			continue
+		}
+		if usesStandardFont(p.content[i].String()) {
+			if p.pdfa {
+				return fmt.Errorf("document does not conform to PDF/A-2b: page %d draws text in the built-in Helvetica, which is not embedded; register a font for it", i+1)
+			}
+			s.helvetica = true
+		}
+		p.written[i] = s.ids.next()
+		if p.writtenStats == nil {
+			p.writtenStats = make(map[int]PageStats)
+		}
+		p.writtenStats[p.written[i]] = p.pageStats(i)
+		s.w.stream(p.written[i], ContentStream, nil, p.pageContent(i))
+		p.content[i] = p.newContentStream()
+	}
+	if s.w.err != nil {
+		return fmt.Errorf("error writing PDF: %v", s.w.err)
+	}
+	return nil
+}
+
+// startStream writes the header of a streamed document, numbering the
+// catalog and page tree first. The header cannot be changed later, so it
+// allows optional content and object streams; the catalog raises the
+// version for OpenType fonts.
+func (p *PDF) startStream() error {
+	s := p.streaming
+	s.version = "1.5"
+	s.catalogObj, s.pagesObj = s.ids.next(), s.ids.next()
+	if p.encryption != nil {
+		crypt, err := p.newEncryptor(p.encryption)
+		if err != nil {
+			return err
+		}
+		s.crypt, s.encryptObj = crypt, s.ids.next()
+		s.version = max(s.version, crypt.version)
+	}
+	s.w = newPDFWriter(s.out, s.version)
+	s.w.compressors = p.compressors()
+	s.w.fileID = p.pdfa
+	s.w.objectStreams = p.objectStreams
+	if s.crypt != nil {
+		s.w.encrypt(s.encryptObj, s.crypt)
+	}
+	return nil
+}
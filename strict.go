@@ -0,0 +1,29 @@
+package svg2pdf
+
+// SetStrictMode makes conversions fail with a descriptive error on the
+// first unsupported or invalid construct, i.e. the first warning, instead
+// of the default lenient mode, which renders what it can and collects
+// warnings. CI pipelines checking their SVGs want strict mode, end users a
+// document with whatever could be converted. The error is kept, so later
+// conversions into the same document fail as well. Images and style sheets
+// that cannot be loaded fail with a *ResourceError, other content with an
+// *UnsupportedFeatureError.
+func (p *PDF) SetStrictMode(strict bool) {
+	p.strict = strict
+}
+
+// WithStrictMode makes conversions fail on the first warning
+func WithStrictMode() Option {
+	return func(p *PDF) error {
+		p.SetStrictMode(true)
+		return nil
+	}
+}
+
+// fail records err as the error stopping conversions in strict mode, unless
+// one was recorded already
+func (p *PDF) fail(err error) {
+	if p.strict && p.abortErr == nil {
+		p.abortErr = err
+	}
+}
@@ -1,45 +1,128 @@
 package svg2pdf
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 )
 
-// SVG represents the SVG document structure
+// SVG represents the SVG document structure. Nested svg elements use the
+// same type and establish a new viewport.
 type SVG struct {
-	XMLName   xml.Name   `xml:"http://www.w3.org/2000/svg svg"`
-	Width     string     `xml:"width,attr"`
-	Height    string     `xml:"height,attr"`
-	Rects     []Rect     `xml:"http://www.w3.org/2000/svg rect"`
-	Texts     []Text     `xml:"http://www.w3.org/2000/svg text"`
-	Paths     []Path     `xml:"http://www.w3.org/2000/svg path"`
-	Gradients []Gradient `xml:"http://www.w3.org/2000/svg linearGradient"`
+	XMLName    xml.Name   `xml:"http://www.w3.org/2000/svg svg"`
+	ID         string     `xml:"id,attr"`
+	Class      string     `xml:"class,attr"`
+	X          Length     `xml:"x,attr"` // Position of a nested viewport
+	Y          Length     `xml:"y,attr"`
+	Width      Length     `xml:"width,attr"`
+	Height     Length     `xml:"height,attr"`
+	FontSize   Length     `xml:"font-size,attr"`
+	ViewBox    string     `xml:"viewBox,attr"`
+	Aspect     string     `xml:"preserveAspectRatio,attr"`
+	Overflow   string     `xml:"overflow,attr"`
+	Clip       string     `xml:"clip,attr"` // Deprecated CSS 2 clip rectangle
+	Gradients  []Gradient `xml:"http://www.w3.org/2000/svg linearGradient"`
+	Styles     []Style    `xml:"http://www.w3.org/2000/svg style"`
+	Title      string     `xml:"http://www.w3.org/2000/svg title"`
+	Desc       string     `xml:"http://www.w3.org/2000/svg desc"`
+	Lang       string     `xml:"lang,attr"` // Matches both lang and xml:lang
+	Display    string     `xml:"display,attr"`
+	Visibility string     `xml:"visibility,attr"`
+	Blend      string     `xml:"mix-blend-mode,attr"`
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
+	Container
+	namespaces []xml.Attr // Namespace prefixes declared by a root, for the Rasterizer
+}
+
+// Container holds the graphics and container elements of svg, symbol and
+// defs elements
+type Container struct {
+	Rects    []Rect          `xml:"http://www.w3.org/2000/svg rect"`
+	Texts    []Text          `xml:"http://www.w3.org/2000/svg text"`
+	Paths    []Path          `xml:"http://www.w3.org/2000/svg path"`
+	Images   []Image         `xml:"http://www.w3.org/2000/svg image"`
+	SVGs     []SVG           `xml:"http://www.w3.org/2000/svg svg"`
+	Symbols  []Symbol        `xml:"http://www.w3.org/2000/svg symbol"`
+	Uses     []Use           `xml:"http://www.w3.org/2000/svg use"`
+	Defs     []Container     `xml:"http://www.w3.org/2000/svg defs"`
+	Clips    []ClipPath      `xml:"http://www.w3.org/2000/svg clipPath"`
+	Filters  []Filter        `xml:"http://www.w3.org/2000/svg filter"`
+	Foreign  []ForeignObject `xml:"http://www.w3.org/2000/svg foreignObject"`
+	Groups   []Group         `xml:"http://www.w3.org/2000/svg g"`
+	Switches []Switch        `xml:"http://www.w3.org/2000/svg switch"`
+	// Elements not drawn by the converter itself, drawn if a handler is
+	// registered for them
+	Extensions []Element `xml:",any"`
 }
 
 // Rect represents an SVG rectangle
 type Rect struct {
-	X      float64 `xml:"x,attr"`
-	Y      float64 `xml:"y,attr"`
-	Width  float64 `xml:"width,attr"`
-	Height float64 `xml:"height,attr"`
-	Stroke string  `xml:"stroke,attr"`
+	X          Length `xml:"x,attr"`
+	Y          Length `xml:"y,attr"`
+	Width      Length `xml:"width,attr"`
+	Height     Length `xml:"height,attr"`
+	Stroke     string `xml:"stroke,attr"`
+	Clip       string `xml:"clip-path,attr"`
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Blend      string `xml:"mix-blend-mode,attr"`
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
 }
 
 // Text represents an SVG text element
 type Text struct {
-	X       float64 `xml:"x,attr"`
-	Y       float64 `xml:"y,attr"`
-	Content string  `xml:",chardata"`
-	Font    string  `xml:"font,attr"`      // Add font attribute for customization
-	Size    float64 `xml:"font-size,attr"` // Font size support
+	X          LengthList `xml:"x,attr"`  // Absolute x per character
+	Y          LengthList `xml:"y,attr"`  // Absolute y per character
+	Dx         LengthList `xml:"dx,attr"` // Relative x shift per character
+	Dy         LengthList `xml:"dy,attr"` // Relative y shift per character
+	Content    string     `xml:",chardata"`
+	Font       string     `xml:"font,attr"`        // Add font attribute for customization
+	Family     string     `xml:"font-family,attr"` // Family list matched against registered fonts
+	Weight     string     `xml:"font-weight,attr"`
+	Style      string     `xml:"font-style,attr"`
+	Dir        string     `xml:"direction,attr"` // ltr or rtl
+	Writing    string     `xml:"writing-mode,attr"`
+	Clip       string     `xml:"clip-path,attr"`
+	Size       Length     `xml:"font-size,attr"` // Font size support
+	ID         string     `xml:"id,attr"`
+	Class      string     `xml:"class,attr"`
+	Display    string     `xml:"display,attr"`
+	Visibility string     `xml:"visibility,attr"`
+	Blend      string     `xml:"mix-blend-mode,attr"`
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
 }
 
-// Path represents an SVG path element
-type Path struct {
-	D string `xml:"d,attr"`
+// ForeignObject represents embedded non-SVG content, typically XHTML. Only
+// its text is used, when reflowing text into columns.
+type ForeignObject struct {
+	Content    string `xml:",innerxml"`
+	X          Length `xml:"x,attr"`
+	Y          Length `xml:"y,attr"`
+	Width      Length `xml:"width,attr"`
+	Height     Length `xml:"height,attr"`
+	Family     string `xml:"font-family,attr"`
+	Weight     string `xml:"font-weight,attr"`
+	Style      string `xml:"font-style,attr"`
+	Size       Length `xml:"font-size,attr"`
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Inline     string `xml:"style,attr"` // Inline CSS declarations
+	Conditions
+	markup *Element // Source, for the Rasterizer
 }
 
 // Gradient represents a gradient definition
@@ -62,11 +145,13 @@ type Stop struct {
 type PDF struct {
 	pages       []string
 	pageCount   int
-	content     []string
+	content     []*contentStream // Content stream of each page, parallel to pages
+	current     int              // Index of the page drawing operations go to
 	pageWidth   float64
 	pageHeight  float64
 	scaleX      float64
 	scaleY      float64
+	userScale   float64 // Points per unit of the user space drawn in, 0 in page space
 	currentX    float64
 	currentY    float64
 	columnWidth float64
@@ -75,16 +160,147 @@ type PDF struct {
 	maxRows     int
 	font        string  // Font for text rendering
 	fontSize    float64 // Font size
+	fonts       []*Font // Embedded fonts, referenced as F2, F3, ...
+	images      []*pdfImage
+	// Font lookup for families that are not registered
+	fontResolver  FontResolver
+	fontFallback  []string
+	resolvedFonts map[string]*Font
+	// colorKeyMasking selects /Mask color keys over soft masks where possible
+	colorKeyMasking bool
+	// Document-wide graphics state defaults, nil or empty when unset
+	renderingIntent         RenderingIntent
+	strokeAdjustment        *bool
+	lineWidth               *float64
+	lineJoin                *LineJoin
+	lineCap                 *LineCap
+	flatness                *float64
+	smoothness              *float64
+	logger                  *slog.Logger                        // Receives traces of conversions, nil for none
+	progress                func(done, total int, stage string) // Progress callback, nil for none
+	progressStates          map[string]progressState            // Progress last reported per stage
+	renderDone, renderTotal int                                 // Elements drawn on the current page, and their number if known
+	precision               *int                                // Decimals of coordinates, nil for the default
+	nup                     *nUpLayout                          // Several SVGs per page, nil for one each
+	tiling                  *Tiling                             // Split SVGs across pages, nil for one page each
+	background              *RGB                                // Fills every page, nil for none
+	limits                  *Limits                             // Caps on the work of conversions, nil for none
+	depth                   int                                 // Containers being drawn, the root included
+	usedSymbols             map[string]bool                     // Symbols being drawn by use, against circular references
+	watermark               *watermark                          // Drawn on every page, nil for none
+	header, footer          *PageTemplate                       // Drawn in the margins of every page, nil for none
+	templates               *templateState                      // Token values of the header and footer being written
+	elementID               string                              // Draw only this element, see SetElementID
+	elementCounts           map[string]int                      // Rendered elements by name, for Stats
+	writtenStats            map[int]PageStats                   // Statistics of streamed pages by content object
+	streamSizes             [streamKinds]int                    // Bytes of the streams of the last output by kind
+	loader                  ResourceLoader                      // Loads referenced resources, data: URIs only if nil
+	handlers                map[xml.Name]HandlerFunc            // Draw elements the converter does not support
+	ctx                     context.Context                     // Context of the running conversion, checked between elements
+	interrupted             bool                                // The conversion stopped because its context was done
+	textAsOutlines          bool                                // Draw embedded font text as glyph outlines
+	symbols                 map[string]*Symbol                  // Symbols of the SVG being converted, by id
+	clipPaths               map[string]*ClipPath
+	filters                 map[string]*Filter // Filters of the SVG being converted, by id
+	filterResolution        float64            // Dots per inch filtered elements are rasterized at, 0 for the default
+	rasterizer              Rasterizer         // Renders content the converter cannot draw, nil for none
+	namespaces              []xml.Attr         // Namespace prefixes declared by the root of the SVG being converted
+	styleSheets             []Style            // Style sheets of the SVG being converted
+	idSeed                  string             // Mixed into generated resource names
+	idSeedSet               bool
+	resourceIDs             map[string]string // Generated resource names to content digests
+	fitMode                 FitMode           // How the SVG canvas is scaled onto the page
+	objects                 []any             // Custom objects added through the object API
+	catalogEntries          Dict              // Custom document catalog entries
+	pageEntries             []Dict            // Custom page dictionary entries, per page
+	pageSizes               [][2]float64      // Width and height of each page in points
+	caption                 *Caption          // Caption drawn beneath converted figures
+	figureCount             int               // Number of the last captioned figure
+	minifyContent           bool              // Optimize content streams when writing
+	compressor              Compressor        // Compressor of all streams, when set
+	compressorSet           bool
+	kindCompressors         map[StreamKind]Compressor // Overrides per kind of stream
+	embeddingPolicy         FontEmbeddingPolicy
+	dpi                     float64      // SVG pixels per inch at actual size, 96 when 0
+	margins                 *pageMargins // Page margins, nil when unset
+	autoPageSize            bool         // Size pages to their SVG
+	afterPage               func(page *Page) error
+	orientation             Orientation // Zero when pages keep their size as given
+	redactions              []selector  // Elements replaced by black boxes
+	alignment               Alignment   // Placement of the canvas within the margins
+	imageColorPolicy        ImageColorPolicy
+	profiles                []*iccProfile // ICC profiles of embedded images
+	metadata                *Metadata     // Document information, nil when unset
+	svgTitle                string        // Title and description of the first SVG
+	svgDesc                 string
+	described               bool
+	audit                   bool                   // Fail on nondeterministic output
+	deterministic           bool                   // Leave out dates and derive encryption keys
+	objectStreams           bool                   // Pack objects into object streams
+	streamingParse          bool                   // Decode and draw SVGs element by element
+	concurrency             int                    // Goroutines for work done in parallel, 0 for GOMAXPROCS
+	stages                  *[stageCount]stageCost // Costs of the conversion stages when benchmarking
+	streaming               *streamState           // Output pages are written to as they are finished, nil to write at the end
+	auditLog                []string               // Nondeterministic inputs used
+	pdfa                    bool                   // Conform to PDF/A-2b
+	encryption              *encryption            // Passwords and permissions, nil if unencrypted
+	encryptionMethod        EncryptionMethod
+	svgProfile              SVGProfile               // Language level documents are validated against
+	cmyk                    *cmykOutput              // Conversion of colors to CMYK, nil for RGB
+	cmykSpace               *iccProfile              // Profile of the CMYK color space resource, once used
+	grayscale               bool                     // Convert colors to DeviceGray
+	mediaType               string                   // Media type of @media rules, "print" when empty
+	animationTime           *time.Duration           // Time animated SVGs are drawn at, nil to draw them unanimated
+	customProperties        map[string]string        // CSS custom properties inherited by the elements being drawn
+	propertyRules           []propertyRule           // Style rules setting properties resolved from style sheets, of the SVG being converted
+	invisible               bool                     // Visibility inherited by the elements being drawn
+	systemLanguages         []string                 // User language preferences for systemLanguage
+	languagePages           bool                     // Draw a page per language of switches
+	layersEnabled           bool                     // Map layer groups to optional content groups
+	layerVisibility         map[string]bool          // Initial visibility of layers, by name
+	layers                  []*layer                 // Optional content groups drawn with, in order of use
+	blendStates             map[string]string        // ExtGState resource names, by blend mode
+	blendModes              []string                 // Blend modes drawn with, in order of use
+	spotColors              map[RGB]*spotColor       // Spot colors, by the SVG color they replace
+	spots                   []*spotColor             // Spot colors drawn with, in order of use
+	encodings               map[textKey]string       // Encoded strings, by font and text
+	textForms               map[textKey]*formXObject // Outline text forms, by font, size and text
+	forms                   []*formXObject
+	tagged                  bool
+	structure               []pageStructure // Tagged content, per page
+	lang                    string          // Natural language of the document
+	outline                 bool            // Write a bookmark for every page
+	bookmarks               []string        // Bookmark titles, per page
+	written                 []int           // Content stream object per page once streamed, 0 before
+	pageBookmark            string          // Bookmark title of the pages being converted
+	contentOffset           [2]float64      // Shift of the canvas from its aligned position
+	flow                    *TextFlow       // Column layout of reflowed text, nil for absolute positioning
+	flowBlocks              []flowBlock     // Text of the page being converted to reflow
+	baselineGrid            *BaselineGrid   // Grid that laid out text snaps to, nil for none
+	eventHook               func(Event)     // Receives conversion events, nil when unset
+	report                  bool            // Embed the warnings as an attachment
+	warnings                []Warning       // Warnings of the conversions
+	unsupported             [][]Warning     // Scanned warnings of the remaining svg documents of the source
+	rootWarnings            []Warning       // Scanned warnings of the svg document being converted
+	strict                  bool            // Fail on the first warning
+	abortErr                error           // Stops all conversions: the first warning in strict mode or a failed element handler
+	attachSources           bool            // Attach the source SVGs
+	sources                 []attachment    // Source SVGs to attach
+	shaper                  TextShaper
+	worker                  *pageWorker // State of a copy drawing pages in parallel, nil for the document itself
 }
 
 // NewPDF creates a new PDF document with row and column support, custom fonts, and font size
+//
+// Deprecated: Use New or Convert with options. The grid parameters only
+// affect AddRow and AddColumn.
 func NewPDF(columns, rows int, font string, fontSize float64) *PDF {
 	return &PDF{
 		pages:       []string{},
 		pageCount:   0,
-		content:     []string{},
-		pageWidth:   595, // A4 in points (210mm at 72 DPI)
-		pageHeight:  842, // A4 in points (297mm at 72 DPI)
+		content:     []*contentStream{},
+		pageWidth:   A4.Width,
+		pageHeight:  A4.Height,
 		currentX:    0,
 		currentY:    0,
 		columnWidth: 150, // Default width for columns
@@ -122,16 +338,23 @@ func ApplyTransformation(x, y float64, transform string) (float64, float64) {
 
 // RenderGradient renders a simple linear gradient on a rectangle
 func (p *PDF) RenderGradient(gradient Gradient, x, y, w, h float64) {
+	if len(gradient.Stops) == 0 {
+		return // Nothing to paint without stops
+	}
+
 	// For simplicity, let's use the first gradient stop's color as the fill color
 	// More complex gradient logic can be added later.
-	gradientColor := gradient.Stops[0].Color // Use the first color for now
+	gradientColor, ok := parseColor(gradient.Stops[0].Color) // Use the first color for now
+	if !ok {
+		gradientColor = RGB{0, 0, 1}
+	}
 
 	// Render a simple rectangle with a solid color fill (linear gradient logic can be extended)
-	p.content = append(p.content,
-		fmt.Sprintf("%.2f %.2f %.2f %.2f re", x, y, w, h), // Define rectangle for gradient
-		"0 0 1 RG", // Set color (for simplicity, using one color here)
-		"S",        // Apply fill
-	)
+	shape := p.newContentStream()
+	shape.rect(x, y, w, h)         // Define rectangle for gradient
+	shape.setStroke(gradientColor) // Set color from the first stop
+	shape.stroke()                 // Apply fill
+	p.emitStream(shape)
 }
 
 // AddTextWithUnicode renders text with font size, font, and Unicode support
@@ -139,214 +362,1115 @@ func (p *PDF) AddTextWithUnicode(x, y float64, text string) {
 	escapedText := escapeText(text)
 	stream := []string{
 		"BT",
-		fmt.Sprintf("/F1 %.2f Tf", p.fontSize), // Set font size
-		fmt.Sprintf("%.2f %.2f Td", x, y),      // Set position
-		fmt.Sprintf("(%s) Tj", escapedText),    // Render text
+		"/F1 " + p.operator("Tf", p.fontSize), // Set font size
+		p.operator("Td", x, y),                // Set position
+		fmt.Sprintf("(%s) Tj", escapedText),   // Render text
 		"ET",
 	}
-	p.content = append(p.content, strings.Join(stream, "\n"))
+	p.emit(stream...)
 }
 
-// AddPage adds a new page to the PDF
+// AddPage adds a new page to the end of the PDF and makes it the current page
 func (p *PDF) AddPage() {
 	p.pageCount++
-	page := fmt.Sprintf("Page %d", p.pageCount)
+	page := fmt.Sprintf("Page %d", p.pageIndex(p.pageCount-1)+1)
 	p.pages = append(p.pages, page)
-	p.content = append(p.content, "")
+	p.content = append(p.content, p.newContentStream())
+	p.pageEntries = append(p.pageEntries, nil)
+	p.pageSizes = append(p.pageSizes, [2]float64{p.pageWidth, p.pageHeight})
+	p.structure = append(p.structure, pageStructure{})
+	p.bookmarks = append(p.bookmarks, "")
+	p.written = append(p.written, 0)
+	p.current = p.pageCount - 1
+}
+
+// InsertPageAt inserts a blank page at index i and makes it the current page,
+// e.g. to add a cover page after the main conversion pass
+func (p *PDF) InsertPageAt(i int) error {
+	if i < 0 || i > p.pageCount {
+		return fmt.Errorf("page index %d out of range [0, %d]", i, p.pageCount)
+	}
+	p.pageCount++
+	p.pages = slices.Insert(p.pages, i, "")
+	p.content = slices.Insert(p.content, i, p.newContentStream())
+	p.pageEntries = slices.Insert(p.pageEntries, i, nil)
+	p.pageSizes = slices.Insert(p.pageSizes, i, [2]float64{p.pageWidth, p.pageHeight})
+	p.structure = slices.Insert(p.structure, i, pageStructure{})
+	p.bookmarks = slices.Insert(p.bookmarks, i, "")
+	p.written = slices.Insert(p.written, i, 0)
+	p.current = i
+	p.labelPages()
+	return nil
+}
+
+// labelPages names the pages after their position, once pages are inserted
+// or moved
+func (p *PDF) labelPages() {
+	for i := range p.pages {
+		p.pages[i] = fmt.Sprintf("Page %d", p.pageIndex(i)+1)
+	}
+}
+
+// MovePage moves the page at index from to index to, shifting the pages in between
+func (p *PDF) MovePage(from, to int) error {
+	if from < 0 || from >= p.pageCount {
+		return fmt.Errorf("page index %d out of range [0, %d)", from, p.pageCount)
+	}
+	if to < 0 || to >= p.pageCount {
+		return fmt.Errorf("page index %d out of range [0, %d)", to, p.pageCount)
+	}
+	page, content, entries, size := p.pages[from], p.content[from], p.pageEntries[from], p.pageSizes[from]
+	structure, bookmark, written := p.structure[from], p.bookmarks[from], p.written[from]
+	p.pages = slices.Insert(slices.Delete(p.pages, from, from+1), to, page)
+	p.content = slices.Insert(slices.Delete(p.content, from, from+1), to, content)
+	p.pageEntries = slices.Insert(slices.Delete(p.pageEntries, from, from+1), to, entries)
+	p.pageSizes = slices.Insert(slices.Delete(p.pageSizes, from, from+1), to, size)
+	p.structure = slices.Insert(slices.Delete(p.structure, from, from+1), to, structure)
+	p.bookmarks = slices.Insert(slices.Delete(p.bookmarks, from, from+1), to, bookmark)
+	p.written = slices.Insert(slices.Delete(p.written, from, from+1), to, written)
+
+	// Keep drawing on the same page it was on before the move
+	switch {
+	case p.current == from:
+		p.current = to
+	case from < p.current && p.current <= to:
+		p.current--
+	case to <= p.current && p.current < from:
+		p.current++
+	}
+	p.labelPages()
+	return nil
+}
+
+// PageCount returns the number of pages in the PDF
+func (p *PDF) PageCount() int {
+	return p.pageCount
+}
+
+// emit appends content stream operations to the current page
+func (p *PDF) emit(ops ...string) {
+	if p.pageCount == 0 {
+		p.AddPage()
+	}
+	if len(ops) == 0 {
+		return
+	}
+	if page := p.page(); page != nil {
+		page.op(ops...)
+	}
+}
+
+// emitStream appends the operators of c to the current page
+func (p *PDF) emitStream(c *contentStream) {
+	if p.pageCount == 0 {
+		p.AddPage()
+	}
+	if c.Len() == 0 {
+		return
+	}
+	if page := p.page(); page != nil {
+		page.append(c)
+	}
+}
+
+// page returns the content stream of the current page, or nil if the page
+// was already written
+func (p *PDF) page() *contentStream {
+	if p.written[p.current] != 0 {
+		p.warn("", "", "page already written, content drawn on it is lost")
+		return nil
+	}
+	return p.content[p.current]
 }
 
 // ConvertSVGToPDF processes the SVG file and handles elements (gradients, transformations, etc.)
 func (p *PDF) ConvertSVGToPDF(svgFilePath string) error {
-	// Open SVG file
-	svgFile, err := os.Open(svgFilePath)
+	// Read SVG file
+	source, err := os.ReadFile(svgFilePath)
 	if err != nil {
 		return fmt.Errorf("error opening SVG file: %v", err)
 	}
-	defer svgFile.Close()
+	return p.ConvertSVGBytes(source)
+}
 
-	// Parse SVG content
-	var svgData SVG
-	if err := xml.NewDecoder(svgFile).Decode(&svgData); err != nil {
-		return fmt.Errorf("error decoding SVG: %v", err)
+// ConvertSVG converts the SVG document read from r, e.g. an uploaded file
+func (p *PDF) ConvertSVG(r io.Reader) error {
+	if p.streamingParse && !p.attachSources && p.svgProfile == SVG2Profile && p.animationTime == nil {
+		return p.convertStream(r, 0)
+	}
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading SVG: %v", err)
 	}
+	return p.ConvertSVGBytes(source)
+}
+
+// ConvertSVGBytes converts the SVG document held in source. Sources holding
+// several concatenated svg documents, as produced by some export pipelines,
+// are converted to one page per document.
+func (p *PDF) ConvertSVGBytes(source []byte) error {
+	p.seedIDs(source)
+	if err := p.measure(stageParse, func() error { return p.checkProfile(source) }); err != nil {
+		return err
+	}
+	p.recordSource(source)
+	source = p.animationFrame(source)
+	if p.streamingParse {
+		return p.convertStream(bytes.NewReader(source), len(source))
+	}
+	p.measure(stageParse, func() error {
+		p.unsupported = scanUnsupported(source, p.handled, p.entityBytes())
+		return nil
+	})
+
+	// Parse SVG content, one root element at a time
+	decoder := newDecoder(bytes.NewReader(source), p.entityBytes())
+	p.reportProgress(ProgressParse, 0, len(source))
+	for n := 1; ; n++ {
+		if p.canceled() {
+			return p.abortErr
+		}
+		var svgData SVG
+		err := p.measure(stageParse, func() error { return decodeRoot(decoder, &svgData) })
+		if err == io.EOF && n > 1 {
+			p.reportProgress(ProgressParse, len(source), len(source))
+			return nil // Only trailing whitespace or comments remain
+		}
+		if err != nil {
+			return decodeError(n, err, decoder)
+		}
+		p.reportProgress(ProgressParse, int(decoder.InputOffset()), len(source))
+		if err := p.measure(stageRender, func() error { return p.convertRoot(&svgData) }); err != nil {
+			return err
+		}
+	}
+}
+
+// decodedSource is an SVG source decoded ahead of being drawn
+type decodedSource struct {
+	roots       []*SVG      // Documents decoded before any error
+	unsupported [][]Warning // Unsupported content of each document
+	invalid     error       // Violation of the SVG profile
+	err         error       // Error decoding the document after the roots
+}
+
+// decodeSource validates source and decodes its svg documents without
+// changing the PDF, so sources may be decoded concurrently
+func (p *PDF) decodeSource(source []byte) decodedSource {
+	var d decodedSource
+	if d.invalid = p.checkProfile(source); d.invalid != nil {
+		return d
+	}
+	source = p.animationFrame(source)
+	d.unsupported = scanUnsupported(source, p.handled, p.entityBytes())
+	decoder := newDecoder(bytes.NewReader(source), p.entityBytes())
+	for n := 1; ; n++ {
+		svgData := new(SVG)
+		err := decodeRoot(decoder, svgData)
+		if err == io.EOF && n > 1 {
+			return d // Only trailing whitespace or comments remain
+		}
+		if err != nil {
+			d.err = decodeError(n, err, decoder)
+			return d
+		}
+		d.roots = append(d.roots, svgData)
+	}
+}
+
+// convertDecoded converts source, decoded by decodeSource, as
+// ConvertSVGBytes does
+func (p *PDF) convertDecoded(source []byte, d decodedSource) error {
+	p.seedIDs(source)
+	if d.invalid != nil {
+		return d.invalid
+	}
+	p.recordSource(source)
+	p.unsupported = d.unsupported
+	for _, svgData := range d.roots {
+		if p.canceled() {
+			return p.abortErr
+		}
+		if err := p.convertRoot(svgData); err != nil {
+			return err
+		}
+	}
+	return d.err
+}
+
+// convertRoot draws a decoded svg document on a new page
+func (p *PDF) convertRoot(svgData *SVG) error {
+	p.recordDescription(svgData)
+	p.nextUnsupported()
+
+	// Register fonts embedded through @font-face rules
+	styles := p.documentStyles(svgData)
+	if err := p.registerFontFaces(styles); err != nil {
+		return err
+	}
+	p.setPropertyRules(styles)
+
+	// Multi-locale SVGs get a page per language of their switches
+	if languages := svgData.languages(); p.languagePages && len(languages) > 0 {
+		saved := p.systemLanguages
+		defer func() { p.systemLanguages = saved }()
+		for _, lang := range languages {
+			if p.canceled() {
+				return p.abortErr
+			}
+			p.systemLanguages = []string{lang}
+			if err := p.drawRoot(svgData); err != nil {
+				return err
+			}
+		}
+		return p.abortErr
+	}
+	if err := p.drawRoot(svgData); err != nil {
+		return err
+	}
+	return p.abortErr
+}
+
+// drawRoot draws a decoded svg document on a new page
+func (p *PDF) drawRoot(svgData *SVG) error {
+	d := p.beginRoot(svgData)
+	defer d.restore()
+	p.renderContainer(p.selectElement(&svgData.Container, d), d.ctx)
+	return p.endRoot(d)
+}
+
+// rootDrawing is an svg document being drawn on a page, between beginRoot
+// and endRoot
+type rootDrawing struct {
+	ctx         unitContext // Context of the root viewport
+	box         viewBox     // Area the figure is fitted into
+	caption     []string
+	captionSize float64
+	bottom      float64 // Bottom of the figure, measured from the top of the page
+	restore     func()  // Restores the page size after the document
+	start       time.Time
+	selected    bool // The element selected by SetElementID was drawn
+	// Length of the page content before the document, which may share the
+	// page with others
+	contentStart int
+}
+
+// rootViewport returns the size of an svg document in user units and the
+// context its lengths resolve in
+func (p *PDF) rootViewport(svgData *SVG) (unitContext, float64, float64) {
+	// Relative units resolve against the root font size, which defaults to
+	// the PDF font size
+	ctx := unitContext{fontSize: p.fontSize, mediumSize: p.fontSize, viewportW: 400, viewportH: 150}
+	ctx = ctx.withFontSize(svgData.FontSize)
+	ctx.rootFontSize = ctx.fontSize
 
-	// Adjust SVG dimensions to fit the page, with scaling
 	svgWidth, svgHeight := 400.0, 150.0
+	if vb, ok := parseViewBox(svgData.ViewBox); ok {
+		svgWidth, svgHeight = vb.W, vb.H // Intrinsic size without width and height
+	}
 	if svgData.Width != "" && svgData.Height != "" {
-		svgWidth, _ = strconv.ParseFloat(svgData.Width, 64)
-		svgHeight, _ = strconv.ParseFloat(svgData.Height, 64)
+		svgWidth = ctx.resolve(svgData.Width, axisX, svgWidth)
+		svgHeight = ctx.resolve(svgData.Height, axisY, svgHeight)
+	}
+	return ctx.withViewport(svgWidth, svgHeight), svgWidth, svgHeight
+}
+
+// beginRoot starts a new page for an svg document, indexing the elements
+// its content refers to, and sets up the mapping of its user space onto the
+// page
+func (p *PDF) beginRoot(svgData *SVG) *rootDrawing {
+	d := &rootDrawing{restore: func() {}, start: time.Now()}
+	if p.tiling != nil {
+		defer p.posterSettings()()
+	}
+
+	// Adjust SVG dimensions to fit the page, with scaling
+	ctx, svgWidth, svgHeight := p.rootViewport(svgData)
+
+	// Pages sized to the SVG hold it at actual size within the margins,
+	// unless it shares the page with others
+	nUp := p.nup != nil && p.tiling == nil
+	if p.autoPageSize && !nUp {
+		width, height := p.pageWidth, p.pageHeight
+		d.restore = func() { p.pageWidth, p.pageHeight = width, height }
+		m := p.pageMargins()
+		p.pageWidth = svgWidth*p.actualScale() + m.left + m.right
+		p.pageHeight = svgHeight*p.actualScale() + m.top + m.bottom
 	}
 
-	// Scale factor to fit SVG content into PDF page
-	p.scaleX = p.pageWidth / svgWidth
-	p.scaleY = p.pageHeight / svgHeight
+	// Figures are fitted within the page margins, or their n-up cell, above
+	// any caption
+	box, newPage := p.contentBox(), true
+	if nUp {
+		box, newPage = p.nextCell(box)
+	}
+	var caption []string
+	var captionSize float64
+	if p.caption != nil {
+		caption, captionSize = p.captionLines(svgData.Title, box.W)
+		if p.autoPageSize {
+			p.pageHeight += p.captionHeight(caption, captionSize) // Grow the page instead of shrinking the figure
+		} else {
+			box.H = max(box.H-p.captionHeight(caption, captionSize), 0)
+		}
+	}
+
+	// Scale factor and offset to fit SVG content into PDF page
+	var offsetX, offsetY float64
+	p.scaleX, p.scaleY, offsetX, offsetY = p.fitBox(svgWidth, svgHeight, box)
 
 	// Start a new page and layout elements into grid
-	p.AddPage()
+	if newPage {
+		p.AddPage()
+		p.recordStructure(svgData)
+		p.recordBookmark(svgData)
+	}
+	p.reportUnsupported()
+	p.startRender(&svgData.Container)
 
 	// Process gradients (rendering a basic linear gradient)
 	for _, gradient := range svgData.Gradients {
+		if p.elementID != "" {
+			break // Not part of the selected element
+		}
 		p.RenderGradient(gradient, 100, 100, 200, 50) // Sample rectangle with gradient
 	}
 
+	// Process SVG elements, indexing referenced elements first. A
+	// single page-level matrix maps the y-down SVG user space into the y-up
+	// PDF space, so all geometry is emitted in SVG coordinates.
+	p.flowBlocks = nil
+	p.symbols = make(map[string]*Symbol)
+	p.clipPaths = make(map[string]*ClipPath)
+	p.filters = make(map[string]*Filter)
+	p.namespaces = svgData.namespaces
+	p.indexReferences(&svgData.Container)
+	p.rootProperties(svgData)
+	p.rootVisibility(svgData)
+	if c, ok := p.rootBackground(svgData); ok {
+		fill := viewBox{0, 0, p.pageWidth, p.pageHeight}
+		if nUp {
+			fill = box
+		}
+		p.emit(p.fillBackground(c, fill, p.pageHeight)...)
+	}
+	d.contentStart = p.content[p.current].Len()
+	p.emit("q")
+	if (p.caption != nil || p.margins != nil || nUp) && p.elementID == "" {
+		// Keep covering figures clear of the margins, caption and other
+		// cells. A selected element is fitted into the box after drawing
+		// instead.
+		p.emit(p.operator("re W n", box.X, p.pageHeight-box.Y-box.H, box.W, box.H))
+	}
+	p.emit(p.transform("cm", p.scaleX, 0, 0, -p.scaleY, offsetX, p.pageHeight-offsetY))
+	p.userScale = max(p.scaleX, p.scaleY)
+	// A root viewBox maps the drawing into the canvas
+	if vb, ok := parseViewBox(svgData.ViewBox); ok {
+		sx, sy, tx, ty := parseAspectRatio(svgData.Aspect).fit(vb, svgWidth, svgHeight)
+		p.emit(p.transform("cm", sx, 0, 0, sy, tx, ty))
+		p.userScale *= max(sx, sy)
+		ctx = ctx.withViewport(vb.W, vb.H)
+	}
+	d.ctx, d.box, d.caption, d.captionSize = ctx, box, caption, captionSize
+	d.bottom = min(offsetY+svgHeight*p.scaleY, box.Y+box.H)
+	return d
+}
+
+// endRoot finishes the page of an svg document with its caption and
+// reflowed text
+func (p *PDF) endRoot(d *rootDrawing) error {
+	if err := p.finishSelection(d); err != nil {
+		return err
+	}
+	p.emit("Q")
+	p.userScale = 0 // Back in page space
+	if p.tiling != nil {
+		p.finishRender()
+		return p.splitTiles(d)
+	}
+	p.drawCaption(d.caption, d.captionSize, d.box, d.bottom)
+	if err := p.drawFlow(d.box); err != nil {
+		return err
+	}
+	p.finishRender()
+	p.log(slog.LevelDebug, "page converted", "page", p.pageIndex(p.current)+1, "duration", time.Since(d.start))
+	if !p.pageFull() {
+		return nil // Finished once its cells are taken
+	}
+	return p.finishPage()
+}
+
+// renderContainer draws the elements of a container in the current viewport,
+// resolving lengths against ctx
+func (p *PDF) renderContainer(c *Container, ctx unitContext) {
+	defer p.unnest()
+	if !p.nest() {
+		return
+	}
+
 	// Process SVG elements (rectangles, text, paths)
-	var stream []string
-	for _, rect := range svgData.Rects {
+	shapes := p.newContentStream()
+	for _, rect := range c.Rects {
+		if p.step() {
+			return
+		}
+		if !p.holds(rect.Conditions) || p.hidden("rect", rect.ID, rect.Class, rect.Display, rect.Visibility, rect.Inline) {
+			continue
+		}
 		p.AddColumn()
-		x := rect.X * p.scaleX
-		y := p.pageHeight - (rect.Y * p.scaleY)
-		w := rect.Width * p.scaleX
-		h := rect.Height * p.scaleY
+		x, y := ctx.resolve(rect.X, axisX, 0), ctx.resolve(rect.Y, axisY, 0)
+		w, h := ctx.resolve(rect.Width, axisX, 0), ctx.resolve(rect.Height, axisY, 0)
+		if p.redacts("rect", rect.ID, rect.Class) {
+			p.drawRedaction(viewBox{x, y, w, h})
+			continue
+		}
 
 		// Append drawing instructions for rectangles
-		stream = append(stream,
-			fmt.Sprintf("%.2f %.2f m", x, y),
-			fmt.Sprintf("%.2f %.2f l", x+w, y),
-			fmt.Sprintf("%.2f %.2f l", x+w, y-h),
-			fmt.Sprintf("%.2f %.2f l", x, y-h),
-			"h",        // Close path
-			"0 0 0 RG", // Black stroke
-			"S",        // Stroke
-		)
+		blend := p.blendMode("rect", rect.ID, rect.Blend, rect.Inline)
+		shapes.op(p.beginBlend(blend)...)
+		clip := p.clipOps(rect.Clip, viewBox{x, y, w, h}, ctx)
+		shapes.op(clip...)
+		shapes.op(p.beginMarked("Figure")...)
+		shapes.moveTo(x, y)
+		shapes.lineTo(x+w, y)
+		shapes.lineTo(x+w, y+h)
+		shapes.lineTo(x, y+h)
+		shapes.closePath()
+		shapes.setStroke(RGB{}) // Black stroke
+		shapes.stroke()
+		shapes.op(p.endMarked()...)
+		if clip != nil {
+			shapes.op("Q")
+		}
+		shapes.op(endBlend(blend)...)
+		p.rendered("rect", rect.ID)
+	}
+
+	// Process images, skipping references that cannot be decoded
+	for _, image := range c.Images {
+		if p.step() {
+			return
+		}
+		if !p.holds(image.Conditions) || p.hidden("image", image.ID, image.Class, image.Display, image.Visibility, image.Inline) {
+			continue
+		}
+		x, y := ctx.resolve(image.X, axisX, 0), ctx.resolve(image.Y, axisY, 0)
+		w, h := ctx.resolve(image.Width, axisX, 0), ctx.resolve(image.Height, axisY, 0)
+		if p.redacts("image", image.ID, image.Class) {
+			p.drawRedaction(viewBox{x, y, w, h}) // The image is never loaded
+			continue
+		}
+		img, err := p.loadImage(image.Href)
+		if err != nil {
+			p.fail(&ResourceError{Op: "loading", Kind: "image", Name: truncate(image.Href, 32), Err: err})
+			p.warn("image", image.ID, "image skipped: %v", err)
+			continue
+		}
+		blend := p.blendMode("image", image.ID, image.Blend, image.Inline)
+		p.emit(p.beginBlend(blend)...)
+		clip := p.clipOps(image.ClipPath, viewBox{x, y, w, h}, ctx)
+		p.emit(clip...)
+		p.emit(p.beginMarked("Figure")...)
+		p.drawImage(img, x, y, w, h, image, ctx)
+		p.emit(p.endMarked()...)
+		if clip != nil {
+			p.emit("Q")
+		}
+		p.emit(endBlend(blend)...)
+		p.rendered("image", image.ID)
+	}
+
+	// Process paths
+	for _, path := range c.Paths {
+		if p.step() {
+			return
+		}
+		if !p.holds(path.Conditions) || p.hidden("path", path.ID, path.Class, path.Display, path.Visibility, path.Inline) {
+			continue
+		}
+		restore := p.scopeProperties("path", path.ID, path.Class, path.Inline)
+		blend := p.blendMode("path", path.ID, path.Blend, path.Inline)
+		p.emit(p.beginBlend(blend)...)
+		p.emit(p.beginMarked("Figure")...)
+		if f := p.filterOf("path", path.ID, path.FilterRef, path.Inline); f != nil && !p.redacts("path", path.ID, path.Class) {
+			if s, ok := p.pathShape(path); !ok {
+				p.drawPath(path, ctx) // Nothing to filter, drawn for its warnings
+			} else if p.drawFiltered(f, path.markup, []rasterShape{s}, ctx, func() { p.drawPath(path, ctx) }, nil) {
+				p.rendered("path", path.ID)
+			}
+		} else {
+			p.drawPath(path, ctx)
+		}
+		p.emit(p.endMarked()...)
+		p.emit(endBlend(blend)...)
+		restore()
 	}
 
 	// Process text elements
-	for _, text := range svgData.Texts {
+	for _, text := range c.Texts {
+		if p.step() {
+			return
+		}
+		if !p.holds(text.Conditions) || p.hidden("text", text.ID, text.Class, text.Display, text.Visibility, text.Inline) {
+			continue
+		}
 		p.AddColumn()
-		x := text.X * p.scaleX
-		y := p.pageHeight - (text.Y * p.scaleY)
-		// Apply transformations and add text with font
-		x, y = ApplyTransformation(x, y, "rotate")
-		p.AddTextWithUnicode(x, y, text.Content)
+		textCtx := ctx.withFontSize(text.Size)
+		fontSize := textCtx.fontSize
+		family := text.Family
+		if family == "" {
+			family = text.Font
+		}
+		face := p.resolveFont(family, text.Weight, text.Style)
+		// Lay out per-character positions in user space
+		runs := layoutTextRuns(text, face, textCtx)
+		// Right-to-left runs are only moved in horizontal text
+		runs = p.shapeRuns(runs, text.Dir == "rtl" && !isVertical(text.Writing), face, fontSize)
+		if p.redacts("text", text.ID, text.Class) {
+			// Layout only measures glyphs, none of them are embedded
+			p.drawRedaction(runsBBox(runs, face, fontSize))
+			continue
+		}
+		if p.reflows(text) {
+			p.addFlowBlock(face, fontSize, text.Content)
+			p.rendered("text", text.ID)
+			continue
+		}
+		// Text is commonly clipped to its cell, e.g. truncated labels
+		blend := p.blendMode("text", text.ID, text.Blend, text.Inline)
+		p.emit(p.beginBlend(blend)...)
+		clip := p.clipOps(text.Clip, runsBBox(runs, face, fontSize), textCtx)
+		p.emit(clip...)
+		p.emit(p.beginMarked("Span")...)
+		if font, ok := isOutlineFont(face); ok && p.textAsOutlines {
+			p.drawTextOutlines(runs, font, fontSize)
+		} else {
+			p.drawTextRuns(runs, face, fontSize)
+		}
+		p.emit(p.endMarked()...)
+		if clip != nil {
+			p.emit("Q")
+		}
+		p.emit(endBlend(blend)...)
+		p.rendered("text", text.ID)
+	}
+
+	// Foreign content is rasterized, or else only used for its text, drawn
+	// in place or set in columns after the page's graphics
+	for _, fo := range c.Foreign {
+		if p.step() {
+			return
+		}
+		if !p.holds(fo.Conditions) || p.hidden("foreignObject", fo.ID, fo.Class, fo.Display, fo.Visibility, fo.Inline) {
+			continue
+		}
+		if p.redacts("foreignObject", fo.ID, fo.Class) {
+			continue
+		}
+		if p.flow == nil && p.rasterizer != nil && fo.markup != nil {
+			box := viewBox{ctx.resolve(fo.X, axisX, 0), ctx.resolve(fo.Y, axisY, 0),
+				ctx.resolve(fo.Width, axisX, 0), ctx.resolve(fo.Height, axisY, 0)}
+			p.emit(p.beginMarked("Figure")...)
+			ok := p.rasterize(fo.markup, nil, box)
+			p.emit(p.endMarked()...)
+			if ok {
+				p.rendered("foreignObject", fo.ID)
+				continue
+			}
+		}
+		if p.flow == nil {
+			p.drawForeignText(fo, ctx)
+			p.rendered("foreignObject", fo.ID)
+			continue
+		}
+		foCtx := ctx.withFontSize(fo.Size)
+		face := p.resolveFont(fo.Family, fo.Weight, fo.Style)
+		for _, para := range paragraphs(fo.Content) {
+			p.addFlowBlock(face, foCtx.fontSize, para)
+		}
 	}
 
 	// Add all processed stream content
-	p.content = append(p.content, strings.Join(stream, "\n"))
-	return nil
+	p.emitStream(shapes)
+	p.renderExtensions(c, ctx)
+
+	// Nested viewports are drawn on top, in their own graphics state
+	for i := range c.SVGs {
+		if p.step() {
+			return
+		}
+		p.renderNestedSVG(&c.SVGs[i], ctx)
+	}
+	for _, use := range c.Uses {
+		if p.step() {
+			return
+		}
+		p.renderUse(use, ctx)
+	}
+	for i := range c.Groups {
+		if p.step() {
+			return
+		}
+		p.renderGroup(&c.Groups[i], ctx)
+	}
+	for i := range c.Switches {
+		if p.step() {
+			return
+		}
+		p.renderSwitch(&c.Switches[i], ctx)
+	}
 }
 
 // Save saves the PDF to a file
 func (p *PDF) Save(filePath string) error {
-	var pdfContent []string
+	var out bytes.Buffer
+	if err := p.Write(&out); err != nil {
+		return err
+	}
 
-	// PDF Header
-	pdfContent = append(pdfContent,
-		"%PDF-1.4",
-		"%âãÏÓ",
-	)
+	// Write to file
+	if err := os.WriteFile(filePath, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing PDF: %v", err)
+	}
+	p.log(slog.LevelInfo, "PDF saved", "path", filePath, "bytes", out.Len())
+	return nil
+}
+
+// Write serializes the PDF to out, e.g. an HTTP response or a bytes.Buffer
+func (p *PDF) Write(out io.Writer) error {
+	if p.streaming != nil {
+		return fmt.Errorf("document is streamed to its output; finish it with Close")
+	}
+	if p.audit {
+		return p.auditedWrite(out)
+	}
+	if p.logger == nil {
+		return p.write(out)
+	}
+	start, counted := time.Now(), &countingWriter{w: out}
+	err := p.write(counted)
+	p.log(slog.LevelDebug, "PDF written", "pages", p.pageCount, "bytes", counted.n, "duration", time.Since(start), "error", err)
+	return err
+}
+
+// write serializes the PDF to out
+func (p *PDF) write(out io.Writer) error {
+	if err := p.finishNUpPage(); err != nil {
+		return err
+	}
+	if err := p.prepareWatermark(); err != nil {
+		return err
+	}
+	p.prepareTemplates()
+	if p.pdfa {
+		if violations := p.pdfaViolations(); len(violations) > 0 {
+			return fmt.Errorf("document does not conform to PDF/A-2b: %s", strings.Join(violations, "; "))
+		}
+	}
+
+	// Number every object up front so references can be resolved in any
+	// order. PDF/A documents never use the built-in font, which cannot be
+	// embedded.
+	var ids objectAllocator
+	var catalogObj, pagesObj, helveticaObj int
+	s := p.streaming
+	if s != nil {
+		// Page content was written as pages were finished
+		if err := p.flushPages(); err != nil {
+			return err
+		}
+		ids, catalogObj, pagesObj = s.ids, s.catalogObj, s.pagesObj
+	} else {
+		catalogObj, pagesObj = ids.next(), ids.next()
+	}
+	if !p.pdfa {
+		helveticaObj = ids.next()
+	}
+	pageObjs := make([]int, p.pageCount)
+	contentObjs := make([]int, p.pageCount)
+	for i := range pageObjs {
+		if s != nil {
+			pageObjs[i], contentObjs[i] = ids.next(), p.written[i]
+		} else {
+			pageObjs[i], contentObjs[i] = ids.next(), ids.next()
+		}
+	}
+
+	// Only fonts that were drawn with are embedded
+	var fonts []*Font
+	var fontObjs []int
+	for _, font := range p.fonts {
+		if len(font.used) > 0 {
+			fonts = append(fonts, font)
+			fontObjs = append(fontObjs, ids.reserve(5))
+		}
+	}
+	imageObjs := make([]int, len(p.images))
+	for i, img := range p.images {
+		imageObjs[i] = ids.reserve(img.objectCount())
+	}
+	formObjs := ids.reserve(len(p.forms))
+	for _, profile := range p.profiles {
+		profile.obj = ids.next()
+	}
+	for _, spot := range p.spots {
+		spot.obj = ids.next()
+	}
+	gstateObj, gstateName := 0, ""
+	if p.hasGraphicsState() {
+		gstateObj = ids.next()
+		gstateName = p.graphicsStateName()
+	}
+	blendObjs := ids.reserve(len(p.blendModes))
+	watermarkObj := 0
+	if p.watermark != nil && p.watermark.state != "" {
+		watermarkObj = ids.next()
+	}
+	for _, lay := range p.layers {
+		lay.obj = ids.next()
+	}
+	structureObj := 0
+	if p.tagged {
+		structureObj = ids.reserve(p.structureObjectCount())
+	}
+	outlineObj := 0
+	if p.outline && p.pageCount > 0 {
+		outlineObj = ids.reserve(p.pageCount + 1)
+	}
+
+	// Document information and image rights are recorded as XMP metadata
+	// unless the caller set its own
+	var created time.Time
+	if p.documentMetadata() != nil {
+		created = p.creationDate()
+	}
+	xmp, xmpObj := p.xmpPacket(created), 0
+	if _, custom := p.catalogEntries["Metadata"]; xmp != nil && !custom {
+		xmpObj = ids.next()
+	}
+
+	// Document information
+	info, infoObj := p.infoEntries(created), 0
+	if info != nil {
+		infoObj = ids.next()
+	}
+	outputProfileObj := 0
+	if p.pdfa {
+		outputProfileObj = ids.next()
+	}
+
+	// Attachments are an embedded file and its file specification each,
+	// unless the caller set their own name trees
+	var attachments []attachment
+	attachmentObjs := 0
+	if _, custom := p.catalogEntries["Names"]; !custom {
+		attachments = p.attachments()
+		attachmentObjs = ids.reserve(2 * len(attachments))
+	}
+
+	// Encryption needs a later PDF version
+	version, encryptObj := "1.4", 0
+	var crypt *encryptor
+	if s != nil {
+		version, encryptObj, crypt = s.version, s.encryptObj, s.crypt
+	} else if p.encryption != nil {
+		var err error
+		if crypt, err = p.newEncryptor(p.encryption); err != nil {
+			return err
+		}
+		version, encryptObj = crypt.version, ids.next()
+	}
+	if (len(p.layers) > 0 || p.objectStreams) && version < "1.5" {
+		version = "1.5" // Optional content and object streams
+	}
+	// OpenType font files need 1.6; the header of a streamed document is
+	// already written, so its catalog raises the version
+	raiseVersion := false
+	if slices.ContainsFunc(fonts, func(f *Font) bool { return f.cff }) && version < "1.6" {
+		version, raiseVersion = "1.6", s != nil
+	}
+
+	// Custom objects added through the object API come last
+	objects := objectWriter{doc: p, first: ids.reserve(len(p.objects))}
+	catalogEntries, err := objects.entries(p.catalogEntries)
+	if err != nil {
+		return fmt.Errorf("error writing catalog: %v", err)
+	}
+	if xmpObj != 0 {
+		catalogEntries = append(catalogEntries, "/Metadata "+ref(xmpObj))
+	}
+	if outputProfileObj != 0 {
+		catalogEntries = append(catalogEntries, outputIntent(outputProfileObj))
+	}
+	if p.cmyk != nil {
+		profileObj := 0
+		if p.cmyk.profile != nil {
+			profileObj = p.cmyk.profile.obj
+		}
+		catalogEntries = append(catalogEntries, p.cmyk.outputIntent(profileObj))
+	}
+	if structureObj != 0 {
+		catalogEntries = append(catalogEntries, "/MarkInfo << /Marked true >>", "/StructTreeRoot "+ref(structureObj))
+		if p.lang != "" {
+			catalogEntries = append(catalogEntries, "/Lang "+textString(p.lang))
+		}
+	}
+	if outlineObj != 0 {
+		catalogEntries = append(catalogEntries, "/Outlines "+ref(outlineObj), "/PageMode /UseOutlines")
+	}
+	if len(attachments) > 0 {
+		catalogEntries = append(catalogEntries, embeddedFilesEntry(attachments, attachmentObjs))
+	}
+	if len(p.layers) > 0 {
+		catalogEntries = append(catalogEntries, p.optionalContent())
+	}
+	if crypt != nil {
+		catalogEntries = append(catalogEntries, crypt.catalog...)
+	}
+	if raiseVersion {
+		catalogEntries = append(catalogEntries, "/Version /"+version)
+	}
+
+	var w *pdfWriter
+	if s != nil {
+		w = s.w
+	} else {
+		w = newPDFWriter(out, version)
+		w.compressors = p.compressors()
+		w.fileID = p.pdfa
+		w.objectStreams = p.objectStreams
+		if crypt != nil {
+			w.encrypt(encryptObj, crypt)
+		}
+	}
 
 	// Catalog
-	pdfContent = append(pdfContent,
-		"1 0 obj",
+	w.object(catalogObj, append(append([]string{
 		"<<",
 		"/Type /Catalog",
-		fmt.Sprintf("/Pages 2 0 R"),
-		">>",
-		"endobj",
-	)
+		"/Pages " + ref(pagesObj),
+	}, catalogEntries...), ">>")...)
 
 	// Pages
-	pdfContent = append(pdfContent,
-		"2 0 obj",
+	kids := make([]string, p.pageCount)
+	for i, num := range pageObjs {
+		kids[i] = ref(num)
+	}
+	w.object(pagesObj,
 		"<<",
 		"/Type /Pages",
 		fmt.Sprintf("/Count %d", p.pageCount),
-		"/Kids [",
-	)
-	for i := 0; i < p.pageCount; i++ {
-		pdfContent = append(pdfContent, fmt.Sprintf("%d 0 R", 3+i*2))
-	}
-	pdfContent = append(pdfContent,
-		"]",
+		"/Kids ["+strings.Join(kids, " ")+"]",
 		">>",
-		"endobj",
 	)
 
 	// Font (Helvetica, built-in)
-	pdfContent = append(pdfContent,
-		"3 0 obj",
-		"<<",
-		"/Type /Font",
-		"/Subtype /Type1",
-		"/BaseFont /Helvetica",
-		"/Name /F1",
-		">>",
-		"endobj",
-	)
+	if helveticaObj != 0 {
+		w.object(helveticaObj,
+			"<<",
+			"/Type /Font",
+			"/Subtype /Type1",
+			"/BaseFont /Helvetica",
+			"/Name /F1",
+			">>",
+		)
+	}
+
+	// Resources are shared by all pages and transparency groups
+	resources := []string{"/Font <<"}
+	if helveticaObj != 0 {
+		resources = append(resources, "/F1 "+ref(helveticaObj))
+	}
+	for j, font := range fonts {
+		resources = append(resources, fmt.Sprintf("/%s %s", font.name, ref(fontObjs[j])))
+	}
+	resources = append(resources, ">>")
+	if len(p.images) > 0 || len(p.forms) > 0 {
+		resources = append(resources, "/XObject <<")
+		for j, img := range p.images {
+			resources = append(resources, fmt.Sprintf("/%s %s", img.name, ref(imageObjs[j])))
+		}
+		for j, form := range p.forms {
+			resources = append(resources, fmt.Sprintf("/%s %s", form.name, ref(formObjs+j)))
+		}
+		resources = append(resources, ">>")
+	}
+	if gstateObj != 0 || len(p.blendModes) > 0 || watermarkObj != 0 {
+		resources = append(resources, "/ExtGState <<")
+		if gstateObj != 0 {
+			resources = append(resources, fmt.Sprintf("/%s %s", gstateName, ref(gstateObj)))
+		}
+		for j, mode := range p.blendModes {
+			resources = append(resources, fmt.Sprintf("/%s %s", p.blendStates[mode], ref(blendObjs+j)))
+		}
+		if watermarkObj != 0 {
+			resources = append(resources, fmt.Sprintf("/%s %s", p.watermark.state, ref(watermarkObj)))
+		}
+		resources = append(resources, ">>")
+	}
+	if len(p.layers) > 0 {
+		resources = append(resources, "/Properties <<")
+		for _, lay := range p.layers {
+			resources = append(resources, fmt.Sprintf("/%s %s", lay.resource, ref(lay.obj)))
+		}
+		resources = append(resources, ">>")
+	}
+	if p.cmykSpace != nil || len(p.spots) > 0 {
+		resources = append(resources, "/ColorSpace <<")
+		if p.cmykSpace != nil {
+			resources = append(resources, fmt.Sprintf("/%s [/ICCBased %s]", cmykColorSpace, ref(p.cmykSpace.obj)))
+		}
+		for _, spot := range p.spots {
+			resources = append(resources, fmt.Sprintf("/%s %s", spot.name, ref(spot.obj)))
+		}
+		resources = append(resources, ">>")
+	}
 
 	// Page objects and content streams
+	// Content streams are compressed in parallel, then written in order
+	var contents []encodedStream
+	if s == nil {
+		contents = p.encodeContents(w, contentObjs)
+	}
 	for i := 0; i < p.pageCount; i++ {
+		p.reportProgress(ProgressWrite, i, p.pageCount)
+
 		// Page
-		pdfContent = append(pdfContent,
-			fmt.Sprintf("%d 0 obj", 4+i*2),
+		page := []string{
 			"<<",
 			"/Type /Page",
-			"/Parent 2 0 R",
-			fmt.Sprintf("/MediaBox [0 0 %.2f %.2f]", p.pageWidth, p.pageHeight),
+			"/Parent " + ref(pagesObj),
+			"/MediaBox [0 0 "+formatNumbers(defaultPrecision, p.pageSizes[i][0], p.pageSizes[i][1])+"]",
 			"/Resources <<",
-			"/Font <<",
-			"/F1 3 0 R",
+		}
+		page = append(page, resources...)
+		pageEntries, err := objects.entries(p.pageEntries[i])
+		if err != nil {
+			return fmt.Errorf("error writing page %d: %v", i+1, err)
+		}
+		page = append(page,
 			">>",
-			">>",
-			fmt.Sprintf("/Contents %d 0 R", 5+i*2),
-			">>",
-			"endobj",
+			"/Contents "+ref(contentObjs[i]),
 		)
+		if structureObj != 0 {
+			page = append(page, fmt.Sprintf("/StructParents %d", i))
+		}
+		page = append(page, pageEntries...)
+		w.object(pageObjs[i], append(page, ">>")...)
 
 		// Content Stream
-		contentStream := p.content[i]
-		pdfContent = append(pdfContent,
-			fmt.Sprintf("%d 0 obj", 5+i*2),
-			"<<",
-			"/Length "+strconv.Itoa(len(contentStream)),
-			">>",
-			"stream",
-			contentStream,
-			"endstream",
-			"endobj",
-		)
+		if s == nil {
+			c := contents[i]
+			if c.err != nil {
+				return c.err
+			}
+			w.rawStream(contentObjs[i], c.dict, c.data)
+			w.streamSizes[ContentStream] += len(c.data)
+		}
 	}
 
-	// Cross-reference table
-	xrefOffset := 0
-	var xref []string
-	xref = append(xref,
-		"xref",
-		fmt.Sprintf("0 %d", 5+p.pageCount*2+1),
-		"0000000000 65535 f ",
-	)
-	xrefOffset += len(strings.Join(pdfContent[:2], "\n")) + 2
-	for i := 1; i <= 4+p.pageCount*2; i++ {
-		xref = append(xref, fmt.Sprintf("%010d 00000 n ", xrefOffset))
-		section := strings.Join(pdfContent[i:i+1], "\n") + "\n"
-		xrefOffset += len(section)
+	// Embedded fonts follow the page objects
+	for j, font := range fonts {
+		font.writeObjects(w, fontObjs[j])
 	}
-
-	// Trailer
-	trailer := []string{
-		"trailer",
-		"<<",
-		fmt.Sprintf("/Size %d", 5+p.pageCount*2+1),
-		"/Root 1 0 R",
-		">>",
-		"startxref",
-		fmt.Sprintf("%d", xrefOffset),
-		"%%EOF",
+	for j, img := range p.images {
+		img.writeObjects(w, imageObjs[j])
+	}
+	for j, form := range p.forms {
+		form.writeObject(w, formObjs+j, resources)
+	}
+	for _, profile := range p.profiles {
+		profile.writeObject(w)
+	}
+	for _, spot := range p.spots {
+		spot.writeObject(w, p.cmyk != nil)
+	}
+	if gstateObj != 0 {
+		p.writeGraphicsState(w, gstateObj)
+	}
+	for j, mode := range p.blendModes {
+		w.object(blendObjs+j, "<<", "/Type /ExtGState", "/BM /"+mode, ">>")
+	}
+	if watermarkObj != 0 {
+		w.object(watermarkObj, "<<", p.watermark.opacityEntries(), ">>")
+	}
+	for _, lay := range p.layers {
+		w.object(lay.obj, "<<", "/Type /OCG", "/Name "+textString(lay.name), ">>")
+	}
+	if structureObj != 0 {
+		p.writeStructure(w, structureObj, pageObjs)
+	}
+	if outlineObj != 0 {
+		p.writeOutline(w, outlineObj, pageObjs)
+	}
+	for j, a := range attachments {
+		a.writeObject(w, attachmentObjs+2*j)
+	}
+	if xmpObj != 0 {
+		// Metadata stays uncompressed so tools can find it without parsing PDF
+		w.rawStream(xmpObj, []string{"/Type /Metadata", "/Subtype /XML"}, xmp)
+	}
+	if infoObj != 0 {
+		w.object(infoObj, append(append([]string{"<<"}, info...), ">>")...)
+	}
+	if outputProfileObj != 0 {
+		w.stream(outputProfileObj, ProfileStream, []string{"/N 3", "/Alternate /DeviceRGB"}, srgbProfile())
+	}
+	for j, v := range p.objects {
+		if err := objects.write(w, objects.first+j, v); err != nil {
+			return fmt.Errorf("error writing object %d: %v", objects.first+j, err)
+		}
 	}
 
-	// Combine all parts
-	finalContent := strings.Join(pdfContent, "\n") + "\n" +
-		strings.Join(xref, "\n") + "\n" +
-		strings.Join(trailer, "\n")
-
-	// Write to file
-	if err := os.WriteFile(filePath, []byte(finalContent), 0644); err != nil {
+	// Cross-reference table and trailer
+	if err := w.finish(catalogObj, infoObj); err != nil {
 		return fmt.Errorf("error writing PDF: %v", err)
 	}
-	fmt.Printf("Successfully generated %s\n", filePath)
+	p.streamSizes = w.streamSizes
+	p.reportProgress(ProgressWrite, p.pageCount, p.pageCount)
 	return nil
 }
 
+// pageContent returns the content stream of page i as written
+func (p *PDF) pageContent(i int) []byte {
+	return p.prefixedContent(i, p.contentPrefix())
+}
+
+// contentPrefix returns the operators every content stream starts with
+func (p *PDF) contentPrefix() string {
+	if !p.hasGraphicsState() {
+		return ""
+	}
+	return "/" + p.graphicsStateName() + " gs\n"
+}
+
+// prefixedContent returns the content stream of page i as written, starting
+// with prefix. It does not change the document, so pages may be prepared
+// concurrently.
+func (p *PDF) prefixedContent(i int, prefix string) []byte {
+	content := p.content[i].buf.Bytes()
+	if wm := p.watermarkOps(i); wm != "" {
+		if p.watermark.over {
+			content = append(append(bytes.Clone(content), '\n'), wm...)
+		} else {
+			content = append([]byte(wm+"\n"), content...)
+		}
+	}
+	if ops := p.backgroundOps(i); ops != "" {
+		content = append([]byte(ops+"\n"), content...)
+	}
+	if ops := p.templateOps(i); ops != "" {
+		content = append(append(bytes.Clone(content), '\n'), ops...)
+	}
+	if prefix != "" {
+		content = append([]byte(prefix), content...)
+	}
+	if p.minifyContent {
+		content = []byte(minifyContent(string(content)))
+	}
+	return content
+}
+
 // escapeText escapes special characters for PDF text
 func escapeText(text string) string {
 	text = strings.ReplaceAll(text, "\\", "\\\\")
@@ -0,0 +1,279 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Switch represents an SVG switch element, which renders its first direct
+// child whose conditions hold, e.g. the text in the user's language
+type Switch struct {
+	ID         string
+	Class      string
+	Display    string
+	Visibility string
+	Inline     string        // Inline CSS declarations
+	Conditions               // Of the switch itself
+	Children   []SwitchChild // In document order
+}
+
+// SwitchChild is a direct child of a switch element
+type SwitchChild struct {
+	Conditions // Those of the child element
+	Container  // Holds the child element alone
+}
+
+// Conditions are the conditional processing attributes of an element, nil
+// when absent. An element whose conditions do not all hold is not drawn,
+// and is passed over by a switch.
+type Conditions struct {
+	SystemLanguage     *string `xml:"systemLanguage,attr"`     // Languages the element is for
+	RequiredFeatures   *string `xml:"requiredFeatures,attr"`   // SVG 1.1 feature strings it needs
+	RequiredExtensions *string `xml:"requiredExtensions,attr"` // Namespaces of extensions it needs
+}
+
+// UnmarshalXML decodes a switch element, keeping its children in order as
+// only the first matching one is rendered
+func (s *Switch) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	s.Conditions = attrConditions(start.Attr)
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			s.ID = attr.Value
+		case "class":
+			s.Class = attr.Value
+		case "display":
+			s.Display = attr.Value
+		case "visibility":
+			s.Visibility = attr.Value
+		case "style":
+			s.Inline = attr.Value
+		}
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child := SwitchChild{Conditions: attrConditions(t.Attr)}
+			if err := child.decode(d, t); err != nil {
+				return err
+			}
+			s.Children = append(s.Children, child)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decode decodes the element started by start into the container
+func (c *SwitchChild) decode(d *xml.Decoder, start xml.StartElement) error {
+	if start.Name.Space != svgNamespace {
+		return d.Skip() // Foreign content is not rendered
+	}
+	switch start.Name.Local {
+	case "rect":
+		c.Rects = make([]Rect, 1)
+		return d.DecodeElement(&c.Rects[0], &start)
+	case "text":
+		c.Texts = make([]Text, 1)
+		return d.DecodeElement(&c.Texts[0], &start)
+	case "path":
+		c.Paths = make([]Path, 1)
+		return d.DecodeElement(&c.Paths[0], &start)
+	case "image":
+		c.Images = make([]Image, 1)
+		return d.DecodeElement(&c.Images[0], &start)
+	case "svg":
+		c.SVGs = make([]SVG, 1)
+		return d.DecodeElement(&c.SVGs[0], &start)
+	case "use":
+		c.Uses = make([]Use, 1)
+		return d.DecodeElement(&c.Uses[0], &start)
+	case "g":
+		c.Groups = make([]Group, 1)
+		return d.DecodeElement(&c.Groups[0], &start)
+	case "foreignObject":
+		c.Foreign = make([]ForeignObject, 1)
+		return d.DecodeElement(&c.Foreign[0], &start)
+	case "switch":
+		c.Switches = make([]Switch, 1)
+		return d.DecodeElement(&c.Switches[0], &start)
+	}
+	return d.Skip()
+}
+
+// attrConditions returns the conditional processing attributes among attrs
+func attrConditions(attrs []xml.Attr) Conditions {
+	var c Conditions
+	for _, attr := range attrs {
+		if attr.Name.Space != "" {
+			continue
+		}
+		value := attr.Value
+		switch attr.Name.Local {
+		case "systemLanguage":
+			c.SystemLanguage = &value
+		case "requiredFeatures":
+			c.RequiredFeatures = &value
+		case "requiredExtensions":
+			c.RequiredExtensions = &value
+		}
+	}
+	return c
+}
+
+// supportedFeatures are the SVG 1.1 feature strings, after
+// "http://www.w3.org/TR/SVG11/feature#", the converter draws
+var supportedFeatures = setOf("BasicStructure", "ConditionalProcessing", "Image", "Style",
+	"ViewportAttribute", "BasicText", "BasicPaintAttribute", "BasicClip")
+
+// holds reports whether all conditions hold: systemLanguage lists one of
+// the user's languages, requiredFeatures only features the converter
+// draws, and requiredExtensions only namespaces of registered element
+// handlers. Empty lists never hold. Extensibility, i.e. foreignObject, is
+// only drawn with a Rasterizer; otherwise the text alternatives diagram
+// tools export are preferred.
+func (p *PDF) holds(c Conditions) bool {
+	if c.SystemLanguage != nil && !p.matchesLanguage(*c.SystemLanguage) {
+		return false
+	}
+	if c.RequiredFeatures != nil {
+		features := strings.Fields(*c.RequiredFeatures)
+		if len(features) == 0 {
+			return false
+		}
+		for _, feature := range features {
+			name, ok := strings.CutPrefix(feature, "http://www.w3.org/TR/SVG11/feature#")
+			if !ok || !supportedFeatures[name] && !(name == "Extensibility" && p.rasterizer != nil) {
+				return false
+			}
+		}
+	}
+	if c.RequiredExtensions != nil {
+		extensions := strings.Fields(*c.RequiredExtensions)
+		if len(extensions) == 0 {
+			return false
+		}
+		for _, ns := range extensions {
+			if !p.handlesNamespace(ns) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// handlesNamespace reports whether an element handler is registered for
+// an element of the namespace
+func (p *PDF) handlesNamespace(ns string) bool {
+	for name := range p.handlers {
+		if name.Space == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSystemLanguage sets the user's language preferences, e.g. "fr" or
+// "en-US", against which systemLanguage attributes are evaluated, on
+// switch children and other elements alike. Without preferences, only
+// elements without systemLanguage are rendered.
+func (p *PDF) SetSystemLanguage(languages ...string) {
+	p.systemLanguages = languages
+}
+
+// WithSystemLanguage sets the user's language preferences
+func WithSystemLanguage(languages ...string) Option {
+	return func(p *PDF) error {
+		p.SetSystemLanguage(languages...)
+		return nil
+	}
+}
+
+// SetLanguagePages draws SVGs whose switches select by systemLanguage once
+// per language they provide, each on its own page, in the order the
+// languages first appear. Other SVGs get a single page as usual.
+func (p *PDF) SetLanguagePages(enabled bool) {
+	p.languagePages = enabled
+}
+
+// WithLanguagePages draws a page per language of multi-locale SVGs
+func WithLanguagePages() Option {
+	return func(p *PDF) error {
+		p.SetLanguagePages(true)
+		return nil
+	}
+}
+
+// matchesLanguage reports whether a systemLanguage value holds for the
+// user's preferences: a preference equals one of the listed languages or
+// a prefix of one followed by "-", so "en" matches "en-US"
+func (p *PDF) matchesLanguage(value string) bool {
+	for _, lang := range strings.Split(value, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		for _, pref := range p.systemLanguages {
+			pref = strings.ToLower(strings.TrimSpace(pref))
+			if pref != "" && (lang == pref || strings.HasPrefix(lang, pref+"-")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderSwitch draws the first child of s whose conditions hold
+func (p *PDF) renderSwitch(s *Switch, ctx unitContext) {
+	defer p.scopeProperties("switch", s.ID, s.Class, s.Inline)()
+	if !p.holds(s.Conditions) || p.displayNone("switch", s.ID, s.Class, s.Display, s.Inline) {
+		return
+	}
+	defer p.inheritVisibility(p.visible("switch", s.ID, s.Class, s.Visibility, s.Inline))()
+	for i := range s.Children {
+		child := &s.Children[i]
+		if p.holds(child.Conditions) {
+			p.renderContainer(&child.Container, ctx)
+			return
+		}
+	}
+	p.warn("switch", s.ID, "no child matches the conditions for languages %s", fmt.Sprint(p.systemLanguages))
+}
+
+// languages returns the languages the switches of svg select by, one per
+// child, in the order they first appear
+func (svg *SVG) languages() []string {
+	var languages []string
+	seen := make(map[string]bool)
+	var walk func(c *Container)
+	walk = func(c *Container) {
+		for i := range c.Switches {
+			for j := range c.Switches[i].Children {
+				child := &c.Switches[i].Children[j]
+				if child.SystemLanguage != nil {
+					// A child listing several languages is one variant,
+					// drawn once for the first
+					lang, _, _ := strings.Cut(*child.SystemLanguage, ",")
+					if lang = strings.TrimSpace(lang); lang != "" && !seen[strings.ToLower(lang)] {
+						seen[strings.ToLower(lang)] = true
+						languages = append(languages, lang)
+					}
+				}
+				walk(&child.Container)
+			}
+		}
+		for i := range c.Groups {
+			walk(&c.Groups[i].Container)
+		}
+		for i := range c.SVGs {
+			walk(&c.SVGs[i].Container)
+		}
+		for i := range c.Symbols {
+			walk(&c.Symbols[i].Container)
+		}
+	}
+	walk(&svg.Container)
+	return languages
+}
@@ -0,0 +1,255 @@
+package svg2pdf
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FontResolver locates font data for families that are not registered with
+// the PDF, e.g. by looking them up among the installed system fonts
+type FontResolver interface {
+	// ResolveFont returns the font file for family closest to the requested
+	// weight and style, or an error if the family is not available
+	ResolveFont(family string, weight int, italic bool) ([]byte, error)
+}
+
+// SetFontResolver makes text fall back to fonts located by r when its
+// font-family is not registered. The fallback families are tried in order
+// when none of the requested families can be resolved.
+func (p *PDF) SetFontResolver(r FontResolver, fallback ...string) {
+	p.fontResolver = r
+	p.fontFallback = fallback
+}
+
+// resolveSystemFont loads family through the font resolver and registers it
+func (p *PDF) resolveSystemFont(family string, weight int, italic bool) *Font {
+	if p.fontResolver == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%d/%t", strings.ToLower(family), weight, italic)
+	if font, seen := p.resolvedFonts[key]; seen {
+		return font // Cached, including failed lookups
+	}
+	// Lookups depend on the fonts available on the machine
+	p.recordInput("font family %q looked up through the font resolver", family)
+	var font *Font
+	if data, err := p.fontResolver.ResolveFont(family, weight, italic); err == nil {
+		font, err = parseFont(data)
+		switch {
+		case err != nil:
+			font = nil
+		case font.embeddingRestriction() != "" && p.embeddingPolicy != IgnoreEmbeddingRestrictions:
+			font = nil // Substituted by the next family
+		default:
+			font.Family = family
+			p.addFont(font)
+		}
+	}
+	if p.resolvedFonts == nil {
+		p.resolvedFonts = make(map[string]*Font)
+	}
+	p.resolvedFonts[key] = font
+	return font
+}
+
+// SystemFonts resolves font families against the fonts installed in the
+// platform font directories. The directories are scanned once, on first use.
+type SystemFonts struct {
+	// Dirs overrides the platform font directories when not empty
+	Dirs []string
+
+	once  sync.Once
+	index map[string][]systemFont // Lowercase family name to faces
+}
+
+// systemFont is a face found while scanning the font directories
+type systemFont struct {
+	path   string
+	weight int
+	italic bool
+}
+
+// NewSystemFonts returns a resolver for the fonts installed on this machine
+func NewSystemFonts() *SystemFonts {
+	return &SystemFonts{}
+}
+
+// ResolveFont returns the installed face of family closest to weight and style
+func (s *SystemFonts) ResolveFont(family string, weight int, italic bool) ([]byte, error) {
+	s.once.Do(s.scan)
+	faces := s.index[strings.ToLower(family)]
+	if len(faces) == 0 {
+		return nil, fmt.Errorf("font family %q not installed", family)
+	}
+	best, bestScore := 0, -1
+	for i, face := range faces {
+		score := abs(face.weight - weight)
+		if face.italic != italic {
+			score += 1000
+		}
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return os.ReadFile(faces[best].path)
+}
+
+// Families returns the names of all installed font families, sorted
+func (s *SystemFonts) Families() []string {
+	s.once.Do(s.scan)
+	families := make([]string, 0, len(s.index))
+	for family := range s.index {
+		families = append(families, family)
+	}
+	slices.Sort(families)
+	return families
+}
+
+// scan indexes every font file below the font directories
+func (s *SystemFonts) scan() {
+	s.index = make(map[string][]systemFont)
+	dirs := s.Dirs
+	if len(dirs) == 0 {
+		dirs = systemFontDirs()
+	}
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil // Skip unreadable entries
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".ttf", ".otf":
+			default:
+				return nil
+			}
+			family, face, err := readFontInfo(path)
+			if err != nil || family == "" {
+				return nil
+			}
+			key := strings.ToLower(family)
+			s.index[key] = append(s.index[key], face)
+			return nil
+		})
+	}
+}
+
+// systemFontDirs returns the font directories of the running platform
+func systemFontDirs() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		windir := os.Getenv("WINDIR")
+		if windir == "" {
+			windir = `C:\Windows`
+		}
+		dirs := []string{filepath.Join(windir, "Fonts")}
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Microsoft", "Windows", "Fonts"))
+		}
+		return dirs
+	case "darwin":
+		return []string{
+			"/System/Library/Fonts",
+			"/Library/Fonts",
+			filepath.Join(home, "Library", "Fonts"),
+		}
+	}
+	dirs := fontconfigDirs("/etc/fonts/fonts.conf", home)
+	if len(dirs) == 0 {
+		dirs = []string{
+			"/usr/share/fonts",
+			"/usr/local/share/fonts",
+			filepath.Join(home, ".local", "share", "fonts"),
+			filepath.Join(home, ".fonts"),
+		}
+	}
+	return dirs
+}
+
+// fontconfigDirs reads the <dir> entries of a fontconfig configuration file
+func fontconfigDirs(path, home string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var dirs []string
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return dirs
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "dir" {
+			continue
+		}
+		var dir string
+		if err := decoder.DecodeElement(&dir, &start); err != nil {
+			continue
+		}
+		dir = strings.TrimSpace(dir)
+		if strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(home, dir[2:])
+		}
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+}
+
+// readFontInfo reads the family name, weight and style of a font file
+// without loading its outlines
+func readFontInfo(path string) (string, systemFont, error) {
+	face := systemFont{path: path, weight: 400}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", face, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return "", face, err
+	}
+	numTables := int(binary.BigEndian.Uint16(header[4:]))
+	dir := make([]byte, 16*numTables)
+	if _, err := io.ReadFull(f, dir); err != nil {
+		return "", face, err
+	}
+	readTable := func(tag string) []byte {
+		for i := 0; i < numTables; i++ {
+			rec := dir[16*i:]
+			if string(rec[:4]) != tag {
+				continue
+			}
+			data := make([]byte, binary.BigEndian.Uint32(rec[12:]))
+			if _, err := f.ReadAt(data, int64(binary.BigEndian.Uint32(rec[8:]))); err != nil {
+				return nil
+			}
+			return data
+		}
+		return nil
+	}
+
+	name := readTable("name")
+	family := parseNameTable(name, 16) // Typographic family groups all weights
+	if family == "" {
+		family = parseNameTable(name, 1)
+	}
+	if os2 := readTable("OS/2"); len(os2) >= 64 {
+		face.weight = int(binary.BigEndian.Uint16(os2[4:]))
+		face.italic = binary.BigEndian.Uint16(os2[62:])&1 != 0
+	}
+	return family, face, nil
+}
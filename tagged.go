@@ -0,0 +1,162 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetTaggedPDF makes documents tagged PDF for accessibility: graphics are
+// marked as a figure whose alternate text is the SVG title or description,
+// text as spans, and page decorations such as redactions and page hook
+// output as artifacts. The language is taken from the lang attribute of
+// the first SVG.
+func (p *PDF) SetTaggedPDF(enabled bool) {
+	p.tagged = enabled
+}
+
+// WithTaggedPDF makes documents tagged PDF
+func WithTaggedPDF() Option {
+	return func(p *PDF) error {
+		p.SetTaggedPDF(true)
+		return nil
+	}
+}
+
+// pageStructure is the tagged content of a page
+type pageStructure struct {
+	alt   string   // Alternate text of the figure
+	marks []string // Structure type of each marked content sequence, by MCID
+}
+
+// beginMarked returns the operator starting a marked content sequence of
+// structure type tag on the current page, or nil if tagging is off. All
+// figure sequences of a page belong to one Figure element, the others get
+// an element each.
+func (p *PDF) beginMarked(tag string) []string {
+	if !p.tagged {
+		return nil
+	}
+	if p.pageCount == 0 {
+		p.AddPage()
+	}
+	s := &p.structure[p.current]
+	s.marks = append(s.marks, tag)
+	return []string{fmt.Sprintf("/%s << /MCID %d >> BDC", tag, len(s.marks)-1)}
+}
+
+// beginArtifact returns the operator starting content that is not part of
+// the document structure, or nil if tagging is off
+func (p *PDF) beginArtifact() []string {
+	if !p.tagged {
+		return nil
+	}
+	return []string{"/Artifact BMC"}
+}
+
+// endMarked returns the operator ending a marked content sequence or
+// artifact, or nil if tagging is off
+func (p *PDF) endMarked() []string {
+	if !p.tagged {
+		return nil
+	}
+	return []string{"EMC"}
+}
+
+// recordStructure sets the alternate text and language from the SVG of the
+// current page
+func (p *PDF) recordStructure(svg *SVG) {
+	if !p.tagged {
+		return
+	}
+	alt := strings.TrimSpace(svg.Title)
+	if alt == "" {
+		alt = strings.TrimSpace(svg.Desc)
+	}
+	p.structure[p.current].alt = strings.Join(strings.Fields(alt), " ")
+	if p.lang == "" {
+		p.lang = strings.TrimSpace(svg.Lang)
+	}
+}
+
+// structureObjectCount returns the number of objects written for the
+// structure tree: the root, the document element and the page elements
+func (p *PDF) structureObjectCount() int {
+	n := 2
+	for _, s := range p.structure {
+		n += len(s.elements())
+	}
+	return n
+}
+
+// structureElement is a structure element of a page
+type structureElement struct {
+	tag   string
+	mcids []int
+}
+
+// elements groups the marked content of a page into structure elements
+func (s pageStructure) elements() []structureElement {
+	var elems []structureElement
+	figure := -1
+	for mcid, tag := range s.marks {
+		if tag == "Figure" && figure >= 0 {
+			elems[figure].mcids = append(elems[figure].mcids, mcid)
+			continue
+		}
+		if tag == "Figure" {
+			figure = len(elems)
+		}
+		elems = append(elems, structureElement{tag: tag, mcids: []int{mcid}})
+	}
+	return elems
+}
+
+// writeStructure writes the structure tree, numbered from first, for pages
+// numbered pageObjs. Pages refer to their entry in the parent tree by index.
+func (p *PDF) writeStructure(w *pdfWriter, first int, pageObjs []int) {
+	root, document := first, first+1
+	next := first + 2
+	var kids, nums []string
+	for i, s := range p.structure {
+		parents := make([]string, len(s.marks))
+		for _, elem := range s.elements() {
+			num := next
+			next++
+			kids = append(kids, ref(num))
+			mcids := make([]string, len(elem.mcids))
+			for j, mcid := range elem.mcids {
+				mcids[j] = fmt.Sprint(mcid)
+				parents[mcid] = ref(num)
+			}
+			lines := []string{
+				"<<",
+				"/Type /StructElem",
+				"/S /" + elem.tag,
+				"/P " + ref(document),
+				"/Pg " + ref(pageObjs[i]),
+				"/K [" + strings.Join(mcids, " ") + "]",
+			}
+			if elem.tag == "Figure" && s.alt != "" {
+				lines = append(lines, "/Alt "+textString(s.alt))
+			}
+			w.object(num, append(lines, ">>")...)
+		}
+		nums = append(nums, fmt.Sprintf("%d [%s]", i, strings.Join(parents, " ")))
+	}
+	w.object(root,
+		"<<",
+		"/Type /StructTreeRoot",
+		"/K "+ref(document),
+		"/ParentTree << /Nums ["+strings.Join(nums, " ")+"] >>",
+		fmt.Sprintf("/ParentTreeNextKey %d", len(p.structure)),
+		">>",
+	)
+	w.object(document,
+		"<<",
+		"/Type /StructElem",
+		"/S /Document",
+		"/P "+ref(root),
+		"/K ["+strings.Join(kids, " ")+"]",
+		">>",
+	)
+}
@@ -0,0 +1,201 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageTemplate is a line of text drawn in the top or bottom margin of every
+// page, e.g. a running title or "Page {page} of {pages}". Text may contain
+// the tokens {page}, {pages}, {date}, the creation date as 2006-01-02, and
+// {title}, the document title or the title of the first SVG.
+type PageTemplate struct {
+	Left     string  // Text aligned with the left margin
+	Center   string  // Text centered between the margins
+	Right    string  // Text aligned with the right margin
+	FontSize float64 // Font size in points, the PDF font size when 0
+	Color    RGB
+}
+
+// templateInset is the least distance in points of headers and footers from
+// the page edges, for pages with narrower margins
+const templateInset = 18
+
+// templateState holds the token values of headers and footers while the
+// document is written
+type templateState struct {
+	date, title string
+	// Page count drawn when streaming, whose pages are written before it is
+	// known; completed when the document is closed
+	pages *formXObject
+}
+
+// SetHeader draws t centered in the top margin of every page, which the
+// page margins should leave room for; nil removes the header. Headers are
+// drawn in Helvetica, over the page content, when the document is written.
+func (p *PDF) SetHeader(t *PageTemplate) error {
+	if t != nil && !(t.FontSize >= 0) {
+		return fmt.Errorf("invalid header font size %g", t.FontSize)
+	}
+	p.header = t
+	return nil
+}
+
+// SetFooter draws t centered in the bottom margin of every page, as
+// SetHeader does in the top one; nil removes the footer
+func (p *PDF) SetFooter(t *PageTemplate) error {
+	if t != nil && !(t.FontSize >= 0) {
+		return fmt.Errorf("invalid footer font size %g", t.FontSize)
+	}
+	p.footer = t
+	return nil
+}
+
+// SetHeader draws t in the top margin of every page
+func (d *Document) SetHeader(t *PageTemplate) error {
+	return d.pdf.SetHeader(t)
+}
+
+// SetFooter draws t in the bottom margin of every page
+func (d *Document) SetFooter(t *PageTemplate) error {
+	return d.pdf.SetFooter(t)
+}
+
+// WithHeader draws t in the top margin of every page
+func WithHeader(t PageTemplate) Option {
+	return func(p *PDF) error {
+		return p.SetHeader(&t)
+	}
+}
+
+// WithFooter draws t in the bottom margin of every page
+func WithFooter(t PageTemplate) Option {
+	return func(p *PDF) error {
+		return p.SetFooter(&t)
+	}
+}
+
+// usesToken reports whether the header or footer contains token
+func (p *PDF) usesToken(token string) bool {
+	for _, t := range []*PageTemplate{p.header, p.footer} {
+		if t != nil && strings.Contains(t.Left+t.Center+t.Right, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareTemplates resolves the token values of headers and footers before
+// pages are written. Streamed documents keep the values of their first
+// page, and update the page count as pages are added.
+func (p *PDF) prepareTemplates() {
+	if p.header == nil && p.footer == nil {
+		return
+	}
+	t := p.templates
+	if t == nil || p.streaming == nil {
+		t = &templateState{title: p.svgTitle}
+		if p.metadata != nil && p.metadata.Title != "" {
+			t.title = p.metadata.Title
+		}
+		if p.usesToken("{date}") {
+			if created := p.creationDate(); !created.IsZero() {
+				t.date = created.Format(time.DateOnly)
+			}
+		}
+		if p.streaming != nil && p.usesToken("{pages}") {
+			name := p.resourceID("Pn", []byte("{pages}"))
+			t.pages = &formXObject{name: name}
+			p.forms = append(p.forms, t.pages)
+			p.created(name)
+		}
+		p.templates = t
+	}
+	if t.pages != nil {
+		// Drawn at a font size of 1, scaled where it is used
+		count := strconv.Itoa(p.pageCount)
+		face := standardFont{}
+		t.pages.ops = strings.Join([]string{"BT", fmt.Sprintf("/%s 1 Tf", face.resourceName()), face.encode(count) + " Tj", "ET"}, "\n")
+		t.pages.bbox = viewBox{0, -0.25, textWidth(count, 1), 1.25}
+	}
+}
+
+// templateOps returns the operators drawing the header and footer of page
+// i, or "" without them
+func (p *PDF) templateOps(i int) string {
+	if p.templates == nil || (p.header == nil && p.footer == nil) {
+		return ""
+	}
+	size := p.pageSizes[i]
+	m := p.pageMargins()
+	left, right := max(m.left, templateInset), size[0]-max(m.right, templateInset)
+	ops := append(p.beginArtifact(), "q")
+	if t := p.header; t != nil {
+		band := max(m.top, 2*templateInset)
+		ops = append(ops, p.templateLine(t, i, left, right, size[1]-band/2)...)
+	}
+	if t := p.footer; t != nil {
+		band := max(m.bottom, 2*templateInset)
+		ops = append(ops, p.templateLine(t, i, left, right, band/2)...)
+	}
+	ops = append(ops, "Q")
+	return strings.Join(append(ops, p.endMarked()...), "\n")
+}
+
+// templateLine returns the operators drawing the text of t for page i
+// between left and right, vertically centered on middle
+func (p *PDF) templateLine(t *PageTemplate, i int, left, right, middle float64) []string {
+	size := t.FontSize
+	if size <= 0 {
+		size = p.fontSize
+	}
+	face := standardFont{}
+	baseline := middle - size*0.35 // Half the height of capitals
+	text := []string{"BT", p.fillOp(t.Color), "/" + face.resourceName() + " " + p.operator("Tf", size)}
+	var counts []string
+	for slot, s := range []string{t.Left, t.Center, t.Right} {
+		if s == "" {
+			continue
+		}
+		// A streamed page count is drawn by its form between the parts
+		s = p.expandTokens(s, i)
+		parts, countWidth := []string{s}, 0.0
+		if p.templates.pages != nil {
+			parts = strings.Split(s, "{pages}")
+			countWidth = textWidth(strconv.Itoa(p.pageCount), size)
+		}
+		width := countWidth * float64(len(parts)-1)
+		for _, part := range parts {
+			width += textWidth(part, size)
+		}
+		x := [...]float64{left, left + (right-left-width)/2, right - width}[slot]
+		for j, part := range parts {
+			if j > 0 {
+				counts = append(counts, p.operator("cm", size, 0, 0, size, x, baseline))
+				x += countWidth
+			}
+			if part != "" {
+				text = append(text, p.operator("Tm", 1, 0, 0, 1, x, baseline), face.encode(part)+" Tj")
+				x += textWidth(part, size)
+			}
+		}
+	}
+	ops := append(text, "ET")
+	for _, cm := range counts {
+		ops = append(ops, "q", cm, "/"+p.templates.pages.name+" Do", "Q")
+	}
+	return ops
+}
+
+// expandTokens replaces the tokens of header or footer text for page i,
+// keeping {pages} when it is drawn by a form
+func (p *PDF) expandTokens(s string, i int) string {
+	t := p.templates
+	replacements := []string{"{page}", strconv.Itoa(i + 1), "{date}", t.date, "{title}", t.title}
+	if t.pages == nil {
+		replacements = append(replacements, "{pages}", strconv.Itoa(p.pageCount))
+	}
+	return strings.NewReplacer(replacements...).Replace(s)
+}
@@ -0,0 +1,209 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseNumberList parses numbers separated by whitespace and/or commas.
+// Commas always separate numbers, never decimals, and separators may be
+// left out where unambiguous, e.g. "10-5" or "0.5.5".
+func parseNumberList(s string) ([]float64, error) {
+	var values []float64
+	if !scanList(s, func(sc *pathScanner) bool {
+		v, ok := sc.number()
+		values = append(values, v)
+		return ok
+	}) {
+		return nil, fmt.Errorf("invalid number list %q", s)
+	}
+	return values, nil
+}
+
+// scanList calls item for each item of a list separated as parseNumberList
+// expects, until it reports false for an invalid item. It reports whether
+// the whole list is valid.
+func scanList(s string, item func(sc *pathScanner) bool) bool {
+	sc := pathScanner{d: s}
+	for {
+		sc.skipSeparators()
+		if sc.pos == len(s) {
+			return true
+		}
+		if !item(&sc) {
+			return false
+		}
+	}
+}
+
+// parseNumber parses a number in the SVG syntax. Unlike strconv.ParseFloat
+// it rejects hexadecimal, infinite and NaN values, which would end up in
+// the PDF as invalid tokens, and decimal commas.
+func parseNumber(s string) (float64, error) {
+	if s == "" || strings.IndexByte(" \t\n\r\f,", s[0]) >= 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	sc := pathScanner{d: s}
+	v, ok := sc.number()
+	if !ok || sc.pos != len(s) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// glyphRun is a piece of text drawn from a single absolute position
+type glyphRun struct {
+	X, Y     float64
+	Text     string
+	Sideways bool // Rotated 90 degrees clockwise, for Latin text set vertically
+}
+
+// Vertical metrics approximated in em, as the fonts' vhea tables are not read
+const (
+	verticalAscent  = 0.88 // Baseline of upright glyphs below the top of the em box
+	sidewaysCentral = 0.3  // Alphabetic baseline left of the central baseline
+)
+
+// isVertical reports whether a writing-mode value sets text top to bottom
+func isVertical(mode string) bool {
+	switch strings.TrimSpace(mode) {
+	case "vertical-rl", "vertical-lr", "tb", "tb-rl", "tb-lr", "sideways-rl", "sideways-lr":
+		return true
+	}
+	return false
+}
+
+// isUpright reports whether r stays upright in vertical text, following
+// text-orientation: mixed; other characters are set sideways
+func isUpright(r rune) bool {
+	switch {
+	case r >= 0x1100 && r <= 0x11FF, // Hangul Jamo
+		r >= 0x2E80 && r <= 0x2FDF,   // CJK radicals
+		r >= 0x3000 && r <= 0x9FFF,   // CJK symbols, kana and ideographs
+		r >= 0xAC00 && r <= 0xD7AF,   // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF,   // CJK compatibility ideographs
+		r >= 0xFF00 && r <= 0xFFEF,   // Fullwidth forms
+		r >= 0x20000 && r <= 0x3FFFF: // Supplementary ideographs
+		return true
+	}
+	return false
+}
+
+// layoutTextRuns splits the text content into runs following the per-character
+// x/y/dx/dy lists. A new run starts at every character that carries its own
+// coordinate, all other characters follow the previous one using the font
+// advance widths. In vertical writing modes each upright character is a run
+// of its own and characters advance downwards.
+func layoutTextRuns(text Text, face fontFace, ctx unitContext) []glyphRun {
+	vertical := isVertical(text.Writing)
+	var runs []glyphRun
+	var current []rune
+	fontSize := ctx.fontSize
+	xs, ys := ctx.resolveList(text.X, axisX), ctx.resolveList(text.Y, axisY)
+	dxs, dys := ctx.resolveList(text.Dx, axisX), ctx.resolveList(text.Dy, axisY)
+	var curX, curY float64
+
+	flush := func() {
+		if len(current) > 0 {
+			runs[len(runs)-1].Text = string(current)
+			current = current[:0]
+		}
+	}
+
+	for i, r := range []rune(text.Content) {
+		positioned := i == 0
+		if i < len(xs) {
+			curX = xs[i]
+			positioned = true
+		}
+		if i < len(ys) {
+			curY = ys[i]
+			positioned = true
+		}
+		if i < len(dxs) {
+			curX += dxs[i]
+			positioned = true
+		}
+		if i < len(dys) {
+			curY += dys[i]
+			positioned = true
+		}
+		advance := face.advance(r) * fontSize / 1000
+		if !vertical {
+			if positioned {
+				flush()
+				runs = append(runs, glyphRun{X: curX, Y: curY})
+			}
+			current = append(current, r)
+			curX += advance
+			continue
+		}
+
+		// Vertical text is centered on x, upright glyphs fill a 1em box
+		upright := isUpright(r)
+		if upright || positioned || len(runs) == 0 || !runs[len(runs)-1].Sideways {
+			flush()
+			if upright {
+				runs = append(runs, glyphRun{X: curX - advance/2, Y: curY + verticalAscent*fontSize})
+			} else {
+				runs = append(runs, glyphRun{X: curX - sidewaysCentral*fontSize, Y: curY, Sideways: true})
+			}
+		}
+		current = append(current, r)
+		if upright {
+			curY += fontSize
+		} else {
+			curY += advance
+		}
+	}
+	flush()
+	return runs
+}
+
+// shapeRuns converts each run to visual order with the text shaper. In
+// right-to-left text a run's position is its right edge, so runs are moved
+// left by their width.
+func (p *PDF) shapeRuns(runs []glyphRun, rtl bool, face fontFace, fontSize float64) []glyphRun {
+	shaper := p.shaper
+	for i := range runs {
+		if shaper == nil {
+			if !rtl && !hasRTL(runs[i].Text) {
+				continue // Plain left-to-right text needs no shaping
+			}
+			shaper = defaultShaper{}
+		}
+		runs[i].Text = shaper.Shape(runs[i].Text, rtl)
+		if rtl {
+			width := 0.0
+			for _, r := range runs[i].Text {
+				width += face.advance(r) * fontSize / 1000
+			}
+			runs[i].X -= width
+		}
+	}
+	return runs
+}
+
+// drawTextRuns renders individually positioned runs of text in a single text object
+func (p *PDF) drawTextRuns(runs []glyphRun, face fontFace, fontSize float64) {
+	if len(runs) == 0 {
+		return
+	}
+	stream := []string{
+		"BT",
+		"/" + face.resourceName() + " " + p.operator("Tf", fontSize), // Set font and size
+	}
+	for _, run := range runs {
+		// Absolute position, flipping glyphs upright in the y-down user space
+		matrix := []float64{1, 0, 0, -1, run.X, run.Y}
+		if run.Sideways {
+			matrix = []float64{0, 1, 1, 0, run.X, run.Y} // Baseline pointing down the page
+		}
+		stream = append(stream,
+			p.operator("Tm", matrix...),
+			p.encode(face, run.Text)+" Tj", // Render run
+		)
+	}
+	stream = append(stream, "ET")
+	p.emit(stream...)
+}
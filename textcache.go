@@ -0,0 +1,115 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// textKey identifies a string drawn in a font, e.g. a tick label repeated
+// all over a chart
+type textKey struct {
+	font     string // Resource name of the font
+	size     float64
+	text     string
+	sideways bool
+	decimals int // Of the outline coordinates, see decimals
+}
+
+// formXObject is a form XObject: the glyph outlines of a string, drawn by
+// every run of that string, or a transparency group
+type formXObject struct {
+	name  string  // Resource name within the document (e.g. Tx3f2a9c01)
+	bbox  viewBox // Bounds of the content, for text around the run origin
+	ops   string
+	group bool // Composited as a whole, with the page resources
+}
+
+// encode returns the string operand drawing s in face, reusing the
+// encoding of identical strings
+func (p *PDF) encode(face fontFace, s string) string {
+	key := textKey{font: face.resourceName(), text: s}
+	if encoded, ok := p.encodings[key]; ok {
+		return encoded
+	}
+	if p.encodings == nil {
+		p.encodings = make(map[textKey]string)
+	}
+	encoded := face.encode(s)
+	p.encodings[key] = encoded
+	return encoded
+}
+
+// outlineForm returns the form drawing the glyph outlines of run with its
+// origin at 0,0, or nil if the run has no visible glyphs. Identical runs
+// share one form.
+func (p *PDF) outlineForm(run glyphRun, font *Font, fontSize float64) *formXObject {
+	key := textKey{font.name, fontSize, run.Text, run.Sideways, p.decimals()}
+	if form, ok := p.textForms[key]; ok {
+		return form
+	}
+	if p.textForms == nil {
+		p.textForms = make(map[textKey]*formXObject)
+	}
+
+	scale := fontSize / font.unitsPerEm
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	var ops []string
+	pen := 0.0 // Distance along the baseline
+	for _, r := range run.Text {
+		gid := font.cmap[r]
+		glyph, err := font.glyphOutline(gid)
+		if err == nil && len(glyph) > 0 {
+			offset := pen
+			ops = append(ops, glyph.ops(p.decimals(), func(pt point) point {
+				// Glyphs are y-up, user space is y-down
+				q := point{offset + pt.X*scale, -pt.Y * scale}
+				if run.Sideways {
+					q = point{pt.Y * scale, offset + pt.X*scale}
+				}
+				minX, minY = min(minX, q.X), min(minY, q.Y)
+				maxX, maxY = max(maxX, q.X), max(maxY, q.Y)
+				return q
+			})...)
+		}
+		pen += font.glyphAdvance(gid) * fontSize / 1000
+	}
+	var form *formXObject
+	if len(ops) > 0 {
+		content := strings.Join(append(ops, "f"), "\n") // Fill all glyphs with the nonzero rule
+		name := p.resourceID("Tx", []byte(content))
+		// Identical outlines, e.g. from a font registered under two
+		// families, share one form
+		if i := slices.IndexFunc(p.forms, func(f *formXObject) bool { return f.name == name }); i >= 0 {
+			form = p.forms[i]
+		} else {
+			form = &formXObject{
+				name: name,
+				bbox: viewBox{minX, minY, maxX - minX, maxY - minY},
+				ops:  content,
+			}
+			p.forms = append(p.forms, form)
+			p.created(form.name)
+		}
+	}
+	p.textForms[key] = form
+	return form
+}
+
+// writeObject writes the form XObject as object num. Transparency groups
+// get the resources, glyph outlines need none.
+func (form *formXObject) writeObject(w *pdfWriter, num int, resources []string) {
+	// The bounding box is rounded outwards to whole units
+	dict := []string{
+		"/Type /XObject",
+		"/Subtype /Form",
+		fmt.Sprintf("/BBox [%.0f %.0f %.0f %.0f]", math.Floor(form.bbox.X), math.Floor(form.bbox.Y),
+			math.Ceil(form.bbox.X+form.bbox.W), math.Ceil(form.bbox.Y+form.bbox.H)),
+	}
+	if form.group {
+		dict = append(dict, "/Group << /Type /Group /S /Transparency >>", "/Resources <<")
+		dict = append(append(dict, resources...), ">>")
+	}
+	w.stream(num, ContentStream, dict, []byte(form.ops))
+}
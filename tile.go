@@ -0,0 +1,121 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Tiling prints SVGs as posters split across several pages, e.g. an A0
+// floor plan on A4 sheets to be glued together
+type Tiling struct {
+	Scale   float64 // Points per SVG user unit, the actual size (see SetDPI) if 0
+	Overlap float64 // Points of the poster each tile repeats of its neighbors
+	Marks   bool    // Draw crop marks at the tile corners and the tile position
+}
+
+// tileMarkLength is the length in points of the crop marks of tiles
+const tileMarkLength = 12
+
+// SetTiling draws each converted SVG at the tiling scale across as many
+// pages as it takes, each page showing one tile within the page margins,
+// in rows from the top left; nil draws every SVG on a single page. Captions
+// and reflowed text are not drawn on posters.
+func (p *PDF) SetTiling(t *Tiling) error {
+	if t != nil && !(t.Scale >= 0 && t.Overlap >= 0) {
+		return fmt.Errorf("invalid tiling scale %g and overlap %g", t.Scale, t.Overlap)
+	}
+	p.tiling = t
+	return nil
+}
+
+// WithTiling draws each converted SVG across several pages
+func WithTiling(t Tiling) Option {
+	return func(p *PDF) error {
+		return p.SetTiling(&t)
+	}
+}
+
+// posterSettings sets up drawing the next svg document at the tiling scale
+// on a page sized to it, returning a function restoring the settings once
+// the page is started
+func (p *PDF) posterSettings() func() {
+	dpi, auto, margins, caption, offset := p.dpi, p.autoPageSize, p.margins, p.caption, p.contentOffset
+	if p.tiling.Scale > 0 {
+		p.dpi = 72 / p.tiling.Scale
+	}
+	p.autoPageSize, p.margins, p.caption, p.contentOffset = true, nil, nil, [2]float64{}
+	return func() {
+		p.dpi, p.autoPageSize, p.margins, p.caption, p.contentOffset = dpi, auto, margins, caption, offset
+	}
+}
+
+// splitTiles turns the finished poster page of d into a form and draws it
+// on as many pages as it takes, one tile each
+func (p *PDF) splitTiles(d *rootDrawing) error {
+	width, height := p.pageWidth, p.pageHeight
+	ops := p.content[p.current].String()
+	d.restore()
+
+	// The poster is a form drawn once per tile
+	name := p.resourceID("Po", []byte(ops))
+	p.forms = append(p.forms, &formXObject{name: name, bbox: viewBox{0, 0, width, height}, ops: ops})
+	p.created(name)
+	p.content[p.current] = p.newContentStream()
+	p.pageSizes[p.current] = [2]float64{p.pageWidth, p.pageHeight}
+
+	box := p.contentBox()
+	overlap := min(p.tiling.Overlap, box.W/2, box.H/2)
+	stepX, stepY := box.W-overlap, box.H-overlap
+	columns := max(int(math.Ceil((width-overlap)/stepX-1e-9)), 1)
+	rows := max(int(math.Ceil((height-overlap)/stepY-1e-9)), 1)
+	for row := range rows {
+		for column := range columns {
+			if row > 0 || column > 0 {
+				p.AddPage()
+			}
+			bottom := p.pageHeight - box.Y - box.H
+			p.emit("q", p.operator("re W n", box.X, bottom, box.W, box.H),
+				p.operator("cm", 1, 0, 0, 1, box.X-float64(column)*stepX, bottom-(height-float64(row)*stepY-box.H)),
+				"/"+name+" Do", "Q")
+			if p.tiling.Marks {
+				p.drawTileMarks(box, row, column, rows, columns)
+			}
+			p.log(slog.LevelDebug, "page converted", "page", p.current+1, "duration", time.Since(d.start))
+			if err := p.finishPage(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// drawTileMarks draws crop marks outside the corners of the tile box and,
+// except in PDF/A documents, whose fonts must be embedded, the position of
+// the tile beneath it
+func (p *PDF) drawTileMarks(box viewBox, row, column, rows, columns int) {
+	left, right := box.X, box.X+box.W
+	top, bottom := p.pageHeight-box.Y, p.pageHeight-box.Y-box.H
+	ops := []string{"q", "0.25 w", p.strokeOp(RGB{})}
+	for _, corner := range [][2]float64{{left, top}, {right, top}, {left, bottom}, {right, bottom}} {
+		dx, dy := float64(tileMarkLength), float64(tileMarkLength)
+		if corner[0] == left {
+			dx = -dx
+		}
+		if corner[1] == bottom {
+			dy = -dy
+		}
+		ops = append(ops,
+			p.operator("m", corner[0], corner[1])+" "+p.operator("l S", corner[0]+dx, corner[1]),
+			p.operator("m", corner[0], corner[1])+" "+p.operator("l S", corner[0], corner[1]+dy))
+	}
+	ops = append(ops, "Q")
+	p.emit(append(p.beginArtifact(), ops...)...)
+	if !p.pdfa {
+		page := &Page{Index: p.current, Width: p.pageWidth, Height: p.pageHeight, pdf: p}
+		label := fmt.Sprintf("Tile %d of %d: row %d, column %d", row*columns+column+1, rows*columns, row+1, column+1)
+		page.Text(left, max(bottom-tileMarkLength-6, 2), 6, label, RGB{})
+	}
+	p.emit(p.endMarked()...)
+}
@@ -0,0 +1,118 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TokenOptions configures ConvertTokens
+type TokenOptions struct {
+	// Paths select the fields holding SVG markup as dot-separated keys, in
+	// which * matches any key or array index, e.g. "icons.*.value". When
+	// empty, every string holding an svg document is converted.
+	Paths []string
+	// Unmarshal decodes the document, json.Unmarshal when nil; pass e.g.
+	// yaml.Unmarshal for YAML documents
+	Unmarshal func(data []byte, v any) error
+}
+
+// ConvertTokens converts the SVG markup held in the fields of a JSON or
+// YAML document, such as icons inlined in design tokens, each to a PDF of
+// its own configured by opts. The PDFs are returned by the dot-separated
+// path of their field, e.g. "icons.home.value".
+func ConvertTokens(data []byte, t TokenOptions, opts ...Option) (map[string][]byte, error) {
+	unmarshal := t.Unmarshal
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	var doc any
+	if err := unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding tokens: %v", err)
+	}
+	patterns := make([][]string, len(t.Paths))
+	for i, path := range t.Paths {
+		patterns[i] = strings.Split(path, ".")
+	}
+
+	pdfs := make(map[string][]byte)
+	err := walkTokens(doc, nil, func(path []string, value string) error {
+		if len(patterns) == 0 && !isSVGMarkup(value) {
+			return nil
+		}
+		if len(patterns) > 0 && !slices.ContainsFunc(patterns, func(pattern []string) bool { return matchesTokenPath(pattern, path) }) {
+			return nil
+		}
+		key := strings.Join(path, ".")
+		var out bytes.Buffer
+		if err := Convert(strings.NewReader(value), &out, opts...); err != nil {
+			return fmt.Errorf("error converting %s: %v", key, err)
+		}
+		pdfs[key] = out.Bytes()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pdfs, nil
+}
+
+// walkTokens calls visit with every string in v and its path, visiting
+// object members in key order. Keys of YAML mappings need not be strings.
+func walkTokens(v any, path []string, visit func(path []string, value string) error) error {
+	switch v := v.(type) {
+	case string:
+		return visit(path, v)
+	case []any:
+		for i, item := range v {
+			if err := walkTokens(item, append(slices.Clip(path), strconv.Itoa(i)), visit); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys) // Errors are reported for the first field in order
+		for _, key := range keys {
+			if err := walkTokens(v[key], append(slices.Clip(path), key), visit); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		members := make(map[string]any, len(v))
+		for key, value := range v {
+			members[fmt.Sprint(key)] = value
+		}
+		return walkTokens(members, path, visit)
+	}
+	return nil
+}
+
+// matchesTokenPath reports whether path is selected by pattern
+func matchesTokenPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, segment := range pattern {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSVGMarkup reports whether s holds an svg document, possibly after an
+// XML declaration
+func isSVGMarkup(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<?xml") {
+		_, s, _ = strings.Cut(s, "?>")
+		s = strings.TrimSpace(s)
+	}
+	return strings.HasPrefix(s, "<svg")
+}
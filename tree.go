@@ -0,0 +1,157 @@
+package svg2pdf
+
+import (
+	"io"
+)
+
+// Parse decodes the svg documents read from r without converting them, so
+// the tree can be inspected or changed, e.g. hidden layers removed, before
+// it is drawn with Render. Sources holding several concatenated documents
+// yield one SVG each. References to declared entities expand to at most
+// 16 MiB in total; see (*PDF).Parse for other caps.
+func Parse(r io.Reader) ([]*SVG, error) {
+	return parse(r, defaultEntityBytes)
+}
+
+// Parse decodes the svg documents read from r like the Parse function,
+// expanding entity references to at most the EntityBytes of the limits, as
+// ConvertSVG does, so untrusted input parsed to be changed is capped alike
+func (p *PDF) Parse(r io.Reader) ([]*SVG, error) {
+	return parse(r, p.entityBytes())
+}
+
+// parse decodes the svg documents read from r, expanding entity references
+// to at most maxEntityBytes
+func parse(r io.Reader, maxEntityBytes int64) ([]*SVG, error) {
+	decoder := newDecoder(r, maxEntityBytes)
+	var roots []*SVG
+	for n := 1; ; n++ {
+		svgData := new(SVG)
+		err := decodeRoot(decoder, svgData)
+		if err == io.EOF && n > 1 {
+			return roots, nil // Only trailing whitespace or comments remain
+		}
+		if err != nil {
+			return roots, decodeError(n, err, decoder)
+		}
+		roots = append(roots, svgData)
+	}
+}
+
+// Render draws a parsed svg document on a new page, as ConvertSVG does for
+// the documents it decodes, within the same Limits. Validation against the
+// SVG profile, warnings about unsupported elements and source attachments
+// need the source, so they only apply to ConvertSVG.
+func (p *PDF) Render(svgData *SVG) error {
+	if p.canceled() {
+		return p.abortErr
+	}
+	p.unsupported = nil
+	return p.measure(stageRender, func() error { return p.convertRoot(svgData) })
+}
+
+// Walk calls visit for the elements of c and their descendants, in the
+// order they are drawn after the definitions: *Symbol, *ClipPath, *Filter,
+// *Rect, *Image, *Path, *Text, *ForeignObject, *SVG, *Use, *Group, *Switch
+// and *Element. The contents of defs are visited as if they were children of c.
+// Elements are passed by pointer, so visit may change them; the children of
+// an element are skipped if visit returns false.
+func (c *Container) Walk(visit func(element any) bool) {
+	for i := range c.Defs {
+		c.Defs[i].Walk(visit)
+	}
+	for i := range c.Symbols {
+		if visit(&c.Symbols[i]) {
+			c.Symbols[i].Walk(visit)
+		}
+	}
+	for i := range c.Clips {
+		visit(&c.Clips[i])
+	}
+	for i := range c.Filters {
+		visit(&c.Filters[i])
+	}
+	for i := range c.Rects {
+		visit(&c.Rects[i])
+	}
+	for i := range c.Images {
+		visit(&c.Images[i])
+	}
+	for i := range c.Paths {
+		visit(&c.Paths[i])
+	}
+	for i := range c.Texts {
+		visit(&c.Texts[i])
+	}
+	for i := range c.Foreign {
+		visit(&c.Foreign[i])
+	}
+	for i := range c.SVGs {
+		if visit(&c.SVGs[i]) {
+			c.SVGs[i].Walk(visit)
+		}
+	}
+	for i := range c.Uses {
+		visit(&c.Uses[i])
+	}
+	for i := range c.Groups {
+		if visit(&c.Groups[i]) {
+			c.Groups[i].Walk(visit)
+		}
+	}
+	for i := range c.Switches {
+		if visit(&c.Switches[i]) {
+			for j := range c.Switches[i].Children {
+				c.Switches[i].Children[j].Walk(visit)
+			}
+		}
+	}
+	for i := range c.Extensions {
+		visit(&c.Extensions[i])
+	}
+}
+
+// Filter removes the elements of c and its descendants for which keep
+// returns false, e.g. hidden layers; keep is passed the same elements as
+// the visit function of Walk. The children of removed elements are not
+// passed to keep.
+func (c *Container) Filter(keep func(element any) bool) {
+	for i := range c.Defs {
+		c.Defs[i].Filter(keep)
+	}
+	c.Symbols = filterElements(c.Symbols, keep, func(s *Symbol) { s.Filter(keep) })
+	c.Clips = filterElements(c.Clips, keep, nil)
+	c.Filters = filterElements(c.Filters, keep, nil)
+	c.Rects = filterElements(c.Rects, keep, nil)
+	c.Images = filterElements(c.Images, keep, nil)
+	c.Paths = filterElements(c.Paths, keep, nil)
+	c.Texts = filterElements(c.Texts, keep, nil)
+	c.Foreign = filterElements(c.Foreign, keep, nil)
+	c.SVGs = filterElements(c.SVGs, keep, func(s *SVG) { s.Filter(keep) })
+	c.Uses = filterElements(c.Uses, keep, nil)
+	c.Groups = filterElements(c.Groups, keep, func(g *Group) { g.Filter(keep) })
+	c.Switches = filterElements(c.Switches, keep, func(s *Switch) {
+		for j := range s.Children {
+			s.Children[j].Filter(keep)
+		}
+	})
+	c.Extensions = filterElements(c.Extensions, keep, nil)
+}
+
+// filterElements removes the elements for which keep returns false,
+// calling descend for the others
+func filterElements[T any](elements []T, keep func(any) bool, descend func(*T)) []T {
+	kept := elements[:0]
+	for i := range elements {
+		if keep(&elements[i]) {
+			kept = append(kept, elements[i])
+		}
+	}
+	clear(elements[len(kept):])
+	if descend != nil {
+		for i := range kept {
+			descend(&kept[i])
+		}
+	}
+	return kept
+}
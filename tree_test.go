@@ -0,0 +1,43 @@
+package svg2pdf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	source := `<!DOCTYPE svg [<!ENTITY e "0123456789">]>` +
+		`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><text>` + strings.Repeat("&e;", 10) + `</text></svg>`
+	if _, err := Parse(strings.NewReader(source)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	p, err := New(WithLimits(Limits{EntityBytes: 50}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *LimitError
+	if _, err := p.Parse(strings.NewReader(source)); !errors.As(err, &limitErr) || limitErr.Limit != "EntityBytes" {
+		t.Errorf("(*PDF).Parse: got %v, want EntityBytes exceeded", err)
+	}
+}
+
+func TestRenderLimits(t *testing.T) {
+	roots, err := Parse(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">` +
+		strings.Repeat(`<rect width="1" height="1"/>`, 5) + `</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(WithLimits(Limits{Elements: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *LimitError
+	if err := p.Render(roots[0]); !errors.As(err, &limitErr) || limitErr.Limit != "Elements" {
+		t.Errorf("got %v, want Elements exceeded", err)
+	}
+	// Later documents are not drawn once a conversion is aborted
+	if err := p.Render(roots[0]); !errors.As(err, &limitErr) {
+		t.Errorf("second render: got %v, want the limit error", err)
+	}
+}
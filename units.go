@@ -0,0 +1,161 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+)
+
+// Length is an SVG length attribute such as "12", "2em" or "50%", kept as
+// written and resolved against a unitContext when drawing
+type Length string
+
+// LengthList is a whitespace and/or comma separated list of lengths, as used
+// by the x, y, dx and dy attributes of text elements
+type LengthList []Length
+
+// UnmarshalXMLAttr splits the attribute value into its lengths, each a
+// number and its unit. As in number lists, separators may be left out where
+// unambiguous, e.g. "10-5". Lengths following an invalid one are ignored.
+func (l *LengthList) UnmarshalXMLAttr(attr xml.Attr) error {
+	*l = nil
+	scanList(attr.Value, func(sc *pathScanner) bool {
+		start := sc.pos
+		if _, ok := sc.number(); !ok {
+			return false
+		}
+		for sc.pos < len(sc.d) && (sc.d[sc.pos] == '%' || 'a' <= sc.d[sc.pos]|0x20 && sc.d[sc.pos]|0x20 <= 'z') {
+			sc.pos++
+		}
+		*l = append(*l, Length(sc.d[start:sc.pos]))
+		return true
+	})
+	return nil
+}
+
+// lengthAxis selects the viewport dimension percentages refer to
+type lengthAxis int
+
+const (
+	axisX     lengthAxis = iota // Horizontal lengths: x, width, dx
+	axisY                       // Vertical lengths: y, height, dy
+	axisOther                   // Neither, e.g. stroke widths and radii
+)
+
+// Ratios used where font metrics are not available, matching Helvetica
+const (
+	xHeightRatio = 0.5   // Height of "x" in em, the CSS fallback value
+	chRatio      = 0.556 // Advance of "0" in em
+)
+
+// absoluteUnits maps absolute CSS units to user units (CSS pixels)
+var absoluteUnits = map[string]float64{
+	"px": 1, "pt": 4.0 / 3, "pc": 16, "in": 96, "cm": 96 / 2.54, "mm": 96 / 25.4, "q": 96 / 101.6,
+}
+
+// fontSizeKeywords maps the absolute font-size keywords to sizes relative
+// to medium
+var fontSizeKeywords = map[string]float64{
+	"xx-small": 3.0 / 5, "x-small": 3.0 / 4, "small": 8.0 / 9, "medium": 1,
+	"large": 6.0 / 5, "x-large": 3.0 / 2, "xx-large": 2, "xxx-large": 3,
+}
+
+// unitContext holds what relative units resolve against: the font size of
+// the element (em, ex, ch), of the root element (rem) and the size of the
+// nearest viewport (percentages)
+type unitContext struct {
+	fontSize     float64
+	rootFontSize float64
+	mediumSize   float64 // Default font size the size keywords scale
+	viewportW    float64
+	viewportH    float64
+}
+
+// split separates the number of a length from its unit, lowercasing the unit
+func (l Length) split() (float64, string, bool) {
+	s := strings.TrimSpace(string(l))
+	end := len(s)
+	for end > 0 && (s[end-1] == '%' || s[end-1] >= 'a' && s[end-1] <= 'z' || s[end-1] >= 'A' && s[end-1] <= 'Z') {
+		end--
+	}
+	v, err := parseNumber(s[:end])
+	if err != nil {
+		return 0, "", false
+	}
+	return v, strings.ToLower(s[end:]), true
+}
+
+// resolve converts l to user units, returning def if l is empty or invalid
+func (u unitContext) resolve(l Length, axis lengthAxis, def float64) float64 {
+	v, unit, ok := l.split()
+	if !ok {
+		return def
+	}
+	switch unit {
+	case "":
+		return v
+	case "%":
+		switch axis {
+		case axisX:
+			return v * u.viewportW / 100
+		case axisY:
+			return v * u.viewportH / 100
+		}
+		// Normalized diagonal, as specified for other lengths
+		return v * math.Sqrt((u.viewportW*u.viewportW+u.viewportH*u.viewportH)/2) / 100
+	case "em":
+		return v * u.fontSize
+	case "ex":
+		return v * u.fontSize * xHeightRatio
+	case "ch":
+		return v * u.fontSize * chRatio
+	case "rem":
+		return v * u.rootFontSize
+	case "vw":
+		return v * u.viewportW / 100
+	case "vh":
+		return v * u.viewportH / 100
+	}
+	if factor, ok := absoluteUnits[unit]; ok {
+		return v * factor
+	}
+	return def
+}
+
+// resolveList converts every length of l to user units
+func (u unitContext) resolveList(l LengthList, axis lengthAxis) []float64 {
+	values := make([]float64, len(l))
+	for i, length := range l {
+		values[i] = u.resolve(length, axis, 0)
+	}
+	return values
+}
+
+// withFontSize returns the context of an element with the given font-size,
+// where em and percentages refer to the font size of the parent
+func (u unitContext) withFontSize(size Length) unitContext {
+	s := strings.ToLower(strings.TrimSpace(string(size)))
+	switch {
+	case s == "":
+		return u // Inherited
+	case s == "larger":
+		u.fontSize *= 1.2
+	case s == "smaller":
+		u.fontSize /= 1.2
+	case fontSizeKeywords[s] > 0:
+		u.fontSize = u.mediumSize * fontSizeKeywords[s]
+	default:
+		parent := u
+		parent.viewportW, parent.viewportH = u.fontSize, u.fontSize // Percentages of the parent font size
+		if v := parent.resolve(size, axisX, -1); v > 0 {
+			u.fontSize = v
+		}
+	}
+	return u
+}
+
+// withViewport returns the context for the content of a w by h viewport
+func (u unitContext) withViewport(w, h float64) unitContext {
+	u.viewportW, u.viewportH = w, h
+	return u
+}
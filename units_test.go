@@ -0,0 +1,40 @@
+package svg2pdf
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestLengthListUnmarshalXMLAttr(t *testing.T) {
+	tests := []struct {
+		value string
+		want  LengthList
+	}{
+		{"", nil},
+		{"10 20,30", LengthList{"10", "20", "30"}},
+		{" 10 ,\t20\n", LengthList{"10", "20"}},
+		{"10-5", LengthList{"10", "-5"}},
+		{"0.5.5", LengthList{"0.5", ".5"}},
+		{"1e2 1E-2", LengthList{"1e2", "1E-2"}},
+		{"10px-5em 50%", LengthList{"10px", "-5em", "50%"}},
+		{"1em2ex", LengthList{"1em", "2ex"}},
+		{"3,5", LengthList{"3", "5"}}, // Commas never separate decimals
+		{"10 x 20", LengthList{"10"}},
+	}
+	// Numbers are read the same whatever the locale of the process
+	for _, locale := range []string{"C", "en_US.UTF-8", "de_DE.UTF-8", "fr_FR.UTF-8", "tr_TR.UTF-8"} {
+		t.Run(locale, func(t *testing.T) {
+			t.Setenv("LC_ALL", locale)
+			for _, tt := range tests {
+				var l LengthList
+				if err := l.UnmarshalXMLAttr(xml.Attr{Value: tt.value}); err != nil {
+					t.Fatalf("%q: %v", tt.value, err)
+				}
+				if !reflect.DeepEqual(l, tt.want) {
+					t.Errorf("%q: got %q, want %q", tt.value, l, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package svg2pdf
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// maxVarDepth is the deepest nesting of var() references substituted, e.g.
+// custom properties defined with other ones, against reference cycles
+const maxVarDepth = 16
+
+// scopeProperties adds the custom properties an element defines, in style
+// sheets or its style attribute, to those it inherits while it is drawn,
+// returning the function restoring them
+func (p *PDF) scopeProperties(tag, id, class, style string) func() {
+	var rules []propertyRule
+	for _, rule := range p.propertyRules {
+		if strings.HasPrefix(rule.property, "--") && rule.sel.matches(tag, id, class) {
+			rules = append(rules, rule)
+		}
+	}
+	var inline []cssDecl
+	if strings.Contains(style, "--") {
+		for _, decl := range parseDeclarations(style) {
+			if strings.HasPrefix(decl.Property, "--") {
+				inline = append(inline, decl)
+			}
+		}
+	}
+	if len(rules) == 0 && len(inline) == 0 {
+		return func() {}
+	}
+	saved := p.customProperties
+	scope := maps.Clone(saved)
+	if scope == nil {
+		scope = make(map[string]string)
+	}
+	slices.SortStableFunc(rules, func(a, b propertyRule) int { return a.specificity - b.specificity })
+	declared := make(map[string]string)
+	for _, rule := range rules {
+		declared[rule.property] = rule.value
+	}
+	for _, decl := range inline {
+		declared[decl.Property] = declValue(decl.Value)
+	}
+	// References are substituted where properties are declared, so
+	// descendants inherit the values and not the references
+	maps.Copy(scope, declared)
+	p.customProperties = scope
+	for name, value := range declared {
+		declared[name] = p.substitute(value)
+	}
+	maps.Copy(scope, declared)
+	return func() { p.customProperties = saved }
+}
+
+// rootProperties sets the custom properties the root element of an svg
+// document defines, e.g. the theme colors of a design system, for its
+// content to inherit
+func (p *PDF) rootProperties(svgData *SVG) {
+	p.customProperties = nil
+	p.scopeProperties("svg", svgData.ID, svgData.Class, svgData.Inline)
+}
+
+// substitute replaces the var() references of a value with the custom
+// properties in scope, or their fallbacks, e.g. var(--brand, #0af)
+func (p *PDF) substitute(value string) string {
+	return p.substituteDepth(value, 0)
+}
+
+// substituteDepth substitutes the references of a value found depth
+// substitutions deep
+func (p *PDF) substituteDepth(value string, depth int) string {
+	if !strings.Contains(value, "var(") || depth > maxVarDepth {
+		return value
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(value, "var(")
+		if i < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		b.WriteString(value[:i])
+		end := matchingParen(value, i+3)
+		if end < 0 {
+			return b.String() // Invalid at computed value time
+		}
+		name, fallback, hasFallback := strings.Cut(value[i+4:end], ",")
+		replacement, ok := p.customProperties[strings.ToLower(strings.TrimSpace(name))]
+		if !ok && hasFallback {
+			replacement, ok = strings.TrimSpace(fallback), true
+		}
+		if ok {
+			b.WriteString(p.substituteDepth(replacement, depth+1))
+		}
+		value = value[end+1:]
+	}
+}
+
+// matchingParen returns the index of the parenthesis closing the one at
+// open, or -1
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,285 @@
+package svg2pdf
+
+import (
+	"slices"
+	"strings"
+)
+
+// Symbol is a reusable template instantiated by use elements
+type Symbol struct {
+	ID       string `xml:"id,attr"`
+	ViewBox  string `xml:"viewBox,attr"`
+	Aspect   string `xml:"preserveAspectRatio,attr"`
+	Overflow string `xml:"overflow,attr"`
+	Clip     string `xml:"clip,attr"`
+	Container
+}
+
+// Use references a symbol by id and draws it in a new viewport
+type Use struct {
+	Href       string `xml:"href,attr"` // Matches both href and xlink:href
+	X          Length `xml:"x,attr"`
+	Y          Length `xml:"y,attr"`
+	Width      Length `xml:"width,attr"`
+	Height     Length `xml:"height,attr"`
+	ID         string `xml:"id,attr"`
+	Class      string `xml:"class,attr"`
+	Display    string `xml:"display,attr"`
+	Visibility string `xml:"visibility,attr"`
+	Blend      string `xml:"mix-blend-mode,attr"`
+	Conditions
+	Inline string `xml:"style,attr"` // Inline CSS declarations
+}
+
+// viewBox is a rectangle in user units
+type viewBox struct {
+	X, Y, W, H float64
+}
+
+// parseViewBox parses a viewBox attribute, reporting false if it is absent
+// or invalid (which disables it, as required by SVG)
+func parseViewBox(s string) (viewBox, bool) {
+	nums, err := parseNumberList(s)
+	if err != nil || len(nums) != 4 || nums[2] <= 0 || nums[3] <= 0 {
+		return viewBox{}, false
+	}
+	return viewBox{nums[0], nums[1], nums[2], nums[3]}, true
+}
+
+// aspectRatio is a parsed preserveAspectRatio attribute
+type aspectRatio struct {
+	none   bool    // Scale non-uniformly to fill the viewport
+	alignX float64 // 0 for min, 0.5 for mid and 1 for max
+	alignY float64
+	slice  bool // Cover the viewport instead of fitting inside it
+}
+
+// parseAspectRatio parses preserveAspectRatio, defaulting to xMidYMid meet
+func parseAspectRatio(s string) aspectRatio {
+	ar := aspectRatio{alignX: 0.5, alignY: 0.5}
+	fields := strings.Fields(s)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:] // Only meaningful for images referencing SVG
+	}
+	if len(fields) == 0 {
+		return ar
+	}
+	align := fields[0]
+	if align == "none" {
+		ar.none = true
+	} else if len(align) == 8 {
+		fractions := map[string]float64{"Min": 0, "Mid": 0.5, "Max": 1}
+		x, okX := fractions[align[1:4]]
+		y, okY := fractions[align[5:8]]
+		if okX && okY && align[0] == 'x' && align[4] == 'Y' {
+			ar.alignX, ar.alignY = x, y
+		}
+	}
+	if len(fields) > 1 && fields[1] == "slice" {
+		ar.slice = true
+	}
+	return ar
+}
+
+// fit returns the scale and translation mapping vb into a viewport of size
+// w by h at the origin, with y growing downwards
+func (ar aspectRatio) fit(vb viewBox, w, h float64) (sx, sy, tx, ty float64) {
+	sx, sy = w/vb.W, h/vb.H
+	if !ar.none {
+		if ar.slice {
+			sx = max(sx, sy)
+		} else {
+			sx = min(sx, sy)
+		}
+		sy = sx
+	}
+	tx = -vb.X*sx + (w-vb.W*sx)*ar.alignX
+	ty = -vb.Y*sy + (h-vb.H*sy)*ar.alignY
+	if ar.none {
+		tx, ty = -vb.X*sx, -vb.Y*sy
+	}
+	return sx, sy, tx, ty
+}
+
+// viewportClip returns the clipping rectangle of a w by h viewport, relative
+// to its top left corner. Viewports clip unless overflow is visible; the
+// deprecated clip property can shrink the rectangle.
+func viewportClip(overflow, clip string, w, h float64, ctx unitContext) (viewBox, bool) {
+	switch strings.TrimSpace(overflow) {
+	case "visible", "auto":
+		return viewBox{}, false
+	}
+	rect := viewBox{0, 0, w, h}
+	clip = strings.TrimSpace(clip)
+	if !strings.HasPrefix(clip, "rect(") || !strings.HasSuffix(clip, ")") {
+		return rect, true // "auto" or unsupported
+	}
+	// rect(top, right, bottom, left), commas being optional in legacy content
+	args := strings.FieldsFunc(clip[5:len(clip)-1], func(r rune) bool { return r == ',' || r == ' ' })
+	if len(args) != 4 {
+		return rect, true
+	}
+	edge := func(s string, def float64) float64 {
+		if s == "auto" {
+			return def
+		}
+		return ctx.resolve(Length(s), axisOther, def)
+	}
+	top, right := edge(args[0], 0), edge(args[1], w)
+	bottom, left := edge(args[2], h), edge(args[3], 0)
+	return viewBox{left, top, max(right-left, 0), max(bottom-top, 0)}, true
+}
+
+// indexReferences records the symbols and clip paths of c and its
+// descendants by id
+func (p *PDF) indexReferences(c *Container) {
+	for i := range c.Symbols {
+		if id := c.Symbols[i].ID; id != "" {
+			p.symbols[id] = &c.Symbols[i]
+		}
+		p.indexReferences(&c.Symbols[i].Container)
+	}
+	for i := range c.Clips {
+		if id := c.Clips[i].ID; id != "" {
+			p.clipPaths[id] = &c.Clips[i]
+		}
+	}
+	for i := range c.Filters {
+		if id := c.Filters[i].ID; id != "" {
+			p.filters[id] = &c.Filters[i]
+		}
+	}
+	for i := range c.Defs {
+		p.indexReferences(&c.Defs[i])
+	}
+	for i := range c.SVGs {
+		p.indexReferences(&c.SVGs[i].Container)
+	}
+	for i := range c.Groups {
+		p.indexReferences(&c.Groups[i].Container)
+	}
+	for i := range c.Switches {
+		for j := range c.Switches[i].Children {
+			p.indexReferences(&c.Switches[i].Children[j].Container)
+		}
+	}
+}
+
+// nestedStyles expands the imports of the style sheets of the svg elements
+// nested in c and returns them. Like those of the root, they apply to the
+// whole document, as figures composed of charts often keep the styles each
+// chart was exported with.
+func (p *PDF) nestedStyles(c *Container) []Style {
+	var styles []Style
+	c.Walk(func(element any) bool {
+		if svg, ok := element.(*SVG); ok {
+			p.importStyles(svg.Styles)
+			styles = append(styles, svg.Styles...)
+		}
+		return true
+	})
+	return styles
+}
+
+// documentStyles expands the imports of the style sheets of an svg document
+// and returns them, those of the root first
+func (p *PDF) documentStyles(svgData *SVG) []Style {
+	p.importStyles(svgData.Styles)
+	return append(slices.Clip(svgData.Styles), p.nestedStyles(&svgData.Container)...)
+}
+
+// renderNestedSVG draws a nested svg element in its own viewport
+func (p *PDF) renderNestedSVG(svg *SVG, ctx unitContext) {
+	defer p.scopeProperties("svg", svg.ID, svg.Class, svg.Inline)()
+	if !p.holds(svg.Conditions) || p.displayNone("svg", svg.ID, svg.Class, svg.Display, svg.Inline) {
+		return
+	}
+	defer p.inheritVisibility(p.visible("svg", svg.ID, svg.Class, svg.Visibility, svg.Inline))()
+	ctx = ctx.withFontSize(svg.FontSize)
+	x, y := ctx.resolve(svg.X, axisX, 0), ctx.resolve(svg.Y, axisY, 0)
+	w := ctx.resolve(svg.Width, axisX, ctx.viewportW) // Defaults to 100%
+	h := ctx.resolve(svg.Height, axisY, ctx.viewportH)
+	if p.redacts("svg", svg.ID, svg.Class) {
+		p.drawRedaction(viewBox{x, y, w, h})
+		return
+	}
+	vp := viewBox{x, y, w, h}
+	draw := func() {
+		p.renderViewport(&svg.Container, vp, svg.ViewBox, svg.Aspect, svg.Overflow, svg.Clip, ctx)
+	}
+	if blend := p.blendMode("svg", svg.ID, svg.Blend, svg.Inline); blend != "" {
+		p.drawBlendGroup(blend, groupBBox(vp, svg.Overflow), draw)
+	} else {
+		draw()
+	}
+	p.rendered("svg", svg.ID)
+}
+
+// renderUse instantiates the symbol referenced by use
+func (p *PDF) renderUse(use Use, ctx unitContext) {
+	defer p.scopeProperties("use", use.ID, use.Class, use.Inline)()
+	if !p.holds(use.Conditions) || p.displayNone("use", use.ID, use.Class, use.Display, use.Inline) {
+		return
+	}
+	defer p.inheritVisibility(p.visible("use", use.ID, use.Class, use.Visibility, use.Inline))()
+	id := strings.TrimPrefix(use.Href, "#")
+	symbol, ok := p.symbols[id]
+	if !ok {
+		p.warn("use", use.ID, "reference %q is not a symbol", use.Href) // Only symbol references are supported
+		return
+	}
+	if p.usedSymbols[id] {
+		p.warn("use", use.ID, "reference %q is circular", use.Href)
+		return
+	}
+	if p.usedSymbols == nil {
+		p.usedSymbols = make(map[string]bool)
+	}
+	p.usedSymbols[id] = true
+	defer delete(p.usedSymbols, id)
+	x, y := ctx.resolve(use.X, axisX, 0), ctx.resolve(use.Y, axisY, 0)
+	w := ctx.resolve(use.Width, axisX, ctx.viewportW) // Defaults to 100%
+	h := ctx.resolve(use.Height, axisY, ctx.viewportH)
+	if p.redacts("use", use.ID, use.Class) {
+		p.drawRedaction(viewBox{x, y, w, h})
+		return
+	}
+	vp := viewBox{x, y, w, h}
+	draw := func() {
+		p.renderViewport(&symbol.Container, vp, symbol.ViewBox, symbol.Aspect, symbol.Overflow, symbol.Clip, ctx)
+	}
+	if blend := p.blendMode("use", use.ID, use.Blend, use.Inline); blend != "" {
+		p.drawBlendGroup(blend, groupBBox(vp, symbol.Overflow), draw)
+	} else {
+		draw()
+	}
+	p.rendered("use", use.ID)
+}
+
+// renderViewport draws c into the viewport rectangle vp of the parent user
+// space, clipping it according to overflow and clip
+func (p *PDF) renderViewport(c *Container, vp viewBox, viewBoxAttr, aspect, overflow, clip string, ctx unitContext) {
+	if vp.W <= 0 || vp.H <= 0 {
+		return // Rendering is disabled for empty viewports
+	}
+	p.emit("q")
+	if r, ok := viewportClip(overflow, clip, vp.W, vp.H, ctx); ok {
+		p.emit(p.operator("re W n", vp.X+r.X, vp.Y+r.Y, r.W, r.H))
+	}
+
+	// Child user space to parent user space: parent = s*child + e
+	// Percentages inside refer to the viewBox if there is one
+	sx, sy, ex, ey := 1.0, 1.0, vp.X, vp.Y
+	ctx = ctx.withViewport(vp.W, vp.H)
+	if vb, ok := parseViewBox(viewBoxAttr); ok {
+		var tx, ty float64
+		sx, sy, tx, ty = parseAspectRatio(aspect).fit(vb, vp.W, vp.H)
+		ex, ey = vp.X+tx, vp.Y+ty
+		ctx = ctx.withViewport(vb.W, vb.H)
+	}
+	p.emit(p.transform("cm", sx, 0, 0, sy, ex, ey))
+	restore := p.scaleUserSpace(sx, sy)
+	p.renderContainer(c, ctx)
+	restore()
+	p.emit("Q")
+}
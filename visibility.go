@@ -0,0 +1,65 @@
+package svg2pdf
+
+import "strings"
+
+// cssKeyword returns a CSS value without surrounding space or !important,
+// in lower case
+func cssKeyword(value string) string {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+	return strings.ToLower(value)
+}
+
+// cascade returns the value of a property for an element from its
+// presentation attribute, the style sheets and its style attribute, in
+// increasing precedence, with custom properties substituted
+func (p *PDF) cascade(property, tag, id, class, attr, style string) string {
+	value := attr
+	if v := p.cssValue(property, tag, id, class); v != "" {
+		value = v
+	}
+	for _, decl := range parseDeclarations(style) {
+		if decl.Property == property {
+			value = decl.Value
+		}
+	}
+	return cssKeyword(p.substitute(value))
+}
+
+// displayNone reports whether an element and all its descendants are left
+// out for display: none
+func (p *PDF) displayNone(tag, id, class, display, style string) bool {
+	return p.cascade("display", tag, id, class, display, style) == "none"
+}
+
+// visible reports whether an element is painted. Visibility is inherited
+// from the enclosing elements, whose children may be made visible again.
+func (p *PDF) visible(tag, id, class, visibility, style string) bool {
+	switch p.cascade("visibility", tag, id, class, visibility, style) {
+	case "hidden", "collapse":
+		return false
+	case "visible":
+		return true
+	}
+	return !p.invisible // Inherited
+}
+
+// hidden reports whether a graphics element is not drawn, for display: none
+// or an inherited or own visibility: hidden
+func (p *PDF) hidden(tag, id, class, display, visibility, style string) bool {
+	return p.displayNone(tag, id, class, display, style) || !p.visible(tag, id, class, visibility, style)
+}
+
+// inheritVisibility passes the visibility of a container element on to the
+// elements drawn within it, returning the function restoring it
+func (p *PDF) inheritVisibility(visible bool) func() {
+	saved := p.invisible
+	p.invisible = !visible
+	return func() { p.invisible = saved }
+}
+
+// rootVisibility sets the visibility the root element of an svg document
+// passes on to its content
+func (p *PDF) rootVisibility(svgData *SVG) {
+	p.invisible = false
+	p.invisible = !p.visible("svg", svgData.ID, svgData.Class, svgData.Visibility, svgData.Inline)
+}
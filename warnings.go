@@ -0,0 +1,192 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"slices"
+)
+
+// Warning describes content that was skipped or approximated during a
+// conversion, e.g. an unsupported element or attribute
+type Warning struct {
+	Page      int    // Index of the page; String numbers pages from 1
+	Element   string // Element name, if the warning concerns an element
+	ID        string // id attribute of the element
+	Attribute string // Attribute that was ignored, if any
+	Line      int    // Position of the element in the source, 0 if unknown
+	Column    int
+	Reason    string // What was skipped or approximated
+}
+
+// String describes the warning, e.g. "page 1: warning: line 4:3:
+// <circle#dot>: element is not supported"
+func (w Warning) String() string {
+	return fmt.Sprintf("page %d: warning: %s", w.Page+1, w.detail())
+}
+
+// detail describes the warning without its page
+func (w Warning) detail() string {
+	var s string
+	if w.Line > 0 {
+		s += fmt.Sprintf("line %d:%d: ", w.Line, w.Column)
+	}
+	if w.Element != "" {
+		element := w.Element
+		if w.ID != "" {
+			element += "#" + w.ID
+		}
+		s += "<" + element + ">: "
+	}
+	return s + w.Reason
+}
+
+// Warnings returns the warnings of all conversions so far, in order, so
+// callers can tell users which content was dropped instead of leaving them
+// to assume a bug
+func (p *PDF) Warnings() []Warning {
+	return slices.Clone(p.warnings)
+}
+
+// Warnings returns the warnings of all pages added so far
+func (d *Document) Warnings() []Warning {
+	return d.pdf.Warnings()
+}
+
+// supportedElements are the SVG elements that are converted. The content
+// of foreignObject and metadata is not SVG and never reported, nor are the
+// primitives of filters, reported as they are applied.
+var supportedElements = setOf("svg", "g", "defs", "symbol", "use", "switch", "rect", "path",
+	"text", "image", "foreignObject", "clipPath", "title", "desc", "style", "metadata",
+	"linearGradient", "stop", "filter")
+
+// unsupportedAttributes are the presentation attributes that are ignored
+// on every element
+var unsupportedAttributes = setOf("transform", "mask", "marker-start", "marker-mid", "marker-end")
+
+// filteredElements are the elements the filter attribute is applied to
+var filteredElements = setOf("path", "g")
+
+// scanUnsupported returns the warnings about unsupported elements and
+// attributes of each svg document in source, except for elements handled
+// by an element handler. Elements inside an unsupported element are not
+// reported. It does not change the PDF, so sources may be scanned
+// concurrently. Entities expand to at most maxEntityBytes.
+func scanUnsupported(source []byte, handled func(local string) bool, maxEntityBytes int64) [][]Warning {
+	var roots [][]Warning
+	var warnings []Warning
+	source = utf8Bytes(source) // Positions are offsets into the decoded text
+	decoder := newDecoder(bytes.NewReader(source), maxEntityBytes)
+	pos := sourcePosition{source: source, line: 1, column: 1}
+	depth := 0
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			break // Reported when decoding
+		}
+		switch t := token.(type) {
+		case xml.Directive:
+			declareEntities(decoder, t) // Errors are reported when decoding
+		case xml.StartElement:
+			depth++
+			if t.Name.Space != svgNamespace {
+				continue // Foreign content, e.g. metadata
+			}
+			if handled(t.Name.Local) {
+				decoder.Skip()
+				depth--
+				continue
+			}
+			line, column := pos.at(offset)
+			warnings = append(warnings, unsupportedContent(t, line, column)...)
+			if !supportedElements[t.Name.Local] || t.Name.Local == "foreignObject" || t.Name.Local == "metadata" || t.Name.Local == "filter" {
+				decoder.Skip()
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				roots = append(roots, warnings)
+				warnings = nil
+			}
+		}
+	}
+	return roots
+}
+
+// unsupportedContent returns the warnings about an SVG element started by
+// start at the given position: that it is not supported, or which of its
+// attributes are not
+func unsupportedContent(start xml.StartElement, line, column int) []Warning {
+	id := rawAttr(start, "id")
+	if !supportedElements[start.Name.Local] {
+		return []Warning{{Element: start.Name.Local, ID: id, Line: line, Column: column,
+			Reason: "element is not supported"}}
+	}
+	var warnings []Warning
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && (unsupportedAttributes[attr.Name.Local] || attr.Name.Local == "filter" && !filteredElements[start.Name.Local]) {
+			warnings = append(warnings, Warning{Element: start.Name.Local, ID: id, Attribute: attr.Name.Local,
+				Line: line, Column: column, Reason: fmt.Sprintf("attribute %s is not supported", attr.Name.Local)})
+		}
+	}
+	return warnings
+}
+
+// rawAttr returns the value of the unqualified attribute name of start
+func rawAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// sourcePosition converts increasing byte offsets into a source to lines
+// and columns, counting from 1
+type sourcePosition struct {
+	source       []byte
+	offset       int
+	line, column int
+}
+
+// at returns the position of the first tag at or after offset
+func (s *sourcePosition) at(offset int) (line, column int) {
+	if i := bytes.IndexByte(s.source[offset:], '<'); i >= 0 {
+		offset += i
+	}
+	for ; s.offset < offset; s.offset++ {
+		if s.source[s.offset] == '\n' {
+			s.line, s.column = s.line+1, 1
+		} else {
+			s.column++
+		}
+	}
+	return s.line, s.column
+}
+
+// nextUnsupported takes the warnings scanned for the next svg document of
+// the source being converted, to be reported on its first page
+func (p *PDF) nextUnsupported() {
+	p.rootWarnings = nil
+	if len(p.unsupported) > 0 {
+		p.rootWarnings, p.unsupported = p.unsupported[0], p.unsupported[1:]
+	}
+}
+
+// reportUnsupported reports the scanned warnings of the svg document
+// started on the current page
+func (p *PDF) reportUnsupported() {
+	for _, w := range p.rootWarnings {
+		p.warnAt(w)
+	}
+	p.rootWarnings = nil
+}
+
+// warnAt reports w on the current page
+func (p *PDF) warnAt(w Warning) {
+	p.event(Event{Kind: WarningEmitted, Element: w.Element, ID: w.ID, Attribute: w.Attribute,
+		Line: w.Line, Column: w.Column, Message: w.Reason})
+}
@@ -0,0 +1,225 @@
+package svg2pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// watermark is drawn under or over the content of every page
+type watermark struct {
+	text    string
+	svg     []byte
+	opacity float64
+	angle   float64 // Degrees counterclockwise
+	width   float64 // Fraction of the page width spanned before rotation
+	color   RGB
+	over    bool
+	form    *formXObject // Built when the first page is written
+	state   string       // Resource name of the ExtGState setting the opacity
+}
+
+// WatermarkOption configures a watermark set with SetWatermark
+type WatermarkOption func(*watermark) error
+
+// WatermarkOpacity sets the opacity of the watermark from 0 to 1, 0.3 by
+// default
+func WatermarkOpacity(opacity float64) WatermarkOption {
+	return func(w *watermark) error {
+		if !(opacity >= 0 && opacity <= 1) {
+			return fmt.Errorf("invalid watermark opacity %g", opacity)
+		}
+		w.opacity = opacity
+		return nil
+	}
+}
+
+// WatermarkAngle sets the rotation of the watermark in degrees
+// counterclockwise, 45 by default
+func WatermarkAngle(degrees float64) WatermarkOption {
+	return func(w *watermark) error {
+		if math.IsNaN(degrees) || math.IsInf(degrees, 0) {
+			return fmt.Errorf("invalid watermark angle %g", degrees)
+		}
+		w.angle = degrees
+		return nil
+	}
+}
+
+// WatermarkWidth sets the width of the unrotated watermark as a fraction of
+// the page width, 0.6 by default
+func WatermarkWidth(fraction float64) WatermarkOption {
+	return func(w *watermark) error {
+		if !(fraction > 0) {
+			return fmt.Errorf("invalid watermark width %g", fraction)
+		}
+		w.width = fraction
+		return nil
+	}
+}
+
+// WatermarkColor sets the color of a text watermark, gray by default
+func WatermarkColor(c RGB) WatermarkOption {
+	return func(w *watermark) error {
+		w.color = c
+		return nil
+	}
+}
+
+// WatermarkOver draws the watermark over the page content instead of under
+// it, as a stamp
+func WatermarkOver() WatermarkOption {
+	return func(w *watermark) error {
+		w.over = true
+		return nil
+	}
+}
+
+// SetWatermark draws a rotated, semi-transparent watermark centered on
+// every page of the document, e.g. "DRAFT" or "CONFIDENTIAL". content is
+// either text, drawn in Helvetica, or an SVG document starting with "<"; ""
+// removes the watermark. Pages carry the watermark as an artifact, and it is
+// not part of their statistics.
+func (p *PDF) SetWatermark(content string, opts ...WatermarkOption) error {
+	if strings.TrimSpace(content) == "" {
+		p.watermark = nil
+		return nil
+	}
+	w := &watermark{opacity: 0.3, angle: 45, width: 0.6, color: namedColors["gray"]}
+	if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "<") {
+		w.svg = []byte(trimmed)
+	} else {
+		w.text = content
+	}
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return err
+		}
+	}
+	p.watermark = w
+	return nil
+}
+
+// SetWatermark draws a watermark on every page
+func (d *Document) SetWatermark(content string, opts ...WatermarkOption) error {
+	return d.pdf.SetWatermark(content, opts...)
+}
+
+// WithWatermark draws a watermark on every page
+func WithWatermark(content string, opts ...WatermarkOption) Option {
+	return func(p *PDF) error {
+		return p.SetWatermark(content, opts...)
+	}
+}
+
+// prepareWatermark turns the watermark into a form once pages are written
+func (p *PDF) prepareWatermark() error {
+	w := p.watermark
+	if w == nil || w.form != nil || p.pageCount == 0 {
+		return nil
+	}
+	var ops string
+	var bbox viewBox
+	if w.svg != nil {
+		var err error
+		if ops, bbox, err = p.renderWatermark(w.svg); err != nil {
+			return fmt.Errorf("error drawing watermark: %v", err)
+		}
+	} else {
+		// Text is drawn at 100 points and scaled onto each page
+		face := standardFont{}
+		ops = strings.Join([]string{
+			"BT",
+			p.fillOp(w.color),
+			fmt.Sprintf("/%s 100 Tf", face.resourceName()),
+			face.encode(w.text) + " Tj",
+			"ET",
+		}, "\n")
+		bbox = viewBox{0, -22, max(textWidth(w.text, 100), 1), 116} // Centered on the capitals
+	}
+	name := p.resourceID("Wm", []byte(ops))
+	w.form = &formXObject{name: name, bbox: bbox, ops: ops, group: true}
+	p.forms = append(p.forms, w.form)
+	p.created(name)
+	if w.opacity < 1 {
+		w.state = p.resourceID("GS", []byte(w.opacityEntries()))
+		p.created(w.state)
+	}
+	return nil
+}
+
+// renderWatermark draws the first svg document of source into the operators
+// of a form, returning them with its bounds in user units
+func (p *PDF) renderWatermark(source []byte) (string, viewBox, error) {
+	d := p.decodeSource(source)
+	if d.invalid != nil {
+		return "", viewBox{}, d.invalid
+	}
+	if d.err != nil {
+		return "", viewBox{}, d.err
+	}
+	if len(d.roots) == 0 {
+		return "", viewBox{}, fmt.Errorf("no svg element")
+	}
+	svgData := d.roots[0]
+	styles := p.documentStyles(svgData)
+	if err := p.registerFontFaces(styles); err != nil {
+		return "", viewBox{}, err
+	}
+	p.setPropertyRules(styles)
+	ctx, width, height := p.rootViewport(svgData)
+
+	// The drawing is captured from the current page, which may have been
+	// written already when streaming
+	page, written := p.current, p.written[p.current]
+	saved, flow := p.content[page], p.flowBlocks
+	p.content[page], p.written[page] = p.newContentStream(), 0
+	defer func() {
+		p.content[page], p.written[page], p.flowBlocks = saved, written, flow
+	}()
+	p.symbols = make(map[string]*Symbol)
+	p.clipPaths = make(map[string]*ClipPath)
+	p.filters = make(map[string]*Filter)
+	p.namespaces = svgData.namespaces
+	p.indexReferences(&svgData.Container)
+	p.rootProperties(svgData)
+	p.rootVisibility(svgData)
+	p.emit(p.operator("cm", 1, 0, 0, -1, 0, height))
+	if vb, ok := parseViewBox(svgData.ViewBox); ok {
+		sx, sy, tx, ty := parseAspectRatio(svgData.Aspect).fit(vb, width, height)
+		p.emit(p.transform("cm", sx, 0, 0, sy, tx, ty))
+		defer p.scaleUserSpace(sx, sy)()
+		ctx = ctx.withViewport(vb.W, vb.H)
+	}
+	p.renderContainer(&svgData.Container, ctx)
+	return p.content[page].String(), viewBox{0, 0, width, height}, nil
+}
+
+// opacityEntries returns the entries of the ExtGState setting the opacity
+// of the watermark
+func (w *watermark) opacityEntries() string {
+	return fmt.Sprintf("/Type /ExtGState /ca %s /CA %[1]s", formatNumbers(colorDecimals, w.opacity))
+}
+
+// watermarkOps returns the operators drawing the watermark centered on page
+// i, or "" without one
+func (p *PDF) watermarkOps(i int) string {
+	w := p.watermark
+	if w == nil || w.form == nil {
+		return ""
+	}
+	size := p.pageSizes[i]
+	b := w.form.bbox
+	scale := w.width * size[0] / b.W
+	sin, cos := math.Sincos(w.angle * math.Pi / 180)
+	m := matrix{1, 0, 0, 1, -b.X - b.W/2, -b.Y - b.H/2}.
+		multiply(matrix{scale, 0, 0, scale, 0, 0}).
+		multiply(matrix{cos, sin, -sin, cos, size[0] / 2, size[1] / 2})
+
+	ops := append(p.beginArtifact(), "q")
+	if w.state != "" {
+		ops = append(ops, "/"+w.state+" gs")
+	}
+	ops = append(ops, p.transform("cm", m[0], m[1], m[2], m[3], m[4], m[5]), "/"+w.form.name+" Do", "Q")
+	return strings.Join(append(ops, p.endMarked()...), "\n")
+}
@@ -0,0 +1,101 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sfntTable is a single table of an sfnt font file
+type sfntTable struct {
+	tag      string
+	checksum uint32
+	data     []byte
+}
+
+// decodeWOFF unpacks a WOFF 1.0 container into plain sfnt data
+func decodeWOFF(data []byte) ([]byte, error) {
+	if len(data) < 44 {
+		return nil, fmt.Errorf("invalid WOFF header")
+	}
+	flavor := binary.BigEndian.Uint32(data[4:])
+	numTables := int(binary.BigEndian.Uint16(data[12:]))
+	if len(data) < 44+20*numTables {
+		return nil, fmt.Errorf("truncated WOFF table directory")
+	}
+
+	tables := make([]sfntTable, 0, numTables)
+	for i := 0; i < numTables; i++ {
+		entry := data[44+20*i:]
+		tag := string(entry[:4])
+		offset := binary.BigEndian.Uint32(entry[4:])
+		compLength := binary.BigEndian.Uint32(entry[8:])
+		origLength := binary.BigEndian.Uint32(entry[12:])
+		if uint64(offset)+uint64(compLength) > uint64(len(data)) {
+			return nil, fmt.Errorf("WOFF table %q out of bounds", tag)
+		}
+		raw := data[offset : offset+compLength]
+		if compLength < origLength {
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("error decompressing WOFF table %q: %v", tag, err)
+			}
+			raw, err = io.ReadAll(io.LimitReader(zr, int64(origLength)+1))
+			zr.Close()
+			if err != nil {
+				return nil, fmt.Errorf("error decompressing WOFF table %q: %v", tag, err)
+			}
+		}
+		if uint32(len(raw)) != origLength {
+			return nil, fmt.Errorf("WOFF table %q has unexpected length", tag)
+		}
+		tables = append(tables, sfntTable{
+			tag:      tag,
+			checksum: binary.BigEndian.Uint32(entry[16:]),
+			data:     raw,
+		})
+	}
+	return buildSFNT(flavor, tables), nil
+}
+
+// buildSFNT serializes tables into an sfnt file with the given version tag
+func buildSFNT(flavor uint32, tables []sfntTable) []byte {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	numTables := len(tables)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	var out bytes.Buffer
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header, flavor)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+	out.Write(header)
+
+	offset := 12 + 16*numTables
+	for _, t := range tables {
+		rec := make([]byte, 16)
+		copy(rec, t.tag)
+		binary.BigEndian.PutUint32(rec[4:], t.checksum)
+		binary.BigEndian.PutUint32(rec[8:], uint32(offset))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(t.data)))
+		out.Write(rec)
+		offset += (len(t.data) + 3) &^ 3
+	}
+	for _, t := range tables {
+		out.Write(t.data)
+		if pad := (4 - len(t.data)%4) % 4; pad > 0 {
+			out.Write(make([]byte, pad))
+		}
+	}
+	return out.Bytes()
+}
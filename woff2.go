@@ -0,0 +1,485 @@
+package svg2pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// woff2Tags are the tags of the known tables of WOFF2 table directories,
+// indexed by the low 6 bits of their flags
+var woff2Tags = [63]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post",
+	"cvt ", "fpgm", "glyf", "loca", "prep", "CFF ", "VORG", "EBDT",
+	"EBLC", "gasp", "hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea",
+	"vmtx", "BASE", "GDEF", "GPOS", "GSUB", "EBSC", "JSTF", "MATH",
+	"CBDT", "CBLC", "COLR", "CPAL", "SVG ", "sbix", "acnt", "avar",
+	"bdat", "bloc", "bsln", "cvar", "fdsc", "feat", "fmtx", "fvar",
+	"gvar", "hsty", "just", "lcar", "mort", "morx", "opbd", "prop",
+	"trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill",
+}
+
+// woff2Table is an entry of a WOFF2 table directory
+type woff2Table struct {
+	tag         string
+	length      int  // Length of the table in the font
+	size        int  // Length of the table in the decompressed stream
+	transformed bool // Whether glyf and loca, or hmtx, are transformed
+}
+
+// decodeWOFF2 unpacks a WOFF2 container into plain sfnt data, undoing the
+// transforms of the glyf, loca and hmtx tables
+func decodeWOFF2(data []byte) ([]byte, error) {
+	if len(data) < 48 {
+		return nil, fmt.Errorf("invalid WOFF2 header")
+	}
+	flavor := binary.BigEndian.Uint32(data[4:])
+	if flavor == 0x74746366 { // ttcf
+		return nil, fmt.Errorf("font collections are not supported")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[12:]))
+	compressedSize := int(binary.BigEndian.Uint32(data[20:]))
+
+	s := &woff2Stream{data: data, pos: 48}
+	entries := make([]woff2Table, numTables)
+	total := 0
+	for i := range entries {
+		e := &entries[i]
+		flags := s.u8()
+		if flags&63 == 63 {
+			e.tag = string(s.bytes(4))
+		} else {
+			e.tag = woff2Tags[flags&63]
+		}
+		e.length = s.base128()
+		e.size = e.length
+		switch version := flags >> 6; {
+		case e.tag == "glyf" || e.tag == "loca":
+			e.transformed = version == 0
+			if version != 0 && version != 3 {
+				s.fail("unknown transform of WOFF2 table %q", e.tag)
+			}
+		case e.tag == "hmtx":
+			e.transformed = version == 1
+			if version > 1 {
+				s.fail("unknown transform of WOFF2 table %q", e.tag)
+			}
+		case version != 0:
+			s.fail("unknown transform of WOFF2 table %q", e.tag)
+		}
+		if e.transformed {
+			e.size = s.base128()
+		}
+		if total += e.size; total > len(data)*woff2MaxRatio {
+			s.fail("WOFF2 tables too large for their compressed size")
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.pos+compressedSize > len(data) {
+		return nil, fmt.Errorf("truncated WOFF2 data")
+	}
+	stream, err := decodeBrotli(data[s.pos:s.pos+compressedSize], total)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing WOFF2 tables: %v", err)
+	}
+	if len(stream) != total {
+		return nil, fmt.Errorf("WOFF2 tables have unexpected length")
+	}
+
+	raw := make(map[string][]byte, numTables)
+	transformed := make(map[string]bool)
+	for _, e := range entries {
+		raw[e.tag], stream = stream[:e.size], stream[e.size:]
+		transformed[e.tag] = e.transformed
+	}
+	if transformed["glyf"] != transformed["loca"] {
+		return nil, fmt.Errorf("WOFF2 glyf and loca tables must be transformed together")
+	}
+	var xMins []int16
+	if transformed["glyf"] {
+		glyf, loca, longOffsets, mins, err := woff2Glyphs(raw["glyf"])
+		if err != nil {
+			return nil, err
+		}
+		raw["glyf"], raw["loca"], xMins = glyf, loca, mins
+		if head := raw["head"]; len(head) >= 54 {
+			head = append([]byte(nil), head...)
+			binary.BigEndian.PutUint16(head[50:], uint16(longOffsets))
+			raw["head"] = head
+		}
+	}
+	if transformed["hmtx"] {
+		if xMins == nil {
+			return nil, fmt.Errorf("WOFF2 hmtx table transformed without glyf")
+		}
+		if raw["hmtx"], err = woff2Metrics(raw["hmtx"], raw["hhea"], xMins); err != nil {
+			return nil, err
+		}
+	}
+
+	tables := make([]sfntTable, 0, numTables)
+	for _, e := range entries {
+		tables = append(tables, sfntTable{tag: e.tag, checksum: sfntChecksum(raw[e.tag]), data: raw[e.tag]})
+	}
+	return buildSFNT(flavor, tables), nil
+}
+
+// woff2MaxRatio caps the size of the tables of WOFF2 data against their
+// compressed size, Brotli compressing little beyond a ratio of 100
+const woff2MaxRatio = 1000
+
+// sfntChecksum returns the checksum of a table
+func sfntChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// woff2Stream reads the values of WOFF2 data. Errors are sticky: once err
+// is set, reads return zeros.
+type woff2Stream struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// fail records the first error of the stream
+func (s *woff2Stream) fail(format string, args ...any) {
+	if s.err == nil {
+		s.err = fmt.Errorf(format, args...)
+	}
+}
+
+// bytes reads n bytes
+func (s *woff2Stream) bytes(n int) []byte {
+	if n < 0 || n > len(s.data)-s.pos {
+		s.fail("truncated WOFF2 data")
+	}
+	if s.err != nil {
+		return make([]byte, max(n, 0))
+	}
+	b := s.data[s.pos : s.pos+n]
+	s.pos += n
+	return b
+}
+
+func (s *woff2Stream) u8() byte {
+	return s.bytes(1)[0]
+}
+
+func (s *woff2Stream) u16() uint16 {
+	return binary.BigEndian.Uint16(s.bytes(2))
+}
+
+func (s *woff2Stream) u32() uint32 {
+	return binary.BigEndian.Uint32(s.bytes(4))
+}
+
+// base128 reads a UIntBase128, a variable-length 32-bit number
+func (s *woff2Stream) base128() int {
+	v := 0
+	for i := 0; i < 5; i++ {
+		b := s.u8()
+		if i == 0 && b == 0x80 || v>>25 != 0 {
+			s.fail("invalid WOFF2 number")
+			return 0
+		}
+		v = v<<7 | int(b&0x7F)
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+	s.fail("invalid WOFF2 number")
+	return 0
+}
+
+// u255 reads a 255UInt16, a variable-length 16-bit number
+func (s *woff2Stream) u255() int {
+	switch b := s.u8(); b {
+	case 253:
+		return int(s.u16())
+	case 254:
+		return 253*2 + int(s.u8())
+	case 255:
+		return 253 + int(s.u8())
+	default:
+		return int(b)
+	}
+}
+
+// sub returns a stream of the next n bytes
+func (s *woff2Stream) sub(n int) *woff2Stream {
+	return &woff2Stream{data: s.bytes(n)}
+}
+
+// Flags of TrueType glyph data
+const (
+	glyphOnCurve      = 0x01
+	glyphShortX       = 0x02
+	glyphShortY       = 0x04
+	glyphSameX        = 0x10 // Or positive short x
+	glyphSameY        = 0x20 // Or positive short y
+	glyphOverlap      = 0x40
+	componentWords    = 0x0001
+	componentScale    = 0x0008
+	componentMore     = 0x0020
+	componentXYScale  = 0x0040
+	component2x2      = 0x0080
+	componentHasInstr = 0x0100
+)
+
+// woff2Glyphs rebuilds the glyf and loca tables from a transformed glyf
+// table. It also returns whether loca has long offsets and the xMin of
+// each glyph, for the hmtx transform.
+func woff2Glyphs(data []byte) (glyf, loca []byte, longOffsets int, xMins []int16, err error) {
+	s := &woff2Stream{data: data}
+	s.u16() // Reserved
+	options := s.u16()
+	numGlyphs := int(s.u16())
+	longOffsets = int(s.u16())
+	var sizes [7]int
+	for i := range sizes {
+		sizes[i] = int(s.u32())
+	}
+	contours, points, flags, glyphs, composites := s.sub(sizes[0]), s.sub(sizes[1]), s.sub(sizes[2]), s.sub(sizes[3]), s.sub(sizes[4])
+	bboxes := s.sub(sizes[5])
+	bboxBitmap := bboxes.bytes(4 * ((numGlyphs + 31) / 32))
+	instructions := s.sub(sizes[6])
+	var overlaps []byte
+	if options&1 != 0 {
+		overlaps = s.bytes((numGlyphs + 7) / 8)
+	}
+	if s.err != nil {
+		return nil, nil, 0, nil, s.err
+	}
+
+	offsets := make([]int, numGlyphs+1)
+	xMins = make([]int16, numGlyphs)
+	for i := 0; i < numGlyphs; i++ {
+		hasBBox := bboxBitmap[i>>3]&(0x80>>(i&7)) != 0
+		var glyph []byte
+		switch n := int16(contours.u16()); {
+		case n == 0:
+			if hasBBox {
+				return nil, nil, 0, nil, fmt.Errorf("WOFF2 glyph %d: bounding box of an empty glyph", i)
+			}
+		case n < 0:
+			if !hasBBox {
+				return nil, nil, 0, nil, fmt.Errorf("WOFF2 glyph %d: composite glyph without bounding box", i)
+			}
+			glyph = binary.BigEndian.AppendUint16(glyph, uint16(n))
+			glyph = append(glyph, bboxes.bytes(8)...)
+			start, hasInstr := composites.pos, false
+			for more := true; more && composites.err == nil; {
+				flag := composites.u16()
+				size := 4 // Flags and glyph index
+				if flag&componentWords != 0 {
+					size += 4
+				} else {
+					size += 2
+				}
+				switch {
+				case flag&componentScale != 0:
+					size += 2
+				case flag&componentXYScale != 0:
+					size += 4
+				case flag&component2x2 != 0:
+					size += 8
+				}
+				composites.bytes(size - 2)
+				more = flag&componentMore != 0
+				hasInstr = hasInstr || flag&componentHasInstr != 0
+			}
+			if composites.err != nil {
+				return nil, nil, 0, nil, composites.err
+			}
+			glyph = append(glyph, composites.data[start:composites.pos]...)
+			if hasInstr {
+				glyph = woff2Instructions(glyph, glyphs, instructions)
+			}
+		default:
+			glyph = woff2SimpleGlyph(int(n), hasBBox, overlaps != nil && overlaps[i>>3]&(0x80>>(i&7)) != 0,
+				points, flags, glyphs, bboxes, instructions)
+		}
+		for _, stream := range []*woff2Stream{contours, points, flags, glyphs, composites, bboxes, instructions} {
+			if stream.err != nil {
+				return nil, nil, 0, nil, fmt.Errorf("WOFF2 glyph %d: %v", i, stream.err)
+			}
+		}
+		if len(glyph) >= 4 {
+			xMins[i] = int16(binary.BigEndian.Uint16(glyph[2:]))
+		}
+		glyf = append(glyf, glyph...)
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+		offsets[i+1] = len(glyf)
+	}
+
+	for _, offset := range offsets {
+		if longOffsets != 0 {
+			loca = binary.BigEndian.AppendUint32(loca, uint32(offset))
+		} else if offset/2 > 0xFFFF {
+			return nil, nil, 0, nil, fmt.Errorf("WOFF2 glyphs too large for short loca offsets")
+		} else {
+			loca = binary.BigEndian.AppendUint16(loca, uint16(offset/2))
+		}
+	}
+	return glyf, loca, longOffsets, xMins, nil
+}
+
+// woff2Instructions appends the instructions of a glyph to it, with their
+// length
+func woff2Instructions(glyph []byte, glyphs, instructions *woff2Stream) []byte {
+	n := glyphs.u255()
+	glyph = binary.BigEndian.AppendUint16(glyph, uint16(n))
+	return append(glyph, instructions.bytes(n)...)
+}
+
+// woff2SimpleGlyph rebuilds a simple glyph of the given number of
+// contours from the streams of a transformed glyf table
+func woff2SimpleGlyph(contours int, hasBBox, overlap bool, points, flags, glyphs, bboxes, instructions *woff2Stream) []byte {
+	ends := make([]int, contours)
+	total := 0
+	for i := range ends {
+		total += points.u255()
+		ends[i] = total - 1
+	}
+	if total > 0xFFFF {
+		points.fail("too many points")
+		return nil
+	}
+	xs, ys, onCurve := make([]int, total), make([]int, total), make([]bool, total)
+	x, y := 0, 0
+	for i := 0; i < total && flags.err == nil && glyphs.err == nil; i++ {
+		flag := int(flags.u8())
+		onCurve[i] = flag&0x80 == 0
+		dx, dy := woff2Triplet(flag&0x7F, glyphs)
+		x, y = x+dx, y+dy
+		xs[i], ys[i] = x, y
+	}
+
+	var glyph []byte
+	glyph = binary.BigEndian.AppendUint16(glyph, uint16(contours))
+	if hasBBox {
+		glyph = append(glyph, bboxes.bytes(8)...)
+	} else {
+		var xMin, yMin, xMax, yMax int
+		for i := range xs {
+			if i == 0 {
+				xMin, yMin, xMax, yMax = xs[i], ys[i], xs[i], ys[i]
+			}
+			xMin, yMin, xMax, yMax = min(xMin, xs[i]), min(yMin, ys[i]), max(xMax, xs[i]), max(yMax, ys[i])
+		}
+		for _, v := range []int{xMin, yMin, xMax, yMax} {
+			glyph = binary.BigEndian.AppendUint16(glyph, uint16(int16(v)))
+		}
+	}
+	for _, end := range ends {
+		glyph = binary.BigEndian.AppendUint16(glyph, uint16(end))
+	}
+	glyph = woff2Instructions(glyph, glyphs, instructions)
+
+	// Flags, then x and y coordinates as deltas in 1 or 2 bytes
+	var coords [2][]byte
+	prevX, prevY := 0, 0
+	for i := range xs {
+		flag := byte(0)
+		if onCurve[i] {
+			flag |= glyphOnCurve
+		}
+		if i == 0 && overlap {
+			flag |= glyphOverlap
+		}
+		for axis, d := range []int{xs[i] - prevX, ys[i] - prevY} {
+			short, same := byte(glyphShortX), byte(glyphSameX)
+			if axis == 1 {
+				short, same = glyphShortY, glyphSameY
+			}
+			switch {
+			case d == 0:
+				flag |= same
+			case d > -256 && d < 256:
+				flag |= short
+				if d > 0 {
+					flag |= same
+				}
+				coords[axis] = append(coords[axis], byte(abs(d)))
+			case d >= -0x8000 && d < 0x8000:
+				coords[axis] = binary.BigEndian.AppendUint16(coords[axis], uint16(int16(d)))
+			default:
+				flags.fail("coordinate out of range")
+			}
+		}
+		prevX, prevY = xs[i], ys[i]
+		glyph = append(glyph, flag)
+	}
+	return append(append(glyph, coords[0]...), coords[1]...)
+}
+
+// woff2Triplet reads the point delta coded by flag from the glyph stream
+func woff2Triplet(flag int, glyphs *woff2Stream) (dx, dy int) {
+	sign := func(bit, v int) int {
+		if flag>>bit&1 == 0 {
+			return -v
+		}
+		return v
+	}
+	switch {
+	case flag < 10:
+		return 0, sign(0, (flag&14)<<7+int(glyphs.u8()))
+	case flag < 20:
+		return sign(0, ((flag-10)&14)<<7+int(glyphs.u8())), 0
+	case flag < 84:
+		b0, b1 := flag-20, int(glyphs.u8())
+		return sign(0, 1+b0&0x30+b1>>4), sign(1, 1+(b0&0x0C)<<2+b1&0x0F)
+	case flag < 120:
+		b0, b := flag-84, glyphs.bytes(2)
+		return sign(0, 1+(b0/12)<<8+int(b[0])), sign(1, 1+(b0%12>>2)<<8+int(b[1]))
+	case flag < 124:
+		b := glyphs.bytes(3)
+		return sign(0, int(b[0])<<4+int(b[1])>>4), sign(1, int(b[1])&0x0F<<8+int(b[2]))
+	default:
+		b := glyphs.bytes(4)
+		return sign(0, int(b[0])<<8+int(b[1])), sign(1, int(b[2])<<8+int(b[3]))
+	}
+}
+
+// woff2Metrics rebuilds the hmtx table from a transformed one, taking the
+// left side bearings it leaves out from the xMin of the glyphs
+func woff2Metrics(data, hhea []byte, xMins []int16) ([]byte, error) {
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("invalid hhea table")
+	}
+	numHMetrics, numGlyphs := int(binary.BigEndian.Uint16(hhea[34:])), len(xMins)
+	if numHMetrics < 1 || numHMetrics > numGlyphs {
+		return nil, fmt.Errorf("invalid number of horizontal metrics")
+	}
+	s := &woff2Stream{data: data}
+	flags := s.u8()
+	advances := s.bytes(2 * numHMetrics)
+	bearings := make([]int16, numGlyphs)
+	for i := range bearings {
+		if i < numHMetrics && flags&1 == 0 || i >= numHMetrics && flags&2 == 0 {
+			bearings[i] = int16(s.u16())
+		} else {
+			bearings[i] = xMins[i]
+		}
+	}
+	if s.err != nil {
+		return nil, fmt.Errorf("invalid WOFF2 hmtx table: %v", s.err)
+	}
+	hmtx := make([]byte, 0, 2*numHMetrics+2*numGlyphs)
+	for i, lsb := range bearings {
+		if i < numHMetrics {
+			hmtx = append(hmtx, advances[2*i:2*i+2]...)
+		}
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(lsb))
+	}
+	return hmtx, nil
+}
@@ -0,0 +1,114 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// storedBrotli returns data as a Brotli stream of uncompressed meta-blocks
+func storedBrotli(data []byte) []byte {
+	var out []byte
+	bits, n := uint32(0), uint(1) // A zero bit for a window of 2^16 bytes
+	put := func(v uint32, count uint) {
+		bits |= v << n
+		for n += count; n >= 8; n -= 8 {
+			out = append(out, byte(bits))
+			bits >>= 8
+		}
+	}
+	for len(data) > 0 {
+		block := data[:min(len(data), 1<<16)]
+		data = data[len(block):]
+		put(0, 1)                     // Not last
+		put(0, 2)                     // 4 nibbles of length
+		put(uint32(len(block)-1), 16) // Length
+		put(1, 1)                     // Uncompressed
+		if n > 0 {
+			put(0, 8-n) // Padding
+		}
+		out = append(out, block...)
+	}
+	put(3, 2) // Last and empty
+	if n > 0 {
+		out = append(out, byte(bits))
+	}
+	return out
+}
+
+func TestDecodeWOFF2(t *testing.T) {
+	be := binary.BigEndian
+	// A triangle, a composite of it and an empty glyph, in glyf streams
+	contours := []byte{0, 1, 0xFF, 0xFF, 0, 0}
+	points := []byte{3}
+	flags := []byte{11, 11, 122 | 0x80}          // dx 10, dx 100, then dx -50 dy 200 off the curve
+	glyphs := []byte{10, 100, 3, 0x20, 200, 0}   // Triplet data, no instructions
+	composites := []byte{0, 3, 0, 0, 0, 5, 0, 6} // Glyph 0 moved by (5, 6)
+	bboxes := []byte{0x40, 0, 0, 0, 0, 15, 0, 6, 0, 115, 0, 206}
+	var glyf []byte
+	glyf = be.AppendUint16(glyf, 0)
+	glyf = be.AppendUint16(glyf, 0) // Options
+	glyf = be.AppendUint16(glyf, 3) // Glyphs
+	glyf = be.AppendUint16(glyf, 0) // Short loca offsets
+	for _, stream := range [][]byte{contours, points, flags, glyphs, composites, bboxes, nil} {
+		glyf = be.AppendUint32(glyf, uint32(len(stream)))
+	}
+	glyf = append(glyf, bytes.Join([][]byte{contours, points, flags, glyphs, composites, bboxes}, nil)...)
+
+	head := make([]byte, 54)
+	be.PutUint16(head[50:], 1)
+	hhea := make([]byte, 36)
+	be.PutUint16(hhea[34:], 2)
+	hmtx := []byte{3, 0x01, 0xF4, 0x02, 0x58} // Advances 500 and 600, bearings left out
+
+	tables := []struct {
+		index, version byte
+		data           []byte
+	}{{10, 0, glyf}, {11, 0, nil}, {3, 1, hmtx}, {1, 0, head}, {2, 0, hhea}}
+	var directory, stream []byte
+	for _, table := range tables {
+		directory = append(directory, table.index|table.version<<6, byte(len(table.data)))
+		if table.index == 10 || table.index == 11 || table.version != 0 {
+			directory = append(directory, byte(len(table.data))) // Transformed length
+		}
+		stream = append(stream, table.data...)
+	}
+	compressed := storedBrotli(stream)
+	data := make([]byte, 48)
+	copy(data, "wOF2")
+	be.PutUint32(data[4:], 0x00010000)
+	be.PutUint16(data[12:], uint16(len(tables)))
+	be.PutUint32(data[20:], uint32(len(compressed)))
+	data = append(append(data, directory...), compressed...)
+
+	sfnt, err := decodeWOFF2(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := sfntTables(sfnt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	triangle := []byte{
+		0, 1, 0, 10, 0, 0, 0, 110, 0, 200, // Contours and computed bounding box
+		0, 2, 0, 0, // End point, no instructions
+		0x33, 0x33, 0x26, // On the curve with positive dx, then off it with negative dx
+		10, 100, 50, 200, 0, 0, 0, // Coordinates and padding
+	}
+	composite := []byte{0xFF, 0xFF, 0, 15, 0, 6, 0, 115, 0, 206, 0, 3, 0, 0, 0, 5, 0, 6}
+	for _, tt := range []struct {
+		tag  string
+		want []byte
+	}{
+		{"glyf", append(append(triangle, composite...), 0, 0)},
+		{"loca", []byte{0, 0, 0, 12, 0, 22, 0, 22}},
+		{"hmtx", []byte{0x01, 0xF4, 0, 10, 0x02, 0x58, 0, 15, 0, 0}},
+	} {
+		if !bytes.Equal(got[tt.tag], tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.tag, got[tt.tag], tt.want)
+		}
+	}
+	if format := be.Uint16(got["head"][50:]); format != 0 {
+		t.Errorf("indexToLocFormat %d, want 0", format)
+	}
+}
@@ -0,0 +1,323 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+)
+
+// SetCompression turns FlateDecode compression of content, font and image
+// streams on or off. Streams are compressed by default; turning it off keeps
+// content streams readable when debugging.
+func (p *PDF) SetCompression(enabled bool) {
+	if enabled {
+		p.SetCompressor(defaultCompressor)
+	} else {
+		p.SetCompressor(nil)
+	}
+}
+
+// SetObjectStreams packs objects other than streams into compressed
+// object streams and writes a cross-reference stream instead of a table,
+// which shrinks documents of many small objects, e.g. gradients and
+// graphics states. It needs PDF 1.5, older readers cannot open the output.
+func (p *PDF) SetObjectStreams(enabled bool) {
+	p.objectStreams = enabled
+}
+
+// WithObjectStreams packs objects into object streams
+func WithObjectStreams() Option {
+	return func(p *PDF) error {
+		p.SetObjectStreams(true)
+		return nil
+	}
+}
+
+// objectStreamSize is the number of objects packed into one object stream,
+// so readers need not decompress a large stream for a single object
+const objectStreamSize = 100
+
+// packedObject is an object waiting to be packed into an object stream
+type packedObject struct {
+	num  int
+	body string
+}
+
+// objectAllocator hands out object numbers while a document is laid out, so
+// references can be resolved before the referenced objects are written
+type objectAllocator struct {
+	last int // Highest number assigned so far
+}
+
+// next returns a new object number
+func (a *objectAllocator) next() int {
+	a.last++
+	return a.last
+}
+
+// reserve returns the first of n consecutive new object numbers
+func (a *objectAllocator) reserve(n int) int {
+	first := a.last + 1
+	a.last += n
+	return first
+}
+
+// ref formats a reference to object num
+func ref(num int) string {
+	return fmt.Sprintf("%d 0 R", num)
+}
+
+// pdfWriter serializes a PDF file, counting the bytes written so the
+// cross-reference table holds the exact offset of every object
+type pdfWriter struct {
+	w           io.Writer
+	n           int                     // Bytes written so far
+	offsets     map[int]int             // Object number to the offset of its "obj" line
+	compressors [streamKinds]Compressor // Per stream kind, nil entries leave streams uncompressed
+	fileID      bool                    // Write a file identifier, derived from the content
+	hash        hash.Hash               // Digest of the bytes written, for the identifier
+	crypt       *encryptor              // Encrypts strings and streams, nil if unencrypted
+	id          []byte                  // File identifier given up front, nil to derive it
+	encryptObj  int                     // Encryption dictionary object, 0 if unencrypted
+	err         error                   // First write error, later writes are skipped
+	streamSizes [streamKinds]int        // Bytes of the streams written, by kind
+
+	objectStreams bool           // Pack objects into object streams, with a cross-reference stream
+	packed        []packedObject // Objects to pack, in the order written
+	packedNums    map[int]bool
+}
+
+// newPDFWriter starts a PDF file of the given version (e.g. "1.4") on w
+// with the header. The comment line of high-bit bytes marks the file as
+// binary for transfer tools.
+func newPDFWriter(w io.Writer, version string) *pdfWriter {
+	pw := &pdfWriter{w: w, offsets: make(map[int]int), hash: sha256.New()}
+	pw.write([]byte("%PDF-" + version + "\n%\xe2\xe3\xcf\xd3\n"))
+	return pw
+}
+
+// encrypt writes the encryption dictionary of e as object num and encrypts
+// all strings and streams written afterwards
+func (w *pdfWriter) encrypt(num int, e *encryptor) {
+	w.encryptObj = num // Never packed
+	w.object(num, e.dict...)
+	w.crypt, w.fileID, w.id = e, true, e.id
+}
+
+// write appends raw bytes to the output
+func (w *pdfWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.hash.Write(b[:n])
+	w.n += n
+	w.err = err
+}
+
+// object writes indirect object num with the given lines as its value, or
+// keeps it for an object stream. Strings of packed objects are encrypted
+// with the stream as a whole.
+func (w *pdfWriter) object(num int, lines ...string) {
+	body := strings.Join(lines, "\n")
+	if w.objectStreams && num != w.encryptObj {
+		if (w.packedNums[num] || w.offsets[num] != 0) && w.err == nil {
+			w.err = fmt.Errorf("object %d written twice", num)
+		}
+		if w.packedNums == nil {
+			w.packedNums = make(map[int]bool)
+		}
+		w.packedNums[num] = true
+		w.packed = append(w.packed, packedObject{num, body})
+		return
+	}
+	w.begin(num)
+	if w.crypt != nil {
+		body = w.crypt.encryptStrings(num, body)
+	}
+	w.write([]byte(body))
+	w.write([]byte("\nendobj\n"))
+}
+
+// stream writes indirect object num as a stream, compressing data with the
+// compressor of its kind unless there is none or the dictionary already
+// names a filter. The dictionary lines are given without the enclosing
+// << >> and /Length, which is added from data.
+func (w *pdfWriter) stream(num int, kind StreamKind, dict []string, data []byte) {
+	dict, data, err := w.compress(num, kind, dict, data)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.streamSizes[kind] += len(data)
+	w.rawStream(num, dict, data)
+}
+
+// compress returns the dictionary and data of stream object num after
+// compressing it as stream does, keeping the data as is on errors. It does
+// not write anything, so streams may be compressed concurrently.
+func (w *pdfWriter) compress(num int, kind StreamKind, dict []string, data []byte) ([]string, []byte, error) {
+	filtered := slices.ContainsFunc(dict, func(line string) bool { return strings.HasPrefix(line, "/Filter") })
+	c := w.compressors[kind]
+	if c == nil || filtered {
+		return dict, data, nil
+	}
+	compressed, err := c.Compress(data)
+	if err != nil {
+		return dict, data, fmt.Errorf("error compressing object %d: %v", num, err)
+	}
+	if len(compressed) < len(data) {
+		dict = append(slices.Clip(dict), "/Filter /"+c.Filter())
+		data = compressed
+	}
+	return dict, data, nil
+}
+
+// rawStream writes indirect object num as a stream holding data as is,
+// apart from encryption
+func (w *pdfWriter) rawStream(num int, dict []string, data []byte) {
+	if w.crypt != nil {
+		data = w.crypt.encrypt(num, data)
+	}
+	w.begin(num)
+	w.write([]byte("<<\n"))
+	for _, line := range dict {
+		if w.crypt != nil {
+			line = w.crypt.encryptStrings(num, line)
+		}
+		w.write([]byte(line + "\n"))
+	}
+	w.write([]byte(fmt.Sprintf("/Length %d\n>>\nstream\n", len(data))))
+	w.write(data)
+	w.write([]byte("\nendstream\nendobj\n"))
+}
+
+// begin records the offset of object num and writes its header
+func (w *pdfWriter) begin(num int) {
+	if _, dup := w.offsets[num]; (dup || w.packedNums[num]) && w.err == nil {
+		w.err = fmt.Errorf("object %d written twice", num)
+	}
+	w.offsets[num] = w.n
+	w.write([]byte(fmt.Sprintf("%d 0 obj\n", num)))
+}
+
+// finish writes the cross-reference table and trailer for the document
+// whose catalog is object root and information dictionary object info (0
+// for none). Every number up to the highest one written must have been used.
+func (w *pdfWriter) finish(root, info int) error {
+	if w.err != nil {
+		return w.err
+	}
+	size := 1
+	for num := range w.offsets {
+		size = max(size, num+1)
+	}
+	for num := range w.packedNums {
+		size = max(size, num+1)
+	}
+	if w.objectStreams {
+		return w.finishStreams(size, root, info)
+	}
+	startxref := w.n
+
+	var xref bytes.Buffer
+	fmt.Fprintf(&xref, "xref\n0 %d\n", size)
+	xref.WriteString("0000000000 65535 f \n") // Entries are exactly 20 bytes
+	for num := 1; num < size; num++ {
+		offset, ok := w.offsets[num]
+		if !ok {
+			return fmt.Errorf("object %d was never written", num)
+		}
+		fmt.Fprintf(&xref, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&xref, "trailer\n<<\n/Size %d\n", size)
+	for _, entry := range w.trailer(root, info) {
+		xref.WriteString(entry + "\n")
+	}
+	fmt.Fprintf(&xref, ">>\nstartxref\n%d\n%%%%EOF\n", startxref)
+	w.write(xref.Bytes())
+	return w.err
+}
+
+// trailer returns the trailer entries after /Size
+func (w *pdfWriter) trailer(root, info int) []string {
+	entries := []string{fmt.Sprintf("/Root %d 0 R", root)}
+	if w.fileID {
+		// The same content always gets the same identifier
+		id := w.id
+		if id == nil {
+			id = w.hash.Sum(nil)[:16]
+		}
+		entries = append(entries, fmt.Sprintf("/ID [<%X> <%X>]", id, id))
+	}
+	if info != 0 {
+		entries = append(entries, fmt.Sprintf("/Info %d 0 R", info))
+	}
+	if w.encryptObj != 0 {
+		entries = append(entries, fmt.Sprintf("/Encrypt %d 0 R", w.encryptObj))
+	}
+	return entries
+}
+
+// finishStreams packs the kept objects into object streams, numbered from
+// size on, and writes a cross-reference stream, which is never encrypted,
+// holding the trailer entries
+func (w *pdfWriter) finishStreams(size, root, info int) error {
+	// Entries of type 1 locate objects by offset, of type 2 by the object
+	// stream and the index within it
+	type entry struct{ kind, field2, field3 int }
+	entries := make(map[int]entry)
+	for num, offset := range w.offsets {
+		entries[num] = entry{1, offset, 0}
+	}
+	next := size
+	for start := 0; start < len(w.packed); start += objectStreamSize {
+		chunk := w.packed[start:min(start+objectStreamSize, len(w.packed))]
+		var header, body bytes.Buffer
+		for i, obj := range chunk {
+			fmt.Fprintf(&header, "%d %d ", obj.num, body.Len())
+			body.WriteString(obj.body + "\n")
+			entries[obj.num] = entry{2, next, i}
+		}
+		dict := []string{"/Type /ObjStm", fmt.Sprintf("/N %d", len(chunk)), fmt.Sprintf("/First %d", header.Len())}
+		w.stream(next, ObjectStream, dict, append(header.Bytes(), body.Bytes()...))
+		entries[next] = entry{1, w.offsets[next], 0}
+		next++
+	}
+	if w.err != nil {
+		return w.err
+	}
+	xrefObj := next
+	size = xrefObj + 1
+	startxref := w.n
+	entries[xrefObj] = entry{1, startxref, 0}
+
+	// Offsets and stream numbers take as many bytes as the largest needs
+	width := 1
+	for limit := 256; limit <= max(startxref, xrefObj); limit <<= 8 {
+		width++
+	}
+	data := make([]byte, 0, size*(width+3))
+	data = append(data, make([]byte, 1+width)...)
+	data = append(data, 0xff, 0xff) // Object 0 heads the free list
+	for num := 1; num < size; num++ {
+		e, ok := entries[num]
+		if !ok {
+			return fmt.Errorf("object %d was never written", num)
+		}
+		data = append(data, byte(e.kind))
+		for shift := 8 * (width - 1); shift >= 0; shift -= 8 {
+			data = append(data, byte(e.field2>>shift))
+		}
+		data = append(data, byte(e.field3>>8), byte(e.field3))
+	}
+	dict := []string{"/Type /XRef", fmt.Sprintf("/Size %d", size), fmt.Sprintf("/W [1 %d 2]", width)}
+	dict = append(dict, w.trailer(root, info)...)
+	w.crypt = nil
+	w.stream(xrefObj, ObjectStream, dict, data)
+	w.write([]byte(fmt.Sprintf("startxref\n%d\n%%%%EOF\n", startxref)))
+	return w.err
+}
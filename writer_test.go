@@ -0,0 +1,220 @@
+package svg2pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testPages are the sources of the documents the writer tests check: text,
+// shared and blended shapes, and a second page
+var testPages = []string{
+	`<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100"><title>One</title>` +
+		`<rect width="50" height="50" style="mix-blend-mode: multiply"/><text x="10" y="80">Hello</text></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100"><title>Two</title>` +
+		`<rect x="20" width="50" height="50"/></svg>`,
+}
+
+// writeDocument converts testPages with opts, streaming the document out
+// as it is drawn if streamed is set
+func writeDocument(t *testing.T, streamed bool, opts ...Option) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if streamed {
+		opts = append(opts, WithStreamingOutput(&out))
+	}
+	d, err := NewDocument(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, page := range testPages {
+		if err := d.AddSVGPage(strings.NewReader(page)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if streamed {
+		err = d.Close()
+	} else {
+		err = d.Write(&out)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// startXref returns the offset after the last startxref keyword
+func startXref(t *testing.T, pdf []byte) int {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	offset, _ := strconv.Atoi(string(m[1]))
+	return offset
+}
+
+// xrefTable returns the object offsets of the cross-reference table at
+// offset and the trailer /Size
+func xrefTable(t *testing.T, pdf []byte, offset int) (map[int]int, int) {
+	t.Helper()
+	if offset > len(pdf) || !bytes.HasPrefix(pdf[offset:], []byte("xref\n")) {
+		t.Fatalf("no xref keyword at %d", offset)
+	}
+	lines := strings.Split(string(pdf[offset:]), "\n")
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil {
+		t.Fatalf("xref subsection %q: %v", lines[1], err)
+	}
+	offsets := make(map[int]int)
+	for i, line := range lines[2 : 2+count] {
+		if len(line) != 19 {
+			t.Errorf("xref entry %q is not 20 bytes", line)
+		}
+		var off, gen int
+		var kind string
+		fmt.Sscanf(line, "%d %d %s", &off, &gen, &kind)
+		if kind == "n" {
+			offsets[first+i] = off
+		}
+	}
+	m := regexp.MustCompile(`trailer\n<<\n/Size (\d+)`).FindStringSubmatch(string(pdf[offset:]))
+	if m == nil {
+		t.Fatal("no trailer /Size")
+	}
+	size, _ := strconv.Atoi(m[1])
+	return offsets, size
+}
+
+// checkObjectAt fails unless object num begins at offset
+func checkObjectAt(t *testing.T, pdf []byte, num, offset int) {
+	t.Helper()
+	header := fmt.Sprintf("%d 0 obj\n", num)
+	if offset < 0 || offset > len(pdf) || !bytes.HasPrefix(pdf[offset:], []byte(header)) {
+		t.Errorf("object %d: offset %d does not point at %q", num, offset, strings.TrimSpace(header))
+	}
+}
+
+func TestXrefTable(t *testing.T) {
+	for _, streamed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streamed=%v", streamed), func(t *testing.T) {
+			pdf := writeDocument(t, streamed)
+			offsets, size := xrefTable(t, pdf, startXref(t, pdf))
+			if len(offsets) != size-1 {
+				t.Errorf("%d objects in use, /Size %d", len(offsets), size)
+			}
+			for num, offset := range offsets {
+				checkObjectAt(t, pdf, num, offset)
+			}
+			// Every object written is listed
+			for _, m := range regexp.MustCompile(`(?m)^(\d+) 0 obj$`).FindAllSubmatchIndex(pdf, -1) {
+				num, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+				if offsets[num] != m[0] {
+					t.Errorf("object %d at %d, listed at %d", num, m[0], offsets[num])
+				}
+			}
+		})
+	}
+}
+
+// objectStream returns the dictionary and the data, inflated if it is
+// Flate encoded, of the stream object num at offset
+func objectStream(t *testing.T, pdf []byte, num, offset int) (string, []byte) {
+	t.Helper()
+	dict, data := rawStream(t, pdf, num, offset)
+	if strings.Contains(dict, "/FlateDecode") {
+		if data = inflate(data); data == nil {
+			t.Fatalf("object %d: invalid Flate data", num)
+		}
+	}
+	return dict, data
+}
+
+// rawStream returns the dictionary and the data as written of the stream
+// object num at offset
+func rawStream(t *testing.T, pdf []byte, num, offset int) (string, []byte) {
+	t.Helper()
+	checkObjectAt(t, pdf, num, offset)
+	start := bytes.Index(pdf[offset:], []byte("\nstream\n"))
+	if start < 0 {
+		t.Fatalf("object %d is not a stream", num)
+	}
+	dict := string(pdf[offset : offset+start])
+	m := regexp.MustCompile(`/Length (\d+)`).FindStringSubmatch(dict)
+	if m == nil {
+		t.Fatalf("object %d has no /Length", num)
+	}
+	length, _ := strconv.Atoi(m[1])
+	start += offset + len("\nstream\n")
+	if start+length > len(pdf) || !bytes.HasPrefix(pdf[start+length:], []byte("\nendstream")) {
+		t.Fatalf("object %d: /Length %d does not end at endstream", num, length)
+	}
+	return dict, pdf[start : start+length]
+}
+
+func TestObjectStreams(t *testing.T) {
+	for _, streamed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streamed=%v", streamed), func(t *testing.T) {
+			pdf := writeDocument(t, streamed, WithObjectStreams())
+			offset := startXref(t, pdf)
+			m := regexp.MustCompile(`^(\d+) 0 obj`).FindSubmatch(pdf[offset:])
+			if m == nil {
+				t.Fatalf("no cross-reference stream at %d", offset)
+			}
+			xrefNum, _ := strconv.Atoi(string(m[1]))
+			dict, data := objectStream(t, pdf, xrefNum, offset)
+			var size, w1, w2, w3 int
+			if m := regexp.MustCompile(`/Size (\d+)`).FindStringSubmatch(dict); m != nil {
+				size, _ = strconv.Atoi(m[1])
+			}
+			fmt.Sscanf(regexp.MustCompile(`/W \[[^]]*\]`).FindString(dict), "/W [%d %d %d]", &w1, &w2, &w3)
+			width := w1 + w2 + w3
+			if size == 0 || w1 != 1 || w3 != 2 || len(data) != size*width {
+				t.Fatalf("cross-reference stream of %d bytes, /Size %d /W [%d %d %d]", len(data), size, w1, w2, w3)
+			}
+			field := func(b []byte) int {
+				v := 0
+				for _, c := range b {
+					v = v<<8 | int(c)
+				}
+				return v
+			}
+
+			// Objects of type 2 are looked up in their object stream
+			packed := 0
+			streams := make(map[int][]byte)
+			for num := 1; num < size; num++ {
+				e := data[num*width : (num+1)*width]
+				kind, f2, f3 := int(e[0]), field(e[1:1+w2]), field(e[1+w2:])
+				switch kind {
+				case 1:
+					checkObjectAt(t, pdf, num, f2)
+				case 2:
+					packed++
+					stm, ok := streams[f2]
+					if !ok {
+						stmOffset := field(data[f2*width+1 : f2*width+1+w2])
+						var stmDict string
+						stmDict, stm = objectStream(t, pdf, f2, stmOffset)
+						if !strings.Contains(stmDict, "/Type /ObjStm") {
+							t.Fatalf("object %d: stream %d is not an object stream", num, f2)
+						}
+						streams[f2] = stm
+					}
+					header := strings.Fields(string(stm))
+					if 2*f3+1 >= len(header) || header[2*f3] != strconv.Itoa(num) {
+						t.Errorf("object %d: not at index %d of object stream %d", num, f3, f2)
+					}
+				default:
+					t.Errorf("object %d: entry of type %d", num, kind)
+				}
+			}
+			if packed == 0 {
+				t.Errorf("no objects packed into object streams")
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package svg2pdf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrExternalEntity is wrapped by the errors of sources declaring external
+// entities. They are never resolved, so converting untrusted uploads cannot
+// read local files or reach the network (XXE).
+var ErrExternalEntity = errors.New("external entities are not resolved")
+
+// maxEntitySize is the largest expansion in bytes of a declared entity
+const maxEntitySize = 1 << 16
+
+// defaultEntityBytes is the most bytes the entity references of a source
+// expand to in total unless Limits.EntityBytes is set, against entities
+// nesting or repeating others ("billion laughs")
+const defaultEntityBytes = 1 << 24
+
+// newDecoder returns a decoder of the svg source read from r, transcoding
+// UTF-16 and legacy single-byte encodings to UTF-8. Elements without a
+// namespace, as in many hand-written files, are SVG elements, while those
+// of SVG documents with an aliased prefix, e.g. <svg:rect>, already are.
+// Entities declared in a DOCTYPE are added as the roots are read, see
+// nextRoot; decoding fails with a *LimitError once the references to them
+// expand to more than maxEntityBytes.
+func newDecoder(r io.Reader, maxEntityBytes int64) *xml.Decoder {
+	entities := make(map[string]string)
+	decoder := xml.NewDecoder(&expansionReader{r: utf8Source(r), entities: entities, max: maxEntityBytes})
+	decoder.CharsetReader = charsetReader
+	decoder.DefaultSpace = svgNamespace
+	decoder.Entity = entities
+	return decoder
+}
+
+// byteSource is a reader the decoder reads byte by byte, without reading
+// ahead
+type byteSource interface {
+	io.Reader
+	io.ByteReader
+}
+
+// expansionReader passes the bytes of r on to a decoder, adding up the
+// bytes the references it reads to the declared entities expand to. As the
+// decoder reads byte by byte, they are those it is about to expand.
+type expansionReader struct {
+	r        byteSource
+	entities map[string]string // Those of the decoder
+	max      int64
+	total    int64
+	ref      []byte // Name of the reference being read
+	inRef    bool
+}
+
+func (e *expansionReader) ReadByte() (byte, error) {
+	c, err := e.r.ReadByte()
+	if err != nil {
+		return c, err
+	}
+	switch {
+	case c == '&':
+		e.inRef, e.ref = true, e.ref[:0]
+	case !e.inRef:
+	case c == ';':
+		e.inRef = false
+		if value, ok := e.entities[string(e.ref)]; ok {
+			e.total += int64(len(value))
+			if e.total > e.max {
+				return 0, &LimitError{Limit: "EntityBytes", Max: float64(e.max)}
+			}
+		}
+	case c == '<' || c == ' ' || c == '\t' || c == '\r' || c == '\n' || len(e.ref) > 256:
+		e.inRef = false // Not a reference
+	default:
+		e.ref = append(e.ref, c)
+	}
+	return c, nil
+}
+
+func (e *expansionReader) Read(b []byte) (int, error) {
+	for i := range b {
+		c, err := e.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		b[i] = c
+	}
+	return len(b), nil
+}
+
+// sourceEncoding returns the length of the byte order mark at the head of
+// a source and, for UTF-16, detected by the mark or by the leading "<", its
+// byte order
+func sourceEncoding(head []byte) (int, binary.ByteOrder) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xef, 0xbb, 0xbf}):
+		return 3, nil
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return 2, binary.LittleEndian
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return 2, binary.BigEndian
+	case bytes.HasPrefix(head, []byte{'<', 0}):
+		return 0, binary.LittleEndian
+	case bytes.HasPrefix(head, []byte{0, '<'}):
+		return 0, binary.BigEndian
+	}
+	return 0, nil
+}
+
+// utf8Source returns r without a byte order mark, transcoding UTF-16
+func utf8Source(r io.Reader) byteSource {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(3)
+	mark, order := sourceEncoding(head)
+	br.Discard(mark)
+	if order == nil {
+		return br
+	}
+	return &runeReader{r: br, decode: func(r byteSource) (rune, error) {
+		var unit [2]byte
+		if _, err := io.ReadFull(r, unit[:]); err != nil {
+			return 0, err
+		}
+		c := rune(order.Uint16(unit[:]))
+		if !utf16.IsSurrogate(c) {
+			return c, nil
+		}
+		if _, err := io.ReadFull(r, unit[:]); err != nil {
+			return 0, err
+		}
+		return utf16.DecodeRune(c, rune(order.Uint16(unit[:]))), nil
+	}}
+}
+
+// utf8Bytes returns source as UTF-8 without a byte order mark, so offsets
+// into it match those of its decoder
+func utf8Bytes(source []byte) []byte {
+	if mark, order := sourceEncoding(source); mark == 0 && order == nil {
+		return source
+	}
+	converted, err := io.ReadAll(utf8Source(bytes.NewReader(source)))
+	if err != nil {
+		return source // Reported when decoding
+	}
+	return converted
+}
+
+// runeReader transcodes the runes decode reads from r to UTF-8
+type runeReader struct {
+	r       byteSource
+	decode  func(r byteSource) (rune, error)
+	pending []byte // Transcoded bytes not yet read
+}
+
+func (t *runeReader) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := t.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (t *runeReader) Read(b []byte) (int, error) {
+	for len(t.pending) == 0 {
+		c, err := t.decode(t.r)
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("truncated character")
+		}
+		if err != nil {
+			return 0, err
+		}
+		t.pending = utf8.AppendRune(t.pending, c)
+	}
+	n := copy(b, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// windows1252 maps the bytes 0x80 to 0x9f of Windows-1252 to runes; the
+// other bytes are those of ISO 8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// charsetReader reads input, in the encoding declared by the source, as
+// UTF-8. UTF-16 sources are transcoded before their declaration is read.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-16", "utf-16le", "utf-16be", "ucs-2", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "l1":
+		return singleByteReader(input, nil), nil
+	case "windows-1252", "cp1252":
+		return singleByteReader(input, &windows1252), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", charset)
+}
+
+// singleByteReader transcodes ISO 8859-1 read from r to UTF-8, mapping the
+// bytes 0x80 to 0x9f through high if not nil
+func singleByteReader(r io.Reader, high *[32]rune) io.Reader {
+	br, ok := r.(byteSource)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &runeReader{r: br, decode: func(r byteSource) (rune, error) {
+		c, err := r.ReadByte()
+		if high != nil && c >= 0x80 && c < 0xa0 {
+			return high[c-0x80], err
+		}
+		return rune(c), err
+	}}
+}
+
+// declareEntities adds the general entities declared in the internal
+// subset of a DOCTYPE to the decoder, refusing external ones. The external
+// subset, e.g. the SVG 1.1 DTD, is never read.
+func declareEntities(decoder *xml.Decoder, directive xml.Directive) error {
+	d := string(directive)
+	if !hasPrefixFold(d, "DOCTYPE") {
+		return nil
+	}
+	open, end := strings.IndexByte(d, '['), strings.LastIndexByte(d, ']')
+	if open < 0 || end < open {
+		return nil
+	}
+	subset := d[open+1 : end]
+	for {
+		i := strings.Index(subset, "<!ENTITY")
+		if i < 0 {
+			return nil
+		}
+		rest := strings.TrimLeft(subset[i+len("<!ENTITY"):], " \t\r\n")
+		parameter := strings.HasPrefix(rest, "%")
+		if parameter {
+			rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		}
+		n := strings.IndexAny(rest, " \t\r\n")
+		if n < 0 {
+			return fmt.Errorf("malformed entity declaration")
+		}
+		name := rest[:n]
+		rest = strings.TrimLeft(rest[n:], " \t\r\n")
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			return fmt.Errorf("entity %q: %w", name, ErrExternalEntity) // A SYSTEM or PUBLIC identifier
+		}
+		n = strings.IndexByte(rest[1:], rest[0])
+		if n < 0 {
+			return fmt.Errorf("unterminated value of entity %q", name)
+		}
+		value := rest[1 : n+1]
+		subset = rest[n+2:]
+		if _, declared := decoder.Entity[name]; parameter || declared {
+			continue // Parameter entities only apply within the DTD, and the first declaration binds
+		}
+		expanded, err := expandEntities(value, decoder.Entity)
+		if err != nil {
+			return fmt.Errorf("error expanding entity %q: %v", name, err)
+		}
+		decoder.Entity[name] = expanded
+	}
+}
+
+// expandEntities replaces the character references and the references to
+// predefined and declared entities in an entity value
+func expandEntities(value string, entities map[string]string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(value, '&')
+		if i < 0 {
+			b.WriteString(value)
+			break
+		}
+		b.WriteString(value[:i])
+		end := strings.IndexByte(value[i:], ';')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference")
+		}
+		ref := value[i+1 : i+end]
+		value = value[i+end+1:]
+		switch {
+		case strings.HasPrefix(ref, "#"):
+			base, digits := 10, ref[1:]
+			if strings.HasPrefix(ref, "#x") {
+				base, digits = 16, ref[2:]
+			}
+			n, err := strconv.ParseUint(digits, base, 32)
+			if err != nil || !utf8.ValidRune(rune(n)) {
+				return "", fmt.Errorf("invalid character reference &%s;", ref)
+			}
+			b.WriteRune(rune(n))
+		default:
+			text, ok := xmlPredefined[ref]
+			if !ok {
+				text, ok = entities[ref]
+			}
+			if !ok {
+				return "", fmt.Errorf("undeclared entity &%s;", ref)
+			}
+			b.WriteString(text)
+		}
+		if b.Len() > maxEntitySize {
+			return "", fmt.Errorf("expansion exceeds %d bytes", maxEntitySize)
+		}
+	}
+	return b.String(), nil
+}
+
+// xmlPredefined are the entities every XML document has
+var xmlPredefined = map[string]string{"lt": "<", "gt": ">", "amp": "&", "apos": "'", "quot": `"`}
@@ -0,0 +1,102 @@
+package svg2pdf
+
+import (
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// creationDate returns the creation date of the document: the one set in
+// the metadata, else the SOURCE_DATE_EPOCH of reproducible builds, else
+// the current time, or the zero time, which is left out, in deterministic
+// mode
+func (p *PDF) creationDate() time.Time {
+	if p.metadata != nil && !p.metadata.Created.IsZero() {
+		return p.metadata.Created
+	}
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	if p.deterministic {
+		return time.Time{} // Left out
+	}
+	p.recordInput("current time used as the creation date")
+	return time.Now()
+}
+
+// pdfDate formats t as a PDF date string
+func pdfDate(t time.Time) string {
+	date := t.Format("D:20060102150405-07'00'")
+	return strings.Replace(date, "+00'00'", "Z", 1)
+}
+
+// xmpPacket returns the XMP metadata packet recording the document
+// information and the rights of the embedded images, or nil if there is
+// neither
+func (p *PDF) xmpPacket(created time.Time) []byte {
+	rights, contributors := p.imageRightsNotices()
+	meta := p.documentMetadata()
+	if meta == nil && len(rights) == 0 && len(contributors) == 0 {
+		return nil
+	}
+	var title, subject string
+	var m Metadata
+	if meta != nil {
+		m = *meta
+		title, subject = p.documentTitle(meta)
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString("<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("<rdf:Description rdf:about=\"\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	b.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if title != "" {
+		b.WriteString("<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">" + html.EscapeString(title) + "</rdf:li></rdf:Alt></dc:title>\n")
+	}
+	if m.Author != "" {
+		b.WriteString("<dc:creator><rdf:Seq><rdf:li>" + html.EscapeString(m.Author) + "</rdf:li></rdf:Seq></dc:creator>\n")
+	}
+	if subject != "" {
+		b.WriteString("<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">" + html.EscapeString(subject) + "</rdf:li></rdf:Alt></dc:description>\n")
+	}
+	if len(rights) > 0 {
+		b.WriteString("<dc:rights><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+		b.WriteString(html.EscapeString(strings.Join(rights, "; ")))
+		b.WriteString("</rdf:li></rdf:Alt></dc:rights>\n")
+	}
+	if len(contributors) > 0 {
+		b.WriteString("<dc:contributor><rdf:Bag>")
+		for _, contributor := range contributors {
+			b.WriteString("<rdf:li>" + html.EscapeString(contributor) + "</rdf:li>")
+		}
+		b.WriteString("</rdf:Bag></dc:contributor>\n")
+	}
+	b.WriteString("</rdf:Description>\n")
+
+	if p.pdfa {
+		b.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\">\n")
+		b.WriteString("<pdfaid:part>2</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>\n")
+		b.WriteString("</rdf:Description>\n")
+	}
+	if meta != nil {
+		// Document information, matching the /Info dictionary
+		b.WriteString("<rdf:Description rdf:about=\"\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+		if !created.IsZero() {
+			b.WriteString("<xmp:CreateDate>" + created.Format(time.RFC3339) + "</xmp:CreateDate>\n")
+		}
+		if m.Creator != "" {
+			b.WriteString("<xmp:CreatorTool>" + html.EscapeString(m.Creator) + "</xmp:CreatorTool>\n")
+		}
+		if m.Keywords != "" {
+			b.WriteString("<pdf:Keywords>" + html.EscapeString(m.Keywords) + "</pdf:Keywords>\n")
+		}
+		b.WriteString("<pdf:Producer>" + producer + "</pdf:Producer>\n")
+		b.WriteString("</rdf:Description>\n")
+	}
+	b.WriteString("</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}